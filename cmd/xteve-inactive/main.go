@@ -10,21 +10,37 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	xteve "xteve/src"
 )
 
 var port = flag.String("port", "", ": Server port          [34400] (default: 34400)")
 var host = flag.String("host", "", ": Server host                  (default: localhost)")
+var timeout = flag.Duration("timeout", 10*time.Second, ": Timeout of the request      (default: 10s)")
+var legacyExit = flag.Bool("legacy-exit", false, ": Exit code is the number of active connections, -1 on errors")
+
+// Exit codes, the number of active connections is printed to stdout
+const (
+	exitInactive = 0 // No tuner or other connection is active
+	exitActive   = 1 // Active or locked (maintenance mode)
+	exitError    = 2
+)
+
+// runLogic returns the exit code. With legacyExit, the exit code is the raw number of active connections (-1 on errors).
+func runLogic(cmdHost, cmdPort string, timeout time.Duration, legacyExit bool, outWriter io.Writer, errWriter io.Writer) int {
+	var errorCode = exitError
+	if legacyExit {
+		errorCode = -1
+	}
 
-func runLogic(cmdHost, cmdPort string, outWriter io.Writer, errWriter io.Writer) int {
 	portNum := 34400
 	if cmdPort != "" {
 		var err error
 		portNum, err = strconv.Atoi(cmdPort)
 		if err != nil {
 			fmt.Fprintf(errWriter, "Unable parse port: %v\n", err)
-			return -1
+			return errorCode
 		}
 	}
 
@@ -38,13 +54,14 @@ func runLogic(cmdHost, cmdPort string, outWriter io.Writer, errWriter io.Writer)
 	})
 	if err != nil {
 		fmt.Fprintf(errWriter, "Unable to marshall request: %v\n", err)
-		return -1
+		return errorCode
 	}
 
-	resp, err := http.Post(fmt.Sprintf("http://%s:%d/api/", hostname, portNum), "application/json", bytes.NewBuffer(requestBody))
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(fmt.Sprintf("http://%s:%d/api/", hostname, portNum), "application/json", bytes.NewBuffer(requestBody))
 	if err != nil {
 		fmt.Fprintf(errWriter, "Unable to get API: %v\n", err)
-		return -1
+		return errorCode
 	}
 
 	defer resp.Body.Close()
@@ -52,18 +69,18 @@ func runLogic(cmdHost, cmdPort string, outWriter io.Writer, errWriter io.Writer)
 	respStr, err := io.ReadAll(resp.Body)
 	if err != nil {
 		fmt.Fprintf(errWriter, "Unable read response: %v\n", err)
-		return -1
+		return errorCode
 	}
 
 	var apiresp xteve.APIResponseStruct
 	err = json.Unmarshal(respStr, &apiresp)
 	if err != nil {
 		if strings.TrimSpace(string(respStr)) == "Locked [423]" {
-			return 1
+			return exitActive
 		} else {
 			fmt.Fprintf(errWriter, "Unable parse response: %v\n", err)
 			fmt.Fprintf(errWriter, "%s\n", respStr)
-			return -1
+			return errorCode
 		}
 	}
 
@@ -74,7 +91,16 @@ func runLogic(cmdHost, cmdPort string, outWriter io.Writer, errWriter io.Writer)
 	if httpActive > 0 {
 		httpActive--
 	}
-	return int(apiresp.TunerActive) + int(httpActive)
+	active := int(apiresp.TunerActive) + int(httpActive)
+	if legacyExit {
+		return active
+	}
+
+	fmt.Fprintf(outWriter, "%d\n", active)
+	if active > 0 {
+		return exitActive
+	}
+	return exitInactive
 }
 
 func main() {
@@ -90,6 +116,6 @@ func main() {
 		cmdHost = *host
 	}
 
-	exitCode := runLogic(cmdHost, cmdPort, os.Stdout, os.Stderr)
+	exitCode := runLogic(cmdHost, cmdPort, *timeout, *legacyExit, os.Stdout, os.Stderr)
 	os.Exit(exitCode)
 }
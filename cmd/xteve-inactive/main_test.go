@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 	"xteve/src"
 )
 
@@ -35,7 +37,7 @@ func TestRunLogic_LockedError(t *testing.T) {
 	host, port := parsedURL.Hostname(), parsedURL.Port()
 
 	var outBuf, errBuf bytes.Buffer
-	exitCode := runLogic(host, port, &outBuf, &errBuf)
+	exitCode := runLogic(host, port, time.Second, false, &outBuf, &errBuf)
 
 	if exitCode != 1 {
 		t.Errorf("Expected exit code 1, got %d", exitCode)
@@ -61,10 +63,10 @@ func TestRunLogic_InvalidJSONError(t *testing.T) {
 	host, port := parsedURL.Hostname(), parsedURL.Port()
 
 	var outBuf, errBuf bytes.Buffer
-	exitCode := runLogic(host, port, &outBuf, &errBuf)
+	exitCode := runLogic(host, port, time.Second, false, &outBuf, &errBuf)
 
-	if exitCode != -1 {
-		t.Errorf("Expected exit code -1, got %d", exitCode)
+	if exitCode != exitError {
+		t.Errorf("Expected exit code %d, got %d", exitError, exitCode)
 	}
 	expectedErr := "Unable parse response:"
 	if !strings.Contains(errBuf.String(), expectedErr) {
@@ -95,16 +97,16 @@ func TestRunLogic_TunerInactive(t *testing.T) {
 	host, port := parsedURL.Hostname(), parsedURL.Port()
 
 	var outBuf, errBuf bytes.Buffer
-	exitCode := runLogic(host, port, &outBuf, &errBuf)
+	exitCode := runLogic(host, port, time.Second, false, &outBuf, &errBuf)
 
-	if exitCode != 0 {
+	if exitCode != exitInactive {
 		t.Errorf("Expected exit code 0, got %d", exitCode)
 	}
 	if errBuf.String() != "" {
 		t.Errorf("Expected empty stderr, got: %s", errBuf.String())
 	}
-	if outBuf.String() != "" {
-		t.Errorf("Expected empty stdout, got: %s", outBuf.String())
+	if outBuf.String() != "0\n" {
+		t.Errorf("Expected the active count on stdout, got: %s", outBuf.String())
 	}
 }
 
@@ -124,26 +126,26 @@ func TestRunLogic_TunerActive(t *testing.T) {
 	host, port := parsedURL.Hostname(), parsedURL.Port()
 
 	var outBuf, errBuf bytes.Buffer
-	exitCode := runLogic(host, port, &outBuf, &errBuf)
+	exitCode := runLogic(host, port, time.Second, false, &outBuf, &errBuf)
 
-	if exitCode != 1 {
+	if exitCode != exitActive {
 		t.Errorf("Expected exit code 1, got %d", exitCode)
 	}
 	if errBuf.String() != "" {
 		t.Errorf("Expected empty stderr, got: %s", errBuf.String())
 	}
-	if outBuf.String() != "" {
-		t.Errorf("Expected empty stdout, got: %s", outBuf.String())
+	if outBuf.String() != "1\n" {
+		t.Errorf("Expected the active count on stdout, got: %s", outBuf.String())
 	}
 }
 
 func TestRunLogic_ServerDown(t *testing.T) {
 	var outBuf, errBuf bytes.Buffer
 	// Attempt to connect to a port that is presumably not listening
-	exitCode := runLogic("localhost", "1", &outBuf, &errBuf)
+	exitCode := runLogic("localhost", "1", time.Second, false, &outBuf, &errBuf)
 
-	if exitCode != -1 {
-		t.Errorf("Expected exit code -1, got %d", exitCode)
+	if exitCode != exitError {
+		t.Errorf("Expected exit code %d, got %d", exitError, exitCode)
 	}
 	expectedErr := "Unable to get API:"
 	if !strings.Contains(errBuf.String(), expectedErr) {
@@ -153,3 +155,74 @@ func TestRunLogic_ServerDown(t *testing.T) {
 		t.Errorf("Expected empty stdout, got: %s", outBuf.String())
 	}
 }
+
+func TestRunLogic_ExitCodeContract(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := src.APIResponseStruct{TunerActive: 300, ActiveHTTPConnections: 2}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Fatalf("Failed to encode response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	parsedURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+	host, port := parsedURL.Hostname(), parsedURL.Port()
+
+	// More than 255 active connections don't wrap the exit code
+	var outBuf, errBuf bytes.Buffer
+	exitCode := runLogic(host, port, time.Second, false, &outBuf, &errBuf)
+	if exitCode != exitActive {
+		t.Errorf("Expected exit code %d, got %d", exitActive, exitCode)
+	}
+	if outBuf.String() != "301\n" {
+		t.Errorf("Expected the active count on stdout, got: %s", outBuf.String())
+	}
+
+	// The raw count with -legacy-exit
+	outBuf.Reset()
+	exitCode = runLogic(host, port, time.Second, true, &outBuf, &errBuf)
+	if exitCode != 301 {
+		t.Errorf("Expected exit code 301, got %d", exitCode)
+	}
+	if outBuf.String() != "" {
+		t.Errorf("Expected empty stdout, got: %s", outBuf.String())
+	}
+
+	// Errors
+	exitCode = runLogic("localhost", "1", time.Second, true, &outBuf, &errBuf)
+	if exitCode != -1 {
+		t.Errorf("Expected exit code -1, got %d", exitCode)
+	}
+}
+
+func TestRunLogic_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The closed connection is only noticed after the body was read
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	parsedURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Failed to parse server URL: %v", err)
+	}
+	host, port := parsedURL.Hostname(), parsedURL.Port()
+
+	var outBuf, errBuf bytes.Buffer
+	start := time.Now()
+	exitCode := runLogic(host, port, 100*time.Millisecond, false, &outBuf, &errBuf)
+
+	if exitCode != exitError {
+		t.Errorf("Expected exit code %d, got %d", exitError, exitCode)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the request to time out, took %s", elapsed)
+	}
+	if !strings.Contains(errBuf.String(), "Unable to get API:") {
+		t.Errorf("Expected stderr to contain 'Unable to get API:', got: %s", errBuf.String())
+	}
+}
@@ -19,6 +19,7 @@ import (
 var port = flag.String("port", "", ": Server port          [34400] (default: 34400)")
 var host = flag.String("host", "", ": Server host                  (default: localhost)")
 var useSocket = flag.Bool("socket", true, ": Use Unix socket         (default: true)")
+var jsonOutput = flag.Bool("json", false, ": Print the status as JSON (default: false)")
 
 func main() {
 	flag.Parse()
@@ -96,6 +97,16 @@ func main() {
 		os.Exit(-1)
 	}
 
+	if *jsonOutput {
+		output, err := json.MarshalIndent(apiresp, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to marshall response: %v\n", err)
+			os.Exit(-1)
+		}
+		fmt.Printf("%s\n", output)
+		os.Exit(0)
+	}
+
 	fmt.Printf("xTeVe status:\n")
 	fmt.Printf("EPG Source:        %v\n", apiresp.EpgSource)
 	fmt.Printf("Error:             %v\n", apiresp.Error)
@@ -0,0 +1,60 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPISocketOnly(t *testing.T) {
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	Settings.AuthenticationAPI = false
+	System.File.UnixSocket = filepath.Join(t.TempDir(), "xteve.sock")
+	require.NoError(t, StartLocalSocketServer())
+
+	tcpServer := httptest.NewServer(http.HandlerFunc(API))
+	defer tcpServer.Close()
+
+	socketClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", System.File.UnixSocket)
+			},
+		},
+	}
+
+	status := func(client *http.Client, url string) int {
+		resp, err := client.Post(url, "application/json", bytes.NewBufferString(`{"cmd":"status"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			var response APIResponseStruct
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+			assert.True(t, response.Status, response.Error)
+		}
+		return resp.StatusCode
+	}
+
+	for _, socketOnly := range []bool{false, true} {
+		Settings.APISocketOnly = socketOnly
+
+		assert.Equal(t, http.StatusOK, status(socketClient, "http://unix/api/"), "api.socket.only: %t", socketOnly)
+
+		var want = http.StatusOK
+		if socketOnly {
+			want = http.StatusForbidden
+		}
+		assert.Equal(t, want, status(tcpServer.Client(), tcpServer.URL+"/api/"), "api.socket.only: %t", socketOnly)
+	}
+}
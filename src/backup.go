@@ -4,8 +4,10 @@ import (
 	b64 "encoding/base64"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 )
@@ -94,9 +96,14 @@ func xteveBackup() (archive string, err error) {
 		return
 	}
 
+	return createBackupArchive(System.Folder.Temp)
+}
+
+// createBackupArchive : Creates the backup ZIP archive in the folder
+func createBackupArchive(folder string) (archive string, err error) {
 	archive = "xteve_backup_" + time.Now().Format("20060102_1504") + ".zip"
 
-	var target = System.Folder.Temp + archive
+	var target = folder + archive
 	var sourceFiles = make([]string, 0)
 
 	for _, i := range SystemFiles {
@@ -117,6 +124,154 @@ func xteveBackup() (archive string, err error) {
 	return
 }
 
+// xteveBackupAPI : Backup for the API command backup.create. Without a path the base64 encoded archive is returned,
+// with a path the archive is saved in this folder on the server and the file is returned.
+func xteveBackupAPI(path string) (file, content string, err error) {
+	if len(path) > 0 {
+		var folder = strings.TrimRight(path, string(os.PathSeparator)) + string(os.PathSeparator)
+
+		err = os.MkdirAll(folder, 0755)
+		if err == nil {
+			err = checkFilePermission(folder)
+		}
+
+		if err != nil {
+			return
+		}
+
+		archive, err := createBackupArchive(folder)
+		if err != nil {
+			return "", "", err
+		}
+
+		showInfo("Backup file:" + folder + archive)
+		return folder + archive, "", nil
+	}
+
+	archive, err := xteveBackup()
+	if err != nil {
+		return
+	}
+	defer os.Remove(System.Folder.Temp + archive)
+
+	data, err := os.ReadFile(System.Folder.Temp + archive)
+	if err != nil {
+		return
+	}
+
+	return archive, b64.StdEncoding.EncodeToString(data), nil
+}
+
+// backupDiffFiles : Files that are compared by backup.diff. authentication.json is left out, it contains the users and password hashes.
+var backupDiffFiles = []string{"settings.json", "xepg.json"}
+
+// xteveBackupDiff : Compares a backup with the current files, or with a second backup (base), for the API command backup.diff.
+// The changes are those a restore of the backup would make. Nothing is restored.
+func xteveBackupDiff(backup, base string) (diff []BackupDiffStruct, err error) {
+	if len(backup) == 0 {
+		return nil, errors.New("backup.diff: backup.data is required")
+	}
+
+	if err = os.MkdirAll(System.Folder.Temp, 0755); err != nil {
+		return
+	}
+
+	tmpDiff, err := os.MkdirTemp(System.Folder.Temp, "backup-diff-")
+	if err != nil {
+		return
+	}
+	defer os.RemoveAll(tmpDiff)
+
+	newFolder, err := extractBackupData(backup, filepath.Join(tmpDiff, "backup"))
+	if err != nil {
+		return
+	}
+
+	var oldFolder = System.Folder.Config
+	if len(base) > 0 {
+		if oldFolder, err = extractBackupData(base, filepath.Join(tmpDiff, "base")); err != nil {
+			return
+		}
+	}
+
+	for _, file := range backupDiffFiles {
+		var oldMap, newMap map[string]any
+		if oldMap, err = loadBackupDiffFile(oldFolder + file); err != nil {
+			return
+		}
+		if newMap, err = loadBackupDiffFile(newFolder + file); err != nil {
+			return
+		}
+
+		diffJSONMaps(file, "", oldMap, newMap, &diff)
+	}
+
+	return
+}
+
+// extractBackupData : Extracts a base64 encoded backup (optionally as data URL) into the folder, returns the folder with a trailing separator
+func extractBackupData(input, folder string) (string, error) {
+	content, err := b64.StdEncoding.DecodeString(input[strings.IndexByte(input, ',')+1:])
+	if err != nil {
+		return "", err
+	}
+
+	var archive = folder + ".zip"
+	if err = writeByteToFile(archive, content); err != nil {
+		return "", err
+	}
+
+	if err = extractZIP(archive, folder); err != nil {
+		return "", err
+	}
+
+	folder += string(os.PathSeparator)
+	if _, err = os.Stat(folder + "settings.json"); err != nil {
+		return "", errors.New("backup.diff: settings.json not found in the backup")
+	}
+	return folder, nil
+}
+
+// loadBackupDiffFile : JSON file as map, a missing file is compared as empty
+func loadBackupDiffFile(file string) (map[string]any, error) {
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		return map[string]any{}, nil
+	}
+	return loadJSONFileToMap(file)
+}
+
+// diffJSONMaps : Appends the added, removed and changed keys of two JSON maps. Nested maps are compared key by key, other values as a whole.
+func diffJSONMaps(file, prefix string, oldMap, newMap map[string]any, diff *[]BackupDiffStruct) {
+	var keys = slices.Sorted(maps.Keys(oldMap))
+	for key := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		var oldValue, inOld = oldMap[key]
+		var newValue, inNew = newMap[key]
+		var path = prefix + key
+
+		switch {
+		case !inOld:
+			*diff = append(*diff, BackupDiffStruct{File: file, Key: path, Change: "added", New: newValue})
+		case !inNew:
+			*diff = append(*diff, BackupDiffStruct{File: file, Key: path, Change: "removed", Old: oldValue})
+		default:
+			oldChild, oldIsMap := oldValue.(map[string]any)
+			newChild, newIsMap := newValue.(map[string]any)
+			if oldIsMap && newIsMap {
+				diffJSONMaps(file, path+"/", oldChild, newChild, diff)
+			} else if mapToJSON(oldValue) != mapToJSON(newValue) {
+				*diff = append(*diff, BackupDiffStruct{File: file, Key: path, Change: "changed", Old: oldValue, New: newValue})
+			}
+		}
+	}
+}
+
 func xteveRestore(archive string) (newWebURL string, err error) {
 	var newPort, oldPort, backupVersion, tmpRestore string
 
@@ -0,0 +1,93 @@
+package src
+
+import (
+	"bytes"
+	b64 "encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupCreateAPI(t *testing.T) {
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	configDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Config = configDir
+	System.Folder.Data = configDir + "data" + string(os.PathSeparator)
+	System.Folder.Temp = t.TempDir() + string(os.PathSeparator)
+	System.Compatibility = "1.0.0"
+	System.URLBase = "http://localhost:34400"
+	Settings.AuthenticationAPI = false
+	Settings.AuthenticationWEB = false
+	Settings.Port = "34400"
+
+	require.NoError(t, os.MkdirAll(System.Folder.Data, 0755))
+	for _, file := range SystemFiles {
+		require.NoError(t, os.WriteFile(configDir+file, []byte(`{"file":"`+file+`"}`), 0644))
+	}
+	require.NoError(t, os.WriteFile(configDir+"settings.json", []byte(`{"version":"2.0.0","port":"34400"}`), 0644))
+	require.NoError(t, os.WriteFile(System.Folder.Data+"Mplaylist.m3u", []byte("#EXTM3U\n"), 0644))
+
+	backupCreate := func(path string) APIResponseStruct {
+		body, err := json.Marshal(map[string]string{"cmd": "backup.create", "path": path})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/", bytes.NewBuffer(body))
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		API(w, req)
+
+		var response APIResponseStruct
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// Base64 content in the response
+	response := backupCreate("")
+	require.True(t, response.Status, response.Error)
+	assert.Contains(t, response.BackupFile, "xteve_backup_")
+	assert.NoFileExists(t, System.Folder.Temp+response.BackupFile, "the temporary archive is removed")
+
+	data, err := b64.StdEncoding.DecodeString(response.BackupData)
+	require.NoError(t, err)
+
+	// Path on the server
+	backupDir := filepath.Join(t.TempDir(), "backups")
+	response = backupCreate(backupDir)
+	require.True(t, response.Status, response.Error)
+	assert.Empty(t, response.BackupData)
+	assert.FileExists(t, response.BackupFile)
+	assert.Equal(t, backupDir, filepath.Dir(response.BackupFile))
+
+	blocked := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(blocked, nil, 0644))
+	response = backupCreate(filepath.Join(blocked, "backups"))
+	assert.False(t, response.Status)
+
+	// The backup can be restored
+	require.NoError(t, os.WriteFile(configDir+"xepg.json", []byte(`{"changed":true}`), 0644))
+	require.NoError(t, os.Remove(System.Folder.Data+"Mplaylist.m3u"))
+
+	archive := System.Folder.Temp + "restore.zip"
+	require.NoError(t, os.WriteFile(archive, data, 0644))
+
+	// A different port only returns the new URL, the system is not restarted
+	Settings.Port = "34401"
+	System.URLBase = "http://localhost:34401"
+	newWebURL, err := xteveRestore(archive)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:34400/web/", newWebURL)
+
+	xepg, err := os.ReadFile(configDir + "xepg.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"file":"xepg.json"}`, string(xepg))
+	assert.FileExists(t, System.Folder.Data+"Mplaylist.m3u")
+}
@@ -0,0 +1,94 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupDiffAPI(t *testing.T) {
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	configDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Config = configDir
+	System.Folder.Data = configDir + "data" + string(os.PathSeparator)
+	System.Folder.Temp = t.TempDir() + string(os.PathSeparator)
+	Settings.AuthenticationAPI = false
+
+	require.NoError(t, os.MkdirAll(System.Folder.Data, 0755))
+	for _, file := range SystemFiles {
+		require.NoError(t, os.WriteFile(configDir+file, []byte(`{}`), 0644))
+	}
+
+	writeConfig := func(settings, xepg string) {
+		require.NoError(t, os.WriteFile(configDir+"settings.json", []byte(settings), 0644))
+		require.NoError(t, os.WriteFile(configDir+"xepg.json", []byte(xepg), 0644))
+	}
+
+	writeConfig(
+		`{"port":"34400","tuner":2,"files":{"m3u":{"M1":{"name":"Provider","file.source":"http://a/1.m3u"}}}}`,
+		`{"x-ID.1":{"x-name":"Alpha","x-active":true}}`,
+	)
+	_, backup, err := xteveBackupAPI("")
+	require.NoError(t, err)
+
+	// Current settings after the backup
+	writeConfig(
+		`{"port":"34400","tuner":4,"epgSource":"XEPG","files":{"m3u":{"M1":{"name":"Renamed","file.source":"http://a/1.m3u"}}}}`,
+		`{"x-ID.1":{"x-name":"Alpha","x-active":false},"x-ID.2":{"x-name":"Beta"}}`,
+	)
+
+	backupDiff := func(request map[string]string) APIResponseStruct {
+		request["cmd"] = "backup.diff"
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/api/", bytes.NewBuffer(body))
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		API(w, req)
+
+		var response APIResponseStruct
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// Changes made by restoring the backup
+	response := backupDiff(map[string]string{"backup.data": backup})
+	require.True(t, response.Status, response.Error)
+
+	var changes = map[string]BackupDiffStruct{}
+	for _, change := range response.BackupDiff {
+		changes[change.File+":"+change.Key] = change
+	}
+
+	assert.Len(t, changes, 5)
+	assert.Equal(t, BackupDiffStruct{File: "settings.json", Key: "epgSource", Change: "removed", Old: "XEPG"}, changes["settings.json:epgSource"])
+	assert.Equal(t, BackupDiffStruct{File: "settings.json", Key: "tuner", Change: "changed", Old: float64(4), New: float64(2)}, changes["settings.json:tuner"])
+	assert.Equal(t, BackupDiffStruct{File: "settings.json", Key: "files/m3u/M1/name", Change: "changed", Old: "Renamed", New: "Provider"}, changes["settings.json:files/m3u/M1/name"])
+	assert.Equal(t, "changed", changes["xepg.json:x-ID.1/x-active"].Change)
+	assert.Equal(t, "removed", changes["xepg.json:x-ID.2"].Change)
+
+	// Against another backup instead of the current settings
+	response = backupDiff(map[string]string{"backup.data": backup, "backup.base": "data:application/zip;base64," + backup})
+	require.True(t, response.Status, response.Error)
+	assert.Empty(t, response.BackupDiff)
+
+	// Invalid input
+	assert.False(t, backupDiff(map[string]string{}).Status)
+	assert.False(t, backupDiff(map[string]string{"backup.data": "!!!"}).Status)
+
+	entries, err := os.ReadDir(System.Folder.Temp)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), "backup-diff-", "the temporary files are removed")
+	}
+}
@@ -0,0 +1,66 @@
+package src
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkHLSSegmentConnections downloads the segments of an HLS playlist like handleHLSStream.
+// With upstream.keepalive the connection is reused, without it every segment needs a new connection (and TLS handshake).
+func BenchmarkHLSSegmentConnections(b *testing.B) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	oldSettings := Settings
+	defer func() { Settings = oldSettings }()
+
+	const segments = 10
+	segment := make([]byte, 188*100)
+
+	var connections atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(segment)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	for _, keepAlive := range []bool{false, true} {
+		name := "close"
+		if keepAlive {
+			name = "keepalive"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			Settings.UpstreamKeepAlive = keepAlive
+			connections.Store(0)
+
+			for b.Loop() {
+				client := NewHTTPClient()
+				for i := range segments {
+					req, _ := http.NewRequest("GET", fmt.Sprintf("%s/segment_%d.ts", server.URL, i), nil)
+					setUpstreamHeaders(req)
+
+					resp, err := client.Do(req)
+					if err != nil {
+						b.Fatal(err)
+					}
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+			}
+
+			b.ReportMetric(float64(connections.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
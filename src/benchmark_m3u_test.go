@@ -141,7 +141,7 @@ func BenchmarkFilterM3U(b *testing.B) {
 
 					for i := 0; i < b.N; i++ {
 						for _, streamMap := range parsedM3U {
-							_ = xteveSrc.FilterThisStream(streamMap)
+							_, _ = xteveSrc.FilterThisStream(streamMap)
 						}
 					}
 				})
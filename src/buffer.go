@@ -1,6 +1,10 @@
 package src
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -8,8 +12,11 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"maps"
@@ -30,6 +37,17 @@ import (
 
 var errTunerLimitReached = errors.New("tuner limit reached")
 
+var errStreamStalled = errors.New("stream stalled: no new PTS was received")
+
+var errClientDisconnected = errors.New("client disconnected")
+
+// bufferFaultHook : Called in every loop of bufferingStream and connectToStreamingServer with the name of the
+// function. Only used by tests to inject a panic.
+var bufferFaultHook func(function string)
+
+// streamFolderSequence : Makes the temp folders of the streams unique (newStreamFolder)
+var streamFolderSequence atomic.Int64
+
 func createStreamID(stream map[int]ThisStream) (streamID int) {
 	var debug string
 
@@ -47,17 +65,25 @@ func createStreamID(stream map[int]ThisStream) (streamID int) {
 	return
 }
 
-func reserveStreamSlot(playlistID, streamingURL, channelName string) (*Playlist, ThisStream, ThisClient, int, bool, error) {
+func reserveStreamSlot(playlistID, streamingURL, channelName, clientIP string) (*Playlist, ThisStream, ThisClient, int, bool, error) {
 	Lock.Lock()
 	defer Lock.Unlock()
 
 	if p, ok := BufferInformation.Load(playlistID); !ok {
 		playlist, stream, client, streamID, err := createNewPlaylist(playlistID, streamingURL, channelName)
+		if err == nil {
+			pushStreamStatus(channelName, "start")
+			logStreamStart(playlistID, streamID, channelName, clientIP)
+		}
 		return playlist, stream, client, streamID, true, err
 	} else {
 		// Playlist is already being used for streaming
 		if playlist, ok := p.(*Playlist); ok {
 			stream, client, streamID, newStream, err := handleExistingPlaylist(playlist, playlistID, streamingURL, channelName)
+			if err == nil && newStream {
+				pushStreamStatus(channelName, "start")
+				logStreamStart(playlistID, streamID, channelName, clientIP)
+			}
 			return playlist, stream, client, streamID, newStream, err
 		}
 		return nil, ThisStream{}, ThisClient{}, -1, false, errors.New("invalid playlist type in map")
@@ -89,6 +115,7 @@ func createNewPlaylist(playlistID, streamingURL, channelName string) (*Playlist,
 	}
 
 	playlist.Tuner = getTuner(playlistID, playlistType)
+	playlist.MaxStreams = getMaxConcurrentStreams(playlistID, playlistType)
 	playlist.PlaylistName = getProviderParameter(playlist.PlaylistID, playlistType, "name")
 
 	// Create Default Values for the Stream
@@ -103,7 +130,8 @@ func createNewPlaylist(playlistID, streamingURL, channelName string) (*Playlist,
 	if err != nil {
 		return playlist, stream, client, -1, err
 	}
-	stream.Folder = playlist.Folder + stream.MD5 + string(os.PathSeparator)
+	stream.Folder = newStreamFolder(playlist, stream.MD5)
+	stream.Format = getStreamFormat(playlistID, streamingURL)
 	stream.PlaylistID = playlistID
 	stream.PlaylistName = playlist.PlaylistName
 
@@ -133,6 +161,7 @@ func handleExistingPlaylist(playlist *Playlist, playlistID, streamingURL, channe
 
 			if c, ok := BufferClients.Load(playlistID + stream.MD5); ok {
 				if clients, ok := c.(*ClientConnection); ok {
+					stopLinger(clients)
 					clients.Connection = clients.Connection + 1
 					showInfo(fmt.Sprintf("Streaming Status:Channel: %s (Clients: %d)", stream.ChannelName, clients.Connection))
 				}
@@ -143,11 +172,17 @@ func handleExistingPlaylist(playlist *Playlist, playlistID, streamingURL, channe
 
 	// New Stream for an already active Playlist
 	if newStream {
-		if len(playlist.Streams) >= playlist.Tuner {
+		if len(playlist.Streams) >= playlist.Tuner && !removeLingeringStream(playlist, playlistID) {
 			showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - No new connections available. Tuner = %d", playlist.PlaylistName, playlist.Tuner))
 			return stream, client, -1, false, errTunerLimitReached
 		}
 
+		// The provider allows fewer connections than tuners are advertised to the clients
+		if playlist.MaxStreams > 0 && len(playlist.Streams) >= playlist.MaxStreams && !removeLingeringStream(playlist, playlistID) {
+			showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - No new connections available. Max concurrent streams = %d", playlist.PlaylistName, playlist.MaxStreams))
+			return stream, client, -1, false, errTunerLimitReached
+		}
+
 		// Playlist allows another Stream (The Tuner limit has not yet been reached)
 		stream = ThisStream{}
 		client = ThisClient{}
@@ -163,7 +198,8 @@ func handleExistingPlaylist(playlist *Playlist, playlistID, streamingURL, channe
 		if err != nil {
 			return stream, client, -1, false, err
 		}
-		stream.Folder = playlist.Folder + stream.MD5 + string(os.PathSeparator)
+		stream.Folder = newStreamFolder(playlist, stream.MD5)
+		stream.Format = getStreamFormat(playlistID, streamingURL)
 		stream.PlaylistID = playlistID
 		stream.PlaylistName = playlist.PlaylistName
 
@@ -177,22 +213,12 @@ func handleExistingPlaylist(playlist *Playlist, playlistID, streamingURL, channe
 func bufferingStream(playlistID, streamingURL, channelName string, w http.ResponseWriter, r *http.Request) {
 	time.Sleep(time.Duration(Settings.BufferTimeout) * time.Millisecond)
 
-	var playlist *Playlist
-	var stream ThisStream
-	var streaming = false
-	var streamID int
-	var timeOut = 0
-	var newStream bool
-	var err error
-
-	rc := http.NewResponseController(w)
-
 	w.Header().Set("Connection", "close")
 
-	playlist, stream, _, streamID, newStream, err = reserveStreamSlot(playlistID, streamingURL, channelName)
+	playlist, stream, _, streamID, newStream, err := reserveStreamSlot(playlistID, streamingURL, channelName, getClientIP(r))
 	if err != nil {
 		if err == errTunerLimitReached {
-			serveStreamLimitVideo(w)
+			serveTunerLimitResponse(w, r)
 		} else {
 			ShowError(err, 000)
 			httpStatusError(w, r, 404)
@@ -200,6 +226,28 @@ func bufferingStream(playlistID, streamingURL, channelName string, w http.Respon
 		return
 	}
 
+	sendBufferedStream(playlistID, playlist, stream, streamID, newStream, w, r)
+}
+
+// sendBufferedStream : Sends the stream of a slot from reserveStreamSlot to the client, a new stream starts the buffer.
+// The slot is released when the client has terminated the connection.
+func sendBufferedStream(playlistID string, playlist *Playlist, stream ThisStream, streamID int, newStream bool, w http.ResponseWriter, r *http.Request) {
+	var streaming = false
+	var timeOut = 0
+
+	logCtx := withStreamLogID(r.Context())
+
+	w = newPacedResponseWriter(r.Context(), w)
+	rc := http.NewResponseController(w)
+
+	// A panic must not leak the tuner, the client connection is terminated
+	defer func() {
+		if r := recover(); r != nil {
+			ShowError(fmt.Errorf("buffer: panic in sendBufferedStream (%s): %v", stream.ChannelName, r), 0)
+			killClientConnection(streamID, playlistID, false)
+		}
+	}()
+
 	// Check whether the Stream is already being played by another Client
 	if !playlist.Streams[streamID].Status && newStream {
 		// New buffer is required.
@@ -212,17 +260,18 @@ func bufferingStream(playlistID, streamingURL, channelName string, w http.Respon
 		case "xteve":
 			// Extract span from request context and create a detached context with it
 			span := trace.SpanFromContext(r.Context())
-			ctx := trace.ContextWithSpan(context.WithoutCancel(r.Context()), span)
+			ctx := trace.ContextWithSpan(context.WithoutCancel(logCtx), span)
 			go connectToStreamingServer(streamID, playlistID, ctx)
 		default:
 			break
 		}
 
-		showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: %d / %d", playlist.PlaylistName, len(playlist.Streams), playlist.Tuner))
+		showStreamInfo(logCtx, fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: %d / %d", playlist.PlaylistName, len(playlist.Streams), playlist.Tuner))
 
 	}
 
-	w.WriteHeader(200)
+	// The header is written with the first segment, after the content type has been detected.
+	// The body has no Content-Length, it is sent with chunked transfer encoding.
 
 	for { // Loop 1: Wait until the first Segment has been downloaded by the Buffer
 		if p, ok := BufferInformation.Load(playlistID); ok {
@@ -253,6 +302,10 @@ func bufferingStream(playlistID, streamingURL, channelName string, w http.Respon
 				sentSegments := make(map[string]bool)
 
 				for { // Loop 2: Temporary files are available, Data can be sent to the Client
+					if bufferFaultHook != nil {
+						bufferFaultHook("bufferingStream")
+					}
+
 					shouldBreak, sendErr := sendSegmentsToClient(r.Context(), playlistID, streamID, &stream, w, rc, &streaming, sentSegments)
 					if sendErr != nil {
 						// Error implies connection should be killed and we return
@@ -265,27 +318,56 @@ func bufferingStream(playlistID, streamingURL, channelName string, w http.Respon
 			} else {
 				// Stream not available
 				killClientConnection(streamID, stream.PlaylistID, false)
-				showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: %d / %d", playlist.PlaylistName, len(playlist.Streams), playlist.Tuner))
+				showStreamInfo(logCtx, fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: %d / %d", playlist.PlaylistName, len(playlist.Streams), playlist.Tuner))
 				return
 			}
+		} else {
+			// The buffer has been removed
+			return
 		} // End of Buffer Information
 	} // End of Loop 1
 }
 
-func serveStreamLimitVideo(w http.ResponseWriter) {
-	content, err := webUI.ReadFile("video/stream-limit.bin")
+// tunerLimitRetryAfter : Retry-After (seconds) of the 503 response if all tuners are in use
+const tunerLimitRetryAfter = 30
+
+// serveTunerLimitResponse : Response to a client if all tuners are in use (tuner.limit.response)
+func serveTunerLimitResponse(w http.ResponseWriter, r *http.Request) {
+	switch Settings.TunerLimitResponse {
+	case "503":
+		w.Header().Set("Retry-After", strconv.Itoa(tunerLimitRetryAfter))
+		http.Error(w, "All tuners are in use", http.StatusServiceUnavailable)
+		return
+	case "redirect-to-url":
+		if len(Settings.TunerLimitRedirectURL) > 0 {
+			http.Redirect(w, r, Settings.TunerLimitRedirectURL, http.StatusFound)
+			return
+		}
+	}
+
+	serveStreamLimitVideo(w, r)
+}
+
+func serveStreamLimitVideo(w http.ResponseWriter, r *http.Request) {
+	content, err := webUI.ReadFile("html/video/stream-limit.bin")
 	if err == nil {
+		var rc = http.NewResponseController(w)
+
+		w.Header().Set("Content-Type", "video/mpeg")
 		w.WriteHeader(200)
-		w.Header().Set("Content-type", "video/mpeg")
-		w.Header().Set("Content-Length:", "0")
 
 		for i := 1; i < 60; i++ {
-			_ = i
 			if _, errWrite := w.Write(content); errWrite != nil {
 				// Log error and break, client connection is likely gone
 				return
 			}
-			time.Sleep(time.Duration(500) * time.Millisecond)
+			_ = rc.Flush()
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(time.Duration(500) * time.Millisecond):
+			}
 		}
 	}
 }
@@ -340,13 +422,7 @@ func sendSegmentsToClient(ctx context.Context, playlistID string, streamID int,
 	}
 
 	// Clean up old segment files from disk
-	if len(stream.OldSegments) > 20 {
-		fileToRemove := stream.Folder + stream.OldSegments[0]
-		if err := bufferVFS.RemoveAll(getPlatformFile(fileToRemove)); err != nil {
-			ShowError(err, 4007)
-		}
-		stream.OldSegments = slices.Delete(stream.OldSegments, 0, 1)
-	}
+	removeOldSegments(playlistID, streamID, stream)
 
 	// 4. Wait if there's nothing to do
 	if len(filesToSend) == 0 {
@@ -383,10 +459,8 @@ func sendSingleSegmentToClient(fts segmentToSend, stream *ThisStream, streamID i
 		_, err = file.Read(buffer)
 		if err == nil {
 			if !*streaming {
-				contentType := http.DetectContentType(buffer)
-				w.Header().Set("Content-type", contentType)
-				w.Header().Set("Content-Length", "0")
-				w.Header().Set("Connection", "close")
+				w.Header().Set("Content-Type", http.DetectContentType(buffer))
+				w.WriteHeader(200)
 				*streaming = true
 			}
 			if Settings.BufferClientTimeout > 0 {
@@ -396,6 +470,8 @@ func sendSingleSegmentToClient(fts segmentToSend, stream *ThisStream, streamID i
 				killClientConnection(streamID, playlistID, false)
 				return errWrite
 			}
+			_ = rc.Flush()
+			addStreamLogBytes(playlistID, streamID, len(buffer))
 		}
 	}
 
@@ -412,6 +488,73 @@ func sendSingleSegmentToClient(fts segmentToSend, stream *ThisStream, streamID i
 	return nil
 }
 
+// pacedResponseWriter : Writes to the client in chunks of stream.client.chunk.kb with at least stream.client.pacing.ms between two writes.
+// Some players stutter if a whole segment arrives at once.
+type pacedResponseWriter struct {
+	http.ResponseWriter
+	ctx       context.Context
+	interval  time.Duration
+	chunkSize int
+	lastWrite time.Time
+}
+
+// newPacedResponseWriter : Without stream.client.pacing.ms and stream.client.chunk.kb the client is written to directly
+func newPacedResponseWriter(ctx context.Context, w http.ResponseWriter) http.ResponseWriter {
+	if Settings.StreamClientPacingMS <= 0 && Settings.StreamClientChunkKB <= 0 {
+		return w
+	}
+
+	return &pacedResponseWriter{
+		ResponseWriter: w,
+		ctx:            ctx,
+		interval:       time.Duration(Settings.StreamClientPacingMS) * time.Millisecond,
+		chunkSize:      Settings.StreamClientChunkKB * 1024,
+	}
+}
+
+func (p *pacedResponseWriter) Write(data []byte) (n int, err error) {
+	var rc = http.NewResponseController(p.ResponseWriter)
+
+	for len(data) > 0 {
+		var chunk = data
+		if p.chunkSize > 0 && len(chunk) > p.chunkSize {
+			chunk = chunk[:p.chunkSize]
+		}
+
+		if wait := p.interval - time.Since(p.lastWrite); !p.lastWrite.IsZero() && wait > 0 {
+			var timer = time.NewTimer(wait)
+			select {
+			case <-p.ctx.Done():
+				timer.Stop()
+				return n, p.ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		// The deadline applies to each chunk, the pacing must not time out the client
+		if Settings.BufferClientTimeout > 0 {
+			_ = rc.SetWriteDeadline(time.Now().Add(time.Duration(Settings.BufferClientTimeout) * time.Millisecond))
+		}
+
+		var written int
+		written, err = p.ResponseWriter.Write(chunk)
+		n += written
+		if err != nil {
+			return
+		}
+
+		_ = rc.Flush()
+		p.lastWrite = time.Now()
+		data = data[len(chunk):]
+	}
+	return
+}
+
+// Unwrap : Used by http.ResponseController
+func (p *pacedResponseWriter) Unwrap() http.ResponseWriter {
+	return p.ResponseWriter
+}
+
 func killClientConnection(streamID int, playlistID string, force bool) {
 	Lock.Lock()
 	defer Lock.Unlock()
@@ -424,8 +567,20 @@ func killClientConnection(streamID int, playlistID string, force bool) {
 			return
 		}
 
+		// force: The stream is removed for all clients
 		if force {
-			delete(playlist.Streams, streamID)
+			if stream, ok := playlist.Streams[streamID]; ok {
+				BufferClients.Delete(playlistID + stream.MD5)
+				delete(playlist.Streams, streamID)
+				delete(playlist.Clients, streamID)
+				hlsPlaylistTypes.Delete(stream.URL)
+				pushStreamStatus(stream.ChannelName, "stop")
+				logStreamStop(playlistID, streamID)
+			}
+
+			if len(playlist.Streams) == 0 {
+				BufferInformation.Delete(playlistID)
+			}
 			showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: %d / %d", playlist.PlaylistName, len(playlist.Streams), playlist.Tuner))
 			return
 		}
@@ -439,22 +594,102 @@ func killClientConnection(streamID int, playlistID string, force bool) {
 					showInfo(fmt.Sprintf("Streaming Status:Channel: %s (Clients: %d)", stream.ChannelName, clients.Connection))
 
 					if clients.Connection <= 0 {
-						BufferClients.Delete(playlistID + stream.MD5)
-						delete(playlist.Streams, streamID)
-						delete(playlist.Clients, streamID)
-						showInfo(fmt.Sprintf("Streaming Status:Channel: %s - No client is using this channel anymore. Streaming Server connection has ended", stream.ChannelName))
+						if Settings.StreamLingerSeconds > 0 {
+							lingerStream(playlist, playlistID, streamID, stream.MD5, clients)
+							return
+						}
+
+						removeStream(playlist, playlistID, streamID, stream)
 					}
 				}
 			}
 
-			if len(playlist.Streams) == 0 {
-				BufferInformation.Delete(playlistID)
-				showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: 0 / %d", playlist.PlaylistName, playlist.Tuner))
-			} else {
-				showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: %d / %d", playlist.PlaylistName, len(playlist.Streams), playlist.Tuner))
+			showTunerStatus(playlist, playlistID)
+		}
+	}
+}
+
+// removeStream : Ends the stream, the connection to the streaming server is closed. The caller holds Lock.
+func removeStream(playlist *Playlist, playlistID string, streamID int, stream ThisStream) {
+	BufferClients.Delete(playlistID + stream.MD5)
+	delete(playlist.Streams, streamID)
+	delete(playlist.Clients, streamID)
+	hlsPlaylistTypes.Delete(stream.URL)
+	showInfo(fmt.Sprintf("Streaming Status:Channel: %s - No client is using this channel anymore. Streaming Server connection has ended", stream.ChannelName))
+	pushStreamStatus(stream.ChannelName, "stop")
+	logStreamStop(playlistID, streamID)
+}
+
+// showTunerStatus : Removes the playlist from the buffer if no stream is left. The caller holds Lock.
+func showTunerStatus(playlist *Playlist, playlistID string) {
+	if len(playlist.Streams) == 0 {
+		BufferInformation.Delete(playlistID)
+		showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: 0 / %d", playlist.PlaylistName, playlist.Tuner))
+	} else {
+		showInfo(fmt.Sprintf("Streaming Status:Playlist: %s - Tuner: %d / %d", playlist.PlaylistName, len(playlist.Streams), playlist.Tuner))
+	}
+}
+
+// lingerStream : The stream keeps buffering for stream.linger.seconds after the last client disconnected,
+// a client that reconnects within this time uses the same connection to the streaming server.
+// The lingering stream occupies its tuner until it is removed. The caller holds Lock.
+func lingerStream(playlist *Playlist, playlistID string, streamID int, streamMD5 string, clients *ClientConnection) {
+	showInfo(fmt.Sprintf("Streaming Status:Channel: %s - No client is using this channel, waiting %d seconds for a reconnect", playlist.Streams[streamID].ChannelName, Settings.StreamLingerSeconds))
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(Settings.StreamLingerSeconds)*time.Second, func() {
+		Lock.Lock()
+		defer Lock.Unlock()
+
+		// Replaced by a reconnect
+		if clients.Linger != timer {
+			return
+		}
+		clients.Linger = nil
+
+		// The buffer may have been removed and created again in the meantime
+		if p, ok := BufferInformation.Load(playlistID); !ok || p != playlist {
+			return
+		}
+		if c, ok := BufferClients.Load(playlistID + streamMD5); !ok || c != clients {
+			return
+		}
+
+		if stream, ok := playlist.Streams[streamID]; ok && stream.MD5 == streamMD5 && clients.Connection <= 0 {
+			removeStream(playlist, playlistID, streamID, stream)
+			showTunerStatus(playlist, playlistID)
+		}
+	})
+
+	clients.Linger = timer
+}
+
+// stopLinger : A client has reconnected to a lingering stream. The caller holds Lock.
+func stopLinger(clients *ClientConnection) {
+	if clients.Linger != nil {
+		clients.Linger.Stop()
+		clients.Linger = nil
+	}
+}
+
+// removeLingeringStream : Frees the tuner of a stream without clients for a new stream. The caller holds Lock.
+func removeLingeringStream(playlist *Playlist, playlistID string) bool {
+	for id, stream := range playlist.Streams {
+		if c, ok := BufferClients.Load(playlistID + stream.MD5); ok {
+			if clients, ok := c.(*ClientConnection); ok && clients.Connection <= 0 && clients.Linger != nil {
+				stopLinger(clients)
+				removeStream(playlist, playlistID, id, stream)
+				return true
 			}
 		}
 	}
+	return false
+}
+
+// newStreamFolder : Temp folder of a new stream. A stream that is started again for the same URL gets another folder,
+// the connection of the removed stream ends with a delay and must not write into the segments of the new stream.
+func newStreamFolder(playlist *Playlist, streamMD5 string) string {
+	return playlist.Folder + streamMD5 + "-" + strconv.FormatInt(streamFolderSequence.Add(1), 10) + string(os.PathSeparator)
 }
 
 func clientConnection(stream ThisStream) (status bool) {
@@ -463,7 +698,19 @@ func clientConnection(stream ThisStream) (status bool) {
 	defer Lock.Unlock()
 
 	if _, ok := BufferClients.Load(stream.PlaylistID + stream.MD5); !ok {
-		status = false
+		return false
+	}
+
+	// The stream may have been removed and started again for the same URL in the meantime
+	if p, ok := BufferInformation.Load(stream.PlaylistID); ok && len(stream.Folder) > 0 {
+		if playlist, ok := p.(*Playlist); ok {
+			status = false
+			for _, current := range playlist.Streams {
+				if current.MD5 == stream.MD5 && current.Folder == stream.Folder {
+					status = true
+				}
+			}
+		}
 	}
 	return
 }
@@ -473,6 +720,14 @@ func connectToStreamingServer(streamID int, playlistID string, ctx context.Conte
 	ctx, span := tracer.Start(ctx, "connectToStreamingServer")
 	defer span.End()
 
+	// A panic must not leak the tuner, the stream is terminated for all clients
+	defer func() {
+		if r := recover(); r != nil {
+			ShowError(fmt.Errorf("buffer: panic in connectToStreamingServer: %v", r), 0)
+			killClientConnection(streamID, playlistID, true)
+		}
+	}()
+
 	if p, ok := BufferInformation.Load(playlistID); ok {
 		var playlist *Playlist
 		if pl, ok := p.(*Playlist); ok {
@@ -498,10 +753,10 @@ func connectToStreamingServer(streamID int, playlistID string, ctx context.Conte
 			go func(folder string) {
 				time.Sleep(60 * time.Second)
 				debugMsg := fmt.Sprintf("Streaming Status:Remove temporary files (%s)", folder)
-				showDebug(debugMsg, 1)
+				showStreamDebug(ctx, debugMsg, 1)
 
 				debugMsg = fmt.Sprintf("Remove tmp folder:%s", folder)
-				showDebug(debugMsg, 1)
+				showStreamDebug(ctx, debugMsg, 1)
 
 				if err := bufferVFS.RemoveAll(getPlatformPath(folder)); err != nil {
 					ShowError(err, 4005)
@@ -539,6 +794,10 @@ func connectToStreamingServer(streamID int, playlistID string, ctx context.Conte
 
 		// M3U8 Segments
 		for {
+			if bufferFaultHook != nil {
+				bufferFaultHook("connectToStreamingServer")
+			}
+
 			setupInitialStreamSegment(playlist, streamID, &timeOut)
 
 			if len(m3u8Segments) > 30 {
@@ -552,11 +811,11 @@ func connectToStreamingServer(streamID int, playlistID string, ctx context.Conte
 
 			if !stream.Status {
 				if strings.Contains(stream.URL, ".m3u8") {
-					showInfo("Streaming Type:" + "[HLS / M3U8]")
+					showStreamInfo(ctx, "Streaming Type:"+"[HLS / M3U8]")
 				} else {
-					showInfo("Streaming Type:" + "[TS]")
+					showStreamInfo(ctx, "Streaming Type:"+"[TS]")
 				}
-				showInfo("Streaming URL:" + stream.URL)
+				showStreamInfo(ctx, "Streaming URL:"+stream.URL)
 			}
 
 			stream.TimeStart = time.Now()
@@ -564,6 +823,19 @@ func connectToStreamingServer(streamID int, playlistID string, ctx context.Conte
 			bandwidth.Size = 0
 
 			if err := processSegments(ctx, &stream, streamID, playlistID, tmpFolder, &tmpSegment, addErrorToStream, buffer, &bandwidth); err != nil {
+				// auto.disable.dead.channels: Only a connection that did not deliver any data is a failure of the channel
+				if stream.Status {
+					resetStreamFailures(playlistID, stream.URL)
+				} else if !errors.Is(err, errClientDisconnected) {
+					recordStreamFailure(playlistID, stream.URL, time.Now())
+				}
+				return
+			}
+
+			// VOD: The playlist is complete, it is not requested again. The clients get the remaining segments.
+			if stream.HLS && stream.VOD {
+				showStreamInfo(ctx, "Streaming Status:VOD playlist completely buffered")
+				setStreamFinished(playlistID, streamID)
 				return
 			}
 		} // End for loop
@@ -583,7 +855,7 @@ func processSegments(ctx context.Context, stream *ThisStream, streamID int, play
 
 	for {
 		if !clientConnection(*stream) {
-			return errors.New("client disconnected")
+			return errClientDisconnected
 		}
 
 		if len(stream.Segment) == 0 || len(stream.URL) == 0 {
@@ -609,6 +881,11 @@ func processSegments(ctx context.Context, stream *ThisStream, streamID int, play
 			return errors.New("stream finished")
 		}
 
+		// The segments of a VOD playlist were all downloaded by handleHLSStream
+		if stream.HLS && stream.VOD {
+			return nil
+		}
+
 		// Calculate the waiting time for the Download of the next Segment
 		if stream.HLS {
 			var sleep float64
@@ -620,7 +897,7 @@ func processSegments(ctx context.Context, stream *ThisStream, streamID int, play
 				sleep = max((segment.Duration-stream.TimeDiff)-(segment.Duration*0.25), 0)
 
 				debug := fmt.Sprintf("HLS Status:Download time: %f s | Segment duration: %f s | Sleep: %f s Sequence: %d", stream.TimeDiff, segment.Duration, sleep, segment.Sequence)
-				showDebug(debug, 1)
+				showStreamDebug(ctx, debug, 1)
 
 				if sleep > 0 {
 					for i := 0.0; i < sleep*1000; i = i + 100 {
@@ -675,22 +952,27 @@ func processStreamingServerResponse(ctx context.Context, stream *ThisStream, cur
 	)
 
 	debug := fmt.Sprintf("Connection to:%s", currentURL)
-	showDebug(debug, 2)
+	showStreamDebug(ctx, debug, 2)
 
 	var retries = 0
-	// Jump for redirect (301 <---> 308)
-	req, _ := http.NewRequestWithContext(ctx, "GET", currentURL, nil)
-	req.Header.Set("User-Agent", Settings.UserAgent)
-	req.Header.Set("Connection", "close")
-	req.Header.Set("Accept", "*/*")
-	if stream.TotalBytesDownloaded > 0 {
-		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", stream.TotalBytesDownloaded))
-	}
-	debugRequest(req)
+	var resp *http.Response
+	var err error
 
-	client := NewHTTPClient()
+	if isNativeMulticast(currentURL) {
+		resp, err = openMulticastStream(ctx, currentURL)
+	} else {
+		// Jump for redirect (301 <---> 308)
+		req, _ := http.NewRequestWithContext(ctx, "GET", currentURL, nil)
+		setUpstreamHeaders(req)
+		if stream.TotalBytesDownloaded > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", stream.TotalBytesDownloaded))
+		}
+		debugRequest(req)
 
-	resp, err := ConnectWithRetry(client, req)
+		client := NewHTTPClient()
+
+		resp, err = ConnectWithRetry(client, req)
+	}
 
 	if err != nil {
 		ShowError(err, 0)
@@ -704,7 +986,7 @@ func processStreamingServerResponse(ctx context.Context, stream *ThisStream, cur
 	// If we requested a Range but the server ignored it (sent 200 instead of 206),
 	// and we know it's a file of known length, we must manually skip bytes to avoid overlap.
 	if resp.StatusCode == http.StatusOK && stream.TotalBytesDownloaded > 0 && resp.ContentLength > 0 {
-		showInfo(fmt.Sprintf("Server ignored Range request, manually skipping %d bytes", stream.TotalBytesDownloaded))
+		showStreamInfo(ctx, fmt.Sprintf("Server ignored Range request, manually skipping %d bytes", stream.TotalBytesDownloaded))
 		_, err = io.CopyN(io.Discard, resp.Body, stream.TotalBytesDownloaded)
 		if err != nil {
 			ShowError(err, 0)
@@ -735,16 +1017,16 @@ func processStreamingServerResponse(ctx context.Context, stream *ThisStream, cur
 		}
 
 		debug = fmt.Sprintf("Server URL:%s", stream.URLStreamingServer)
-		showDebug(debug, 1)
+		showStreamDebug(ctx, debug, 1)
 
 		debug = fmt.Sprintf("Temp Folder:%s", tmpFolder)
-		showDebug(debug, 1)
+		showStreamDebug(ctx, debug, 1)
 
-		showInfo("Streaming Status:" + "HTTP Response Status [" + strconv.Itoa(resp.StatusCode) + "] " + http.StatusText(resp.StatusCode))
-		showInfo("Content Type:" + contentType)
+		showStreamInfo(ctx, "Streaming Status:"+"HTTP Response Status ["+strconv.Itoa(resp.StatusCode)+"] "+http.StatusText(resp.StatusCode))
+		showStreamInfo(ctx, "Content Type:"+contentType)
 	} else {
 		debug = fmt.Sprintf("Content Type:%s", contentType)
-		showDebug(debug, 2)
+		showStreamDebug(ctx, debug, 2)
 	}
 
 	// Clean up Content Type
@@ -753,6 +1035,14 @@ func processStreamingServerResponse(ctx context.Context, stream *ThisStream, cur
 		contentType = strings.ToLower(ct[0])
 	}
 
+	// x-stream-format of the channel replaces a missing or wrong Content-Type
+	switch stream.Format {
+	case "ts":
+		contentType = "video/mp2t"
+	case "hls":
+		contentType = "application/vnd.apple.mpegurl"
+	}
+
 	switch contentType {
 	// M3U8 Playlist
 	case "application/x-mpegurl", "application/vnd.apple.mpegurl", "audio/mpegurl", "audio/x-mpegurl":
@@ -776,7 +1066,7 @@ func processStreamingServerResponse(ctx context.Context, stream *ThisStream, cur
 		}
 	// Unknown Format
 	default:
-		showInfo("Content Type:" + resp.Header.Get("Content-Type"))
+		showStreamInfo(ctx, "Content Type:"+resp.Header.Get("Content-Type"))
 		err = errors.New("streaming error")
 		ShowError(err, 4003)
 
@@ -848,9 +1138,7 @@ func (stream *ThisStream) handleHLSStream(ctx context.Context, resp *http.Respon
 			// Retry loop for the segment
 			for retry := 0; retry < 3; retry++ {
 				req, _ := http.NewRequestWithContext(ctx, "GET", segment.URL, nil)
-				req.Header.Set("User-Agent", Settings.UserAgent)
-				req.Header.Set("Connection", "close")
-				req.Header.Set("Accept", "*/*")
+				setUpstreamHeaders(req)
 				debugRequest(req)
 
 				segResp, reqErr := ConnectWithRetry(client, req)
@@ -875,6 +1163,15 @@ func (stream *ThisStream) handleHLSStream(ctx context.Context, resp *http.Respon
 				continue // Skip this segment after retries fail
 			}
 
+			if Settings.HLSDecrypt && segment.Key.Method == "AES-128" {
+				body, err = stream.decryptHLSSegment(ctx, client, segment, body)
+				if err != nil {
+					ShowError(err, 0)
+					addErrorToStream(err)
+					continue
+				}
+			}
+
 			tmpFile := fmt.Sprintf("%s%d.ts", tmpFolder, *tmpSegment)
 			bufferFile, err := bufferVFS.Create(tmpFile)
 			if err != nil {
@@ -900,6 +1197,96 @@ func (stream *ThisStream) handleHLSStream(ctx context.Context, resp *http.Respon
 	return nil
 }
 
+// decryptHLSSegment decrypts an AES-128 encrypted HLS segment (hls.decrypt)
+func (stream *ThisStream) decryptHLSSegment(ctx context.Context, client *http.Client, segment Segment, data []byte) ([]byte, error) {
+	key, err := stream.hlsKey(ctx, client, segment.Key.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without IV, the media sequence number is used as IV
+	var iv = segment.Key.IV
+	if len(iv) == 0 {
+		iv = make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint64(iv[8:], uint64(segment.Sequence))
+	}
+
+	return decryptAES128(data, key, iv)
+}
+
+// maxHLSKeys : Downloaded keys kept per stream. Playlists that rotate the key with every segment
+// only need the keys of the current playlist, older keys are downloaded again if needed.
+const maxHLSKeys = 16
+
+// hlsKey returns the AES-128 key, every key is only downloaded once per stream
+func (stream *ThisStream) hlsKey(ctx context.Context, client *http.Client, uri string) ([]byte, error) {
+	if len(uri) == 0 {
+		return nil, errors.New("#EXT-X-KEY without URI")
+	}
+
+	if key, ok := stream.HLSKeys[uri]; ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	setUpstreamHeaders(req)
+	debugRequest(req)
+
+	resp, err := ConnectWithRetry(client, req)
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	key, err := io.ReadAll(io.LimitReader(resp.Body, aes.BlockSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid AES-128 key: %d bytes (%s)", len(key), uri)
+	}
+
+	if stream.HLSKeys == nil {
+		stream.HLSKeys = make(map[string][]byte)
+	} else if len(stream.HLSKeys) >= maxHLSKeys {
+		clear(stream.HLSKeys)
+	}
+	stream.HLSKeys[uri] = key
+
+	showStreamDebug(ctx, "HLS Key:"+uri, 2)
+	return key, nil
+}
+
+// decryptAES128 decrypts AES-128 CBC data with PKCS#7 padding
+func decryptAES128(data, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("encrypted segment is not a multiple of the AES block size: %d bytes", len(data))
+	}
+
+	var plain = make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// All padding bytes have the value of the padding length
+	var padding = int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("invalid padding of the encrypted segment, wrong key?")
+	}
+
+	return plain[:len(plain)-padding], nil
+}
+
 func processTSStreamPacketsVFS(parser *mpegts.Parser, packetBuf []byte, bufferFile avfs.File, fileSize *int, tmpFileSize int, playlistID string, streamID int, stream *ThisStream, bandwidth *BandwidthCalculation, tmpFile *string, tmpFolder string, tmpSegment *int, addErrorToStream func(err error), state *tsStreamState) (avfs.File, error) {
 	for {
 		err := parser.NextInto(packetBuf)
@@ -912,6 +1299,12 @@ func processTSStreamPacketsVFS(parser *mpegts.Parser, packetBuf []byte, bufferFi
 			bufferFile.Close()
 			return nil, err
 		}
+		if state.pidsIn != nil {
+			state.pidsIn.Add(packetBuf)
+		}
+		if pts, ok := mpegts.ExtractPTS(packetBuf); ok {
+			state.trackPTS(packetBuf, pts)
+		}
 
 		// PCR tracking: eliminate the backward-overlap window that CDN
 		// connection rotation produces, then resume from exactly where the
@@ -959,33 +1352,88 @@ func processTSStreamPacketsVFS(parser *mpegts.Parser, packetBuf []byte, bufferFi
 		if !hasPCR {
 			state.packetsAfterLastPCR++
 		}
+		if state.pidsOut != nil {
+			state.pidsOut.Add(packetBuf)
+		}
 
 		if *fileSize >= tmpFileSize {
-			bufferFile.Close()
-			completeTSsegment(playlistID, streamID, stream, bandwidth, *fileSize, *tmpFile, *tmpSegment)
-			*tmpSegment++
+			bufferFile, err = nextTSSegmentVFS(bufferFile, fileSize, playlistID, streamID, stream, bandwidth, tmpFile, tmpFolder, tmpSegment, addErrorToStream)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return bufferFile, nil
+}
 
-			*tmpFile = fmt.Sprintf("%s%d.ts", tmpFolder, *tmpSegment)
+// writePassthroughVFS writes the upstream bytes unchanged (buffer.passthrough), without MPEG-TS parsing.
+// The segments are only sized by tmpFileSize.
+func writePassthroughVFS(data []byte, bufferFile avfs.File, fileSize *int, tmpFileSize int, playlistID string, streamID int, stream *ThisStream, bandwidth *BandwidthCalculation, tmpFile *string, tmpFolder string, tmpSegment *int, addErrorToStream func(err error)) (avfs.File, error) {
+	for len(data) > 0 {
+		var chunk = data[:min(len(data), tmpFileSize-*fileSize)]
 
-			if !clientConnection(*stream) {
-				if err = bufferVFS.RemoveAll(stream.Folder); err != nil {
-					ShowError(err, 4005)
-				}
-				return nil, errors.New("client connection lost")
-			}
+		if _, err := bufferFile.Write(chunk); err != nil {
+			ShowError(err, 0)
+			addErrorToStream(err)
+			bufferFile.Close()
+			return nil, err
+		}
+		*fileSize += len(chunk)
+		data = data[len(chunk):]
 
-			newBufferFile, err := bufferVFS.Create(*tmpFile)
+		if *fileSize >= tmpFileSize {
+			var err error
+			bufferFile, err = nextTSSegmentVFS(bufferFile, fileSize, playlistID, streamID, stream, bandwidth, tmpFile, tmpFolder, tmpSegment, addErrorToStream)
 			if err != nil {
-				addErrorToStream(err)
 				return nil, err
 			}
-			bufferFile = newBufferFile
-			*fileSize = 0
 		}
 	}
 	return bufferFile, nil
 }
 
+// nextTSSegmentVFS completes the current segment and creates the file for the next one
+func nextTSSegmentVFS(bufferFile avfs.File, fileSize *int, playlistID string, streamID int, stream *ThisStream, bandwidth *BandwidthCalculation, tmpFile *string, tmpFolder string, tmpSegment *int, addErrorToStream func(err error)) (avfs.File, error) {
+	bufferFile.Close()
+	completeTSsegment(playlistID, streamID, stream, bandwidth, *fileSize, *tmpFile, *tmpSegment)
+	*tmpSegment++
+
+	*tmpFile = fmt.Sprintf("%s%d.ts", tmpFolder, *tmpSegment)
+
+	if !clientConnection(*stream) {
+		if err := bufferVFS.RemoveAll(stream.Folder); err != nil {
+			ShowError(err, 4005)
+		}
+		return nil, errors.New("client connection lost")
+	}
+
+	newBufferFile, err := bufferVFS.Create(*tmpFile)
+	if err != nil {
+		addErrorToStream(err)
+		return nil, err
+	}
+	*fileSize = 0
+	return newBufferFile, nil
+}
+
+// isBufferPassthrough reports whether the provider of the playlist has buffer.passthrough enabled
+func isBufferPassthrough(playlistID string) bool {
+	var dataMap map[string]any
+
+	switch {
+	case strings.HasPrefix(playlistID, "M"):
+		dataMap = Settings.Files.M3U
+	case strings.HasPrefix(playlistID, "H"):
+		dataMap = Settings.Files.HDHR
+	}
+
+	if data, ok := dataMap[playlistID].(map[string]any); ok {
+		passthrough, _ := data["buffer.passthrough"].(bool)
+		return passthrough
+	}
+	return false
+}
+
 // tsStreamState carries per-connection PCR tracking data through the packet
 // writing loop.  Grouping the fields into a struct keeps the
 // processTSStreamPacketsVFS signature from growing further.
@@ -1014,6 +1462,63 @@ type tsStreamState struct {
 	// lastPCR was updated.  Saved at disconnect so the next connection can
 	// skip exactly those packets.
 	packetsAfterLastPCR int
+
+	// --- PID diagnostics (debug level 3, nil otherwise) ---
+
+	// pidsIn counts the packets per PID received from the server, pidsOut
+	// the packets per PID written to the buffer.
+	pidsIn, pidsOut mpegts.PIDCounter
+
+	// --- stall detection (stream.stall.detect.seconds) ---
+
+	// maxPTS is the highest PTS per PID, lastPTSAdvance the time one of them
+	// increased (zero until the first PTS, a slow stream start is no stall).
+	maxPTS         map[uint16]int64
+	lastPTSAdvance time.Time
+}
+
+// trackPTS remembers the highest PTS per PID and the time it last advanced.
+// A source that keeps sending the same frames (e.g. a static frame in a loop)
+// never advances the PTS, while the bytes keep flowing.
+// A PTS wrap-around (every ~26.5 hours) looks like a stall as well and only
+// causes one reconnect.
+func (state *tsStreamState) trackPTS(packet []byte, pts int64) {
+	if state.maxPTS == nil {
+		state.maxPTS = make(map[uint16]int64)
+	}
+
+	var pid = mpegts.PID(packet)
+	if last, ok := state.maxPTS[pid]; !ok || pts > last {
+		state.maxPTS[pid] = pts
+		state.lastPTSAdvance = time.Now()
+	}
+}
+
+// stalled reports whether no PTS advanced within stream.stall.detect.seconds
+func (state *tsStreamState) stalled() bool {
+	if Settings.StreamStallDetectSeconds <= 0 || state.lastPTSAdvance.IsZero() {
+		return false
+	}
+
+	return time.Since(state.lastPTSAdvance) > time.Duration(Settings.StreamStallDetectSeconds)*time.Second
+}
+
+// showPIDStatistics logs the packets per PID of a connection (debug level 3).
+// All PIDs (video, audio, subtitles, teletext, ...) are written to the buffer,
+// only the reconnect overlap window is dropped. A PID that is missing in the
+// buffer therefore points to a problem with the stream.
+func showPIDStatistics(state *tsStreamState) {
+	if state.pidsIn == nil {
+		return
+	}
+
+	for _, pid := range slices.Sorted(maps.Keys(state.pidsIn)) {
+		showDebug(fmt.Sprintf("Buffer PID:0x%04X (received: %d, buffered: %d)", pid, state.pidsIn[pid], state.pidsOut[pid]), 3)
+	}
+
+	for _, pid := range state.pidsIn.Missing(state.pidsOut) {
+		showDebug(fmt.Sprintf("Buffer PID:0x%04X was not written to the buffer", pid), 3)
+	}
 }
 
 // shortLivedConnThreshold is the maximum connection duration below which an
@@ -1022,11 +1527,11 @@ type tsStreamState struct {
 // skip the retry sleep so the reconnect happens immediately.
 const shortLivedConnThreshold = 60 * time.Second
 
-func handleTSStreamError(err error, bufferFile avfs.File, fileSize int, retries *int, tmpFile string, tmpSegment *int, stream *ThisStream, playlistID string, streamID int, bandwidth *BandwidthCalculation, addErrorToStream func(err error), connectedAt time.Time) bool {
+func handleTSStreamError(ctx context.Context, err error, bufferFile avfs.File, fileSize int, retries *int, tmpFile string, tmpSegment *int, stream *ThisStream, playlistID string, streamID int, bandwidth *BandwidthCalculation, addErrorToStream func(err error), connectedAt time.Time) bool {
 	if err != io.EOF {
 		if Settings.StreamRetryEnabled && *retries < Settings.StreamMaxRetries {
 			*retries++
-			showInfo(fmt.Sprintf("Stream Read Error (%s). Retry %d/%d in %d seconds.", err.Error(), *retries, Settings.StreamMaxRetries, Settings.StreamRetryDelay))
+			showStreamInfo(ctx, fmt.Sprintf("Stream Read Error (%s). Retry %d/%d in %d seconds.", err.Error(), *retries, Settings.StreamMaxRetries, Settings.StreamRetryDelay))
 			time.Sleep(time.Duration(Settings.StreamRetryDelay) * time.Second)
 			if bufferFile != nil {
 				bufferFile.Close()
@@ -1052,9 +1557,9 @@ func handleTSStreamError(err error, bufferFile avfs.File, fileSize int, retries
 			// immediately and avoid a gap in the buffered output.
 			connDuration := time.Since(connectedAt)
 			if connDuration < shortLivedConnThreshold {
-				showInfo(fmt.Sprintf("Stream EOF after %s (short-lived connection, reconnecting immediately). Retry %d/%d.", connDuration.Round(time.Millisecond), *retries, Settings.StreamMaxRetries))
+				showStreamInfo(ctx, fmt.Sprintf("Stream EOF after %s (short-lived connection, reconnecting immediately). Retry %d/%d.", connDuration.Round(time.Millisecond), *retries, Settings.StreamMaxRetries))
 			} else {
-				showInfo(fmt.Sprintf("Stream EOF (upstream closed connection). Retry %d/%d in %d seconds.", *retries, Settings.StreamMaxRetries, Settings.StreamRetryDelay))
+				showStreamInfo(ctx, fmt.Sprintf("Stream EOF (upstream closed connection). Retry %d/%d in %d seconds.", *retries, Settings.StreamMaxRetries, Settings.StreamRetryDelay))
 				time.Sleep(time.Duration(Settings.StreamRetryDelay) * time.Second)
 			}
 			if bufferFile != nil {
@@ -1087,7 +1592,13 @@ func handleTSStreamError(err error, bufferFile avfs.File, fileSize int, retries
 
 	stream.Status = true
 	stream.StreamFinished = true
+	setStreamFinished(playlistID, streamID)
+
+	return false
+}
 
+// setStreamFinished : Marks a stream as finished, the clients are disconnected after the last segment
+func setStreamFinished(playlistID string, streamID int) {
 	if p, ok := BufferInformation.Load(playlistID); ok {
 		if playlist, ok := p.(*Playlist); ok {
 			if s, ok := playlist.Streams[streamID]; ok {
@@ -1098,8 +1609,6 @@ func handleTSStreamError(err error, bufferFile avfs.File, fileSize int, retries
 			}
 		}
 	}
-
-	return false
 }
 
 func (stream *ThisStream) handleTSStream(ctx context.Context, resp *http.Response, streamID int, playlistID, tmpFolder string, tmpSegment *int, addErrorToStream func(err error), buffer []byte, bandwidth *BandwidthCalculation, retries int) (bool, error) {
@@ -1126,11 +1635,15 @@ func (stream *ThisStream) handleTSStream(ctx context.Context, resp *http.Respons
 		savedPacketsAfterLastPCR: stream.PacketsAfterLastPCR,
 	}
 
+	if debugLevel(debugBuffer) >= 3 {
+		state.pidsIn, state.pidsOut = mpegts.PIDCounter{}, mpegts.PIDCounter{}
+	}
+
 	debug = fmt.Sprintf("Buffer Size:%d KB [SERVER CONNECTION]", len(buffer)/1024)
-	showDebug(debug, 3)
+	showStreamDebug(ctx, debug, 3)
 
 	debug = fmt.Sprintf("Buffer Size:%d KB [CLIENT CONNECTION]", tmpFileSize/1024)
-	showDebug(debug, 3)
+	showStreamDebug(ctx, debug, 3)
 
 	var tmpFile = fmt.Sprintf("%s%d.ts", tmpFolder, *tmpSegment)
 
@@ -1149,30 +1662,41 @@ func (stream *ThisStream) handleTSStream(ctx context.Context, resp *http.Respons
 
 	parser := mpegts.NewParser()
 	packetBuf := make([]byte, mpegts.PacketSize)
+	passthrough := isBufferPassthrough(playlistID)
+	span.SetAttributes(attribute.Bool("passthrough", passthrough))
 
 	defer resp.Body.Close()
 	var lastBufferingFile string
 	for {
 		if fileSize == 0 && tmpFile != lastBufferingFile {
 			debug = fmt.Sprintf("Buffer Status:Buffering (%s)", tmpFile)
-			showDebug(debug, 2)
+			showStreamDebug(ctx, debug, 2)
 			lastBufferingFile = tmpFile
 		}
 
 		n, err := resp.Body.Read(buffer)
 		if n > 0 {
 			stream.TotalBytesDownloaded += int64(n)
-			if _, err := parser.Write(buffer[:n]); err != nil {
-				ShowError(err, 0)
-				addErrorToStream(err)
-				bufferFile.Close()
-				return false, err
-			}
 
-			bufferFile, err = processTSStreamPacketsVFS(parser, packetBuf, bufferFile, &fileSize, tmpFileSize, playlistID, streamID, stream, bandwidth, &tmpFile, tmpFolder, tmpSegment, addErrorToStream, state)
+			if passthrough {
+				bufferFile, err = writePassthroughVFS(buffer[:n], bufferFile, &fileSize, tmpFileSize, playlistID, streamID, stream, bandwidth, &tmpFile, tmpFolder, tmpSegment, addErrorToStream)
+			} else {
+				if _, err := parser.Write(buffer[:n]); err != nil {
+					ShowError(err, 0)
+					addErrorToStream(err)
+					bufferFile.Close()
+					return false, err
+				}
+
+				bufferFile, err = processTSStreamPacketsVFS(parser, packetBuf, bufferFile, &fileSize, tmpFileSize, playlistID, streamID, stream, bandwidth, &tmpFile, tmpFolder, tmpSegment, addErrorToStream, state)
+			}
 			if err != nil {
 				return false, err
 			}
+
+			if state.stalled() {
+				err = errStreamStalled
+			}
 		}
 
 		if err != nil {
@@ -1182,7 +1706,8 @@ func (stream *ThisStream) handleTSStream(ctx context.Context, resp *http.Respons
 				stream.LastPCR = state.lastPCR
 				stream.PacketsAfterLastPCR = state.packetsAfterLastPCR
 			}
-			return handleTSStreamError(err, bufferFile, fileSize, &retries, tmpFile, tmpSegment, stream, playlistID, streamID, bandwidth, addErrorToStream, connectedAt), nil // error is handled inside
+			showPIDStatistics(state)
+			return handleTSStreamError(ctx, err, bufferFile, fileSize, &retries, tmpFile, tmpSegment, stream, playlistID, streamID, bandwidth, addErrorToStream, connectedAt), nil // error is handled inside
 		}
 		retries = 0
 
@@ -1200,17 +1725,21 @@ func (stream *ThisStream) switchBandwidth() (err error) {
 	bandwidth := slices.Collect(maps.Keys(stream.DynamicStream))
 	slices.Sort(bandwidth)
 
+	// Use the moving average to avoid switching the variant on transient dips
+	networkBandwidth := stream.averageBandwidth()
+
 	if len(bandwidth) == 0 {
 		err = errors.New("M3U8 does not contain streaming URLs")
 		return
 	}
 
+	// Start with the lowest variant and pick the highest one that fits into the bandwidth
+	dynamicStream = stream.DynamicStream[bandwidth[0]]
+
 	for _, bw := range bandwidth {
 		segment.StreamInf.Bandwidth = stream.DynamicStream[bw].Bandwidth
 
-		dynamicStream = stream.DynamicStream[bandwidth[0]]
-
-		if stream.NetworkBandwidth == 0 || bw > stream.NetworkBandwidth {
+		if networkBandwidth == 0 || bw > networkBandwidth {
 			break
 		}
 		dynamicStream = stream.DynamicStream[bw]
@@ -1223,6 +1752,31 @@ func (stream *ThisStream) switchBandwidth() (err error) {
 	return
 }
 
+// addBandwidthSample stores the measured bandwidth and keeps the last samples for the moving average
+func (stream *ThisStream) addBandwidthSample(bandwidth int) {
+	var samples = max(Settings.HLSBandwidthSmoothingSamples, 1)
+
+	stream.BandwidthSamples = append(stream.BandwidthSamples, bandwidth)
+	if len(stream.BandwidthSamples) > samples {
+		stream.BandwidthSamples = slices.Clone(stream.BandwidthSamples[len(stream.BandwidthSamples)-samples:])
+	}
+}
+
+// averageBandwidth returns the moving average of the measured bandwidth.
+// Without samples, the configured (or last) network bandwidth is used.
+func (stream *ThisStream) averageBandwidth() int {
+	if len(stream.BandwidthSamples) == 0 {
+		return stream.NetworkBandwidth
+	}
+
+	var sum int
+	for _, sample := range stream.BandwidthSamples {
+		sum += sample
+	}
+
+	return sum / len(stream.BandwidthSamples)
+}
+
 // getSegmentsAndStatus safely retrieves the list of completed segments and the stream's finished status.
 func getSegmentsAndStatus(playlistID string, streamID int) ([]SegmentInfo, bool, bool) {
 	Lock.Lock()
@@ -1278,6 +1832,39 @@ func updateSegmentSentCount(playlistID string, streamID int, filename string) {
 	}
 }
 
+// removeOldSegments deletes the segment files sent to the client, the last buffer.retain.segments files are kept.
+// A file that another client has not received yet is still in CompletedSegments (cleanupCompletedSegments) and is not deleted.
+func removeOldSegments(playlistID string, streamID int, stream *ThisStream) {
+	for len(stream.OldSegments) > max(Settings.BufferRetainSegments, 0) {
+		if isSegmentPending(playlistID, streamID, stream.OldSegments[0]) {
+			return
+		}
+
+		fileToRemove := stream.Folder + stream.OldSegments[0]
+		if err := bufferVFS.RemoveAll(getPlatformFile(fileToRemove)); err != nil {
+			ShowError(err, 4007)
+		}
+		stream.OldSegments = slices.Delete(stream.OldSegments, 0, 1)
+	}
+}
+
+// isSegmentPending reports whether a segment has not been sent to all clients of the stream yet.
+func isSegmentPending(playlistID string, streamID int, filename string) bool {
+	Lock.Lock()
+	defer Lock.Unlock()
+
+	if p, ok := BufferInformation.Load(playlistID); ok {
+		if pl, ok := p.(*Playlist); ok {
+			if s, ok := pl.Streams[streamID]; ok {
+				return slices.ContainsFunc(s.CompletedSegments, func(segment SegmentInfo) bool {
+					return segment.Filename == filename
+				})
+			}
+		}
+	}
+	return false
+}
+
 // cleanupCompletedSegments safely removes segments that have been sent to all clients.
 func cleanupCompletedSegments(playlistID string, streamID int, streamMD5 string) {
 	Lock.Lock()
@@ -1326,6 +1913,7 @@ func completeTSsegment(playlistID string, streamID int, stream *ThisStream, band
 	bandwidth.Size += fileSize
 	bandwidth.TimeDiff = bandwidth.Stop.Sub(bandwidth.Start).Seconds()
 	stream.NetworkBandwidth = int(float64(bandwidth.Size) / bandwidth.TimeDiff * 1000)
+	stream.addBandwidthSample(stream.NetworkBandwidth)
 
 	debug := fmt.Sprintf("Buffer Status:Done (%s)", tmpFile)
 	showDebug(debug, 2)
@@ -1340,6 +1928,8 @@ func completeTSsegment(playlistID string, streamID int, stream *ThisStream, band
 				s.CompletedSegments = append(s.CompletedSegments, segmentInfo)
 				s.Status = true
 				s.NetworkBandwidth = stream.NetworkBandwidth
+				s.BandwidthSamples = stream.BandwidthSamples
+				s.HLSKeys = stream.HLSKeys
 				playlist.Streams[streamID] = s
 				BufferInformation.Store(playlistID, playlist)
 				prevLastPCR := stream.LastPCR
@@ -1368,6 +1958,111 @@ func getTuner(id, playlistType string) (tuner int) {
 	return
 }
 
+// streamFormats : x-stream-format of the XEPG channels by playlist ID and stream URL.
+// The buffer does not read the XEPG channels, the index is rebuilt with xteve.m3u.
+var streamFormats struct {
+	sync.RWMutex
+	formats map[string]string
+}
+
+// updateStreamFormats rebuilds streamFormats from the channels
+func updateStreamFormats(channels map[string]XEPGChannelStruct) {
+	var formats = make(map[string]string)
+	for _, channel := range channels {
+		if len(channel.XStreamFormat) > 0 {
+			formats[channel.FileM3UID+"\x00"+channel.URL] = channel.XStreamFormat
+		}
+	}
+
+	streamFormats.Lock()
+	streamFormats.formats = formats
+	streamFormats.Unlock()
+}
+
+// getStreamFormat returns the x-stream-format of the channel with the stream URL, empty if the Content-Type is used
+func getStreamFormat(playlistID, streamURL string) string {
+	if Settings.EpgSource != "XEPG" {
+		return ""
+	}
+
+	streamFormats.RLock()
+	defer streamFormats.RUnlock()
+	return streamFormats.formats[playlistID+"\x00"+streamURL]
+}
+
+// getMaxConcurrentStreams returns the max.concurrent.streams of the provider, 0 if it is not set.
+// Unlike the tuner count, it is not advertised to the clients.
+func getMaxConcurrentStreams(id, playlistType string) int {
+	var value = getProviderParameter(id, playlistType, "max.concurrent.streams")
+	if value == "" {
+		return 0
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		ShowError(fmt.Errorf("invalid max.concurrent.streams of %s: %q", id, value), 0)
+		return 0
+	}
+	return i
+}
+
+// activeStreamCount returns the number of active streams of all playlists. The caller holds Lock.
+func activeStreamCount() (count int) {
+	BufferInformation.Range(func(k, v any) bool {
+		if playlist, ok := v.(*Playlist); ok {
+			count += len(playlist.Streams)
+		}
+		return true
+	})
+	return
+}
+
+// isBufferTempFolder reports whether entry is a playlist folder of the buffer
+// (System.Folder.Temp + playlist ID, e.g. M1a2b3c...).
+func isBufferTempFolder(entry os.DirEntry) bool {
+	var name = entry.Name()
+	return entry.IsDir() && len(name) > 1 && (name[0] == 'M' || name[0] == 'H')
+}
+
+// cleanupTempFolder removes everything in the temporary folder. Playlist folders of
+// the buffer, e.g. left behind after a crash, are only removed with buffer and if they
+// do not belong to a live buffer.
+func cleanupTempFolder(dir string, buffer bool) (err error) {
+	dir = filepath.Clean(dir) + string(os.PathSeparator)
+
+	// Never touch the configuration if the temporary folder points to (a parent of) it
+	for _, folder := range []string{System.Folder.Config, System.Folder.Data} {
+		if len(folder) > 0 && strings.HasPrefix(filepath.Clean(folder)+string(os.PathSeparator), dir) {
+			return fmt.Errorf("temporary folder %s contains the folder %s, cleanup skipped", dir, folder)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	var removed int
+	for _, entry := range entries {
+		if isBufferTempFolder(entry) {
+			if _, ok := BufferInformation.Load(entry.Name()); ok || !buffer {
+				continue
+			}
+			removed++
+		}
+
+		if err = os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return
+		}
+	}
+
+	if removed > 0 {
+		showInfo(fmt.Sprintf("Buffer:Removed %d orphaned playlist folders from the temporary folder", removed))
+	}
+
+	return
+}
+
 func initBufferVFS(virtual bool) {
 	if virtual {
 		bufferVFS = memfs.New()
@@ -1377,9 +2072,9 @@ func initBufferVFS(virtual bool) {
 }
 
 func debugRequest(req *http.Request) {
-	var debugLevel = 3
+	var level = 3
 
-	if System.Flag.Debug < debugLevel {
+	if debugLevel(debugBuffer) < level {
 		return
 	}
 
@@ -1387,34 +2082,34 @@ func debugRequest(req *http.Request) {
 
 	fmt.Println()
 	debug = "Request:* * * * * * BEGIN HTTP(S) REQUEST * * * * * * "
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 
 	debug = fmt.Sprintf("Method:%s", req.Method)
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 
 	debug = fmt.Sprintf("Proto:%s", req.Proto)
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 
 	debug = fmt.Sprintf("URL:%s", req.URL)
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 
 	for name, headers := range req.Header {
 		name = strings.ToLower(name)
 
 		for _, h := range headers {
 			debug = fmt.Sprintf("Header:%v: %v", name, h)
-			showDebug(debug, debugLevel)
+			showSubsystemDebug(debugBuffer, debug, level)
 		}
 	}
 
 	debug = "Request:* * * * * * END HTTP(S) REQUEST * * * * * *"
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 }
 
 func debugResponse(resp *http.Response) {
-	var debugLevel = 3
+	var level = 3
 
-	if System.Flag.Debug < debugLevel {
+	if debugLevel(debugBuffer) < level {
 		return
 	}
 
@@ -1423,16 +2118,16 @@ func debugResponse(resp *http.Response) {
 	fmt.Println()
 
 	debug = "Response:* * * * * * BEGIN RESPONSE * * * * * * "
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 
 	debug = fmt.Sprintf("Proto:%s", resp.Proto)
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 
 	debug = fmt.Sprintf("Status Code:%d", resp.StatusCode)
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 
 	debug = fmt.Sprintf("Status Text:%s", http.StatusText(resp.StatusCode))
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 
 	for key, value := range resp.Header {
 		switch fmt.Sprintf("%T", value) {
@@ -1441,9 +2136,9 @@ func debugResponse(resp *http.Response) {
 		default:
 			debug = fmt.Sprintf("Header:%v: %v", key, value)
 		}
-		showDebug(debug, debugLevel)
+		showSubsystemDebug(debugBuffer, debug, level)
 	}
 
 	debug = "Pesponse:* * * * * * END RESPONSE * * * * * * "
-	showDebug(debug, debugLevel)
+	showSubsystemDebug(debugBuffer, debug, level)
 }
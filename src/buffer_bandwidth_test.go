@@ -0,0 +1,63 @@
+package src
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSwitchBandwidth_Smoothing(t *testing.T) {
+	originalSamples := Settings.HLSBandwidthSmoothingSamples
+	t.Cleanup(func() { Settings.HLSBandwidthSmoothingSamples = originalSamples })
+
+	variants := map[int]DynamicStream{
+		1000000: {Bandwidth: 1000000, URL: "http://example.com/low.m3u8"},
+		3000000: {Bandwidth: 3000000, URL: "http://example.com/mid.m3u8"},
+		6000000: {Bandwidth: 6000000, URL: "http://example.com/high.m3u8"},
+	}
+
+	// A stable connection of ~5 Mbit/s with short dips below 2 Mbit/s
+	measurements := []int{5000000, 5200000, 1500000, 5100000, 4900000, 1200000, 5000000, 5300000}
+
+	chooseVariants := func(samples int) (chosen []string) {
+		Settings.HLSBandwidthSmoothingSamples = samples
+		stream := &ThisStream{DynamicStream: variants}
+
+		for _, bw := range measurements {
+			stream.NetworkBandwidth = bw
+			stream.addBandwidthSample(bw)
+
+			stream.Segment = nil
+			assert.NoError(t, stream.switchBandwidth())
+			chosen = append(chosen, stream.Segment[0].URL)
+		}
+		return
+	}
+
+	// Without smoothing every dip switches to the low variant
+	unsmoothed := chooseVariants(1)
+	assert.Contains(t, unsmoothed, "http://example.com/low.m3u8")
+
+	// With smoothing, the variant stays the same after the first samples
+	smoothed := chooseVariants(5)
+	for i, url := range smoothed[1:] {
+		assert.Equal(t, "http://example.com/mid.m3u8", url, "variant changed at measurement %d", i+1)
+	}
+}
+
+func TestAddBandwidthSample_Window(t *testing.T) {
+	originalSamples := Settings.HLSBandwidthSmoothingSamples
+	t.Cleanup(func() { Settings.HLSBandwidthSmoothingSamples = originalSamples })
+
+	Settings.HLSBandwidthSmoothingSamples = 3
+	stream := &ThisStream{NetworkBandwidth: 42}
+
+	assert.Equal(t, 42, stream.averageBandwidth(), "without samples the network bandwidth is used")
+
+	for _, bw := range []int{10, 20, 30, 40} {
+		stream.addBandwidthSample(bw)
+	}
+
+	assert.Equal(t, []int{20, 30, 40}, stream.BandwidthSamples)
+	assert.Equal(t, 30, stream.averageBandwidth())
+}
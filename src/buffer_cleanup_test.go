@@ -0,0 +1,70 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupTempFolder(t *testing.T) {
+	oldSystem := System
+	t.Cleanup(func() {
+		System = oldSystem
+		BufferInformation.Delete("M_live")
+	})
+
+	System.Folder.Config = filepath.Join(t.TempDir(), "config") + string(os.PathSeparator)
+	System.Folder.Data = System.Folder.Config + "data" + string(os.PathSeparator)
+	BufferInformation.Store("M_live", &Playlist{PlaylistID: "M_live"})
+
+	setup := func(t *testing.T) string {
+		tmp := t.TempDir()
+		for _, name := range []string{"M_stale1", "H_stale2", "M_live"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(tmp, name, "segments"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(tmp, name, "1.ts"), []byte("ts"), 0644))
+		}
+		require.NoError(t, os.MkdirAll(filepath.Join(tmp, "restore"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tmp, "restore.zip"), []byte("zip"), 0644))
+		return tmp
+	}
+
+	t.Run("buffer cleanup", func(t *testing.T) {
+		tmp := setup(t)
+		require.NoError(t, cleanupTempFolder(tmp, true))
+
+		assert.NoDirExists(t, filepath.Join(tmp, "M_stale1"))
+		assert.NoDirExists(t, filepath.Join(tmp, "H_stale2"))
+		assert.NoDirExists(t, filepath.Join(tmp, "restore"))
+		assert.NoFileExists(t, filepath.Join(tmp, "restore.zip"))
+		assert.FileExists(t, filepath.Join(tmp, "M_live", "1.ts"))
+		assert.DirExists(t, tmp)
+	})
+
+	t.Run("buffer.cleanup.on.start disabled", func(t *testing.T) {
+		tmp := setup(t)
+		require.NoError(t, cleanupTempFolder(tmp, false))
+
+		// Everything else in the temporary folder is still removed
+		assert.FileExists(t, filepath.Join(tmp, "M_stale1", "1.ts"))
+		assert.FileExists(t, filepath.Join(tmp, "H_stale2", "1.ts"))
+		assert.FileExists(t, filepath.Join(tmp, "M_live", "1.ts"))
+		assert.NoDirExists(t, filepath.Join(tmp, "restore"))
+		assert.NoFileExists(t, filepath.Join(tmp, "restore.zip"))
+	})
+}
+
+func TestCleanupTempFolder_ProtectsConfig(t *testing.T) {
+	oldSystem := System
+	t.Cleanup(func() { System = oldSystem })
+
+	tmp := t.TempDir()
+	System.Folder.Config = filepath.Join(tmp, "config") + string(os.PathSeparator)
+	require.NoError(t, os.MkdirAll(System.Folder.Config, 0755))
+
+	assert.Error(t, cleanupTempFolder(tmp, true))
+	assert.Error(t, cleanupTempFolder(tmp, false))
+	assert.DirExists(t, System.Folder.Config)
+}
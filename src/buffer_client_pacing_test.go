@@ -0,0 +1,70 @@
+package src
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timedRecorder records the time and size of every write
+type timedRecorder struct {
+	*httptest.ResponseRecorder
+	writes []time.Time
+	sizes  []int
+}
+
+func (r *timedRecorder) Write(data []byte) (int, error) {
+	r.writes = append(r.writes, time.Now())
+	r.sizes = append(r.sizes, len(data))
+	return r.ResponseRecorder.Write(data)
+}
+
+func TestPacedResponseWriter(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	// Default: The client is written to directly
+	var rr = &timedRecorder{ResponseRecorder: httptest.NewRecorder()}
+	assert.Same(t, rr, newPacedResponseWriter(context.Background(), rr))
+
+	Settings.StreamClientPacingMS = 20
+	Settings.StreamClientChunkKB = 1
+
+	var w = newPacedResponseWriter(context.Background(), rr)
+	var segment = make([]byte, 4*1024+100)
+
+	n, err := w.Write(segment)
+	require.NoError(t, err)
+	assert.Equal(t, len(segment), n)
+	assert.Equal(t, []int{1024, 1024, 1024, 1024, 100}, rr.sizes)
+	assert.Equal(t, len(segment), rr.Body.Len())
+
+	// The next segment keeps the pace
+	_, err = w.Write(segment[:10])
+	require.NoError(t, err)
+
+	require.Len(t, rr.writes, 6)
+	for i := 1; i < len(rr.writes); i++ {
+		assert.GreaterOrEqual(t, rr.writes[i].Sub(rr.writes[i-1]), 20*time.Millisecond, "write %d", i)
+	}
+
+	// http.ResponseController reaches the client through the writer
+	assert.NoError(t, http.NewResponseController(w).Flush())
+
+	// A client that disconnects stops the pacing
+	ctx, cancel := context.WithCancel(context.Background())
+	Settings.StreamClientPacingMS = 10000
+	w = newPacedResponseWriter(ctx, httptest.NewRecorder())
+	_, err = w.Write(segment[:10])
+	require.NoError(t, err)
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	n, err = w.Write(segment)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, n)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"testing"
 	"xteve/src/mpegts"
 )
@@ -419,3 +420,76 @@ func TestHandleTSStream_EOFNoRetryWhenDisabled(t *testing.T) {
 		t.Errorf("addErrorToStream should not have been called, got: %v", streamErrors)
 	}
 }
+
+func TestHandleTSStream_Passthrough(t *testing.T) {
+	// fMP4 data in a .ts URL would be rejected or corrupted by the MPEG-TS parser
+	var content bytes.Buffer
+	content.WriteString("\x00\x00\x00\x18ftypmp42")
+	for i := content.Len(); i < 2500; i++ {
+		content.WriteByte(byte(i))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp2t")
+		_, _ = w.Write(content.Bytes())
+	}))
+	defer server.Close()
+
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	initBufferVFS(true)
+	Settings.BufferSize = 1 // 1 KB segments
+	Settings.StreamRetryEnabled = false
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{"buffer.passthrough": true}}
+
+	playlistID := "M1"
+	tmpFolder := "/tmp/xteve_test_ts_passthrough/"
+	if err := bufferVFS.MkdirAll(tmpFolder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	md5, err := getMD5(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := ThisStream{URL: server.URL, Folder: tmpFolder, PlaylistID: playlistID, MD5: md5}
+
+	var clients = ClientConnection{Connection: 1}
+	BufferClients.Store(playlistID+md5, &clients)
+	defer BufferClients.Delete(playlistID + md5)
+
+	var tmpSegment = 1
+	var errs []error
+	var bandwidth BandwidthCalculation
+	var buffer = make([]byte, 4096)
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = stream.handleTSStream(t.Context(), resp, 0, playlistID, tmpFolder, &tmpSegment, func(err error) { errs = append(errs, err) }, buffer, &bandwidth, 0); err != nil {
+		t.Fatalf("handleTSStream returned an error: %v", err)
+	}
+	if len(errs) > 0 {
+		t.Fatalf("addErrorToStream was called with errors: %v", errs)
+	}
+
+	// The segments are sized by the buffer size only and contain the unchanged bytes
+	var written []byte
+	for i, wantSize := range []int{1024, 1024, 452} {
+		data, err := bufferVFS.ReadFile(tmpFolder + strconv.Itoa(i+1) + ".ts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) != wantSize {
+			t.Errorf("Segment %d: expected %d bytes, got %d", i+1, wantSize, len(data))
+		}
+		written = append(written, data...)
+	}
+
+	if !bytes.Equal(written, content.Bytes()) {
+		t.Error("Passthrough segments do not match the upstream content")
+	}
+}
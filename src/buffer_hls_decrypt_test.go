@@ -0,0 +1,175 @@
+package src
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encryptAES128 encrypts like an HLS packager: AES-128 CBC with PKCS#7 padding
+func encryptAES128(t *testing.T, plain, key, iv []byte) []byte {
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+
+	var padding = aes.BlockSize - len(plain)%aes.BlockSize
+	var data = append(bytes.Clone(plain), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+	return data
+}
+
+func TestHandleHLSStream_Decrypt(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	var key = []byte("0123456789abcdef")
+	var explicitIV = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	var sequenceIV = make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(sequenceIV[8:], 8)
+
+	var plain = [][]byte{
+		bytes.Repeat([]byte("first segment "), 50),
+		bytes.Repeat([]byte("second segment"), 64), // Multiple of the block size, padded with a whole block
+		[]byte("clear segment"),
+	}
+	var segments = map[string][]byte{
+		"/seg1.ts": encryptAES128(t, plain[0], key, explicitIV),
+		"/seg2.ts": encryptAES128(t, plain[1], key, sequenceIV),
+		"/seg3.ts": plain[2],
+	}
+
+	const playlist = "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:10\n#EXT-X-MEDIA-SEQUENCE:7\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\",IV=0x000102030405060708090a0b0c0d0e0f\n#EXTINF:10.0,\nseg1.ts\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\"\n#EXTINF:10.0,\nseg2.ts\n" +
+		"#EXT-X-KEY:METHOD=NONE\n#EXTINF:10.0,\nseg3.ts\n#EXT-X-ENDLIST\n"
+
+	var keyRequests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/playlist.m3u8":
+			w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+			w.Write([]byte(playlist))
+		case r.URL.Path == "/key.bin":
+			keyRequests.Add(1)
+			w.Write(key)
+		case strings.HasSuffix(r.URL.Path, ".ts"):
+			w.Header().Set("Content-Type", "video/mp2t")
+			w.Write(segments[r.URL.Path])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	initBufferVFS(true)
+	Settings.UserAgent = "xTeVe-Test"
+	tmpFolder := "/tmp/xteve_test_hls_decrypt/"
+	require.NoError(t, bufferVFS.MkdirAll(tmpFolder, 0755))
+	defer bufferVFS.RemoveAll(tmpFolder)
+
+	play := func() [][]byte {
+		resp, err := http.Get(server.URL + "/playlist.m3u8")
+		require.NoError(t, err)
+
+		stream := ThisStream{URL: server.URL + "/playlist.m3u8", URLStreamingServer: server.URL, Folder: tmpFolder}
+		// completeTSsegment replaces the stream with the copy of the playlist
+		BufferInformation.Store("test-playlist", &Playlist{PlaylistID: "test-playlist", Streams: map[int]ThisStream{0: stream}})
+		defer BufferInformation.Delete("test-playlist")
+
+		var tmpSegment = 1
+		var errs []error
+		require.NoError(t, stream.handleHLSStream(t.Context(), resp, 0, "test-playlist", tmpFolder, &tmpSegment, func(err error) { errs = append(errs, err) }, stream.URL, &BandwidthCalculation{}))
+		require.Empty(t, errs)
+		require.Equal(t, 4, tmpSegment)
+
+		var written [][]byte
+		for _, file := range []string{"1.ts", "2.ts", "3.ts"} {
+			f, err := bufferVFS.Open(tmpFolder + file)
+			require.NoError(t, err)
+			content, err := io.ReadAll(f)
+			f.Close()
+			require.NoError(t, err)
+			written = append(written, content)
+		}
+		return written
+	}
+
+	// Decrypted output matches the plaintext, the key is only downloaded once
+	Settings.HLSDecrypt = true
+	assert.Equal(t, plain, play())
+	assert.Equal(t, int64(1), keyRequests.Load())
+
+	// Default: the segments are passed through unchanged
+	Settings.HLSDecrypt = false
+	keyRequests.Store(0)
+	assert.Equal(t, [][]byte{segments["/seg1.ts"], segments["/seg2.ts"], segments["/seg3.ts"]}, play())
+	assert.Zero(t, keyRequests.Load())
+}
+
+func TestParseM3U8Key(t *testing.T) {
+	stream := ThisStream{M3U8URL: "http://provider.example/live/index.m3u8", URLStreamingServer: "http://provider.example"}
+
+	require.NoError(t, parseM3U8Key(`METHOD=AES-128,URI="keys/1.key?a=1,b=2",IV=0X0000000000000000000000000000002A`, &stream))
+	assert.Equal(t, "AES-128", stream.HLSKey.Method)
+	assert.Equal(t, "http://provider.example/live/keys/1.key?a=1,b=2", stream.HLSKey.URI)
+	assert.Equal(t, append(make([]byte, 15), 42), stream.HLSKey.IV)
+
+	// Keys of other key systems are ignored
+	require.NoError(t, parseM3U8Key(`METHOD=SAMPLE-AES,URI="skd://key",KEYFORMAT="com.apple.streamingkeydelivery"`, &stream))
+	assert.Equal(t, "AES-128", stream.HLSKey.Method)
+
+	require.NoError(t, parseM3U8Key(`METHOD=NONE`, &stream))
+	assert.Equal(t, HLSKey{}, stream.HLSKey)
+
+	assert.Error(t, parseM3U8Key(`METHOD=AES-128,URI="/k",IV=0x1234`, &stream))
+}
+
+func TestDecryptAES128_Padding(t *testing.T) {
+	var key, iv = []byte("0123456789abcdef"), make([]byte, aes.BlockSize)
+
+	plain, err := decryptAES128(encryptAES128(t, []byte("segment"), key, iv), key, iv)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("segment"), plain)
+
+	// Only the last byte is a valid padding length
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	var data = append([]byte("segment"), 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	_, err = decryptAES128(data, key, iv)
+	assert.Error(t, err)
+}
+
+func TestHLSKey_Bounded(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789abcdef"))
+	}))
+	defer server.Close()
+
+	// A key per segment
+	var stream ThisStream
+	for i := range 3 * maxHLSKeys {
+		_, err := stream.hlsKey(t.Context(), NewHTTPClient(), fmt.Sprintf("%s/key%d.bin", server.URL, i))
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(stream.HLSKeys), maxHLSKeys)
+	}
+	assert.Contains(t, stream.HLSKeys, fmt.Sprintf("%s/key%d.bin", server.URL, 3*maxHLSKeys-1), "the current key is kept")
+}
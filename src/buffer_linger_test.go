@@ -0,0 +1,153 @@
+package src
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"xteve/src/mpegts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupLingerTest(t *testing.T, linger int) (newSource func() (*httptest.Server, *atomic.Int32), get func(url string)) {
+	t.Helper()
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	t.Cleanup(func() { os.Unsetenv("XTEVE_ALLOW_LOOPBACK") })
+
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	initBufferVFS(true)
+	Settings.Buffer = "xteve"
+	Settings.BufferSize = 1024
+	Settings.BufferTimeout = 0
+	Settings.UserAgent = "xTeVe-Test"
+	Settings.StreamRetryEnabled = false
+	Settings.StreamLingerSeconds = linger
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{"name": "TestPlaylist", "tuner": "1"}}
+	System.Folder.Temp = "/tmp/xteve_test_linger/"
+
+	content := make([]byte, 100*mpegts.PacketSize)
+	for i := range 100 {
+		content[i*mpegts.PacketSize] = mpegts.SyncByte
+	}
+
+	newSource = func() (*httptest.Server, *atomic.Int32) {
+		var connections atomic.Int32
+		source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			connections.Add(1)
+			w.Header().Set("Content-Type", "video/mp2t")
+			for {
+				if _, err := w.Write(content); err != nil {
+					return
+				}
+				w.(http.Flusher).Flush()
+				time.Sleep(20 * time.Millisecond)
+			}
+		}))
+		t.Cleanup(source.Close)
+		return source, &connections
+	}
+
+	xteve := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bufferingStream("M1", r.URL.Query().Get("url"), "TestChannel", w, r)
+	}))
+	t.Cleanup(xteve.Close)
+
+	// get : Client that disconnects after the first data
+	get = func(url string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, xteve.URL+"?url="+url, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_, err = io.ReadFull(resp.Body, make([]byte, mpegts.PacketSize))
+		require.NoError(t, err)
+		cancel()
+		resp.Body.Close()
+	}
+
+	t.Cleanup(func() {
+		require.Eventually(t, func() bool { return activeTuners("M1") == 0 }, 10*time.Second, 50*time.Millisecond)
+	})
+	return
+}
+
+// isLingering : Reports whether a stream of the playlist is waiting for a reconnect
+func isLingering(playlistID string) bool {
+	Lock.Lock()
+	defer Lock.Unlock()
+
+	if p, ok := BufferInformation.Load(playlistID); ok {
+		for _, stream := range p.(*Playlist).Streams {
+			if c, ok := BufferClients.Load(playlistID + stream.MD5); ok && c.(*ClientConnection).Linger != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestStreamLinger_ReconnectReusesTuner(t *testing.T) {
+	newSource, get := setupLingerTest(t, 2)
+	source, connections := newSource()
+
+	get(source.URL)
+
+	// The stream keeps buffering without clients
+	require.Eventually(t, func() bool { return isLingering("M1") }, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, 1, activeTuners("M1"))
+
+	get(source.URL)
+	assert.Equal(t, int32(1), connections.Load(), "the reconnect uses the same connection to the streaming server")
+
+	// The stream is removed after the grace period
+	require.Eventually(t, func() bool { return activeTuners("M1") == 0 }, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestStreamLinger_NewChannelReplacesLingeringStream(t *testing.T) {
+	newSource, get := setupLingerTest(t, 30)
+	first, _ := newSource()
+	second, secondConnections := newSource()
+
+	get(first.URL)
+	require.Eventually(t, func() bool { return isLingering("M1") }, 5*time.Second, 20*time.Millisecond)
+
+	// Only one tuner: The lingering stream is replaced
+	get(second.URL)
+	assert.Equal(t, int32(1), secondConnections.Load())
+
+	// Remove the stream instead of waiting 30 seconds for the cleanup of the test
+	Lock.Lock()
+	if p, ok := BufferInformation.Load("M1"); ok {
+		for id, stream := range p.(*Playlist).Streams {
+			assert.Equal(t, second.URL, stream.URL)
+			if c, ok := BufferClients.Load("M1" + stream.MD5); ok {
+				stopLinger(c.(*ClientConnection))
+				removeStream(p.(*Playlist), "M1", id, stream)
+				showTunerStatus(p.(*Playlist), "M1")
+			}
+		}
+	}
+	Lock.Unlock()
+}
+
+func TestStreamLinger_Disabled(t *testing.T) {
+	newSource, get := setupLingerTest(t, 0)
+	source, connections := newSource()
+
+	get(source.URL)
+	require.Eventually(t, func() bool { return activeTuners("M1") == 0 }, 5*time.Second, 50*time.Millisecond)
+
+	get(source.URL)
+	require.Eventually(t, func() bool { return connections.Load() == 2 }, 5*time.Second, 50*time.Millisecond)
+}
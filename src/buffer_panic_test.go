@@ -0,0 +1,93 @@
+package src
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"xteve/src/mpegts"
+
+	"github.com/stretchr/testify/require"
+)
+
+// activeTuners : Number of streams of the playlist in the buffer
+func activeTuners(playlistID string) int {
+	Lock.Lock()
+	defer Lock.Unlock()
+
+	if p, ok := BufferInformation.Load(playlistID); ok {
+		return len(p.(*Playlist).Streams)
+	}
+	return 0
+}
+
+func TestBufferPanic_ReleasesTuner(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	content := make([]byte, 100*mpegts.PacketSize)
+	for i := 0; i < 100; i++ {
+		content[i*mpegts.PacketSize] = mpegts.SyncByte
+	}
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp2t")
+		for {
+			if _, err := w.Write(content); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer source.Close()
+
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	initBufferVFS(true)
+	Settings.Buffer = "xteve"
+	Settings.BufferSize = 1024
+	Settings.BufferTimeout = 0
+	Settings.UserAgent = "xTeVe-Test"
+	Settings.StreamRetryEnabled = false
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{"name": "TestPlaylist", "tuner": "1"}}
+	System.Folder.Temp = "/tmp/xteve_test_panic/"
+
+	for _, function := range []string{"connectToStreamingServer", "bufferingStream"} {
+		t.Run(function, func(t *testing.T) {
+			playlistID := "M1"
+			md5, err := getMD5(source.URL)
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				BufferInformation.Delete(playlistID)
+				BufferClients.Delete(playlistID + md5)
+			})
+
+			bufferFaultHook = func(f string) {
+				if f == function {
+					panic("injected panic")
+				}
+			}
+			t.Cleanup(func() { bufferFaultHook = nil })
+
+			xteve := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				bufferingStream(playlistID, source.URL, "TestChannel", w, r)
+			}))
+			defer xteve.Close()
+
+			resp, err := http.Get(xteve.URL)
+			require.NoError(t, err)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			require.Eventually(t, func() bool {
+				_, ok := BufferClients.Load(playlistID + md5)
+				return activeTuners(playlistID) == 0 && !ok
+			}, 10*time.Second, 50*time.Millisecond, "the tuner is released")
+		})
+	}
+}
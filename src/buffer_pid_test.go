@@ -0,0 +1,71 @@
+package src
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"xteve/src/mpegts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleTSStream_PreservesAllPIDs feeds a stream with video, audio,
+// subtitle and teletext PIDs through the buffer and checks that every PID
+// appears unchanged in the buffered segments.
+func TestHandleTSStream_PreservesAllPIDs(t *testing.T) {
+	oldDebug := System.Flag.Debug
+	t.Cleanup(func() { System.Flag.Debug = oldDebug })
+	System.Flag.Debug = 3
+
+	stream, streamID, playlistID, folder, buf, bw, cleanup := setupDedupTest(t, "pids")
+	defer cleanup()
+
+	// PAT, PMT, video, audio (2 languages), DVB subtitles, teletext
+	pids := []uint16{0x0000, 0x1000, 0x0100, 0x0101, 0x0102, 0x0103, 0x0104}
+
+	var data bytes.Buffer
+	input := mpegts.PIDCounter{}
+	for i := range 50 {
+		for _, pid := range pids {
+			pkt := make([]byte, mpegts.PacketSize)
+			pkt[0] = mpegts.SyncByte
+			pkt[1] = byte(pid >> 8)
+			pkt[2] = byte(pid)
+			pkt[3] = 0x10 | byte(i&0x0F)
+			data.Write(pkt)
+			input.Add(pkt)
+		}
+	}
+
+	tmpSegment := 1
+	runConn(t, stream, streamID, playlistID, folder, &tmpSegment, buf, bw, data.Bytes())
+
+	entries, err := bufferVFS.ReadDir(folder)
+	require.NoError(t, err)
+
+	output := mpegts.PIDCounter{}
+	var buffered bytes.Buffer
+	for _, e := range entries {
+		f, err := bufferVFS.Open(folder + e.Name())
+		require.NoError(t, err)
+		_, err = io.Copy(&buffered, f)
+		f.Close()
+		require.NoError(t, err)
+	}
+
+	parser := mpegts.NewParser()
+	_, _ = parser.Write(buffered.Bytes())
+	for {
+		pkt, err := parser.Next()
+		if err != nil {
+			break
+		}
+		output.Add(pkt)
+	}
+
+	assert.Empty(t, input.Missing(output), "PIDs missing in the buffer")
+	assert.Equal(t, input, output)
+	assert.Equal(t, data.Bytes(), buffered.Bytes(), "the packets are buffered unchanged")
+}
@@ -0,0 +1,82 @@
+package src
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSegmentsToClient_RetainSegments(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	initBufferVFS(true)
+	Settings.BufferRetainSegments = 2
+	Settings.BufferClientTimeout = 0
+
+	playlistID := "M1"
+	streamID := 0
+	folder := "/tmp/xteve_test_retain_segments/"
+	md5 := "retain"
+	require.NoError(t, bufferVFS.MkdirAll(folder, 0755))
+	t.Cleanup(func() { bufferVFS.RemoveAll(folder) })
+
+	var segments []SegmentInfo
+	for i := 1; i <= 5; i++ {
+		filename := strconv.Itoa(i) + ".ts"
+		file, err := bufferVFS.Create(folder + filename)
+		require.NoError(t, err)
+		file.Write([]byte("segment " + filename))
+		file.Close()
+		segments = append(segments, SegmentInfo{Filename: filename})
+	}
+
+	stream := ThisStream{Folder: folder, PlaylistID: playlistID, MD5: md5, CompletedSegments: segments}
+	BufferInformation.Store(playlistID, &Playlist{PlaylistID: playlistID, Streams: map[int]ThisStream{streamID: stream}})
+	t.Cleanup(func() { BufferInformation.Delete(playlistID) })
+
+	// Two clients share the stream
+	BufferClients.Store(playlistID+md5, &ClientConnection{Connection: 2})
+	t.Cleanup(func() { BufferClients.Delete(playlistID + md5) })
+
+	send := func(client *ThisStream, sent map[string]bool) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		var streaming bool
+		_, err := sendSegmentsToClient(context.Background(), playlistID, streamID, client, rr, http.NewResponseController(rr), &streaming, sent)
+		require.NoError(t, err)
+		return rr
+	}
+
+	exists := func(filename string) bool {
+		_, err := bufferVFS.Stat(folder + filename)
+		return err == nil
+	}
+
+	// The fast client received all segments, the slow client none of them yet
+	fastClient, fastSent := stream, map[string]bool{}
+	send(&fastClient, fastSent)
+	assert.Len(t, fastSent, 5)
+	for _, segment := range segments {
+		assert.True(t, exists(segment.Filename), "%s was not sent to the slow client and must not be removed", segment.Filename)
+	}
+
+	// The slow client gets every segment
+	slowClient, slowSent := stream, map[string]bool{}
+	rr := send(&slowClient, slowSent)
+	assert.Equal(t, "segment 1.tssegment 2.tssegment 3.tssegment 4.tssegment 5.ts", rr.Body.String())
+
+	// Now the segments were received by both clients, the last 2 are kept
+	assert.Equal(t, []string{"4.ts", "5.ts"}, slowClient.OldSegments)
+	for filename, want := range map[string]bool{"1.ts": false, "2.ts": false, "3.ts": false, "4.ts": true, "5.ts": true} {
+		assert.Equal(t, want, exists(filename), filename)
+	}
+
+	// The fast client removes the files it still has in its list, the other client already did
+	removeOldSegments(playlistID, streamID, &fastClient)
+	assert.Equal(t, []string{"4.ts", "5.ts"}, fastClient.OldSegments)
+}
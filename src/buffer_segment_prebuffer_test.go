@@ -328,3 +328,98 @@ func TestBufferingStream_ClientWaitsForSlowSource(t *testing.T) {
 			maxGap, minExpectedGap)
 	}
 }
+
+// TestBufferingStream_BufferSegmentsShortStream verifies that a stream that
+// ends before Settings.BufferSegments segments are buffered does not hold the
+// client until the timeout: the pre-buffer wait ends with the stream and the
+// client receives the complete content.
+func TestBufferingStream_BufferSegmentsShortStream(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	// 3 packets fit into a single 1 KB segment
+	const numPackets = 3
+	content := make([]byte, numPackets*mpegts.PacketSize)
+	for i := 0; i < numPackets; i++ {
+		copy(content[i*mpegts.PacketSize:], makePacketWithPCR(i))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp2t")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	initBufferVFS(true)
+
+	origSettings := Settings
+	defer func() { Settings = origSettings }()
+
+	Settings.BufferSegments = 5
+	Settings.BufferSize = 1
+	Settings.BufferTimeout = 0
+	Settings.BufferClientTimeout = 0
+	Settings.Buffer = "xteve"
+	Settings.StreamRetryEnabled = false
+	Settings.UserAgent = "xTeVe-Test"
+
+	playlistID := "M-buf-segments-short-test"
+	streamID := 0
+	streamURL := server.URL
+	channelName := "Short Stream"
+	tempFolder := "/tmp/xteve_test_bufseg_short/"
+
+	md5Val, err := getMD5(streamURL)
+	if err != nil {
+		t.Fatalf("getMD5: %v", err)
+	}
+
+	playlist := Playlist{
+		Folder:       tempFolder,
+		PlaylistID:   playlistID,
+		PlaylistName: "TestPlaylist",
+		Tuner:        1,
+		Streams:      make(map[int]ThisStream),
+		Clients:      make(map[int]ThisClient),
+	}
+	playlist.Streams[streamID] = ThisStream{
+		URL:         streamURL,
+		ChannelName: channelName,
+		Folder:      tempFolder + md5Val + string(os.PathSeparator),
+		MD5:         md5Val,
+		PlaylistID:  playlistID,
+	}
+	playlist.Clients[streamID] = ThisClient{Connection: 1}
+
+	BufferInformation.Store(playlistID, &playlist)
+
+	var clients ClientConnection
+	clients.Connection = 1
+	BufferClients.Store(playlistID+md5Val, &clients)
+
+	defer func() {
+		BufferInformation.Delete(playlistID)
+		BufferClients.Delete(playlistID + md5Val)
+	}()
+
+	go connectToStreamingServer(streamID, playlistID, t.Context())
+
+	recorder := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bufferingStream(playlistID, streamURL, channelName, recorder, httptest.NewRequest("GET", "/stream", nil))
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("bufferingStream waited for segments of a finished stream")
+	}
+
+	if recorder.Body.Len() != len(content) {
+		t.Errorf("client received %d bytes, want %d", recorder.Body.Len(), len(content))
+	}
+}
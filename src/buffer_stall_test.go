@@ -0,0 +1,104 @@
+package src
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"xteve/src/mpegts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ptsPkt returns a video packet that starts a PES packet with the given PTS
+func ptsPkt(pts int64) []byte {
+	pkt := make([]byte, mpegts.PacketSize)
+	pkt[0] = mpegts.SyncByte
+	pkt[1] = 0x41 // payload_unit_start_indicator, PID 0x0100
+	pkt[2] = 0x00
+	pkt[3] = 0x10 // payload only
+	copy(pkt[4:], []byte{0x00, 0x00, 0x01, 0xE0, 0x00, 0x00, 0x80, 0x80, 0x05})
+	pkt[13] = byte(pts>>29)&0x0E | 0x21
+	pkt[14] = byte(pts >> 22)
+	pkt[15] = byte(pts>>14) | 0x01
+	pkt[16] = byte(pts >> 7)
+	pkt[17] = byte(pts<<1) | 0x01
+	return pkt
+}
+
+// tsSource : Endless MPEG-TS source, packet returns the packet for every read
+type tsSource struct {
+	packet func(i int64) []byte
+	until  time.Time
+	i      int64
+}
+
+func (s *tsSource) Read(p []byte) (int, error) {
+	if !s.until.IsZero() && time.Now().After(s.until) {
+		return 0, io.EOF
+	}
+	time.Sleep(5 * time.Millisecond)
+	s.i++
+	return copy(p, s.packet(s.i)), nil
+}
+
+func TestHandleTSStream_StallDetection(t *testing.T) {
+	stream, streamID, playlistID, folder, buf, bw, cleanup := setupDedupTest(t, "stall")
+	defer cleanup()
+
+	Settings.StreamRetryEnabled = false
+	Settings.StreamStallDetectSeconds = 1
+
+	run := func(source *tsSource) (errs []error) {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(source)}
+		tmpSegment := 1
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := stream.handleTSStream(t.Context(), resp, streamID, playlistID, folder, &tmpSegment, func(err error) { errs = append(errs, err) }, buf, bw, 0)
+			assert.NoError(t, err)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "the stream was not stopped")
+		}
+		return
+	}
+
+	t.Run("frozen source", func(t *testing.T) {
+		// The same frame forever
+		errs := run(&tsSource{packet: func(int64) []byte { return ptsPkt(90000) }})
+		assert.Equal(t, []error{errStreamStalled}, errs)
+	})
+
+	t.Run("live source", func(t *testing.T) {
+		errs := run(&tsSource{
+			packet: func(i int64) []byte { return ptsPkt(90000 + i*3600) },
+			until:  time.Now().Add(1500 * time.Millisecond),
+		})
+		assert.Empty(t, errs)
+	})
+
+	t.Run("slow start", func(t *testing.T) {
+		// No PTS yet, the timer starts with the first one
+		errs := run(&tsSource{
+			packet: func(i int64) []byte { return noPCRPkt(int(i)) },
+			until:  time.Now().Add(1500 * time.Millisecond),
+		})
+		assert.Empty(t, errs)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		Settings.StreamStallDetectSeconds = 0
+		errs := run(&tsSource{
+			packet: func(int64) []byte { return ptsPkt(90000) },
+			until:  time.Now().Add(1500 * time.Millisecond),
+		})
+		assert.Empty(t, errs)
+	})
+}
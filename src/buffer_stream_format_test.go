@@ -0,0 +1,112 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"xteve/src/mpegts"
+)
+
+// TestBufferingStream_StreamFormat plays a TS stream that the server delivers as text/plain.
+// The x-stream-format of the channel replaces the wrong Content-Type.
+func TestBufferingStream_StreamFormat(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	const numPackets = 20
+	content := make([]byte, numPackets*mpegts.PacketSize)
+	for i := 0; i < numPackets; i++ {
+		copy(content[i*mpegts.PacketSize:], makePacketWithPCR(i))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	oldSettings, oldChannels := Settings, Data.XEPG.Channels
+	t.Cleanup(func() { Settings, Data.XEPG.Channels = oldSettings, oldChannels })
+
+	initBufferVFS(true)
+	Settings.EpgSource = "XEPG"
+	Settings.BufferSegments = 1
+	Settings.BufferSize = 1
+	Settings.BufferTimeout = 0
+	Settings.BufferClientTimeout = 0
+	Settings.Buffer = "xteve"
+	Settings.StreamRetryEnabled = false
+	Settings.AutoDisableDeadChannels = false
+
+	const playlistID = "M-stream-format-test"
+	const tempFolder = "/tmp/xteve_test_stream_format/"
+	streamURL := server.URL + "/live.ts"
+	md5Val, err := getMD5(streamURL)
+	require.NoError(t, err)
+
+	play := func(format string) int {
+		Data.XEPG.Channels = map[string]XEPGChannelStruct{
+			"x-ID.1": {FileM3UID: playlistID, URL: streamURL, XActive: true, XStreamFormat: format},
+		}
+		updateStreamFormats(Data.XEPG.Channels)
+
+		playlist := &Playlist{Folder: tempFolder, PlaylistID: playlistID, Tuner: 1, Streams: map[int]ThisStream{}, Clients: map[int]ThisClient{}}
+		playlist.Streams[0] = ThisStream{
+			URL:        streamURL,
+			Folder:     tempFolder + md5Val + string(os.PathSeparator),
+			Format:     getStreamFormat(playlistID, streamURL),
+			MD5:        md5Val,
+			PlaylistID: playlistID,
+		}
+		playlist.Clients[0] = ThisClient{Connection: 1}
+		BufferInformation.Store(playlistID, playlist)
+		BufferClients.Store(playlistID+md5Val, &ClientConnection{Connection: 1})
+		defer BufferInformation.Delete(playlistID)
+		defer BufferClients.Delete(playlistID + md5Val)
+
+		go connectToStreamingServer(0, playlistID, t.Context())
+
+		recorder := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			bufferingStream(playlistID, streamURL, "Channel", recorder, httptest.NewRequest("GET", "/stream", nil))
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("bufferingStream timed out")
+		}
+		return recorder.Body.Len()
+	}
+
+	assert.Positive(t, play("ts"), "the TS stream is sent to the client")
+	assert.Zero(t, play(""), "without the hint the Content-Type is a streaming error")
+
+	Settings.EpgSource = "PMS"
+	assert.Empty(t, getStreamFormat(playlistID, streamURL))
+}
+
+func TestGetStreamFormat_UpdatedWithM3U(t *testing.T) {
+	setupOutputProfileTest(t)
+	System.File.M3U = t.TempDir() + "/xteve.m3u"
+	System.File.URLS = t.TempDir() + "/urls.json"
+	t.Cleanup(func() { updateStreamFormats(nil) })
+
+	var channel = Data.XEPG.Channels["x-ID.1"]
+	channel.XStreamFormat = "hls"
+	Data.XEPG.Channels["x-ID.1"] = channel
+	assert.Empty(t, getStreamFormat(channel.FileM3UID, channel.URL), "the buffer does not read the channels")
+
+	require.NoError(t, createM3UFile())
+	assert.Equal(t, "hls", getStreamFormat(channel.FileM3UID, channel.URL))
+	assert.Empty(t, getStreamFormat(channel.FileM3UID, Data.XEPG.Channels["x-ID.2"].URL))
+}
@@ -607,3 +607,87 @@ func TestBufferingStream_ClosesOnStreamEnd(t *testing.T) {
 	BufferInformation.Delete(playlistID)
 	BufferClients.Delete(playlistID + stream.MD5)
 }
+
+func TestBufferingStream_ChunkedTransfer(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	numPackets := 10
+	content := make([]byte, numPackets*mpegts.PacketSize)
+	for i := 0; i < numPackets; i++ {
+		content[i*mpegts.PacketSize] = mpegts.SyncByte
+		content[i*mpegts.PacketSize+4] = byte(i)
+	}
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/mp2t")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(content)
+	}))
+	defer source.Close()
+
+	initBufferVFS(true)
+	Settings.BufferSize = 1024
+	Settings.UserAgent = "xTeVe-Test"
+	Settings.StreamRetryEnabled = false
+
+	playlistID := "M1"
+	streamID := 0
+	md5, err := getMD5(source.URL)
+	if err != nil {
+		t.Fatalf("getMD5 failed: %v", err)
+	}
+	tempFolder := "/tmp/xteve_test_chunked/"
+
+	playlist := Playlist{
+		Folder:       tempFolder,
+		PlaylistID:   playlistID,
+		PlaylistName: "TestPlaylist",
+		Tuner:        1,
+		Streams: map[int]ThisStream{streamID: {
+			URL:         source.URL,
+			ChannelName: "TestChannel",
+			Folder:      tempFolder + md5 + string(os.PathSeparator),
+			MD5:         md5,
+			PlaylistID:  playlistID,
+		}},
+		Clients: map[int]ThisClient{streamID: {Connection: 1}},
+	}
+	BufferInformation.Store(playlistID, &playlist)
+	BufferClients.Store(playlistID+md5, &ClientConnection{Connection: 1})
+	defer func() {
+		BufferInformation.Delete(playlistID)
+		BufferClients.Delete(playlistID + md5)
+	}()
+
+	go connectToStreamingServer(streamID, playlistID, t.Context())
+
+	xteve := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bufferingStream(playlistID, source.URL, "TestChannel", w, r)
+	}))
+	defer xteve.Close()
+
+	resp, err := http.Get(xteve.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body failed: %v", err)
+	}
+
+	if resp.Header.Get("Content-Length") != "" || resp.ContentLength != -1 {
+		t.Errorf("Expected no Content-Length, got %q (%d)", resp.Header.Get("Content-Length"), resp.ContentLength)
+	}
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("Expected chunked transfer encoding, got %v", resp.TransferEncoding)
+	}
+	if resp.Header.Get("Content-Type") == "" {
+		t.Error("Expected a Content-Type header")
+	}
+	if string(body) != string(content) {
+		t.Errorf("Expected %d bytes of stream content, got %d bytes", len(content), len(body))
+	}
+}
@@ -0,0 +1,134 @@
+package src
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTunerLimitResponse(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	Settings.BufferTimeout = 0
+	Settings.TunerLimitRedirectURL = "http://example.com/busy.ts"
+
+	// The only tuner is in use
+	playlistID := "M1"
+	BufferInformation.Store(playlistID, &Playlist{
+		PlaylistID:   playlistID,
+		PlaylistName: "TestPlaylist",
+		Tuner:        1,
+		Streams:      map[int]ThisStream{0: {URL: "http://provider.example/1.ts", ChannelName: "Channel 1"}},
+		Clients:      map[int]ThisClient{0: {Connection: 1}},
+	})
+	t.Cleanup(func() { BufferInformation.Delete(playlistID) })
+
+	request := func(w http.ResponseWriter, r *http.Request) {
+		bufferingStream(playlistID, "http://provider.example/2.ts", "Channel 2", w, r)
+	}
+
+	t.Run("503", func(t *testing.T) {
+		Settings.TunerLimitResponse = "503"
+
+		rr := httptest.NewRecorder()
+		request(rr, httptest.NewRequest(http.MethodGet, "/stream/2", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.Equal(t, strconv.Itoa(tunerLimitRetryAfter), rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("redirect-to-url", func(t *testing.T) {
+		Settings.TunerLimitResponse = "redirect-to-url"
+
+		rr := httptest.NewRecorder()
+		request(rr, httptest.NewRequest(http.MethodGet, "/stream/2", nil))
+
+		assert.Equal(t, http.StatusFound, rr.Code)
+		assert.Equal(t, "http://example.com/busy.ts", rr.Header().Get("Location"))
+	})
+
+	for _, mode := range []string{"clip", "", "unknown"} {
+		t.Run("clip/"+mode, func(t *testing.T) {
+			Settings.TunerLimitResponse = mode
+
+			server := httptest.NewServer(http.HandlerFunc(request))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "video/mpeg", resp.Header.Get("Content-Type"))
+			_, err = io.ReadFull(resp.Body, make([]byte, 1))
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("redirect-to-url without URL", func(t *testing.T) {
+		Settings.TunerLimitResponse = "redirect-to-url"
+		Settings.TunerLimitRedirectURL = ""
+
+		server := httptest.NewServer(http.HandlerFunc(request))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "video/mpeg", resp.Header.Get("Content-Type"))
+	})
+}
+
+func TestReserveStreamSlot_MaxConcurrentStreams(t *testing.T) {
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	initBufferVFS(true)
+	Settings.Buffer = "xteve"
+	System.Folder.Temp = "/tmp/xteve_test_max_streams/"
+
+	playlistID := "M1"
+	t.Cleanup(func() { BufferInformation.Delete(playlistID) })
+
+	reserve := func(channel string) error {
+		_, _, _, _, _, err := reserveStreamSlot(playlistID, "http://provider.example/"+channel+".ts", channel, "127.0.0.1")
+		return err
+	}
+
+	for _, tt := range []struct {
+		name     string
+		provider map[string]any
+		streams  int
+	}{
+		{"tuner limit", map[string]any{"name": "Provider", "tuner": float64(2)}, 2},
+		{"concurrency limit below the tuner count", map[string]any{"name": "Provider", "tuner": float64(4), "max.concurrent.streams": float64(2)}, 2},
+		{"concurrency limit above the tuner count", map[string]any{"name": "Provider", "tuner": float64(1), "max.concurrent.streams": float64(3)}, 1},
+		{"no concurrency limit", map[string]any{"name": "Provider", "tuner": float64(3), "max.concurrent.streams": float64(0)}, 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			BufferInformation.Delete(playlistID)
+			Settings.Files.M3U = map[string]any{playlistID: tt.provider}
+
+			for i := range tt.streams {
+				require.NoError(t, reserve(strconv.Itoa(i)))
+			}
+			assert.ErrorIs(t, reserve("next"), errTunerLimitReached)
+			assert.Equal(t, tt.streams, activeTuners(playlistID))
+
+			// Another client of a running stream doesn't need a new connection
+			assert.NoError(t, reserve("0"))
+		})
+	}
+
+	// The tuner count advertised to the clients is not changed
+	Settings.Files.M3U = map[string]any{playlistID: map[string]any{"tuner": float64(4), "max.concurrent.streams": float64(2)}}
+	assert.Equal(t, 4, getTuner(playlistID, "m3u"))
+}
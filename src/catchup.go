@@ -0,0 +1,217 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidCatchup = errors.New("invalid catchup URL")
+
+// catchupPlaceholder : Placeholders of the catchup-source templates (Kodi IPTV Simple), e.g. {utc}, ${start} or {duration:60}
+var catchupPlaceholder = regexp.MustCompile(`\$?\{([A-Za-z]+)(?::(\d+))?\}`)
+
+// hasCatchup : The provider offers catchup / timeshift for the channel
+func hasCatchup(channel XEPGChannelStruct) bool {
+	return len(channel.Catchup) > 0 || len(channel.CatchupSource) > 0
+}
+
+// catchupAttributes : catchup, catchup-source and catchup-days of the #EXTINF line (Kodi IPTV Simple).
+// The clients use /catchup/ of xTeVe, with m3u.direct.urls the attributes of the provider are kept.
+// Like radio="true", the quote of the last value is closed by the caller.
+func catchupAttributes(channel m3uChannelData, streamURL string, direct bool) string {
+	if len(channel.Catchup) == 0 && len(channel.CatchupSource) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	if direct {
+		for _, attribute := range [][2]string{{"catchup", channel.Catchup}, {"catchup-source", channel.CatchupSource}} {
+			if len(attribute[1]) > 0 {
+				b.WriteString(`" ` + attribute[0] + `="` + attribute[1])
+			}
+		}
+	} else {
+		b.WriteString(`" catchup="default" catchup-source="`)
+		b.WriteString(strings.Replace(streamURL, "/stream/", "/catchup/", 1))
+		b.WriteString("/{utc}/{duration}")
+	}
+
+	if len(channel.CatchupDays) > 0 {
+		b.WriteString(`" catchup-days="` + channel.CatchupDays)
+	}
+	return b.String()
+}
+
+// getCatchupStream : Stream and catchup URL of the provider for /catchup/<stream ID>/<start>/<duration>.
+// The stream ID is the one of /stream/, start is a Unix time and duration in seconds.
+// window is the time of the programme for the log, the catchup URL can contain the credentials of the provider (xc).
+func getCatchupStream(path string, now time.Time) (streamInfo StreamInfo, catchupURL, window string, err error) {
+	var parts = strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 3 {
+		return streamInfo, "", "", fmt.Errorf("%w, expected /catchup/<stream ID>/<start>/<duration>", errInvalidCatchup)
+	}
+
+	start, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || start <= 0 {
+		return streamInfo, "", "", fmt.Errorf("%w: start %q", errInvalidCatchup, parts[1])
+	}
+	duration, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || duration <= 0 {
+		return streamInfo, "", "", fmt.Errorf("%w: duration %q", errInvalidCatchup, parts[2])
+	}
+
+	if streamInfo, err = getStreamInfo(parts[0]); err != nil {
+		return
+	}
+
+	var channel, ok = getCatchupChannel(streamInfo)
+	if !ok {
+		return streamInfo, "", "", fmt.Errorf("no catchup for channel: %s", streamInfo.Name)
+	}
+
+	var startTime = time.Unix(start, 0)
+	if startTime.After(now) {
+		return streamInfo, "", "", fmt.Errorf("%w: start %q is in the future", errInvalidCatchup, parts[1])
+	}
+	if days, errDays := strconv.Atoi(channel.CatchupDays); errDays == nil && days > 0 && startTime.Before(now.AddDate(0, 0, -days)) {
+		return streamInfo, "", "", fmt.Errorf("catchup of %s is only available for %d days", streamInfo.Name, days)
+	}
+
+	window = fmt.Sprintf("%s, %s", startTime.Format("2006-01-02 15:04"), time.Duration(duration)*time.Second)
+	catchupURL, err = buildCatchupURL(channel, streamInfo.URL, startTime, time.Duration(duration)*time.Second, now)
+	return
+}
+
+// getCatchupChannel : Active XEPG channel of the stream with catchup
+func getCatchupChannel(streamInfo StreamInfo) (XEPGChannelStruct, bool) {
+	if Settings.EpgSource != "XEPG" {
+		return XEPGChannelStruct{}, false
+	}
+
+	for _, channel := range Data.XEPG.Channels {
+		if channel.FileM3UID == streamInfo.PlaylistID && channel.XChannelID == streamInfo.ChannelNumber && isChannelEnabled(channel) && hasCatchup(channel) {
+			return channel, true
+		}
+	}
+	return XEPGChannelStruct{}, false
+}
+
+// buildCatchupURL : URL of the provider for the programme from start with the duration.
+// catchup selects the template, like Kodi IPTV Simple: default (catchup-source), append, shift, flussonic and xc.
+func buildCatchupURL(channel XEPGChannelStruct, streamURL string, start time.Time, duration time.Duration, now time.Time) (string, error) {
+	var template string
+
+	switch mode := strings.ToLower(channel.Catchup); mode {
+	case "default", "":
+		template = channel.CatchupSource
+	case "append":
+		template = streamURL + channel.CatchupSource
+	case "shift", "timeshift":
+		template = streamURL + "?utc={utc}&lutc={lutc}"
+		if strings.Contains(streamURL, "?") {
+			template = streamURL + "&utc={utc}&lutc={lutc}"
+		}
+	case "flussonic", "flussonic-hls", "flussonic-ts", "fs":
+		template = flussonicCatchupTemplate(streamURL)
+	case "xc":
+		template = xcCatchupTemplate(streamURL)
+	default:
+		return "", fmt.Errorf("unsupported catchup: %q", mode)
+	}
+
+	if len(template) == 0 {
+		return "", fmt.Errorf("catchup %q is not supported for the stream of %s", channel.Catchup, channel.XName)
+	}
+
+	return expandCatchupTemplate(template, start, duration, now), nil
+}
+
+// flussonicCatchupTemplate : .../index.m3u8 becomes .../index-{utc}-{duration}.m3u8, .../mpegts becomes .../timeshift_abs-{utc}.ts
+func flussonicCatchupTemplate(streamURL string) string {
+	var address, query, hasQuery = strings.Cut(streamURL, "?")
+	var i = strings.LastIndex(address, "/")
+	if i < 0 {
+		return ""
+	}
+
+	var dir, file = address[:i+1], address[i+1:]
+	switch {
+	case strings.HasSuffix(file, ".m3u8"):
+		file = strings.TrimSuffix(file, ".m3u8") + "-{utc}-{duration}.m3u8"
+	case file == "mpegts" || strings.HasSuffix(file, ".ts"):
+		file = "timeshift_abs-{utc}.ts"
+	default:
+		return ""
+	}
+
+	if hasQuery {
+		return dir + file + "?" + query
+	}
+	return dir + file
+}
+
+// xcCatchupTemplate : Xtream Codes, http://host/[live/]user/pass/id.ts becomes http://host/timeshift/user/pass/{duration:60}/{Y}-{m}-{d}:{H}-{M}/id.ts
+func xcCatchupTemplate(streamURL string) string {
+	var scheme, rest, ok = strings.Cut(streamURL, "://")
+	if !ok {
+		return ""
+	}
+
+	var parts = strings.Split(rest, "/")
+	if len(parts) == 5 && parts[1] == "live" {
+		parts = append(parts[:1], parts[2:]...)
+	}
+	if len(parts) != 4 {
+		return ""
+	}
+
+	var host, user, password, stream = parts[0], parts[1], parts[2], parts[3]
+	if !strings.Contains(stream, ".") {
+		stream += ".ts"
+	}
+	return fmt.Sprintf("%s://%s/timeshift/%s/%s/{duration:60}/{Y}-{m}-{d}:{H}-{M}/%s", scheme, host, user, password, stream)
+}
+
+// expandCatchupTemplate : Replaces the placeholders, unknown placeholders are kept.
+// {Y}, {m}, {d}, {H}, {M} and {S} are the start in the local time of xTeVe.
+func expandCatchupTemplate(template string, start time.Time, duration time.Duration, now time.Time) string {
+	var local = start.Local()
+
+	return catchupPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		var match = catchupPlaceholder.FindStringSubmatch(placeholder)
+		var divisor int64 = 1
+		if n, err := strconv.ParseInt(match[2], 10, 64); err == nil && n > 0 {
+			divisor = n
+		}
+
+		switch match[1] {
+		case "utc", "start", "timestamp":
+			return strconv.FormatInt(start.Unix(), 10)
+		case "utcend", "end":
+			return strconv.FormatInt(start.Add(duration).Unix(), 10)
+		case "lutc", "now":
+			return strconv.FormatInt(now.Unix(), 10)
+		case "duration":
+			return strconv.FormatInt(int64(duration.Seconds())/divisor, 10)
+		case "offset":
+			return strconv.FormatInt(int64(now.Sub(start).Seconds())/divisor, 10)
+		case "Y":
+			return local.Format("2006")
+		case "m":
+			return local.Format("01")
+		case "d":
+			return local.Format("02")
+		case "H":
+			return local.Format("15")
+		case "M":
+			return local.Format("04")
+		case "S":
+			return local.Format("05")
+		}
+		return placeholder
+	})
+}
@@ -0,0 +1,196 @@
+package src
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	m3u "xteve/src/internal/m3u-parser"
+)
+
+func TestCatchup_M3UAttributes(t *testing.T) {
+	setupOutputProfileTest(t)
+
+	const playlist = "#EXTM3U\n" +
+		`#EXTINF:-1 tvg-id="zeta.uk" catchup="shift" catchup-source="?utc={utc}" catchup-days="7" group-title="News",Zeta News` +
+		"\nhttp://provider.example/1.ts\n"
+
+	streams, err := m3u.MakeInterfaceFromM3U([]byte(playlist))
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+
+	var m3uChannel M3UChannelStructXEPG
+	bindMapToM3UChannelStruct(streams[0].(map[string]string), &m3uChannel)
+	assert.Equal(t, "shift", m3uChannel.Catchup)
+	assert.Equal(t, "?utc={utc}", m3uChannel.CatchupSource)
+	assert.Equal(t, "7", m3uChannel.CatchupDays)
+
+	var channel = Data.XEPG.Channels["x-ID.1"]
+	channel.Catchup, channel.CatchupSource, channel.CatchupDays = m3uChannel.Catchup, m3uChannel.CatchupSource, m3uChannel.CatchupDays
+	Data.XEPG.Channels["x-ID.1"] = channel
+
+	build := func(profile OutputProfile) []string {
+		var sb strings.Builder
+		require.NoError(t, buildM3UToWriter(&sb, []string{}, profile))
+		return strings.Split(strings.TrimSpace(sb.String()), "\n")
+	}
+
+	// xTeVe: The clients use /catchup/
+	var lines = build(OutputProfile{Name: defaultOutputProfile})
+	require.Len(t, lines, 7)
+	var catchupURL = strings.Replace(lines[2], "/stream/", "/catchup/", 1)
+	assert.Contains(t, lines[1], ` catchup="default" catchup-source="`+catchupURL+`/{utc}/{duration}" catchup-days="7",Zeta News`)
+	assert.NotContains(t, lines[3], "catchup", "channels without catchup")
+
+	// m3u.direct.urls: The attributes of the provider
+	lines = build(OutputProfile{Name: "kodi", Format: "direct"})
+	assert.Contains(t, lines[1], ` catchup="shift" catchup-source="?utc={utc}" catchup-days="7",Zeta News`)
+
+	// m3u.format "samsung"
+	Settings.M3UFormat = "samsung"
+	lines = build(OutputProfile{Name: defaultOutputProfile})
+	assert.Contains(t, lines[1], ` catchup="default" catchup-source="`+catchupURL+`/{utc}/{duration}" catchup-days="7",Zeta News`)
+	assert.NotContains(t, lines[3], "catchup", "channels without catchup")
+}
+
+func TestBuildCatchupURL(t *testing.T) {
+	var start = time.Date(2026, 3, 1, 20, 15, 0, 0, time.Local)
+	var now = start.Add(2 * time.Hour)
+	var duration = 90 * time.Minute
+
+	var tests = []struct {
+		name      string
+		channel   XEPGChannelStruct
+		streamURL string
+		want      string
+	}{
+		{
+			name:      "default template",
+			channel:   XEPGChannelStruct{Catchup: "default", CatchupSource: "http://provider.example/archive/1.ts?start={utc}&end={utcend}&len={duration:60}&now=${now}&offset={offset}"},
+			streamURL: "http://provider.example/1.ts",
+			want:      fmt.Sprintf("http://provider.example/archive/1.ts?start=%d&end=%d&len=90&now=%d&offset=7200", start.Unix(), start.Add(duration).Unix(), now.Unix()),
+		},
+		{
+			name:      "date placeholders",
+			channel:   XEPGChannelStruct{CatchupSource: "http://provider.example/{Y}/{m}/{d}/{H}-{M}-{S}.ts?id=${start}&x={unknown}"},
+			streamURL: "http://provider.example/1.ts",
+			want:      fmt.Sprintf("http://provider.example/2026/03/01/20-15-00.ts?id=%d&x={unknown}", start.Unix()),
+		},
+		{
+			name:      "append",
+			channel:   XEPGChannelStruct{Catchup: "append", CatchupSource: "?utc={utc}&lutc={lutc}"},
+			streamURL: "http://provider.example/1.ts",
+			want:      fmt.Sprintf("http://provider.example/1.ts?utc=%d&lutc=%d", start.Unix(), now.Unix()),
+		},
+		{
+			name:      "shift",
+			channel:   XEPGChannelStruct{Catchup: "shift"},
+			streamURL: "http://provider.example/1.ts?token=abc",
+			want:      fmt.Sprintf("http://provider.example/1.ts?token=abc&utc=%d&lutc=%d", start.Unix(), now.Unix()),
+		},
+		{
+			name:      "flussonic hls",
+			channel:   XEPGChannelStruct{Catchup: "flussonic"},
+			streamURL: "http://provider.example/zeta/index.m3u8?token=abc",
+			want:      fmt.Sprintf("http://provider.example/zeta/index-%d-5400.m3u8?token=abc", start.Unix()),
+		},
+		{
+			name:      "flussonic mpegts",
+			channel:   XEPGChannelStruct{Catchup: "fs"},
+			streamURL: "http://provider.example/zeta/mpegts",
+			want:      fmt.Sprintf("http://provider.example/zeta/timeshift_abs-%d.ts", start.Unix()),
+		},
+		{
+			name:      "xtream codes",
+			channel:   XEPGChannelStruct{Catchup: "xc"},
+			streamURL: "http://provider.example:8080/live/user/pass/1234.ts",
+			want:      "http://provider.example:8080/timeshift/user/pass/90/2026-03-01:20-15/1234.ts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildCatchupURL(tt.channel, tt.streamURL, start, duration, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := buildCatchupURL(XEPGChannelStruct{Catchup: "vod"}, "http://provider.example/1.ts", start, duration, now)
+	assert.Error(t, err, "unknown catchup")
+
+	_, err = buildCatchupURL(XEPGChannelStruct{Catchup: "xc"}, "http://provider.example/1.ts", start, duration, now)
+	assert.Error(t, err, "not an Xtream Codes URL")
+}
+
+func TestCatchup_Endpoint(t *testing.T) {
+	setupOutputProfileTest(t)
+	Settings.Buffer = "-"
+
+	// Each channel needs its own stream URL
+	for id, channel := range Data.XEPG.Channels {
+		channel.Name = channel.XName
+		Data.XEPG.Channels[id] = channel
+	}
+
+	var channel = Data.XEPG.Channels["x-ID.1"]
+	channel.CatchupSource, channel.CatchupDays = "http://provider.example/archive/1.ts?start={utc}&duration={duration}", "2"
+	Data.XEPG.Channels["x-ID.1"] = channel
+
+	var sb strings.Builder
+	require.NoError(t, buildM3UToWriter(&sb, []string{}, OutputProfile{Name: defaultOutputProfile}))
+
+	var urlID string
+	for id, info := range Data.Cache.StreamingURLS {
+		if info.ChannelNumber == "1" {
+			urlID = id
+		}
+	}
+	require.NotEmpty(t, urlID)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		var w = httptest.NewRecorder()
+		Catchup(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	Settings.LogEntriesRAM = 100
+	WebScreenLog.Mu.Lock()
+	oldLog := WebScreenLog.Log
+	WebScreenLog.Log = nil
+	WebScreenLog.Mu.Unlock()
+	t.Cleanup(func() {
+		WebScreenLog.Mu.Lock()
+		WebScreenLog.Log = oldLog
+		WebScreenLog.Mu.Unlock()
+	})
+
+	var start = time.Now().Add(-3 * time.Hour).Unix()
+	var w = request(fmt.Sprintf("/catchup/%s/%d/3600", urlID, start))
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, fmt.Sprintf("http://provider.example/archive/1.ts?start=%d&duration=3600", start), w.Header().Get("Location"))
+
+	// The catchup URL can contain the credentials of the provider, the log shows the time of the programme
+	WebScreenLog.Mu.Lock()
+	var log = strings.Join(WebScreenLog.Log, "\n")
+	WebScreenLog.Mu.Unlock()
+	assert.Contains(t, log, time.Unix(start, 0).Format("2006-01-02&nbsp;15:04")+",&nbsp;1h0m0s")
+	assert.NotContains(t, log, "provider.example/archive")
+
+	assert.Equal(t, http.StatusBadRequest, request("/catchup/"+urlID+"/abc/3600").Code)
+	assert.Equal(t, http.StatusBadRequest, request("/catchup/"+urlID).Code)
+	assert.Equal(t, http.StatusNotFound, request(fmt.Sprintf("/catchup/%s/%d/3600", urlID, time.Now().AddDate(0, 0, -3).Unix())).Code, "older than catchup-days")
+
+	// Channels without catchup
+	for id, info := range Data.Cache.StreamingURLS {
+		if info.ChannelNumber == "2" {
+			assert.Equal(t, http.StatusNotFound, request(fmt.Sprintf("/catchup/%s/%d/3600", id, start)).Code)
+		}
+	}
+}
@@ -3,15 +3,29 @@ package src
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	b64 "encoding/base64"
 	"encoding/pem"
+	"errors"
+	"fmt"
 	"math/big"
 	"net"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
+// pendingCert holds an uploaded certificate or private key until its counterpart
+// has been uploaded as well. A pair is only installed when both belong together.
+var pendingCert struct {
+	sync.Mutex
+	cert []byte
+	key  []byte
+}
+
 // genCertFiles creates a self-signed certificate and it's private key in config/certificates directory.
 //
 //	Inspired by https://gist.github.com/shaneutt/5e1995295cff6721c89a71d13a71c251
@@ -76,3 +90,135 @@ func genCertFiles() (err error) {
 	}
 	return
 }
+
+// uploadServerCert validates an uploaded PEM certificate (base64, like uploadLogo) and installs it
+func uploadServerCert(input string) (err error) {
+	certPEM, err := decodeUploadedPEM(input, "CERTIFICATE")
+	if err != nil {
+		return
+	}
+
+	if err = checkCertValidity(certPEM, time.Now()); err != nil {
+		return
+	}
+
+	pendingCert.Lock()
+	defer pendingCert.Unlock()
+
+	keyPEM := pendingCert.key
+	if keyPEM == nil {
+		keyPEM, _ = os.ReadFile(System.File.ServerCertPrivKey)
+	}
+
+	return pairServerCert(certPEM, keyPEM, pendingCert.key != nil, "certificate")
+}
+
+// uploadServerKey validates an uploaded PEM private key (base64, like uploadLogo) and installs it
+func uploadServerKey(input string) (err error) {
+	keyPEM, err := decodeUploadedPEM(input, "PRIVATE KEY")
+	if err != nil {
+		return
+	}
+
+	pendingCert.Lock()
+	defer pendingCert.Unlock()
+
+	certPEM := pendingCert.cert
+	if certPEM == nil {
+		certPEM, _ = os.ReadFile(System.File.ServerCert)
+	}
+
+	return pairServerCert(certPEM, keyPEM, pendingCert.cert != nil, "private key")
+}
+
+// pairServerCert installs certificate and key when they match. If the counterpart
+// was uploaded in this session a mismatch is an error, otherwise the upload is kept
+// until the counterpart follows. The caller must hold pendingCert.
+func pairServerCert(certPEM, keyPEM []byte, uploadedCounterpart bool, uploaded string) (err error) {
+	if _, errPair := tls.X509KeyPair(certPEM, keyPEM); errPair != nil {
+		if uploadedCounterpart {
+			return errors.New("the private key does not match the certificate")
+		}
+
+		if uploaded == "certificate" {
+			pendingCert.cert = certPEM
+		} else {
+			pendingCert.key = keyPEM
+		}
+
+		showInfo("Web server:" + fmt.Sprintf("Uploaded %s does not match the current files, waiting for the counterpart", uploaded))
+		return
+	}
+
+	if err = writeFileAtomic(System.File.ServerCert, certPEM, 0644); err != nil {
+		return
+	}
+
+	if err = writeFileAtomic(System.File.ServerCertPrivKey, keyPEM, 0600); err != nil {
+		return
+	}
+
+	pendingCert.cert, pendingCert.key = nil, nil
+	showInfo("Web server:" + "New TLS certificate installed")
+
+	if Settings.TLSMode {
+		select {
+		case restartWebserver <- true:
+		default: // A restart is already pending
+		}
+	}
+
+	return
+}
+
+// decodeUploadedPEM decodes the base64 upload and checks that it contains a PEM block of the expected type
+func decodeUploadedPEM(input, blockType string) (data []byte, err error) {
+	data, err = b64.StdEncoding.DecodeString(input[strings.IndexByte(input, ',')+1:])
+	if err != nil {
+		return
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || !strings.Contains(block.Type, blockType) {
+		err = fmt.Errorf("the uploaded file is not a PEM encoded %s", strings.ToLower(blockType))
+	}
+
+	return
+}
+
+// checkCertValidity rejects certificates that are expired or not yet valid
+func checkCertValidity(certPEM []byte, now time.Time) (err error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return errors.New("the uploaded file is not a PEM encoded certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case now.After(cert.NotAfter):
+		err = fmt.Errorf("the certificate has expired on %s", cert.NotAfter.Format(time.DateOnly))
+	case now.Before(cert.NotBefore):
+		err = fmt.Errorf("the certificate is not valid before %s", cert.NotBefore.Format(time.DateOnly))
+	}
+
+	return
+}
+
+// writeFileAtomic writes to a temporary file first and renames it upon success
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) (err error) {
+	var tmp = filename + ".tmp"
+
+	if err = os.WriteFile(tmp, data, perm); err != nil {
+		return
+	}
+
+	if err = os.Rename(tmp, filename); err != nil {
+		_ = os.Remove(tmp)
+	}
+
+	return
+}
@@ -0,0 +1,111 @@
+package src
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	b64 "encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generateTestCert(t *testing.T, notBefore, notAfter time.Time) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "xteve.test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+func setupCertUploadTest(t *testing.T) {
+	t.Helper()
+
+	oldSystem, oldSettings := System, Settings
+	t.Cleanup(func() {
+		System, Settings = oldSystem, oldSettings
+		pendingCert.cert, pendingCert.key = nil, nil
+	})
+
+	dir := t.TempDir()
+	System.File.ServerCert = filepath.Join(dir, "xteve.crt")
+	System.File.ServerCertPrivKey = filepath.Join(dir, "xteve.key")
+	Settings.TLSMode = false
+}
+
+func toUpload(data []byte) string {
+	return "data:application/x-pem-file;base64," + b64.StdEncoding.EncodeToString(data)
+}
+
+func TestUploadServerCert_Pair(t *testing.T) {
+	setupCertUploadTest(t)
+
+	certPEM, keyPEM := generateTestCert(t, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+
+	require.NoError(t, uploadServerCert(toUpload(certPEM)))
+	assert.NoFileExists(t, System.File.ServerCert, "certificate must wait for its key")
+
+	require.NoError(t, uploadServerKey(toUpload(keyPEM)))
+
+	written, err := os.ReadFile(System.File.ServerCert)
+	require.NoError(t, err)
+	assert.Equal(t, certPEM, written)
+
+	info, err := os.Stat(System.File.ServerCertPrivKey)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Nil(t, pendingCert.cert)
+}
+
+func TestUploadServerCert_Mismatch(t *testing.T) {
+	setupCertUploadTest(t)
+
+	certPEM, _ := generateTestCert(t, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+	_, otherKeyPEM := generateTestCert(t, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+
+	require.NoError(t, uploadServerCert(toUpload(certPEM)))
+
+	err := uploadServerKey(toUpload(otherKeyPEM))
+	require.EqualError(t, err, "the private key does not match the certificate")
+	assert.NoFileExists(t, System.File.ServerCertPrivKey)
+}
+
+func TestUploadServerCert_Invalid(t *testing.T) {
+	setupCertUploadTest(t)
+
+	expiredPEM, _ := generateTestCert(t, time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+	err := uploadServerCert(toUpload(expiredPEM))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the certificate has expired on")
+
+	_, keyPEM := generateTestCert(t, time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+	err = uploadServerCert(toUpload(keyPEM))
+	assert.EqualError(t, err, "the uploaded file is not a PEM encoded certificate")
+
+	err = uploadServerKey(toUpload([]byte("not a key")))
+	assert.EqualError(t, err, "the uploaded file is not a PEM encoded private key")
+}
@@ -0,0 +1,207 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+const channelFailuresFile = "channel_failures.json" // In the cache folder, failed connections of auto.disable.dead.channels
+
+// channelFailures : Unix times of the failed connections per stream (auto.disable.dead.channels).
+// The buffer only records the streams, the channels are resolved by the maintenance or the mapping of a scan.
+// The failures are saved in the cache folder and loaded again with the first access after a restart.
+type channelFailures struct {
+	sync.Mutex
+	loaded   bool
+	failures map[string][]int64
+}
+
+var deadChannels channelFailures
+
+// load reads the saved failures once, the lock must be held
+func (c *channelFailures) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+
+	if err := loadJSONFile(System.Folder.Cache+channelFailuresFile, &c.failures); err != nil || c.failures == nil {
+		c.failures = make(map[string][]int64)
+	}
+}
+
+// save writes the failures to the cache folder, the lock must be held
+func (c *channelFailures) save() {
+	if err := saveMapToJSONFile(System.Folder.Cache+channelFailuresFile, c.failures); err != nil {
+		ShowError(err, 0)
+	}
+}
+
+// streamFailureKey : Key of the failures of a stream. The URL is hashed, it can contain the credentials of the provider.
+func streamFailureKey(playlistID, streamURL string) string {
+	var hash, _ = getMD5(streamURL)
+	return playlistID + ":" + hash
+}
+
+// recordStreamFailure : Counts a connection of the buffer that failed before any data was received.
+// The channels of a stream that reaches auto.disable.dead.channels.failures within the window are disabled by disableFailedChannels.
+func recordStreamFailure(playlistID, streamURL string, now time.Time) {
+	if !Settings.AutoDisableDeadChannels || Settings.EpgSource != "XEPG" {
+		return
+	}
+
+	var key = streamFailureKey(playlistID, streamURL)
+
+	deadChannels.Lock()
+	defer deadChannels.Unlock()
+	deadChannels.load()
+
+	deadChannels.failures[key] = append(deadChannels.failures[key], now.Unix())
+	deadChannels.dead(now)
+	deadChannels.save()
+}
+
+// resetStreamFailures : A stream that delivered data clears its failures
+func resetStreamFailures(playlistID, streamURL string) {
+	if !Settings.AutoDisableDeadChannels || Settings.EpgSource != "XEPG" {
+		return
+	}
+
+	var key = streamFailureKey(playlistID, streamURL)
+
+	deadChannels.Lock()
+	defer deadChannels.Unlock()
+	deadChannels.load()
+
+	if _, ok := deadChannels.failures[key]; ok {
+		delete(deadChannels.failures, key)
+		deadChannels.save()
+	}
+}
+
+// disableFailedChannels : Disables the channels of the dead streams (maintenance, not during a scan)
+func disableFailedChannels(now time.Time) {
+	var dead = getDeadStreams(now)
+	if len(dead) == 0 {
+		return
+	}
+
+	if _, err := updateXEPGChannels(func(channels map[string]XEPGChannelStruct) []string {
+		return disableDeadChannels(channels, dead)
+	}); err != nil {
+		ShowError(err, 0)
+		return
+	}
+	deadChannels.clear(dead)
+}
+
+// getDeadStreams : Keys of the streams with auto.disable.dead.channels.failures failed connections within the window
+func getDeadStreams(now time.Time) []string {
+	if !Settings.AutoDisableDeadChannels || Settings.EpgSource != "XEPG" {
+		return nil
+	}
+
+	deadChannels.Lock()
+	defer deadChannels.Unlock()
+	deadChannels.load()
+
+	return deadChannels.dead(now)
+}
+
+// disableDeadChannels : Disables the active channels of the dead streams. The failures are kept until the caller has saved the channels (clear).
+func disableDeadChannels(channels map[string]XEPGChannelStruct, dead []string) (changed []string) {
+	for id, channel := range channels {
+		if !isChannelEnabled(channel) || !slices.Contains(dead, streamFailureKey(channel.FileM3UID, channel.URL)) {
+			continue
+		}
+
+		channel.XActive, channel.XUserDisabled, channel.XAutoDisabled = false, true, true
+		channels[id] = channel
+		changed = append(changed, id)
+
+		showInfo(fmt.Sprintf("Dead channel:%s (%s) disabled after %d failed connections", channel.XName, channel.XChannelID, max(Settings.AutoDisableFailures, 1)))
+	}
+	return
+}
+
+// dead removes the failures outside of the window and returns the streams that reached the limit, the lock must be held
+func (c *channelFailures) dead(now time.Time) (keys []string) {
+	var oldest = now.Add(-time.Duration(Settings.AutoDisableWindowHours) * time.Hour).Unix()
+
+	for key, failures := range c.failures {
+		if Settings.AutoDisableWindowHours > 0 {
+			failures = slices.DeleteFunc(failures, func(t int64) bool { return t < oldest })
+			c.failures[key] = failures
+		}
+		if len(failures) == 0 {
+			delete(c.failures, key)
+			continue
+		}
+		if len(failures) >= max(Settings.AutoDisableFailures, 1) {
+			keys = append(keys, key)
+		}
+	}
+	return
+}
+
+// clear removes the failures of the streams whose channels were disabled
+func (c *channelFailures) clear(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.load()
+
+	for _, key := range keys {
+		delete(c.failures, key)
+	}
+	c.save()
+}
+
+// reenableDeadChannels : Activates the channels that were disabled by auto.disable.dead.channels (API command channels.reenable).
+// Without IDs, all of these channels are activated.
+func reenableDeadChannels(ids []string) ([]string, error) {
+	enabled, err := updateXEPGChannels(func(channels map[string]XEPGChannelStruct) (changed []string) {
+		for id, channel := range channels {
+			if !channel.XAutoDisabled || (len(ids) > 0 && !slices.Contains(ids, id)) {
+				continue
+			}
+
+			channel.XActive, channel.XUserDisabled, channel.XAutoDisabled = true, false, false
+			channels[id] = channel
+			changed = append(changed, id)
+
+			showInfo(fmt.Sprintf("Dead channel:%s (%s) enabled again", channel.XName, channel.XChannelID))
+		}
+		return
+	})
+
+	slices.Sort(enabled)
+	return enabled, err
+}
+
+// updateXEPGChannels : Changes a copy of the channels, saves xepg.json and recreates xteve.m3u and xteve.xml
+func updateXEPGChannels(update func(channels map[string]XEPGChannelStruct) []string) (changed []string, err error) {
+	if System.ScanInProgress == 1 {
+		return nil, errors.New("a scan is in progress, try again later")
+	}
+
+	var channels = maps.Clone(Data.XEPG.Channels)
+	if changed = update(channels); len(changed) == 0 {
+		return
+	}
+
+	if err = saveMapToJSONFile(System.File.XEPG, channels); err != nil {
+		return
+	}
+	Data.XEPG.Channels = channels
+
+	_, err = regenerateFiles()
+	return
+}
@@ -0,0 +1,161 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoDisableDeadChannels(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	setupOutputProfileTest(t)
+	Settings.OutputProfiles = nil
+	Settings.AuthenticationAPI = false
+	Settings.StreamRetryEnabled = false
+	Settings.AutoDisableDeadChannels = true
+	Settings.AutoDisableFailures = 3
+	Settings.AutoDisableWindowHours = 24
+	System.ScanInProgress = 0
+	System.Folder.Cache = System.Folder.Data
+	System.File.M3U = System.Folder.Data + "xteve.m3u"
+	System.File.XEPG = System.Folder.Data + "xepg.json"
+	System.File.URLS = System.Folder.Data + "urls.json"
+
+	resetDeadChannels := func() {
+		deadChannels.Lock()
+		deadChannels.loaded, deadChannels.failures = false, nil
+		deadChannels.Unlock()
+	}
+	resetDeadChannels()
+	t.Cleanup(resetDeadChannels)
+
+	// The provider does not deliver the stream of x-ID.1
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	var channel = Data.XEPG.Channels["x-ID.1"]
+	channel.URL = server.URL + "/dead.ts"
+	Data.XEPG.Channels["x-ID.1"] = channel
+
+	initBufferVFS(true)
+	const playlistID = "M1"
+	md5Val, err := getMD5(channel.URL)
+	require.NoError(t, err)
+
+	connect := func() {
+		playlist := &Playlist{PlaylistID: playlistID, Tuner: 1, Streams: map[int]ThisStream{}, Clients: map[int]ThisClient{}}
+		playlist.Streams[0] = ThisStream{URL: channel.URL, MD5: md5Val, PlaylistID: playlistID, Folder: "/tmp/xteve_test_dead_channel/" + md5Val + "/"}
+		playlist.Clients[0] = ThisClient{Connection: 1}
+		BufferInformation.Store(playlistID, playlist)
+		BufferClients.Store(playlistID+md5Val, &ClientConnection{Connection: 1})
+		defer BufferInformation.Delete(playlistID)
+		defer BufferClients.Delete(playlistID + md5Val)
+
+		connectToStreamingServer(0, playlistID, t.Context())
+	}
+
+	connect()
+	connect()
+	disableFailedChannels(time.Now())
+	assert.True(t, isChannelEnabled(Data.XEPG.Channels["x-ID.1"]))
+
+	// The failures are kept after a restart, the buffer records the stream and not the channel
+	var key = streamFailureKey(playlistID, channel.URL)
+	var saved map[string][]int64
+	require.NoError(t, loadJSONFile(System.Folder.Cache+channelFailuresFile, &saved))
+	assert.Len(t, saved[key], 2)
+	assert.NotContains(t, key, "dead.ts", "the URL can contain the credentials of the provider")
+	resetDeadChannels()
+
+	// The buffer does not change the channels, the maintenance disables them
+	connect()
+	assert.True(t, isChannelEnabled(Data.XEPG.Channels["x-ID.1"]))
+	disableFailedChannels(time.Now())
+	channel = Data.XEPG.Channels["x-ID.1"]
+	assert.False(t, channel.XActive)
+	assert.True(t, channel.XAutoDisabled)
+	assert.True(t, channel.XUserDisabled, "a mapped channel is not activated again by the next update")
+
+	m3u, err := os.ReadFile(System.File.M3U)
+	require.NoError(t, err)
+	assert.NotContains(t, string(m3u), "Zeta News")
+	assert.Contains(t, string(m3u), "Alpha Sport")
+
+	var xepg map[string]XEPGChannelStruct
+	require.NoError(t, loadJSONFile(System.File.XEPG, &xepg))
+	assert.True(t, xepg["x-ID.1"].XAutoDisabled)
+
+	// Failures outside of the window are not counted
+	var url2 = Data.XEPG.Channels["x-ID.2"].URL
+	var now = time.Now()
+	recordStreamFailure("M1", url2, now.Add(-48*time.Hour))
+	recordStreamFailure("M1", url2, now.Add(-25*time.Hour))
+	recordStreamFailure("M1", url2, now)
+	disableFailedChannels(now)
+	assert.True(t, isChannelEnabled(Data.XEPG.Channels["x-ID.2"]))
+
+	// A stream that delivered data clears the failures
+	recordStreamFailure("M1", url2, now)
+	resetStreamFailures("M1", url2)
+	recordStreamFailure("M1", url2, now)
+	disableFailedChannels(now)
+	assert.True(t, isChannelEnabled(Data.XEPG.Channels["x-ID.2"]))
+
+	// Re-enable with the API
+	req := httptest.NewRequest("POST", "/api/", bytes.NewBufferString(`{"cmd":"channels.reenable"}`))
+	req.RemoteAddr = "127.0.0.1:1234"
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	API(w, req)
+
+	var response APIResponseStruct
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.True(t, response.Status, response.Error)
+	assert.Equal(t, []string{"x-ID.1"}, response.Channels)
+
+	channel = Data.XEPG.Channels["x-ID.1"]
+	assert.True(t, isChannelEnabled(channel))
+	assert.False(t, channel.XAutoDisabled)
+
+	m3u, err = os.ReadFile(System.File.M3U)
+	require.NoError(t, err)
+	assert.Contains(t, string(m3u), "Zeta News")
+
+	// Disabled
+	Settings.AutoDisableDeadChannels = false
+	for range 3 {
+		connect()
+	}
+	disableFailedChannels(time.Now())
+	assert.True(t, isChannelEnabled(Data.XEPG.Channels["x-ID.1"]))
+
+	// The mapping of a scan disables the channels as well
+	Settings.AutoDisableDeadChannels = true
+	resetStreamFailures("M1", url2)
+	System.ScanInProgress = 1
+	for range 3 {
+		recordStreamFailure("M1", url2, now)
+	}
+	disableFailedChannels(now)
+	assert.True(t, isChannelEnabled(Data.XEPG.Channels["x-ID.2"]), "not during a scan")
+	require.NoError(t, loadJSONFile(System.Folder.Cache+channelFailuresFile, &saved))
+	assert.Len(t, saved[streamFailureKey("M1", url2)], 3)
+
+	require.NoError(t, mapping())
+	System.ScanInProgress = 0
+	assert.False(t, isChannelEnabled(Data.XEPG.Channels["x-ID.2"]))
+	assert.True(t, Data.XEPG.Channels["x-ID.2"].XAutoDisabled)
+	assert.Empty(t, getDeadStreams(now))
+
+	require.NoError(t, loadJSONFile(System.File.XEPG, &xepg))
+	assert.True(t, xepg["x-ID.2"].XAutoDisabled)
+}
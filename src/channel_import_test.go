@@ -0,0 +1,152 @@
+package src
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportFromInstance(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	setupOutputProfileTest(t)
+	Settings.OutputProfiles = nil
+	Settings.AuthenticationWEB = false
+	Settings.AuthenticationPMS = false
+	System.ConfigurationWizard = false
+	System.ScanInProgress = 0
+	System.File.M3U = System.Folder.Data + "xteve.m3u"
+	System.File.XEPG = System.Folder.Data + "xepg.json"
+	System.File.URLS = System.Folder.Data + "urls.json"
+
+	var local = Data.XEPG.Channels
+	for id, tvgID := range map[string]string{"x-ID.1": "zeta.uk", "x-ID.2": "alpha.uk"} {
+		var channel = local[id]
+		channel.TvgID, channel.Name = tvgID, channel.XName
+		local[id] = channel
+	}
+	var channel = local["x-ID.2"]
+	channel.XmltvFile, channel.XMapping = "X1.xml", "other.sport"
+	local["x-ID.2"] = channel
+	channel = local["x-ID.3"]
+	channel.Name = "Beta Movies"
+	local["x-ID.3"] = channel
+	local["x-ID.4"] = XEPGChannelStruct{XActive: true, XChannelID: "4", XName: "Gamma Kids", TvgID: "gamma.uk", XEPG: "x-ID.4", FileM3UID: "M1", URL: "http://provider.example/4.ts"}
+
+	Data.XMLTV.Mapping = map[string]map[string]XMLTVChannelMapping{
+		"X1.xml":      {"zeta.uk": {}, "alpha.uk": {}, "gamma.uk": {}, "other.sport": {}},
+		"xTeVe Dummy": {"120_Minutes": {}},
+	}
+
+	// The channel list of the other instance
+	var remote = []ChannelExportStruct{
+		{TvgID: "zeta.uk", Name: "Zeta", XActive: true, XName: "Zeta HD", XChannelID: "100", XCategory: "News", XGroupTitle: "Nachrichten", XmltvFile: "X9.xml", XMapping: "zeta.uk"},
+		{TvgID: "alpha.uk", Name: "Alpha", XActive: true, XName: "Alpha", XChannelID: "101", XmltvFile: "X9.xml", XMapping: "alpha.uk"},
+		{Name: "Beta Movies", XActive: true, XName: "Beta", XChannelID: "102", XmltvFile: "xTeVe Dummy", XMapping: "120_Minutes", XStreamFormat: "ts"},
+		{TvgID: "gamma.uk", Name: "Gamma", XActive: true, XName: "Gamma", XChannelID: "2", XmltvFile: "X9.xml", XMapping: "gamma.uk"},
+		{TvgID: "delta.uk", Name: "Delta", XActive: true, XName: "Delta", XChannelID: "103", XmltvFile: "X9.xml", XMapping: "delta.uk"},
+		{TvgID: "gamma.uk", Name: "Gamma", XActive: false, XName: "Gamma", XChannelID: "104", XmltvFile: "-", XMapping: "-"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/channels.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(remote)
+	}))
+	defer server.Close()
+
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	Settings.LogEntriesRAM = 100
+	WebScreenLog.Mu.Lock()
+	oldLog := WebScreenLog.Log
+	WebScreenLog.Log = nil
+	WebScreenLog.Mu.Unlock()
+	t.Cleanup(func() {
+		WebScreenLog.Mu.Lock()
+		WebScreenLog.Log = oldLog
+		WebScreenLog.Mu.Unlock()
+	})
+
+	// Credentials for authentication.pms are part of the URL
+	require.NoError(t, ws.WriteJSON(map[string]string{"cmd": "importFromInstance", "url": strings.Replace(server.URL, "://", "://user:secret@", 1) + "/"}))
+
+	var response struct {
+		Status        bool                `json:"status"`
+		Error         string              `json:"err"`
+		ChannelImport ChannelImportStruct `json:"channelImport"`
+	}
+	require.NoError(t, ws.ReadJSON(&response))
+	require.True(t, response.Status, response.Error)
+
+	assert.Equal(t, []string{"x-ID.1", "x-ID.3"}, response.ChannelImport.Imported)
+	assert.Equal(t, []string{"Delta"}, response.ChannelImport.Unmatched)
+
+	WebScreenLog.Mu.Lock()
+	var log = strings.Join(WebScreenLog.Log, "\n")
+	WebScreenLog.Mu.Unlock()
+	assert.Contains(t, log, "user:xxxxx@", "the password is not logged")
+	assert.NotContains(t, log, "secret")
+	assert.Equal(t, []ChannelImportConflict{
+		{ID: "x-ID.2", TvgID: "alpha.uk", Name: "Alpha", Reason: "already mapped to X1.xml / other.sport"},
+		{ID: "x-ID.4", TvgID: "gamma.uk", Name: "Gamma", Reason: "channel number 2 is used by Alpha Sport"},
+	}, response.ChannelImport.Conflicts)
+
+	// Matched by tvg-id, the unknown XMLTV file of the other instance is replaced by the local file with this channel
+	var zeta = Data.XEPG.Channels["x-ID.1"]
+	assert.Equal(t, "X1.xml", zeta.XmltvFile)
+	assert.Equal(t, "zeta.uk", zeta.XMapping)
+	assert.Equal(t, "100", zeta.XChannelID)
+	assert.Equal(t, "Zeta HD", zeta.XName)
+	assert.Equal(t, "News", zeta.XCategory)
+	assert.Equal(t, "Nachrichten", zeta.XGroupTitle)
+
+	// Matched by name
+	var beta = Data.XEPG.Channels["x-ID.3"]
+	assert.Equal(t, "xTeVe Dummy", beta.XmltvFile)
+	assert.Equal(t, "102", beta.XChannelID)
+	assert.Equal(t, "ts", beta.XStreamFormat)
+
+	// Conflicts are not overwritten
+	assert.Equal(t, "other.sport", Data.XEPG.Channels["x-ID.2"].XMapping)
+	assert.Equal(t, "4", Data.XEPG.Channels["x-ID.4"].XChannelID)
+
+	var saved map[string]XEPGChannelStruct
+	require.NoError(t, loadJSONFile(System.File.XEPG, &saved))
+	assert.Equal(t, "100", saved["x-ID.1"].XChannelID)
+
+	// The channel list of this instance
+	rr := httptest.NewRecorder()
+	Index(rr, httptest.NewRequest(http.MethodGet, "/channels.json", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var exported []ChannelExportStruct
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &exported))
+	require.Len(t, exported, 4)
+	assert.Equal(t, []string{"Alpha Sport", "Gamma Kids", "Zeta HD", "Beta"}, []string{exported[0].XName, exported[1].XName, exported[2].XName, exported[3].XName}, "sorted by channel number")
+	assert.Equal(t, ChannelExportStruct{TvgID: "zeta.uk", Name: "Zeta News", XActive: true, XName: "Zeta HD", XChannelID: "100", XCategory: "News", XGroupTitle: "Nachrichten", XmltvFile: "X1.xml", XMapping: "zeta.uk"}, exported[2])
+
+	// Errors
+	_, err = importFromInstance(t.Context(), server.URL+"/missing")
+	assert.ErrorContains(t, err, "404")
+	_, err = importFromInstance(t.Context(), "ftp://example.com")
+	assert.Error(t, err)
+}
@@ -174,7 +174,7 @@ func Init() (err error) {
 		return
 	}
 
-	err = removeChildItems(getPlatformPath(System.Folder.Temp))
+	err = cleanupTempFolder(getPlatformPath(System.Folder.Temp), Settings.BufferCleanupOnStart)
 	if err != nil {
 		return
 	}
@@ -184,9 +184,9 @@ func Init() (err error) {
 	}
 
 	// Set Domain Names
-	setGlobalDomain(fmt.Sprintf("%s:%s", Settings.HostIP, Settings.Port))
+	setGlobalDomain(joinHostPort(Settings.HostIP, Settings.Port))
 
-	System.URLBase = fmt.Sprintf("%s://%s:%s", System.ServerProtocol.WEB, Settings.HostIP, Settings.Port)
+	System.URLBase = fmt.Sprintf("%s://%s", System.ServerProtocol.WEB, joinHostPort(Settings.HostIP, Settings.Port))
 
 	// Start the DLNA Server
 	err = SSDP()
@@ -1,19 +1,24 @@
 package src
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"slices"
 	"xteve/src/internal/authentication"
-	"xteve/src/internal/imgcache"
 )
 
 // Change Settings (WebUI)
@@ -34,34 +39,16 @@ func updateServerSettings(request RequestStruct) (settings SettingsStruct, err e
 			switch key {
 			case "tuner":
 				showWarning(2105)
-			case "epgSource":
+			case "epgSource", "m3u.prefix.group.with.provider":
 				reloadData = true
 			case "update":
-				// Remove spaces from the Values and check the formatting of the Time (0000 - 2359)
-				var newUpdateTimes = make([]string, 0)
-				if values, ok := value.([]any); ok {
-					for _, v := range values {
-						if s, ok := v.(string); ok {
-							s = strings.Replace(s, " ", "", -1)
-
-							_, err := time.Parse("1504", s)
-							if err != nil {
-								ShowError(err, 1012)
-								return Settings, err
-							}
-							newUpdateTimes = append(newUpdateTimes, s)
-						} else {
-							return Settings, fmt.Errorf("invalid type in update times array: expected string, got %T", v)
-						}
-					}
-				} else {
-					return Settings, fmt.Errorf("invalid type for update times: expected []any, got %T", value)
+				value, err = parseUpdateTimes(value)
+				if err != nil {
+					return Settings, err
 				}
-
-				value = newUpdateTimes
 			case "cache.images":
 				cacheImages = true
-			case "xepg.replace.missing.images":
+			case "xepg.replace.missing.images", "default.channel.logo", "xmltv.category.whitelist", "xmltv.category.blacklist", "xmltv.generate.progid", "xmltv.dedupe.programs", "xmltv.use.source.ids", "plex.channel.limit.enforce", "m3u.direct.urls", "m3u.passthrough.extinf", "channel.name.prefix", "channel.name.suffix", "channels.pinned":
 				createXEPGFiles = true
 			case "backup.path":
 				if s, ok := value.(string); ok {
@@ -88,6 +75,22 @@ func updateServerSettings(request RequestStruct) (settings SettingsStruct, err e
 				}
 			case "scheme.m3u", "scheme.xml":
 				createXEPGFiles = true
+			case "m3u.format":
+				switch value {
+				case "xteve", "samsung":
+					createXEPGFiles = true
+				default:
+					err = fmt.Errorf("m3u.format has an invalid value: %v", value)
+					return
+				}
+			case "m3u.sort.order":
+				switch value {
+				case "channel-number", "name", "group-then-name", "provider":
+					createXEPGFiles = true
+				default:
+					err = fmt.Errorf("m3u.sort.order has an invalid value: %v", value)
+					return
+				}
 			case "defaultMissingEPG":
 				// If DefaultMissingEPG was set, rebuild DVR and XEPG database
 				if newSettings["defaultMissingEPG"] != "-" && oldSettings["defaultMissingEPG"] == "-" {
@@ -155,7 +158,7 @@ func updateServerSettings(request RequestStruct) (settings SettingsStruct, err e
 
 		if cacheImages {
 			if Settings.EpgSource == "XEPG" && System.ImageCachingInProgress == 0 {
-				Data.Cache.Images, err = imgcache.New(System.Folder.ImagesCache, fmt.Sprintf("%s://%s/images/", System.ServerProtocol.WEB, System.Domain), Settings.CacheImages, NewHTTPClient())
+				Data.Cache.Images, err = newImageCache()
 				if err != nil {
 					ShowError(err, 0)
 				}
@@ -214,6 +217,383 @@ func updateServerSettings(request RequestStruct) (settings SettingsStruct, err e
 	return
 }
 
+// settingsChanges : Subsystems that are affected by a change of the settings
+type settingsChanges struct {
+	Webserver bool // Host, IP, port or TLS changed, the web server has to be restarted
+	Database  bool // Provider, filter or EPG source changed, DVR and XEPG database have to be rebuilt
+	Files     bool // Only the output (M3U / XMLTV) is affected
+}
+
+// diffSettings : Compares two settings and returns which subsystems have to be reloaded
+func diffSettings(oldSettings, newSettings SettingsStruct) (changes settingsChanges) {
+	if oldSettings.TLSMode != newSettings.TLSMode ||
+		oldSettings.HostIP != newSettings.HostIP ||
+		oldSettings.HostName != newSettings.HostName ||
+		oldSettings.Port != newSettings.Port {
+		changes.Webserver = true
+	}
+
+	if mapToJSON(oldSettings.Files) != mapToJSON(newSettings.Files) ||
+		mapToJSON(oldSettings.Filter) != mapToJSON(newSettings.Filter) ||
+		oldSettings.EpgSource != newSettings.EpgSource ||
+		oldSettings.M3UPrefixGroupWithProvider != newSettings.M3UPrefixGroupWithProvider ||
+		oldSettings.DefaultMissingEPG != newSettings.DefaultMissingEPG ||
+		oldSettings.EnableMappedChannels != newSettings.EnableMappedChannels ||
+		!maps.Equal(oldSettings.GroupRenames, newSettings.GroupRenames) ||
+		!slices.Equal(oldSettings.MappingNameRules, newSettings.MappingNameRules) ||
+		oldSettings.PreferSourceChno != newSettings.PreferSourceChno ||
+		oldSettings.XepgRetainMissingDays != newSettings.XepgRetainMissingDays ||
+		oldSettings.XMLTVTolerantParse != newSettings.XMLTVTolerantParse {
+		changes.Database = true
+	}
+
+	if oldSettings.M3USortOrder != newSettings.M3USortOrder ||
+		oldSettings.M3UFormat != newSettings.M3UFormat ||
+		oldSettings.M3UDirectURLs != newSettings.M3UDirectURLs ||
+		!maps.Equal(oldSettings.M3UHeaderAttributes, newSettings.M3UHeaderAttributes) ||
+		oldSettings.M3UPassthroughEXTINF != newSettings.M3UPassthroughEXTINF ||
+		!slices.Equal(oldSettings.ChannelsPinned, newSettings.ChannelsPinned) ||
+		oldSettings.PlexChannelLimitEnforce != newSettings.PlexChannelLimitEnforce ||
+		oldSettings.XepgReplaceMissingImages != newSettings.XepgReplaceMissingImages ||
+		!slices.Equal(oldSettings.XMLTVCategoryWhitelist, newSettings.XMLTVCategoryWhitelist) ||
+		oldSettings.XMLTVDedupePrograms != newSettings.XMLTVDedupePrograms ||
+		oldSettings.XMLTVGenerateProgID != newSettings.XMLTVGenerateProgID ||
+		oldSettings.XMLTVUseSourceIDs != newSettings.XMLTVUseSourceIDs ||
+		oldSettings.DefaultChannelLogo != newSettings.DefaultChannelLogo ||
+		oldSettings.ChannelNamePrefix != newSettings.ChannelNamePrefix ||
+		oldSettings.ChannelNameSuffix != newSettings.ChannelNameSuffix ||
+		!slices.Equal(oldSettings.XMLTVCategoryBlacklist, newSettings.XMLTVCategoryBlacklist) ||
+		!slices.Equal(oldSettings.GroupOrder, newSettings.GroupOrder) ||
+		mapToJSON(oldSettings.OutputProfiles) != mapToJSON(newSettings.OutputProfiles) {
+		changes.Files = true
+	}
+
+	return
+}
+
+// reloadSettings : Reloads settings.json from disk and applies the changes without restarting xTeVe (API)
+func reloadSettings() (reloaded []string, err error) {
+	var oldSettings = Settings
+
+	newSettings, err := loadSettings()
+	if err != nil {
+		Settings = oldSettings
+		return
+	}
+
+	reloaded = append(reloaded, "settings")
+	changes := diffSettings(oldSettings, newSettings)
+
+	if oldSettings.WebDAVGroupSeparator != newSettings.WebDAVGroupSeparator {
+		ClearWebDAVCache("")
+	}
+
+	if changes.Webserver {
+		showInfo("Web server:" + "Settings have been reloaded, restarting web server")
+		reinitialize()
+		reloaded = append(reloaded, "webserver")
+
+		select {
+		case restartWebserver <- true:
+		default: // A restart is already pending
+		}
+	}
+
+	switch {
+	case changes.Database:
+		err = buildDatabaseDVR()
+		if err != nil {
+			return
+		}
+
+		err = buildXEPG(false)
+		if err != nil {
+			return
+		}
+		reloaded = append(reloaded, "dvr", "xepg")
+
+	case changes.Files:
+		if Settings.EpgSource == "XEPG" {
+			err = createXMLTVFile()
+			if err != nil {
+				return
+			}
+		}
+
+		err = createM3UFile()
+		if err != nil {
+			return
+		}
+		reloaded = append(reloaded, "files")
+	}
+
+	return
+}
+
+// isWebSetting : Reports whether a setting can be changed with the WebUI and the API command settings.set
+func isWebSetting(key string) bool {
+	t := reflect.TypeOf(RequestStruct{}.Settings)
+
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// apiProtectedSettings : Settings that settings.get and settings.set refuse, besides authentication.*.
+// They control the access to xTeVe or contain the provider URLs with their credentials.
+var apiProtectedSettings = []string{"api", "api.socket.only", "file", "files", "key", "xmltv"}
+
+// isAPIProtectedSetting : Reports whether a setting can only be read and changed in the WebUI and settings.json
+func isAPIProtectedSetting(key string) bool {
+	return strings.HasPrefix(key, "authentication.") || slices.Contains(apiProtectedSettings, key)
+}
+
+// getSetting : Returns the value of a single setting (API)
+func getSetting(key string) (value any, err error) {
+	if isAPIProtectedSetting(key) {
+		return nil, fmt.Errorf("setting %q is not available with the API", key)
+	}
+
+	var settings = make(map[string]any)
+	if err = bindToStruct(Settings, &settings); err != nil {
+		return
+	}
+
+	value, ok := settings[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown setting: %q", key)
+	}
+	return
+}
+
+// setSetting : Changes a single setting with the same validation and rebuilds as the WebUI (API)
+func setSetting(key string, value any) (newValue any, err error) {
+	if _, err = getSetting(key); err != nil {
+		return
+	}
+
+	if !isWebSetting(key) {
+		return nil, fmt.Errorf("setting %q can only be changed in settings.json", key)
+	}
+
+	if value == nil {
+		return nil, fmt.Errorf("value for %s is missing", key)
+	}
+
+	var request RequestStruct
+	if err = bindToStruct(map[string]any{key: value}, &request.Settings); err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+
+	if _, err = updateServerSettings(request); err != nil {
+		return
+	}
+
+	return getSetting(key)
+}
+
+// setEPGSource : Switches the EPG source between PMS and XEPG and rebuilds the lineup (API).
+// The XEPG mapping (xepg.json) is kept when switching to PMS, only the generated XMLTV file and the caches are removed.
+func setEPGSource(source string) (lineup int, err error) {
+	if source != "PMS" && source != "XEPG" {
+		return 0, fmt.Errorf("invalid EPG source: %q, expected PMS or XEPG", source)
+	}
+
+	if System.ScanInProgress == 1 {
+		return 0, errors.New("a scan is in progress, try again later")
+	}
+
+	var oldSource = Settings.EpgSource
+	Settings.EpgSource = source
+	if err = saveSettings(Settings); err != nil {
+		Settings.EpgSource = oldSource
+		return
+	}
+
+	setGlobalDomain(System.Domain)
+	showInfo(fmt.Sprintf("EPG Source:%s", Settings.EpgSource))
+
+	switch source {
+	case "PMS":
+		System.ScanInProgress = 1
+		defer func() { System.ScanInProgress = 0 }()
+
+		Data.XEPG.XEPGCount = 0
+		clearXMLTVCache()
+
+		for _, file := range []string{System.File.XML, System.Compressed.GZxml} {
+			if len(file) == 0 {
+				continue
+			}
+			if errRemove := os.Remove(getPlatformFile(file)); errRemove != nil && !errors.Is(errRemove, fs.ErrNotExist) {
+				ShowError(errRemove, 0)
+			}
+		}
+
+		var content []byte
+		content, err = getLineup("")
+		if err != nil {
+			return
+		}
+
+		var streams []LineupStream
+		if err = json.Unmarshal(content, &streams); err != nil {
+			return
+		}
+		lineup = len(streams)
+
+		err = createM3UFile()
+
+	case "XEPG":
+		if err = buildXEPG(false); err != nil {
+			return
+		}
+		lineup = int(Data.XEPG.XEPGCount)
+	}
+
+	return
+}
+
+// regenerateFiles : Rewrites the M3U and XMLTV files from the current XEPG database without downloading the providers or rebuilding the database (API).
+// Returns the size of each written file.
+func regenerateFiles() (files map[string]int64, err error) {
+	if System.ScanInProgress == 1 {
+		return nil, errors.New("a scan is in progress, try again later")
+	}
+
+	System.ScanInProgress = 1
+	defer func() { System.ScanInProgress = 0 }()
+
+	var written = []string{System.File.M3U}
+	if Settings.EpgSource == "XEPG" {
+		if err = createXMLTVFile(); err != nil {
+			return
+		}
+		written = append(written, System.File.XML, System.Compressed.GZxml)
+	}
+
+	if err = createM3UFile(); err != nil {
+		return
+	}
+
+	files = make(map[string]int64)
+	for _, file := range written {
+		if len(file) == 0 {
+			continue
+		}
+		if info, errStat := os.Stat(getPlatformFile(file)); errStat == nil {
+			files[filepath.Base(file)] = info.Size()
+		}
+	}
+
+	return
+}
+
+// renameGroup : Renames a group for all channels and remembers the rename for future rebuilds (WebUI)
+func renameGroup(from, to string) (err error) {
+	from = strings.TrimSpace(from)
+	to = strings.TrimSpace(to)
+
+	if len(from) == 0 || len(to) == 0 {
+		return errors.New("renameGroup: old and new group title are required")
+	}
+
+	if from == to {
+		return
+	}
+
+	if Settings.GroupRenames == nil {
+		Settings.GroupRenames = make(map[string]string)
+	}
+
+	// Existing renames to the old group follow the new name (A -> B, B -> C results in A -> C)
+	for source, target := range Settings.GroupRenames {
+		if target == from {
+			Settings.GroupRenames[source] = to
+		}
+	}
+	Settings.GroupRenames[from] = to
+
+	// A rename back to the original group title removes the rule
+	maps.DeleteFunc(Settings.GroupRenames, func(source, target string) bool {
+		return source == target
+	})
+
+	var count int
+	for id, xepgChannel := range Data.XEPG.Channels {
+		if xepgChannel.XGroupTitle == from {
+			xepgChannel.XGroupTitle = to
+			Data.XEPG.Channels[id] = xepgChannel
+			count++
+		}
+	}
+
+	showInfo(fmt.Sprintf("XEPG:Rename group '%s' to '%s' (%d channels)", from, to, count))
+
+	err = saveSettings(Settings)
+	if err != nil {
+		return
+	}
+
+	err = saveMapToJSONFile(System.File.XEPG, Data.XEPG.Channels)
+	if err != nil {
+		return
+	}
+
+	err = buildDatabaseDVR()
+	if err != nil {
+		return
+	}
+
+	return buildXEPG(false)
+}
+
+// saveGroupOrder : Custom display order of the groups (WebUI). Groups that are not in the list follow alphabetically.
+func saveGroupOrder(groups []string) (err error) {
+	var order = make([]string, 0, len(groups))
+
+	for _, group := range groups {
+		group = strings.TrimSpace(group)
+		if len(group) > 0 && !slices.Contains(order, group) {
+			order = append(order, group)
+		}
+	}
+
+	Settings.GroupOrder = order
+
+	err = saveSettings(Settings)
+	if err != nil {
+		return
+	}
+
+	err = buildDatabaseDVR()
+	if err != nil {
+		return
+	}
+
+	if Settings.M3USortOrder == "group-then-name" {
+		err = createM3UFile()
+	}
+
+	return
+}
+
+// compareGroups : Groups from group.order first and in this order, all other groups alphabetically
+func compareGroups(a, b string) int {
+	var index = func(group string) int {
+		if i := slices.Index(Settings.GroupOrder, group); i != -1 {
+			return i
+		}
+		return len(Settings.GroupOrder)
+	}
+
+	return cmp.Compare(index(a), index(b))
+}
+
 // Save Provider Data (WebUI)
 func saveFiles(request RequestStruct, fileType string) (err error) {
 	var filesMap = make(map[string]any)
@@ -241,6 +621,27 @@ func saveFiles(request RequestStruct, fileType string) (err error) {
 	}
 
 	for dataID, data := range newData {
+		// Per provider update times are validated like the global ones
+		if dMap, ok := data.(map[string]any); ok {
+			if value, ok := dMap["update.times"]; ok && value != nil {
+				if dMap["update.times"], err = parseUpdateTimes(value); err != nil {
+					return
+				}
+			}
+
+			for _, key := range []string{"include", "exclude"} {
+				if value, ok := dMap[key].(string); ok {
+					if _, err = regexp.Compile(value); err != nil {
+						return fmt.Errorf("invalid %s expression: %w", key, err)
+					}
+					// Changed expressions change the streams of the provider
+					if oldValue, _ := filesMap[dataID].(map[string]any)[key].(string); oldValue != value {
+						reloadData = true
+					}
+				}
+			}
+		}
+
 		if dataID == "-" {
 			// New Provider File
 			var rStr string
@@ -370,8 +771,16 @@ func deleteLocalProviderFiles(dataID, fileType string) {
 		fileExtension = ".xml"
 	}
 
-	if _, ok := removeData[dataID]; ok {
+	if data, ok := removeData[dataID]; ok {
 		delete(removeData, dataID)
+
+		// The EPG of the playlist header (epg.auto) is removed together with the playlist
+		if data, ok := data.(map[string]any); ok && fileType == "m3u" {
+			if xmltvID, ok := data["epg.xmltv"].(string); ok && len(xmltvID) > 0 {
+				deleteLocalProviderFiles(xmltvID, "xmltv")
+			}
+		}
+
 		filePathToRemove := System.Folder.Data + dataID + fileExtension
 		if errRemove := os.RemoveAll(filePathToRemove); errRemove != nil {
 			// log.Printf("Error deleting local provider file %s: %v", filePathToRemove, errRemove)
@@ -469,7 +878,7 @@ func saveFilter(request RequestStruct) (settings SettingsStruct, err error) {
 
 // Save XEPG Mapping
 func saveXEpgMapping(request RequestStruct) (err error) {
-	Data.Cache.Images, err = imgcache.New(System.Folder.ImagesCache, fmt.Sprintf("%s://%s/images/", System.ServerProtocol.WEB, System.Domain), Settings.CacheImages, NewHTTPClient())
+	Data.Cache.Images, err = newImageCache()
 	if err != nil {
 		ShowError(err, 0)
 	}
@@ -482,6 +891,20 @@ func saveXEpgMapping(request RequestStruct) (err error) {
 		return
 	}
 
+	for id, channel := range newChannels {
+		var oldChannel = Data.XEPG.Channels[id]
+		channel = applyUserDisabled(oldChannel, channel)
+
+		// Maintained by cleanupXEPG, not by the WebUI
+		channel.LastSeen, channel.Missing = oldChannel.LastSeen, oldChannel.Missing
+		newChannels[id] = channel
+	}
+
+	err = resolveChannelNumberConflicts(newChannels)
+	if err != nil {
+		return
+	}
+
 	// Save to file (saveMapToJSONFile handles any, so passing the struct map is fine)
 	err = saveMapToJSONFile(System.File.XEPG, newChannels)
 	if err != nil {
@@ -530,6 +953,70 @@ func saveXEpgMapping(request RequestStruct) (err error) {
 	return
 }
 
+// resolveChannelNumberConflicts : Enabled channels with the same channel number (e.g. after an import) break the clients.
+// With xepg.resolve.chno.conflicts, the channel that already had the number keeps it and the others get the next free number.
+// Otherwise the mapping is rejected with a list of the conflicts.
+func resolveChannelNumberConflicts(channels map[string]XEPGChannelStruct) error {
+	var allChannelNumbers = make(map[channelNumber]bool, len(channels))
+	var numbers = make(map[channelNumber][]string)
+
+	for id, channel := range channels {
+		number, ok := parseChannelNumber(channel.XChannelID)
+		if !ok {
+			continue
+		}
+
+		allChannelNumbers[number] = true
+		if isChannelEnabled(channel) {
+			numbers[number] = append(numbers[number], id)
+		}
+	}
+
+	var conflicts []string
+
+	for _, number := range slices.SortedFunc(maps.Keys(numbers), channelNumber.Compare) {
+		var ids = numbers[number]
+		if len(ids) < 2 {
+			continue
+		}
+
+		// The channel that already had this number first, then in the order of the XEPG IDs
+		slices.SortFunc(ids, func(a, b string) int {
+			var keepA = Data.XEPG.Channels[a].XChannelID == channels[a].XChannelID
+			var keepB = Data.XEPG.Channels[b].XChannelID == channels[b].XChannelID
+			if keepA != keepB {
+				if keepA {
+					return -1
+				}
+				return 1
+			}
+			return strings.Compare(a, b)
+		})
+
+		if !Settings.XepgResolveChnoConflicts {
+			var names = make([]string, 0, len(ids))
+			for _, id := range ids {
+				names = append(names, channels[id].XName)
+			}
+			conflicts = append(conflicts, fmt.Sprintf("%s (%s)", number, strings.Join(names, ", ")))
+			continue
+		}
+
+		for _, id := range ids[1:] {
+			var channel = channels[id]
+			channel.XChannelID = findFreeChannelNumber(allChannelNumbers, channel.XChannelID)
+			channels[id] = channel
+			showInfo(fmt.Sprintf("XEPG:Channel number %s is already used, '%s' gets the channel number %s", number, channel.XName, channel.XChannelID))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("duplicate channel numbers: %s", strings.Join(conflicts, "; "))
+	}
+
+	return nil
+}
+
 // Save User Data (WebUI)
 func saveUserData(request RequestStruct) (err error) {
 	var userData = request.UserData
@@ -822,6 +1309,7 @@ func buildDatabaseDVR() (err error) {
 
 	err = createFilterRules()
 	if err != nil {
+		System.ScanInProgress = 0
 		return
 	}
 
@@ -851,6 +1339,16 @@ func buildDatabaseDVR() (err error) {
 				playlistFile = slices.Delete(playlistFile, n, n+1)
 			}
 
+			// Provider include / exclude, applied before the streams are stored
+			if include, exclude, errFilter := getProviderStreamFilter(id, fileType); errFilter != nil {
+				ShowError(fmt.Errorf("%s: %w", playlistName, errFilter), 0)
+			} else if include != nil || exclude != nil {
+				channels = slices.DeleteFunc(channels, func(stream any) bool {
+					s, ok := stream.(map[string]string)
+					return ok && !matchProviderStreamFilter(s, include, exclude)
+				})
+			}
+
 			// Analyze Streams
 			for _, stream := range channels {
 				var s, ok = stream.(map[string]string)
@@ -882,6 +1380,9 @@ func buildDatabaseDVR() (err error) {
 					case "group-title":
 						if value, ok := s[key]; ok {
 							if len(value) > 0 {
+								if Settings.M3UPrefixGroupWithProvider {
+									value = prefixGroupWithProvider(playlistName, value)
+								}
 								tmpGroupsM3U[value]++
 								groupTitle++
 							}
@@ -898,7 +1399,7 @@ func buildDatabaseDVR() (err error) {
 
 				// New Filter from Version 1.3.0
 				var preview string
-				var status = FilterThisStream(stream) // Corrected: Call exported function
+				var status, reason = FilterThisStream(stream) // Corrected: Call exported function
 
 				if name, ok := s["name"]; ok {
 					var group string
@@ -909,11 +1410,21 @@ func buildDatabaseDVR() (err error) {
 					preview = fmt.Sprintf("%s [%s]", name, group)
 				}
 
+				// Renamed groups are applied after the filter, filter rules use the group title of the provider
+				if Settings.M3UPrefixGroupWithProvider {
+					s["group-title"] = prefixGroupWithProvider(playlistName, s["group-title"])
+				}
+
+				if group, ok := Settings.GroupRenames[s["group-title"]]; ok {
+					s["group-title"] = group
+				}
+
 				switch status {
 				case true:
 					Data.StreamPreviewUI.Active = append(Data.StreamPreviewUI.Active, preview)
 					Data.Streams.Active = append(Data.Streams.Active, stream)
 				case false:
+					s["_filter.reason"] = reason
 					Data.StreamPreviewUI.Inactive = append(Data.StreamPreviewUI.Inactive, preview)
 					Data.Streams.Inactive = append(Data.Streams.Inactive, stream)
 				}
@@ -946,16 +1457,17 @@ func buildDatabaseDVR() (err error) {
 		}
 	}
 
-	for group, count := range tmpGroupsM3U {
-		var text = fmt.Sprintf("%s (%d)", group, count)
+	var groups = slices.SortedFunc(maps.Keys(tmpGroupsM3U), func(a, b string) int {
+		return cmp.Or(compareGroups(a, b), strings.Compare(a, b))
+	})
+
+	for _, group := range groups {
+		var text = fmt.Sprintf("%s (%d)", group, tmpGroupsM3U[group])
 		var value = group
 		Data.Playlist.M3U.Groups.Text = append(Data.Playlist.M3U.Groups.Text, text)
 		Data.Playlist.M3U.Groups.Value = append(Data.Playlist.M3U.Groups.Value, value)
 	}
 
-	slices.Sort(Data.Playlist.M3U.Groups.Text)
-	slices.Sort(Data.Playlist.M3U.Groups.Value)
-
 	if len(Data.Streams.Active) == 0 && len(Settings.Filter) == 0 {
 		Data.Streams.Active = Data.Streams.All
 		Data.Streams.Inactive = make([]any, 0)
@@ -1030,6 +1542,134 @@ func getProviderParameter(id, fileType, key string) (s string) {
 	return
 }
 
+// getProviderStreamFilter returns the include and exclude expressions of a provider ("include" and "exclude" in the provider settings)
+func getProviderStreamFilter(id, fileType string) (include, exclude *regexp.Regexp, err error) {
+	if value := getProviderParameter(id, fileType, "include"); len(value) > 0 {
+		if include, err = regexp.Compile(value); err != nil {
+			return nil, nil, fmt.Errorf("invalid include expression: %w", err)
+		}
+	}
+
+	if value := getProviderParameter(id, fileType, "exclude"); len(value) > 0 {
+		if exclude, err = regexp.Compile(value); err != nil {
+			return nil, nil, fmt.Errorf("invalid exclude expression: %w", err)
+		}
+	}
+	return
+}
+
+// matchProviderStreamFilter reports whether the name or the group title of a stream passes the include and exclude expressions of its provider
+func matchProviderStreamFilter(stream map[string]string, include, exclude *regexp.Regexp) bool {
+	var match = func(re *regexp.Regexp) bool {
+		return re.MatchString(stream["name"]) || re.MatchString(stream["group-title"])
+	}
+
+	if include != nil && !match(include) {
+		return false
+	}
+
+	return exclude == nil || !match(exclude)
+}
+
+// getInactiveStreams returns the streams that were filtered out, with the reason of the filter
+func getInactiveStreams() (streams []InactiveStreamStruct) {
+	streams = make([]InactiveStreamStruct, 0, len(Data.Streams.Inactive))
+
+	for _, s := range Data.Streams.Inactive {
+		stream, ok := s.(map[string]string)
+		if !ok {
+			continue
+		}
+
+		streams = append(streams, InactiveStreamStruct{
+			Name:       stream["name"],
+			GroupTitle: stream["group-title"],
+			Playlist:   stream["_file.m3u.name"],
+			Reason:     stream["_filter.reason"],
+		})
+	}
+
+	return
+}
+
+// getEffectiveFilters returns the filter rules built by createFilterRules, in the order they are applied
+func getEffectiveFilters() []EffectiveFilterStruct {
+	var filters = make([]EffectiveFilterStruct, 0, len(Data.Filter))
+	for _, filter := range Data.Filter {
+		filters = append(filters, EffectiveFilterStruct{
+			Type:            filter.Type,
+			Rule:            filter.Rule,
+			CaseSensitive:   filter.CaseSensitive,
+			PreserveMapping: filter.PreserveMapping,
+			StartingChannel: filter.StartingChannel,
+		})
+	}
+	return filters
+}
+
+// getProviderStats returns the compatibility stats of all providers, sorted by type and name
+func getProviderStats() (stats []ProviderStatsStruct) {
+	var active = make(map[string]int)
+	for _, s := range Data.Streams.Active {
+		if stream, ok := s.(map[string]string); ok {
+			active[stream["_file.m3u.id"]]++
+		}
+	}
+
+	for _, fileType := range []string{"m3u", "hdhr", "xmltv"} {
+		var dataMap map[string]any
+		switch fileType {
+		case "m3u":
+			dataMap = Settings.Files.M3U
+		case "hdhr":
+			dataMap = Settings.Files.HDHR
+		case "xmltv":
+			dataMap = Settings.Files.XMLTV
+		}
+
+		var providers []ProviderStatsStruct
+		for id, d := range dataMap {
+			data, ok := d.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			var provider = ProviderStatsStruct{ID: id, Type: fileType, Compatibility: make(map[string]int), Active: active[id]}
+			provider.Name, _ = data["name"].(string)
+			provider.LastUpdate, _ = data["last.update"].(string)
+
+			maps.Copy(provider.Compatibility, getProviderCompatibility(data))
+
+			providers = append(providers, provider)
+		}
+
+		slices.SortFunc(providers, func(a, b ProviderStatsStruct) int {
+			return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.ID, b.ID))
+		})
+		stats = append(stats, providers...)
+	}
+
+	return
+}
+
+// getProviderCompatibility : Returns a copy of the compatibility values of the provider data
+func getProviderCompatibility(data map[string]any) (compatibility map[string]int) {
+	compatibility = make(map[string]int)
+
+	// map[string]int after a database build, map[string]any after loading settings.json
+	switch c := data["compatibility"].(type) {
+	case map[string]int:
+		maps.Copy(compatibility, c)
+	case map[string]any:
+		for key, value := range c {
+			if f, ok := value.(float64); ok {
+				compatibility[key] = int(f)
+			}
+		}
+	}
+	return
+}
+
 // Update Provider Statistics Compatibility
 func setProviderCompatibility(id, fileType string, compatibility map[string]int) error { // Added error return type
 	var dataMap map[string]any // Declare, assign below
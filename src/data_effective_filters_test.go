@@ -0,0 +1,48 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetEffectiveFilters(t *testing.T) {
+	oldSettings, oldSystem, oldData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = oldSettings, oldSystem, oldData })
+
+	Settings.AuthenticationWEB = false
+	System.ConfigurationWizard = false
+	Settings.Filter = map[int64]any{
+		0: map[string]any{"type": "group-title", "filter": "Sports", "include": "HD,FHD", "exclude": "US", "caseSensitive": true, "startingChannel": "100"},
+		1: map[string]any{"type": "group-title", "filter": "News"},
+		2: map[string]any{"type": "custom-filter", "filter": "Movies {HD}"},
+	}
+	require.NoError(t, createFilterRules())
+
+	filters := getEffectiveFilters()
+	require.Len(t, filters, 3)
+	assert.Contains(t, filters, EffectiveFilterStruct{Type: "group-title", Rule: "Sports {HD,FHD} !{US}", CaseSensitive: true, StartingChannel: "100"})
+	assert.Contains(t, filters, EffectiveFilterStruct{Type: "group-title", Rule: "News"})
+
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.WriteJSON(map[string]string{"cmd": "getEffectiveFilters"}))
+
+	var response struct {
+		EffectiveFilters []EffectiveFilterStruct `json:"effectiveFilters"`
+	}
+	require.NoError(t, ws.ReadJSON(&response))
+	assert.ElementsMatch(t, filters, response.EffectiveFilters)
+}
@@ -0,0 +1,117 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetEPGSource(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	tempDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Data = tempDir
+	System.Folder.Temp = tempDir
+	System.Folder.ImagesCache = tempDir + "images" + string(os.PathSeparator)
+	System.File.Settings = filepath.Join(tempDir, "settings.json")
+	System.File.XEPG = filepath.Join(tempDir, "xepg.json")
+	System.File.URLS = filepath.Join(tempDir, "urls.json")
+	System.File.PMS = filepath.Join(tempDir, "pms.json")
+	System.File.M3U = filepath.Join(tempDir, "xteve.m3u")
+	System.File.XML = filepath.Join(tempDir, "xteve.xml")
+	System.Compressed.GZxml = filepath.Join(tempDir, "xteve.xml.gz")
+	System.Domain = "localhost:34400"
+	System.ScanInProgress = 0
+	require.NoError(t, os.MkdirAll(System.Folder.ImagesCache, 0755))
+
+	Settings = SettingsStruct{EpgSource: "XEPG", TempPath: tempDir, M3USortOrder: "channel-number", MappingFirstChannel: 1000, DefaultMissingEPG: "-"}
+	Settings.Files.M3U = map[string]any{"Msource": map[string]any{"name": "Source Test"}}
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+
+	playlist := "#EXTM3U\n" +
+		"#EXTINF:-1 group-title=\"News\",News 1\nhttp://example.com/1\n" +
+		"#EXTINF:-1 group-title=\"Sports\",Sports 1\nhttp://example.com/2\n" +
+		"#EXTINF:-1 group-title=\"Movies\",Movies 1\nhttp://example.com/3\n"
+	require.NoError(t, os.WriteFile(tempDir+"Msource.m3u", []byte(playlist), 0644))
+	require.NoError(t, saveMapToJSONFile(System.File.PMS, map[string]any{}))
+	require.NoError(t, saveMapToJSONFile(System.File.XEPG, map[string]any{}))
+	require.NoError(t, buildDatabaseDVR())
+	require.NoError(t, buildXEPG(false))
+
+	// The mapping of the XEPG channels is kept for a switch back
+	Data.XEPG.XEPGCount = 0
+	for id, channel := range Data.XEPG.Channels {
+		channel.XActive = true
+		channel.XName = "Renamed " + channel.Name
+		channel.XmltvFile = "xTeVe Dummy"
+		channel.XMapping = "60_Minutes"
+		Data.XEPG.Channels[id] = channel
+		Data.XEPG.XEPGCount++
+	}
+	require.NoError(t, saveMapToJSONFile(System.File.XEPG, Data.XEPG.Channels))
+	require.NoError(t, createXMLTVFile())
+	require.FileExists(t, System.File.XML)
+
+	setSource := func(source string) APIResponseStruct {
+		req := httptest.NewRequest("POST", "/api/", bytes.NewBufferString(`{"cmd":"epg.setSource","epg.source":"`+source+`"}`))
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		API(w, req)
+
+		var response APIResponseStruct
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// XEPG -> PMS
+	response := setSource("PMS")
+	require.True(t, response.Status, response.Error)
+	assert.Equal(t, "PMS", response.EpgSource)
+	assert.Equal(t, 3, response.ChannelsLineup)
+	assert.Equal(t, int64(3), response.StreamsActive)
+	assert.Zero(t, response.StreamsXepg)
+	assert.Equal(t, 0, System.ScanInProgress)
+	assert.NoFileExists(t, System.File.XML, "the XMLTV file of XEPG is removed")
+	assert.NoFileExists(t, System.Compressed.GZxml)
+
+	lineup, err := getLineup("")
+	require.NoError(t, err)
+	assert.Contains(t, string(lineup), `"GuideName": "News 1"`)
+	assert.NotContains(t, string(lineup), "Renamed")
+
+	settings, err := loadJSONFileToMap(System.File.Settings)
+	require.NoError(t, err)
+	assert.Equal(t, "PMS", settings["epgSource"])
+
+	// PMS -> XEPG
+	response = setSource("XEPG")
+	require.True(t, response.Status, response.Error)
+	assert.Equal(t, "XEPG", response.EpgSource)
+	assert.Equal(t, 3, response.ChannelsLineup)
+	assert.Equal(t, int64(3), response.StreamsXepg)
+	assert.FileExists(t, System.File.XML)
+
+	lineup, err = getLineup("")
+	require.NoError(t, err)
+	assert.Contains(t, string(lineup), `"GuideName": "Renamed News 1"`, "the mapping is restored")
+
+	// Invalid source and running scan
+	response = setSource("TVH")
+	assert.False(t, response.Status)
+	assert.Equal(t, "XEPG", Settings.EpgSource)
+
+	System.ScanInProgress = 1
+	response = setSource("PMS")
+	assert.False(t, response.Status)
+	assert.Equal(t, "XEPG", Settings.EpgSource)
+	System.ScanInProgress = 0
+}
@@ -0,0 +1,58 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveGroupOrder(t *testing.T) {
+	originalSettings := Settings
+	originalSystem := System
+	originalData := Data
+	t.Cleanup(func() {
+		Settings = originalSettings
+		System = originalSystem
+		Data = originalData
+	})
+
+	tempDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Data = tempDir
+	System.Folder.Temp = tempDir
+	System.Folder.ImagesCache = tempDir
+	System.File.Settings = filepath.Join(tempDir, "settings.json")
+	System.File.XEPG = filepath.Join(tempDir, "xepg.json")
+	System.ScanInProgress = 0
+
+	Settings = SettingsStruct{EpgSource: "PMS", TempPath: tempDir}
+	Settings.Files.M3U = map[string]any{"Morder": map[string]any{"name": "Order Test"}}
+
+	playlist := "#EXTM3U\n" +
+		"#EXTINF:-1 group-title=\"News\",News 1\nhttp://example.com/1\n" +
+		"#EXTINF:-1 group-title=\"Sports\",Sports 1\nhttp://example.com/2\n" +
+		"#EXTINF:-1 group-title=\"Movies\",Movies 1\nhttp://example.com/3\n" +
+		"#EXTINF:-1 group-title=\"Kids\",Kids 1\nhttp://example.com/4\n" +
+		"#EXTINF:-1 group-title=\"Sports\",Sports 2\nhttp://example.com/5\n"
+	require.NoError(t, os.WriteFile(tempDir+"Morder.m3u", []byte(playlist), 0644))
+
+	require.NoError(t, buildDatabaseDVR())
+	assert.Equal(t, []string{"Kids", "Movies", "News", "Sports"}, Data.Playlist.M3U.Groups.Value)
+
+	require.NoError(t, saveGroupOrder([]string{" Sports ", "News", "Sports", "", "Unknown"}))
+	assert.Equal(t, []string{"Sports", "News", "Unknown"}, Settings.GroupOrder)
+
+	// Known groups in the configured order, the others alphabetically after them
+	assert.Equal(t, []string{"Sports", "News", "Kids", "Movies"}, Data.Playlist.M3U.Groups.Value)
+	assert.Equal(t, []string{"Sports (2)", "News (1)", "Kids (1)", "Movies (1)"}, Data.Playlist.M3U.Groups.Text)
+
+	// The order is kept across a rebuild and a restart
+	require.NoError(t, buildDatabaseDVR())
+	assert.Equal(t, []string{"Sports", "News", "Kids", "Movies"}, Data.Playlist.M3U.Groups.Value)
+
+	settings, err := loadJSONFileToMap(System.File.Settings)
+	require.NoError(t, err)
+	assert.Equal(t, []any{"Sports", "News", "Unknown"}, settings["group.order"])
+}
@@ -0,0 +1,57 @@
+package src
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixGroupWithProvider(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	tempDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Data = tempDir
+	System.Folder.Temp = tempDir
+	System.ScanInProgress = 0
+
+	Settings = SettingsStruct{EpgSource: "PMS", TempPath: tempDir, M3UPrefixGroupWithProvider: true}
+	Settings.Files.M3U = map[string]any{
+		"Mone": map[string]any{"name": "Provider A"},
+		"Mtwo": map[string]any{"name": "Provider B"},
+	}
+	Settings.Filter = map[int64]any{
+		0: map[string]any{"type": "group-title", "filter": "News"},                // Group title of the provider
+		1: map[string]any{"type": "group-title", "filter": "Provider B / Sports"}, // Group title of the list
+	}
+
+	require.NoError(t, os.WriteFile(tempDir+"Mone.m3u", []byte("#EXTM3U\n"+
+		"#EXTINF:-1 group-title=\"News\",News A\nhttp://example.com/a1\n"+
+		"#EXTINF:-1 group-title=\"Sports\",Sports A\nhttp://example.com/a2\n"+
+		"#EXTINF:-1,No Group\nhttp://example.com/a3\n"), 0644))
+	require.NoError(t, os.WriteFile(tempDir+"Mtwo.m3u", []byte("#EXTM3U\n"+
+		"#EXTINF:-1 group-title=\"Sports\",Sports B\nhttp://example.com/b1\n"), 0644))
+
+	require.NoError(t, buildDatabaseDVR())
+
+	assert.Equal(t, []string{"Provider A / News", "Provider A / Sports", "Provider B / Sports"}, Data.Playlist.M3U.Groups.Value)
+	assert.Contains(t, Data.Playlist.M3U.Groups.Text, "Provider A / Sports (1)")
+
+	var active = make(map[string]string)
+	for _, stream := range Data.Streams.Active {
+		s := stream.(map[string]string)
+		active[s["name"]] = s["group-title"]
+	}
+	assert.Equal(t, map[string]string{"News A": "Provider A / News", "Sports B": "Provider B / Sports"}, active)
+
+	// Without the setting the groups of both providers are merged
+	Settings.M3UPrefixGroupWithProvider = false
+	require.NoError(t, buildDatabaseDVR())
+	assert.Equal(t, []string{"News", "Sports"}, Data.Playlist.M3U.Groups.Value)
+	assert.Len(t, Data.Streams.Active, 1)
+
+	assert.Equal(t, "", prefixGroupWithProvider("Provider A", ""))
+	assert.Equal(t, "News", prefixGroupWithProvider("", "News"))
+}
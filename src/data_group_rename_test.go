@@ -0,0 +1,62 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameGroup(t *testing.T) {
+	originalSettings := Settings
+	originalSystem := System
+	originalChannels := Data.XEPG.Channels
+	originalStreams := Data.Streams
+	t.Cleanup(func() {
+		Settings = originalSettings
+		System = originalSystem
+		Data.XEPG.Channels = originalChannels
+		Data.Streams = originalStreams
+	})
+
+	tempDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Data = tempDir
+	System.Folder.Temp = tempDir
+	System.Folder.ImagesCache = tempDir
+	System.File.Settings = filepath.Join(tempDir, "settings.json")
+	System.File.XEPG = filepath.Join(tempDir, "xepg.json")
+	System.ScanInProgress = 0
+
+	Settings = SettingsStruct{EpgSource: "PMS", TempPath: tempDir}
+	Settings.Files.M3U = map[string]any{"Mrename": map[string]any{"name": "Rename Test"}}
+
+	playlist := "#EXTM3U\n#EXTINF:-1 tvg-id=\"one\" group-title=\"Old\",Channel One\nhttp://example.com/1\n"
+	require.NoError(t, os.WriteFile(tempDir+"Mrename.m3u", []byte(playlist), 0644))
+
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {XName: "Channel One", XGroupTitle: "Old"},
+		"x-ID.2": {XName: "Channel Two", XGroupTitle: "Other"},
+	}
+
+	require.NoError(t, renameGroup("Old", "New"))
+
+	assert.Equal(t, "New", Data.XEPG.Channels["x-ID.1"].XGroupTitle)
+	assert.Equal(t, "Other", Data.XEPG.Channels["x-ID.2"].XGroupTitle)
+	assert.Equal(t, map[string]string{"Old": "New"}, Settings.GroupRenames)
+
+	// The rename is applied to the provider streams on every rebuild
+	require.Len(t, Data.Streams.Active, 1)
+	assert.Equal(t, "New", Data.Streams.Active[0].(map[string]string)["group-title"])
+
+	// Renaming again follows the chain, renaming back removes the rule
+	require.NoError(t, renameGroup("New", "Newer"))
+	assert.Equal(t, map[string]string{"Old": "Newer", "New": "Newer"}, Settings.GroupRenames)
+
+	require.NoError(t, renameGroup("Newer", "Old"))
+	assert.Equal(t, map[string]string{"New": "Old", "Newer": "Old"}, Settings.GroupRenames)
+	assert.Equal(t, "Old", Data.XEPG.Channels["x-ID.1"].XGroupTitle)
+
+	assert.Error(t, renameGroup("", "New"))
+}
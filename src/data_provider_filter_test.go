@@ -0,0 +1,79 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderStreamFilter(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	tempDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Data = tempDir
+	System.Folder.Temp = tempDir
+	System.ScanInProgress = 0
+
+	Settings = SettingsStruct{EpgSource: "PMS", TempPath: tempDir}
+	Settings.Files.M3U = map[string]any{
+		"Mbig":   map[string]any{"name": "Big Provider", "include": "^(News|Sports)$", "exclude": "(?i)radio"},
+		"Mother": map[string]any{"name": "Other Provider"},
+	}
+
+	require.NoError(t, os.WriteFile(tempDir+"Mbig.m3u", []byte("#EXTM3U\n"+
+		"#EXTINF:-1 group-title=\"News\",News 1\nhttp://example.com/big1\n"+
+		"#EXTINF:-1 group-title=\"News\",News Radio\nhttp://example.com/big2\n"+
+		"#EXTINF:-1 group-title=\"Sports\",Sports 1\nhttp://example.com/big3\n"+
+		"#EXTINF:-1 group-title=\"Movies\",Movie 1\nhttp://example.com/big4\n"), 0644))
+	require.NoError(t, os.WriteFile(tempDir+"Mother.m3u", []byte("#EXTM3U\n"+
+		"#EXTINF:-1 group-title=\"Movies\",Movie 2\nhttp://example.com/other1\n"), 0644))
+
+	require.NoError(t, buildDatabaseDVR())
+
+	var names []string
+	for _, stream := range Data.Streams.All {
+		names = append(names, stream.(map[string]string)["name"])
+	}
+	assert.ElementsMatch(t, []string{"News 1", "Sports 1", "Movie 2"}, names, "only the matching streams of the provider are stored")
+	assert.Equal(t, 2, Settings.Files.M3U["Mbig"].(map[string]any)["compatibility"].(map[string]int)["streams"])
+
+	// An invalid expression disables the filter of the provider
+	Settings.Files.M3U["Mbig"].(map[string]any)["include"] = "("
+	require.NoError(t, buildDatabaseDVR())
+	assert.Len(t, Data.Streams.All, 5)
+}
+
+func TestSaveFiles_FilterReload(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	tempDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Data = tempDir
+	System.Folder.Temp = tempDir
+	System.File.Settings = filepath.Join(tempDir, "settings.json")
+	System.ScanInProgress = 0
+
+	Settings = SettingsStruct{EpgSource: "PMS", TempPath: tempDir}
+	Settings.Files.M3U = map[string]any{"Mbig": map[string]any{"name": "Big Provider", "include": "^News$"}}
+	require.NoError(t, os.WriteFile(tempDir+"Mbig.m3u", []byte("#EXTM3U\n"+
+		"#EXTINF:-1 group-title=\"News\",News 1\nhttp://example.com/big1\n"+
+		"#EXTINF:-1 group-title=\"Sports\",Sports 1\nhttp://example.com/big2\n"), 0644))
+
+	save := func(include string) {
+		var request RequestStruct
+		request.Files.M3U = map[string]any{"Mbig": map[string]any{"name": "Big Provider", "include": include}}
+		require.NoError(t, saveFiles(request, "m3u"))
+	}
+
+	// Unchanged expressions don't reload the data
+	Data.Streams.All = []any{"not reloaded"}
+	save("^News$")
+	assert.Equal(t, []any{"not reloaded"}, Data.Streams.All)
+
+	save("^(News|Sports)$")
+	assert.Len(t, Data.Streams.All, 2)
+}
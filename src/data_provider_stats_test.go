@@ -0,0 +1,95 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupProviderStatsTest(t *testing.T) {
+	t.Helper()
+
+	oldSettings, oldSystem, oldData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = oldSettings, oldSystem, oldData })
+
+	Settings.Files.M3U = map[string]any{
+		"M2": map[string]any{
+			"name":        "B Playlist",
+			"last.update": "2026-01-02 10:00:00",
+			// Loaded from settings.json
+			"compatibility": map[string]any{"tvg.id": float64(12), "group.title": float64(100), "stream.id": float64(0), "streams": float64(3)},
+		},
+		"M1": map[string]any{
+			"name":          "A Playlist",
+			"last.update":   "2026-01-01 10:00:00",
+			"compatibility": map[string]int{"tvg.id": 90, "group.title": 80, "stream.id": 0, "streams": 2},
+		},
+		"broken": "not a provider",
+	}
+	Settings.Files.HDHR = nil
+	Settings.Files.XMLTV = map[string]any{
+		"X1": map[string]any{
+			"name":          "Guide",
+			"compatibility": map[string]any{"xmltv.channels": float64(5), "xmltv.programs": float64(100)},
+		},
+	}
+
+	Data.Streams.Active = []any{
+		map[string]string{"_file.m3u.id": "M1"},
+		map[string]string{"_file.m3u.id": "M1"},
+		map[string]string{"_file.m3u.id": "M2"},
+	}
+}
+
+func TestGetProviderStats(t *testing.T) {
+	setupProviderStatsTest(t)
+
+	stats := getProviderStats()
+	require.Len(t, stats, 3)
+
+	assert.Equal(t, ProviderStatsStruct{
+		ID: "M1", Name: "A Playlist", Type: "m3u", LastUpdate: "2026-01-01 10:00:00", Active: 2,
+		Compatibility: map[string]int{"tvg.id": 90, "group.title": 80, "stream.id": 0, "streams": 2},
+	}, stats[0])
+
+	assert.Equal(t, "M2", stats[1].ID)
+	assert.Equal(t, 12, stats[1].Compatibility["tvg.id"])
+	assert.Equal(t, 3, stats[1].Compatibility["streams"])
+	assert.Equal(t, 1, stats[1].Active)
+
+	assert.Equal(t, "xmltv", stats[2].Type)
+	assert.Equal(t, 100, stats[2].Compatibility["xmltv.programs"])
+
+	// The stats are a copy
+	stats[0].Compatibility["tvg.id"] = 0
+	assert.Equal(t, 90, Settings.Files.M3U["M1"].(map[string]any)["compatibility"].(map[string]int)["tvg.id"])
+}
+
+func TestWebSocket_GetProviderStats(t *testing.T) {
+	setupProviderStatsTest(t)
+	Settings.AuthenticationWEB = false
+	System.ConfigurationWizard = false
+
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.WriteJSON(map[string]string{"cmd": "getProviderStats"}))
+
+	var response struct {
+		ProviderStats []ProviderStatsStruct `json:"providerStats"`
+	}
+	require.NoError(t, ws.ReadJSON(&response))
+	require.Len(t, response.ProviderStats, 3)
+	assert.Equal(t, "A Playlist", response.ProviderStats[0].Name)
+}
@@ -0,0 +1,70 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesRegenerateAPI(t *testing.T) {
+	setupOutputProfileTest(t)
+	Settings.OutputProfiles = nil
+	Settings.AuthenticationAPI = false
+	System.ScanInProgress = 0
+	System.File.M3U = System.Folder.Data + "xteve.m3u"
+	System.File.URLS = System.Folder.Data + "urls.json"
+
+	// Provider that must not be downloaded
+	var downloads atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		w.Write([]byte("#EXTM3U\n"))
+	}))
+	defer server.Close()
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{"name": "Provider", "file.source": server.URL + "/playlist.m3u"}}
+
+	require.NoError(t, os.WriteFile(System.File.M3U, []byte("stale"), 0644))
+	require.NoError(t, os.WriteFile(System.File.XML, []byte("stale"), 0644))
+
+	regenerate := func() APIResponseStruct {
+		req := httptest.NewRequest("POST", "/api/", bytes.NewBufferString(`{"cmd":"files.regenerate"}`))
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		API(w, req)
+
+		var response APIResponseStruct
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := regenerate()
+	require.True(t, response.Status, response.Error)
+
+	m3u, err := os.ReadFile(System.File.M3U)
+	require.NoError(t, err)
+	assert.Contains(t, string(m3u), "Zeta News")
+	assert.Equal(t, int64(len(m3u)), response.Files["xteve.m3u"])
+
+	xml, err := os.ReadFile(System.File.XML)
+	require.NoError(t, err)
+	assert.Contains(t, string(xml), "Alpha Sport")
+	assert.Equal(t, int64(len(xml)), response.Files["xteve.xml"])
+
+	assert.Zero(t, downloads.Load(), "the providers are not downloaded")
+	assert.Equal(t, 0, System.ScanInProgress)
+
+	// Not during a scan
+	System.ScanInProgress = 1
+	response = regenerate()
+	assert.False(t, response.Status)
+	assert.Contains(t, response.Error, "scan is in progress")
+}
@@ -7,10 +7,18 @@ import (
 	"encoding/xml"
 	"fmt"
 	"maps"
+	"net/url"
 	"slices"
-	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
+// lineupScan : Channel scan started by a client via /lineup.post?scan=start
+var lineupScan struct {
+	active   atomic.Bool
+	progress atomic.Int32
+}
+
 func makeInteraceFromHDHR(content []byte, playlistName, id string) (channels []any, err error) {
 	var hdhrData []any
 
@@ -72,7 +80,8 @@ func getCapability() (xmlContent []byte, err error) {
 	return
 }
 
-func getDiscover() (jsonContent []byte, err error) {
+// getDiscover : discover.json of xTeVe. With a provider ID, xTeVe identifies as a separate tuner with the lineup and tuners of this provider.
+func getDiscover(provider string) (jsonContent []byte, err error) {
 	var discover Discover
 
 	discover.BaseURL = System.ServerProtocol.WEB + "://" + System.Domain
@@ -87,15 +96,42 @@ func getDiscover() (jsonContent []byte, err error) {
 	discover.ModelNumber = System.Version
 	discover.TunerCount = Settings.Tuner
 
+	if fileType, ok := getProviderFileType(provider); ok {
+		discover.DeviceID = System.DeviceID + "-" + provider
+		discover.FriendlyName = System.Name + " " + getProviderParameter(provider, fileType, "name")
+		discover.LineupURL += "?provider=" + url.QueryEscape(provider)
+
+		if tuner := getTuner(provider, fileType); tuner > 0 {
+			discover.TunerCount = tuner
+		}
+	}
+
 	jsonContent, err = json.MarshalIndent(discover, "", "  ")
 	return
 }
 
+// getProviderFileType : File type ("m3u" or "hdhr") of a provider ID, false if the provider doesn't exist
+func getProviderFileType(provider string) (fileType string, ok bool) {
+	if _, ok = Settings.Files.M3U[provider]; ok {
+		return "m3u", true
+	}
+
+	if _, ok = Settings.Files.HDHR[provider]; ok {
+		return "hdhr", true
+	}
+
+	return "", false
+}
+
 func getLineupStatus() (jsonContent []byte, err error) {
 	var lineupStatus LineupStatus
 
 	lineupStatus.ScanInProgress = System.ScanInProgress
-	lineupStatus.ScanPossible = 0
+	lineupStatus.ScanPossible = 1
+	if lineupScan.active.Load() {
+		lineupStatus.ScanInProgress = 1
+		lineupStatus.Progress = int(lineupScan.progress.Load())
+	}
 	lineupStatus.Source = "Cable"
 	lineupStatus.SourceList = []string{"Cable"}
 
@@ -103,11 +139,49 @@ func getLineupStatus() (jsonContent []byte, err error) {
 	return
 }
 
-func getLineup() (jsonContent []byte, err error) {
+// startLineupScan rebuilds the DVR database and XEPG in the background. Returns false if a scan is already running.
+func startLineupScan() bool {
+	if System.ScanInProgress == 1 || !lineupScan.active.CompareAndSwap(false, true) {
+		return false
+	}
+
+	lineupScan.progress.Store(0)
+	showInfo("HDHR:Channel scan started by client")
+
+	go func() {
+		defer lineupScan.active.Store(false)
+
+		// buildDatabaseDVR and buildXEPG reset System.ScanInProgress themselves, the XEPG is built synchronously in this goroutine
+		if err := buildDatabaseDVR(); err != nil {
+			ShowError(err, 0)
+			lineupScan.progress.Store(100)
+			showInfo("HDHR:Channel scan failed")
+			return
+		}
+		lineupScan.progress.Store(50)
+
+		if err := buildXEPG(false); err != nil {
+			ShowError(err, 0)
+			lineupScan.progress.Store(100)
+			showInfo("HDHR:Channel scan failed")
+			return
+		}
+
+		lineupScan.progress.Store(100)
+		showInfo("HDHR:Channel scan finished")
+	}()
+
+	return true
+}
+
+// getLineup : lineup.json of all active channels. With a provider ID, only the channels of this provider are listed.
+func getLineup(provider string) (jsonContent []byte, err error) {
 	var lineup Lineup
+	var pinned = pinnedChannels()
 
 	switch Settings.EpgSource {
 	case "PMS":
+		var usedURLIDs = make(map[string]string)
 		for i, dsa := range Data.Streams.Active {
 			var m3uChannel M3UChannelStructXEPG
 
@@ -123,6 +197,10 @@ func getLineup() (jsonContent []byte, err error) {
 				}
 			}
 
+			if len(provider) > 0 && m3uChannel.FileM3UID != provider {
+				continue
+			}
+
 			var stream LineupStream
 			stream.GuideName = m3uChannel.Name
 			switch len(m3uChannel.UUIDValue) {
@@ -136,8 +214,17 @@ func getLineup() (jsonContent []byte, err error) {
 			default:
 				stream.GuideNumber = m3uChannel.UUIDValue
 			}
+			stream.pin = pinRank(pinned, stream.GuideNumber, stream.GuideName)
+			stream.GuideName = decorateChannelName(stream.GuideName)
 
-			stream.URL, err = createStreamingURL("DVR", m3uChannel.FileM3UID, stream.GuideNumber, m3uChannel.Name, m3uChannel.URL)
+			var urlID string
+			urlID, err = getStreamingURLID(m3uChannel.FileM3UID, m3uChannel.Name, m3uChannel.GroupTitle, m3uChannel.TvgID, m3uChannel.TvgName, m3uChannel.UUIDKey, m3uChannel.UUIDValue)
+			if err == nil {
+				urlID, err = uniqueStreamingURLID(usedURLIDs, urlID, m3uChannel.FileM3UID, m3uChannel.URL)
+			}
+			if err == nil {
+				stream.URL, err = createStreamingURL("DVR", urlID, m3uChannel.FileM3UID, stream.GuideNumber, m3uChannel.Name, m3uChannel.URL)
+			}
 			if err == nil {
 				lineup = append(lineup, stream)
 			} else {
@@ -147,12 +234,18 @@ func getLineup() (jsonContent []byte, err error) {
 	case "XEPG":
 		for _, xepgChannel := range Data.XEPG.Channels {
 
-			if xepgChannel.XActive {
+			if isChannelEnabled(xepgChannel) && (len(provider) == 0 || xepgChannel.FileM3UID == provider) {
 				var stream LineupStream
-				stream.GuideName = xepgChannel.XName
+				stream.GuideName = decorateChannelName(xepgChannel.XName)
 				stream.GuideNumber = xepgChannel.XChannelID
+				stream.mapped = isMappedChannel(xepgChannel)
+				stream.pin = pinRank(pinned, xepgChannel.XChannelID, xepgChannel.XName)
 				//stream.URL = fmt.Sprintf("%s://%s/stream/%s-%s", System.ServerProtocol.DVR, System.Domain, xepgChannel.FileM3UID, base64.StdEncoding.EncodeToString([]byte(xepgChannel.URL)))
-				stream.URL, err = createStreamingURL("DVR", xepgChannel.FileM3UID, xepgChannel.XChannelID, xepgChannel.XName, xepgChannel.URL)
+				var urlID string
+				urlID, err = getStreamingURLID(xepgChannel.FileM3UID, xepgChannel.Name, xepgChannel.GroupTitle, xepgChannel.TvgID, xepgChannel.TvgName, xepgChannel.UUIDKey, xepgChannel.UUIDValue)
+				if err == nil {
+					stream.URL, err = createStreamingURL("DVR", urlID, xepgChannel.FileM3UID, xepgChannel.XChannelID, xepgChannel.XName, xepgChannel.URL)
+				}
 				if err == nil {
 					lineup = append(lineup, stream)
 				} else {
@@ -162,13 +255,18 @@ func getLineup() (jsonContent []byte, err error) {
 		}
 	}
 
-	// Sort the lineup
+	// Sort the lineup, pinned channels first
 	slices.SortFunc(lineup, func(a, b LineupStream) int {
-		chanA, _ := strconv.ParseFloat(a.GuideNumber, 64)
-		chanB, _ := strconv.ParseFloat(b.GuideNumber, 64)
-		return cmp.Compare(chanA, chanB)
+		return cmp.Or(
+			cmp.Compare(a.pin, b.pin),
+			compareChannelNumbers(a.GuideNumber, b.GuideNumber),
+		)
 	})
 
+	lineup, _ = enforcePlexChannelLimit(lineup,
+		func(s LineupStream) bool { return s.mapped },
+		func(s LineupStream) string { return s.GuideName })
+
 	jsonContent, err = json.MarshalIndent(lineup, "", "  ")
 	if err != nil {
 		return
@@ -219,3 +317,49 @@ func getGuideNumberPMS(channelName string) (pmsID string, err error) {
 	}
 	return
 }
+
+// isMappedChannel : Channel has an XMLTV file and a mapping (not "-")
+func isMappedChannel(xepgChannel XEPGChannelStruct) bool {
+	return len(xepgChannel.XmltvFile) > 0 && xepgChannel.XmltvFile != "-" &&
+		len(xepgChannel.XMapping) > 0 && xepgChannel.XMapping != "-"
+}
+
+// enforcePlexChannelLimit : Caps the channels to System.PlexChannelLimit if plex.channel.limit.enforce is enabled.
+// Mapped channels are preferred, the order of the kept channels is not changed.
+func enforcePlexChannelLimit[T any](channels []T, mapped func(T) bool, name func(T) string) (kept []T, dropped []string) {
+	var limit = System.PlexChannelLimit
+
+	if !Settings.PlexChannelLimitEnforce || limit <= 0 || len(channels) <= limit {
+		return channels, nil
+	}
+
+	var keep = make([]bool, len(channels))
+	var count int
+
+	// Mapped channels first, then the remaining channels in their current order
+	for _, preferMapped := range []bool{true, false} {
+		for i, channel := range channels {
+			if count == limit {
+				break
+			}
+			if !keep[i] && mapped(channel) == preferMapped {
+				keep[i] = true
+				count++
+			}
+		}
+	}
+
+	kept = make([]T, 0, limit)
+	for i, channel := range channels {
+		if keep[i] {
+			kept = append(kept, channel)
+		} else {
+			dropped = append(dropped, name(channel))
+		}
+	}
+
+	showInfo(fmt.Sprintf("Plex Channel Limit:%d channels exceed the limit of %d and have been removed", len(dropped), limit))
+	showDebug(fmt.Sprintf("Plex Channel Limit:Removed channels: %s", strings.Join(dropped, ", ")), 1)
+
+	return
+}
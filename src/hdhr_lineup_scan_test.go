@@ -0,0 +1,102 @@
+package src
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineupPost_ScanStart(t *testing.T) {
+	oldSystem, oldSettings, oldData := System, Settings, Data
+	t.Cleanup(func() {
+		System, Settings, Data = oldSystem, oldSettings, oldData
+	})
+
+	System.ScanInProgress = 0
+	System.Folder.Data = t.TempDir() + "/"
+	Settings.EpgSource = "PMS"
+	Settings.AuthenticationPMS = false
+	Settings.Files.M3U = map[string]any{}
+	Settings.Files.HDHR = map[string]any{}
+	Settings.Filter = map[int64]any{}
+
+	req := httptest.NewRequest(http.MethodGet, "/lineup.post?scan=start", nil)
+	rr := httptest.NewRecorder()
+	Index(rr, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/lineup.post?scan=unknown", nil)
+	rr = httptest.NewRecorder()
+	Index(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/lineup.post?scan=start", nil)
+	rr = httptest.NewRecorder()
+	Index(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var status LineupStatus
+	require.Eventually(t, func() bool {
+		req := httptest.NewRequest(http.MethodGet, "/lineup_status.json", nil)
+		rr := httptest.NewRecorder()
+		Index(rr, req)
+		if rr.Code != http.StatusOK {
+			return false
+		}
+		status = LineupStatus{}
+		return json.Unmarshal(rr.Body.Bytes(), &status) == nil && status.ScanInProgress == 0
+	}, 10*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, 1, status.ScanPossible)
+	assert.Equal(t, int32(100), lineupScan.progress.Load())
+}
+
+func TestGetLineupStatus_ScanInProgress(t *testing.T) {
+	oldSystem := System
+	t.Cleanup(func() {
+		System = oldSystem
+		lineupScan.active.Store(false)
+		lineupScan.progress.Store(0)
+	})
+
+	System.ScanInProgress = 0
+	lineupScan.active.Store(true)
+	lineupScan.progress.Store(50)
+
+	content, err := getLineupStatus()
+	require.NoError(t, err)
+
+	var status LineupStatus
+	require.NoError(t, json.Unmarshal(content, &status))
+	assert.Equal(t, 1, status.ScanInProgress)
+	assert.Equal(t, 50, status.Progress)
+
+	assert.False(t, startLineupScan(), "a second scan must not be started")
+}
+
+func TestStartLineupScan_Failed(t *testing.T) {
+	oldSystem, oldSettings, oldData := System, Settings, Data
+	t.Cleanup(func() {
+		System, Settings, Data = oldSystem, oldSettings, oldData
+		lineupScan.progress.Store(0)
+	})
+	logs := captureScreenLog(t)
+
+	System.ScanInProgress = 0
+	Settings.Files.M3U = map[string]any{}
+	Settings.Files.HDHR = map[string]any{}
+	Settings.Filter = map[int64]any{0: "invalid"}
+
+	require.True(t, startLineupScan())
+	require.Eventually(t, func() bool { return !lineupScan.active.Load() }, 10*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, int32(100), lineupScan.progress.Load())
+	assert.Equal(t, 0, System.ScanInProgress, "buildDatabaseDVR must reset the scan flag after an error")
+	assert.Contains(t, strings.Join(logs(), "\n"), "Channel scan failed")
+}
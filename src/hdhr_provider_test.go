@@ -0,0 +1,86 @@
+package src
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineup_ProviderFilter(t *testing.T) {
+	oldSystem, oldSettings, oldData := System, Settings, Data
+	t.Cleanup(func() {
+		System, Settings, Data = oldSystem, oldSettings, oldData
+	})
+
+	System.Name = "xTeVe"
+	System.DeviceID = "2024-01-ABCD-EFGH"
+	System.ServerProtocol.DVR = "http"
+	System.ServerProtocol.WEB = "http"
+	System.File.URLS = t.TempDir() + "/urls.json"
+	Settings.EpgSource = "XEPG"
+	Settings.Buffer = "xteve"
+	Settings.Tuner = 4
+	Settings.AuthenticationPMS = false
+	Settings.Files.M3U = map[string]any{
+		"M1": map[string]any{"name": "Cable", "tuner": float64(2)},
+		"M2": map[string]any{"name": "IPTV", "tuner": float64(5)},
+	}
+	Settings.Files.HDHR = map[string]any{}
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x1": {XActive: true, XChannelID: "1", XName: "One", FileM3UID: "M1", URL: "http://example.com/1", XmltvFile: "-", XMapping: "-"},
+		"x2": {XActive: true, XChannelID: "2", XName: "Two", FileM3UID: "M2", URL: "http://example.com/2", XmltvFile: "-", XMapping: "-"},
+		"x3": {XActive: true, XChannelID: "3", XName: "Three", FileM3UID: "M1", URL: "http://example.com/3", XmltvFile: "-", XMapping: "-"},
+	}
+
+	get := func(path string, v any) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		Index(rr, req)
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), v))
+		}
+		return rr.Code
+	}
+
+	guideNames := func(lineup Lineup) (names []string) {
+		for _, stream := range lineup {
+			names = append(names, stream.GuideName)
+		}
+		return
+	}
+
+	var lineup Lineup
+	require.Equal(t, http.StatusOK, get("/lineup.json", &lineup))
+	assert.Equal(t, []string{"One", "Two", "Three"}, guideNames(lineup))
+
+	lineup = nil
+	require.Equal(t, http.StatusOK, get("/lineup.json?provider=M1", &lineup))
+	assert.Equal(t, []string{"One", "Three"}, guideNames(lineup))
+
+	lineup = nil
+	require.Equal(t, http.StatusOK, get("/lineup.json?provider=M2", &lineup))
+	assert.Equal(t, []string{"Two"}, guideNames(lineup))
+
+	assert.Equal(t, http.StatusNotFound, get("/lineup.json?provider=M9", &lineup))
+
+	// discover.json
+	var discover Discover
+	require.Equal(t, http.StatusOK, get("/discover.json", &discover))
+	assert.Equal(t, 4, discover.TunerCount)
+	assert.Equal(t, "2024-01-ABCD-EFGH", discover.DeviceID)
+	assert.Equal(t, "http://example.com/lineup.json", discover.LineupURL)
+
+	discover = Discover{}
+	require.Equal(t, http.StatusOK, get("/discover.json?provider=M1", &discover))
+	assert.Equal(t, 2, discover.TunerCount)
+	assert.Equal(t, "2024-01-ABCD-EFGH-M1", discover.DeviceID, "every provider is a separate device")
+	assert.Equal(t, "xTeVe Cable", discover.FriendlyName)
+	assert.Equal(t, "http://example.com/lineup.json?provider=M1", discover.LineupURL)
+
+	assert.Equal(t, http.StatusNotFound, get("/discover.json?provider=M9", &discover))
+}
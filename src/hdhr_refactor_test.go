@@ -1,7 +1,9 @@
 package src
 
 import (
+	"encoding/json"
 	"os"
+	"slices"
 	"testing"
 )
 
@@ -86,3 +88,135 @@ func TestGetGuideNumberPMS(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLineup_PlexChannelLimitEnforce(t *testing.T) {
+	originalSettings := Settings
+	originalLimit := System.PlexChannelLimit
+	originalURLS := System.File.URLS
+	originalChannels := Data.XEPG.Channels
+	originalStreamingURLS := Data.Cache.StreamingURLS
+	t.Cleanup(func() {
+		Settings = originalSettings
+		System.PlexChannelLimit = originalLimit
+		System.File.URLS = originalURLS
+		Data.XEPG.Channels = originalChannels
+		Data.Cache.StreamingURLS = originalStreamingURLS
+	})
+
+	Settings.EpgSource = "XEPG"
+	System.PlexChannelLimit = 3
+	System.File.URLS = t.TempDir() + "/urls.json"
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+
+	// 5 active channels, only channel 4 and 5 are mapped
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x1": {XActive: true, XChannelID: "1", XName: "One", URL: "http://example.com/1", XmltvFile: "-", XMapping: "-"},
+		"x2": {XActive: true, XChannelID: "2", XName: "Two", URL: "http://example.com/2", XmltvFile: "-", XMapping: "-"},
+		"x3": {XActive: true, XChannelID: "3", XName: "Three", URL: "http://example.com/3", XmltvFile: "-", XMapping: "-"},
+		"x4": {XActive: true, XChannelID: "4", XName: "Four", URL: "http://example.com/4", XmltvFile: "X1.xml", XMapping: "four"},
+		"x5": {XActive: true, XChannelID: "5", XName: "Five", URL: "http://example.com/5", XmltvFile: "X1.xml", XMapping: "five"},
+	}
+
+	guideNames := func() (names []string) {
+		content, err := getLineup("")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var lineup Lineup
+		if err := json.Unmarshal(content, &lineup); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, stream := range lineup {
+			names = append(names, stream.GuideName)
+		}
+		return
+	}
+
+	// Disabled: only the warning, all channels are returned
+	Settings.PlexChannelLimitEnforce = false
+	if got := guideNames(); len(got) != 5 {
+		t.Errorf("expected 5 channels without enforcement, got %v", got)
+	}
+
+	// Enabled: mapped channels are preferred, the order is kept
+	Settings.PlexChannelLimitEnforce = true
+	if got, want := guideNames(), []string{"One", "Four", "Five"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestEnforcePlexChannelLimit_DropList(t *testing.T) {
+	originalEnforce := Settings.PlexChannelLimitEnforce
+	originalLimit := System.PlexChannelLimit
+	t.Cleanup(func() {
+		Settings.PlexChannelLimitEnforce = originalEnforce
+		System.PlexChannelLimit = originalLimit
+	})
+
+	Settings.PlexChannelLimitEnforce = true
+	System.PlexChannelLimit = 2
+
+	channels := []LineupStream{
+		{GuideName: "A"},
+		{GuideName: "B", mapped: true},
+		{GuideName: "C"},
+		{GuideName: "D"},
+	}
+
+	kept, dropped := enforcePlexChannelLimit(channels,
+		func(s LineupStream) bool { return s.mapped },
+		func(s LineupStream) string { return s.GuideName })
+
+	if len(kept) != 2 || kept[0].GuideName != "A" || kept[1].GuideName != "B" {
+		t.Errorf("unexpected kept channels: %v", kept)
+	}
+	if !slices.Equal(dropped, []string{"C", "D"}) {
+		t.Errorf("unexpected dropped channels: %v", dropped)
+	}
+}
+
+func TestGetLineup_Pinned(t *testing.T) {
+	originalSettings := Settings
+	originalURLS := System.File.URLS
+	originalChannels := Data.XEPG.Channels
+	originalStreamingURLS := Data.Cache.StreamingURLS
+	t.Cleanup(func() {
+		Settings = originalSettings
+		System.File.URLS = originalURLS
+		Data.XEPG.Channels = originalChannels
+		Data.Cache.StreamingURLS = originalStreamingURLS
+	})
+
+	Settings.EpgSource = "XEPG"
+	Settings.ChannelsPinned = []string{"Three", "2"}
+	System.File.URLS = t.TempDir() + "/urls.json"
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x1": {XActive: true, XChannelID: "1", XName: "One", URL: "http://example.com/1", XmltvFile: "-", XMapping: "-"},
+		"x2": {XActive: true, XChannelID: "2", XName: "Two", URL: "http://example.com/2", XmltvFile: "-", XMapping: "-"},
+		"x3": {XActive: true, XChannelID: "3", XName: "Three", URL: "http://example.com/3", XmltvFile: "-", XMapping: "-"},
+		"x4": {XActive: true, XChannelID: "4", XName: "Four", URL: "http://example.com/4", XmltvFile: "-", XMapping: "-"},
+	}
+
+	content, err := getLineup("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lineup Lineup
+	if err := json.Unmarshal(content, &lineup); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, stream := range lineup {
+		names = append(names, stream.GuideName)
+	}
+
+	if expected := []string{"Three", "Two", "One", "Four"}; !slices.Equal(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
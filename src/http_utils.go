@@ -44,10 +44,25 @@ func getXTeVeTransport() *http.Transport {
 			}
 		}
 		xTeVeTransport.DialContext = dialContextWithRetry
+
+		// Idle connections to the streaming servers are kept for the next HLS segment (upstream.keepalive)
+		if Settings.UpstreamMaxIdleConnsPerHost > 0 {
+			xTeVeTransport.MaxIdleConnsPerHost = Settings.UpstreamMaxIdleConnsPerHost
+		}
 	})
 	return xTeVeTransport
 }
 
+// setUpstreamHeaders : Headers of the requests to the streaming server.
+// Without upstream.keepalive the connection is closed after every request.
+func setUpstreamHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", Settings.UserAgent)
+	if !Settings.UpstreamKeepAlive {
+		req.Header.Set("Connection", "close")
+	}
+	req.Header.Set("Accept", "*/*")
+}
+
 func dialContextWithRetry(ctx context.Context, network, addr string) (net.Conn, error) {
 	var conn net.Conn
 	var err error
@@ -134,6 +149,32 @@ func NewHTTPClient() *http.Client {
 	}
 }
 
+// redactURL returns the URL with the password replaced by "xxxxx" like url.URL.Redacted, for logs and error messages.
+// The rest of the URL is kept as it is, e.g. the ${IPTV_...} placeholders of the provider URLs.
+func redactURL(rawURL string) string {
+	var start = strings.Index(rawURL, "://")
+	if start < 0 {
+		return rawURL
+	}
+	start += len("://")
+
+	var end = len(rawURL)
+	if i := strings.IndexAny(rawURL[start:], "/?#"); i >= 0 {
+		end = start + i
+	}
+
+	var at = strings.LastIndex(rawURL[start:end], "@")
+	if at < 0 {
+		return rawURL
+	}
+
+	var user, _, hasPassword = strings.Cut(rawURL[start:start+at], ":")
+	if !hasPassword {
+		return rawURL
+	}
+	return rawURL[:start] + user + ":xxxxx" + rawURL[start+at:]
+}
+
 // isResponseHeaderTimeout reports whether err is a net/http ResponseHeaderTimeout.
 // otelhttp records all *url.Error values under the same error type, so we detect
 // this specifically and add a custom span attribute to keep it queryable in traces.
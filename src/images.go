@@ -1,11 +1,43 @@
 package src
 
 import (
+	"context"
 	b64 "encoding/base64"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
+)
+
+// logoExtensions : Image types that can be uploaded as logo, file extension and Content-Type
+var logoExtensions = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".ico":  "image/x-icon",
+}
+
+// logoExtensionsByType : File extension of a logo downloaded from a URL
+var logoExtensionsByType = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/svg+xml": ".svg",
+	"image/x-icon":  ".ico",
+}
+
+const (
+	logoDownloadTimeout = 30 * time.Second
+	maxLogoDownloadSize = 5 << 20 // 5 MB
 )
 
 func uploadLogo(input, filename string) (logoURL string, err error) {
@@ -17,27 +49,24 @@ func uploadLogo(input, filename string) (logoURL string, err error) {
 		return
 	}
 
+	return saveLogo(filename, sDec)
+}
+
+// saveLogo : Saves an uploaded logo in the images upload folder and returns the URL of the logo
+func saveLogo(filename string, content []byte) (logoURL string, err error) {
 	// Sanitize filename to prevent path traversal
 	filename = filepath.Base(filename)
 
 	// Security: Validate file extension to prevent uploading malicious files (e.g., HTML for XSS)
-	allowedExts := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".gif":  true,
-		".svg":  true,
-		".ico":  true,
-	}
 	ext := strings.ToLower(filepath.Ext(filename))
-	if !allowedExts[ext] {
+	if _, ok := logoExtensions[ext]; !ok {
 		err = errors.New("invalid file extension: only image files are allowed")
 		return
 	}
 
 	var file = fmt.Sprintf("%s%s", System.Folder.ImagesUpload, filename)
 
-	err = writeByteToFile(file, sDec)
+	err = writeByteToFile(file, content)
 	if err != nil {
 		return
 	}
@@ -45,3 +74,110 @@ func uploadLogo(input, filename string) (logoURL string, err error) {
 	logoURL = fmt.Sprintf("%s://%s/data_images/%s", System.ServerProtocol.XML, System.Domain, filename)
 	return
 }
+
+// uploadLogoFromURL : Downloads a logo from a URL and saves it like an uploaded logo (websocket command uploadLogoFromURL).
+// The download uses the HTTP client with SSRF protection, the Content-Type and the content have to be an image.
+func uploadLogoFromURL(ctx context.Context, logoURL string) (string, error) {
+	u, err := url.ParseRequestURI(logoURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported URL scheme: %q", u.Scheme)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, logoDownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, logoURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", Settings.UserAgent)
+
+	resp, err := NewHTTPClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("not an image: Content-Type %q", resp.Header.Get("Content-Type"))
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxLogoDownloadSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(content) > maxLogoDownloadSize {
+		return "", fmt.Errorf("image too large: exceeds %d bytes", maxLogoDownloadSize)
+	}
+
+	// The content has to match the Content-Type, SVG files are text and can't be detected
+	if contentType != "image/svg+xml" && http.DetectContentType(content) != logoContentType(contentType) {
+		return "", fmt.Errorf("not an image: the content does not match the Content-Type %q", contentType)
+	}
+
+	// File name from the URL, the extension from the Content-Type
+	var name = strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+	if name == "" || name == "." || name == "/" {
+		name = "logo"
+	}
+
+	var ext = strings.ToLower(path.Ext(u.Path))
+	if logoExtensions[ext] != logoContentType(contentType) {
+		ext = logoExtensionsByType[logoContentType(contentType)]
+	}
+	if ext == "" {
+		return "", fmt.Errorf("unsupported image type: %q", contentType)
+	}
+
+	return saveLogo(name+ext, content)
+}
+
+// logoContentType : Normalizes the Content-Type of an image to the type returned by http.DetectContentType
+func logoContentType(contentType string) string {
+	switch contentType {
+	case "image/vnd.microsoft.icon", "image/x-icon", "image/ico":
+		return "image/x-icon"
+	case "image/jpg", "image/pjpeg":
+		return "image/jpeg"
+	}
+	return contentType
+}
+
+// clearImageCache : Removes all cached images. The logos and posters are downloaded again with the next XEPG update.
+func clearImageCache() (removed int, err error) {
+	if System.ImageCachingInProgress == 1 {
+		return 0, errors.New("images are being cached, try again later")
+	}
+
+	files, err := filepath.Glob(filepath.Join(System.Folder.ImagesCache, "*"))
+	if err != nil {
+		return
+	}
+
+	for _, file := range files {
+		if err = os.RemoveAll(file); err != nil {
+			return
+		}
+		removed++
+	}
+
+	Data.Cache.ImagesCache = []string{}
+	Data.Cache.ImagesFiles = []string{}
+	Data.Cache.ImagesURLS = []string{}
+
+	Data.Cache.Images, err = newImageCache()
+	if err != nil {
+		return
+	}
+
+	showInfo(fmt.Sprintf("Image Caching:Image cache cleared (%d files removed)", removed))
+	return
+}
@@ -0,0 +1,83 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClearImageCache(t *testing.T) {
+	oldSettings, oldSystem, oldData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = oldSettings, oldSystem, oldData })
+
+	System.Folder.ImagesCache = t.TempDir() + string(os.PathSeparator)
+	System.Folder.ImagesUpload = t.TempDir() + string(os.PathSeparator)
+	System.Domain = "localhost:34400"
+	System.ImageCachingInProgress = 0
+	Settings.CacheImages = true
+
+	for _, name := range []string{"a.png", "b.jpg", "c.gif"} {
+		require.NoError(t, os.WriteFile(filepath.Join(System.Folder.ImagesCache, name), []byte("image"), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(System.Folder.ImagesUpload, "logo.png"), []byte("image"), 0644))
+
+	Data.Cache.ImagesCache = []string{"a.png", "b.jpg", "c.gif"}
+	Data.Cache.ImagesURLS = []string{"http://example.com/a.png"}
+	Data.Cache.ImagesFiles = []string{"a.png"}
+	oldCache, err := newImageCache()
+	require.NoError(t, err)
+	Data.Cache.Images = oldCache
+
+	// Not possible while images are cached
+	System.ImageCachingInProgress = 1
+	_, err = clearImageCache()
+	assert.Error(t, err)
+	assert.FileExists(t, filepath.Join(System.Folder.ImagesCache, "a.png"))
+	System.ImageCachingInProgress = 0
+
+	removed, err := clearImageCache()
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+
+	files, err := os.ReadDir(System.Folder.ImagesCache)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	assert.FileExists(t, filepath.Join(System.Folder.ImagesUpload, "logo.png"), "uploaded logos are kept")
+
+	assert.Empty(t, Data.Cache.ImagesCache)
+	assert.Empty(t, Data.Cache.ImagesURLS)
+	assert.Empty(t, Data.Cache.ImagesFiles)
+	require.NotNil(t, Data.Cache.Images)
+	assert.NotSame(t, oldCache, Data.Cache.Images)
+
+	// Websocket command
+	require.NoError(t, os.WriteFile(filepath.Join(System.Folder.ImagesCache, "d.png"), []byte("image"), 0644))
+	Settings.AuthenticationWEB = false
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.WriteJSON(map[string]string{"cmd": "clearImageCache"}))
+
+	var response struct {
+		Status        bool   `json:"status"`
+		Error         string `json:"err"`
+		ImagesRemoved int    `json:"imagesRemoved"`
+	}
+	require.NoError(t, ws.ReadJSON(&response))
+	assert.True(t, response.Status, response.Error)
+	assert.Equal(t, 1, response.ImagesRemoved)
+	assert.NoFileExists(t, filepath.Join(System.Folder.ImagesCache, "d.png"))
+}
@@ -0,0 +1,71 @@
+package src
+
+import (
+	b64 "encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"xteve/src/internal/imgcache"
+)
+
+func TestImages_DataURILogo(t *testing.T) {
+	oldSystem := System
+	t.Cleanup(func() { System = oldSystem })
+	System.Folder.ImagesCache = t.TempDir() + "/"
+
+	var png = []byte("\x89PNG\r\n\x1a\nlogo")
+	var dataURI = "data:image/png;base64," + b64.StdEncoding.EncodeToString(png)
+
+	cache, err := imgcache.New(System.Folder.ImagesCache, "http://localhost:34400/images/", true, NewHTTPClient())
+	require.NoError(t, err)
+
+	logoURL := cache.Image.GetURL(dataURI)
+	require.Regexp(t, `^http://localhost:34400/images/[0-9a-f]{32}\.png$`, logoURL)
+	assert.Empty(t, cache.Queue, "data URIs are not downloaded")
+	assert.Equal(t, logoURL, cache.Image.GetURL(dataURI))
+
+	tmpFiles, err := filepath.Glob(System.Folder.ImagesCache + ".download-*")
+	require.NoError(t, err)
+	assert.Empty(t, tmpFiles, "the temporary file is renamed")
+
+	u, err := url.Parse(logoURL)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	Images(rr, httptest.NewRequest(http.MethodGet, u.Path, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.Equal(t, png, rr.Body.Bytes())
+
+	// The cached file is kept by the next cache
+	cache, err = imgcache.New(System.Folder.ImagesCache, "http://localhost:34400/images/", true, NewHTTPClient())
+	require.NoError(t, err)
+	assert.Equal(t, logoURL, cache.Image.GetURL(dataURI))
+	cache.Image.Remove()
+	assert.FileExists(t, System.Folder.ImagesCache+u.Path[len("/images/"):])
+
+	// URL encoded SVG without base64
+	svgURL := cache.Image.GetURL(`data:image/svg+xml,%3Csvg xmlns="http://www.w3.org/2000/svg"/%3E`)
+	require.Regexp(t, `\.svg$`, svgURL)
+	u, err = url.Parse(svgURL)
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	Images(rr, httptest.NewRequest(http.MethodGet, u.Path, nil))
+	assert.Equal(t, `<svg xmlns="http://www.w3.org/2000/svg"/>`, rr.Body.String())
+
+	// Other media types and invalid data are not changed
+	for _, src := range []string{"data:text/html;base64,PGI+PC9iPg==", "data:image/png;base64,!!!", "data:image/png"} {
+		assert.Equal(t, src, cache.Image.GetURL(src))
+	}
+
+	// Without image caching the data URI is used as it is
+	cache, err = imgcache.New(System.Folder.ImagesCache, "http://localhost:34400/images/", false, NewHTTPClient())
+	require.NoError(t, err)
+	assert.Equal(t, dataURI, cache.Image.GetURL(dataURI))
+}
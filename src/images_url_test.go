@@ -0,0 +1,116 @@
+package src
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadLogoFromURL(t *testing.T) {
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	System.Folder.ImagesUpload = t.TempDir() + string(os.PathSeparator)
+	System.ServerProtocol.XML = "http"
+	System.Domain = "localhost:34400"
+
+	// 1x1 PNG
+	png, err := base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg==")
+	require.NoError(t, err)
+
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logos/channel.png", "/logo":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(png)
+		case "/logos/channel.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg" width="1" height="1"/>`))
+		case "/logos/page.png":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		case "/logos/fake.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("<html><script>alert(1)</script></html>"))
+		case "/logos/slow.png":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mock.Close()
+
+	t.Run("SSRF protection", func(t *testing.T) {
+		os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+		_, err := uploadLogoFromURL(context.Background(), mock.URL+"/logos/channel.png")
+		assert.Error(t, err)
+		assert.NoFileExists(t, System.Folder.ImagesUpload+"channel.png")
+	})
+
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	t.Cleanup(func() { os.Unsetenv("XTEVE_ALLOW_LOOPBACK") })
+
+	logoURL, err := uploadLogoFromURL(context.Background(), mock.URL+"/logos/channel.png?size=large")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:34400/data_images/channel.png", logoURL)
+	content, err := os.ReadFile(filepath.Join(System.Folder.ImagesUpload, "channel.png"))
+	require.NoError(t, err)
+	assert.Equal(t, png, content)
+
+	// The extension is taken from the Content-Type
+	logoURL, err = uploadLogoFromURL(context.Background(), mock.URL+"/logo")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:34400/data_images/logo.png", logoURL)
+
+	logoURL, err = uploadLogoFromURL(context.Background(), mock.URL+"/logos/channel.svg")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:34400/data_images/channel.svg", logoURL)
+
+	for _, path := range []string{"/logos/page.png", "/logos/fake.png", "/logos/missing.png"} {
+		_, err = uploadLogoFromURL(context.Background(), mock.URL+path)
+		assert.Error(t, err, path)
+	}
+	assert.NoFileExists(t, System.Folder.ImagesUpload+"page.png")
+	assert.NoFileExists(t, System.Folder.ImagesUpload+"fake.png")
+
+	_, err = uploadLogoFromURL(context.Background(), "file:///etc/passwd")
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = uploadLogoFromURL(ctx, mock.URL+"/logos/slow.png")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Websocket command
+	Settings.AuthenticationWEB = false
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.WriteJSON(map[string]string{"cmd": "uploadLogoFromURL", "url": mock.URL + "/logos/channel.png"}))
+
+	var response struct {
+		Status  bool   `json:"status"`
+		LogoURL string `json:"logoURL"`
+	}
+	require.NoError(t, ws.ReadJSON(&response))
+	assert.True(t, response.Status)
+	assert.True(t, strings.HasSuffix(response.LogoURL, "/data_images/channel.png"), response.LogoURL)
+}
@@ -1,6 +1,7 @@
 package imgcache
 
 import (
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Cache : Cache strcut
@@ -18,9 +20,11 @@ type Cache struct {
 	caching  bool
 	client   *http.Client
 	images   map[string]string
+	fetched  map[string]time.Time // Time of the download per cached file (modification time of the file)
 	Queue    []string
 	Cache    []string
 	Image    imageFunc
+	TTL      time.Duration // Cached images older than the TTL are downloaded again (0 = never)
 	sync.RWMutex
 }
 
@@ -35,6 +39,7 @@ func New(path, chacheURL string, caching bool, client *http.Client) (c *Cache, e
 	c = &Cache{}
 
 	c.images = make(map[string]string)
+	c.fetched = make(map[string]time.Time)
 	c.path = path
 	c.cacheURL = chacheURL
 	c.caching = caching
@@ -57,6 +62,11 @@ func New(path, chacheURL string, caching bool, client *http.Client) (c *Cache, e
 			return src
 		}
 
+		// Logos embedded as data URI don't need a download, they are decoded into the cache
+		if strings.HasPrefix(src, "data:") {
+			return c.saveDataURI(src)
+		}
+
 		u, err := url.Parse(src)
 		if err != nil || len(filepath.Ext(u.Path)) == 0 {
 			return src
@@ -64,6 +74,11 @@ func New(path, chacheURL string, caching bool, client *http.Client) (c *Cache, e
 
 		// Optimization: Avoid redundant MD5 calculation and string allocation
 		var filename = fmt.Sprintf("%s%s", strToMD5(src), filepath.Ext(u.Path))
+		if c.expired(filename) && indexOfString(src, c.Queue) == -1 {
+			// The cached image is used until the next caching pass has downloaded it again
+			c.Queue = append(c.Queue, src)
+		}
+
 		if cacheURL, ok := c.images[filename]; ok {
 			return cacheURL
 		}
@@ -102,20 +117,26 @@ func New(path, chacheURL string, caching bool, client *http.Client) (c *Cache, e
 
 			filename = fmt.Sprintf("%s%s%s%s", c.path, string(os.PathSeparator), md5Src, filepath.Ext(src))
 
-			file, err := os.Create(filename)
+			// Download into a temporary file, an expired image is only replaced by a complete download
+			file, err := os.CreateTemp(c.path, ".download-*")
 			if err != nil {
 				continue
 			}
-			defer file.Close()
 
 			_, err = io.Copy(file, resp.Body)
+			file.Close()
+			if err == nil {
+				err = os.Rename(file.Name(), filename)
+			}
 			if err != nil {
+				os.Remove(file.Name())
 				continue
 			}
 
 			u, err := url.Parse(src)
 			if err == nil {
 				c.images[fmt.Sprintf("%s%s", md5Src, filepath.Ext(u.Path))] = c.cacheURL + filename
+				c.fetched[fmt.Sprintf("%s%s", md5Src, filepath.Ext(u.Path))] = time.Now()
 			}
 			queue = append(queue, src)
 		}
@@ -153,6 +174,90 @@ func New(path, chacheURL string, caching bool, client *http.Client) (c *Cache, e
 
 	for _, entry := range dirEntries {
 		c.Cache = append(c.Cache, entry.Name())
+
+		if info, err := entry.Info(); err == nil {
+			c.fetched[entry.Name()] = info.ModTime()
+		}
 	}
 	return
 }
+
+// dataURIExtensions : File extension of the image types that can be embedded as data URI
+var dataURIExtensions = map[string]string{
+	"image/gif":     ".gif",
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/svg+xml": ".svg",
+	"image/webp":    ".webp",
+	"image/x-icon":  ".ico",
+}
+
+// saveDataURI decodes a data URI (data:image/png;base64,...) into the cache and returns the URL of the cached file.
+// Invalid data URIs and other media types are returned unchanged. The caller holds the lock.
+func (c *Cache) saveDataURI(src string) string {
+	header, data, ok := strings.Cut(strings.TrimPrefix(src, "data:"), ",")
+	if !ok {
+		return src
+	}
+
+	params := strings.Split(header, ";")
+	ext, ok := dataURIExtensions[strings.ToLower(strings.TrimSpace(params[0]))]
+	if !ok {
+		return src
+	}
+
+	var filename = strToMD5(src) + ext
+	if cacheURL, ok := c.images[filename]; ok {
+		return cacheURL
+	}
+
+	if indexOfString(filename, c.Cache) == -1 {
+		var content []byte
+		var err error
+		if strings.EqualFold(params[len(params)-1], "base64") {
+			// Padding and line breaks are optional in data URIs
+			data = strings.Join(strings.Fields(data), "")
+			content, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+		} else {
+			var unescaped string
+			unescaped, err = url.PathUnescape(data)
+			content = []byte(unescaped)
+		}
+		if err != nil || len(content) == 0 {
+			return src
+		}
+
+		// Written into a temporary file like the downloads, the cache never contains a partial image
+		file, err := os.CreateTemp(c.path, ".download-*")
+		if err != nil {
+			return src
+		}
+
+		_, err = file.Write(content)
+		if errClose := file.Close(); err == nil {
+			err = errClose
+		}
+		if err == nil {
+			err = os.Rename(file.Name(), filepath.Join(c.path, filename))
+		}
+		if err != nil {
+			os.Remove(file.Name())
+			return src
+		}
+		c.Cache = append(c.Cache, filename)
+		c.fetched[filename] = time.Now()
+	}
+
+	c.images[filename] = c.cacheURL + filename
+	return c.images[filename]
+}
+
+// expired reports whether the cached image is older than the TTL
+func (c *Cache) expired(filename string) bool {
+	if c.TTL <= 0 {
+		return false
+	}
+
+	fetched, ok := c.fetched[filename]
+	return ok && time.Since(fetched) > c.TTL
+}
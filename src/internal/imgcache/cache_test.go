@@ -0,0 +1,71 @@
+package imgcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_TTL(t *testing.T) {
+	var logo = "v1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(logo))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir() + string(os.PathSeparator)
+	src := server.URL + "/logo.png"
+	cached := dir + strToMD5(src) + ".png"
+	unused := dir + strToMD5(server.URL+"/old.png") + ".png"
+	require.NoError(t, os.WriteFile(unused, []byte("old"), 0644))
+
+	newCache := func(ttl time.Duration) *Cache {
+		c, err := New(dir, "http://xteve/images/", true, server.Client())
+		require.NoError(t, err)
+		c.TTL = ttl
+		return c
+	}
+
+	// First caching pass
+	c := newCache(time.Hour)
+	assert.Equal(t, src, c.Image.GetURL(src))
+	c.Image.Caching()
+	content, err := os.ReadFile(cached)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+
+	// The logo changes upstream, the cached file is still fresh
+	logo = "v2"
+	c = newCache(time.Hour)
+	assert.Equal(t, "http://xteve/images/"+filepath.Base(cached), c.Image.GetURL(src))
+	assert.Empty(t, c.Queue)
+
+	// The cached file is older than the TTL: The cached logo is used until it is downloaded again
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(cached, old, old))
+	c = newCache(time.Hour)
+	assert.Equal(t, "http://xteve/images/"+filepath.Base(cached), c.Image.GetURL(src))
+	assert.Equal(t, []string{src}, c.Queue)
+	c.Image.Caching()
+	c.Image.Remove()
+
+	content, err = os.ReadFile(cached)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(content))
+	assert.Empty(t, c.Queue)
+
+	// Logos that are no longer used are still removed
+	assert.NoFileExists(t, unused)
+
+	// Without a TTL, cached logos are kept forever
+	require.NoError(t, os.Chtimes(cached, old, old))
+	c = newCache(0)
+	c.Image.GetURL(src)
+	assert.Empty(t, c.Queue)
+}
@@ -68,6 +68,11 @@ func MakeInterfaceFromM3U(byteStream []byte) (allChannels []any, err error) {
 				} else {
 					processedHeader = true
 					// It's the parameter line (the part after #EXTINF)
+					// The original line is kept for m3u.passthrough.extinf
+					stream["_extinf"] = "#EXTINF" + line
+					if !strings.HasPrefix(line, ":") {
+						stream["_extinf"] = "#EXTINF:" + line
+					}
 					// Format: ... attributes ... ,Channel Name
 					// Find separator comma (first comma not in quotes)
 					commaPos := -1
@@ -207,3 +212,29 @@ func MakeInterfaceFromM3U(byteStream []byte) (allChannels []any, err error) {
 	}
 	return
 }
+
+// EPGURL : Returns the EPG URL of the #EXTM3U header (url-tvg or x-tvg-url), only the first one of a comma separated list
+func EPGURL(byteStream []byte) string {
+	var header, _, _ = strings.Cut(strings.TrimSpace(string(byteStream)), "\n")
+	if !strings.HasPrefix(header, "#EXTM3U") {
+		return ""
+	}
+
+	var attributes = make(map[string]string)
+	offset := 0
+	for offset < len(header) {
+		matches, pos, ok := matchAttribute.FindString(header[offset:])
+		if !ok {
+			break
+		}
+		attributes[strings.ToLower(matches[1])] = matches[2]
+		offset += pos + len(matches[0])
+	}
+
+	for _, key := range []string{"url-tvg", "x-tvg-url"} {
+		if value, _, _ := strings.Cut(attributes[key], ","); len(strings.TrimSpace(value)) > 0 {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -147,3 +147,17 @@ func TestMakeInterfaceFromM3U_ErrorCases(t *testing.T) {
 		})
 	}
 }
+
+func TestEPGURL(t *testing.T) {
+	var tests = map[string]string{
+		"#EXTM3U url-tvg=\"http://example.com/epg.xml\"\n#EXTINF:-1,Channel\nhttp://example.com/1": "http://example.com/epg.xml",
+		"#EXTM3U x-tvg-url=\"http://example.com/a.xml.gz,http://example.com/b.xml\"\n":             "http://example.com/a.xml.gz",
+		"\r\n#EXTM3U URL-TVG=\"http://example.com/epg.xml\"\r\n":                                   "http://example.com/epg.xml",
+		"#EXTM3U\n#EXTINF:-1 url-tvg=\"http://example.com/epg.xml\",Channel\nhttp://example.com/1": "",
+		"#EXTINF:-1,Channel\nhttp://example.com/1":                                                 "",
+	}
+
+	for content, expected := range tests {
+		assert.Equal(t, expected, EPGURL([]byte(content)), content)
+	}
+}
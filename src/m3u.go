@@ -1,18 +1,57 @@
 package src
 
 import (
+	"bytes"
 	"cmp"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"math"
+	"net/url"
 	"path"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 
 	m3u "xteve/src/internal/m3u-parser"
+
+	"golang.org/x/text/encoding/htmlindex"
 )
 
+// m3uChannelData is a slimmed-down version of XEPGChannelStruct
+// containing only fields necessary for M3U generation and sorting.
+// This reduces memory overhead significantly compared to copying the full struct.
+type m3uChannelData struct {
+	XEPG        string
+	XChannelID  string
+	XName       string
+	TvgID       string
+	TvgLogo     string
+	XGroupTitle string
+	XMLTVID     string // Channel ID in the XMLTV file (XEPG)
+	FileM3UID   string
+	FileM3UName string
+	URL         string
+	URLID       string // Stable ID of the /stream/ URL (getStreamingURLID)
+	Radio       bool
+	EXTINF      string // Original #EXTINF line of the playlist (m3u.passthrough.extinf)
+
+	// Catchup attributes of the provider (XEPG)
+	Catchup       string
+	CatchupSource string
+	CatchupDays   string
+}
+
+// channelWithNum : M3U channel together with its parsed channel number (used for sorting)
+type channelWithNum struct {
+	channel m3uChannelData
+	num     channelNumber
+	mapped  bool
+}
+
 // Parse Playlists
 func parsePlaylist(filename, fileType string) (channels []any, err error) {
 	content, err := readByteFromFile(filename)
@@ -22,7 +61,15 @@ func parsePlaylist(filename, fileType string) (channels []any, err error) {
 	if err == nil {
 		switch fileType {
 		case "m3u":
+			// Playlists are stored as UTF-8 (getProviderData), only an old BOM has to be removed
+			content, err = decodePlaylist(content, "")
+			if err != nil {
+				return
+			}
 			channels, err = m3u.MakeInterfaceFromM3U(content)
+			if err == nil {
+				resolveRelativeStreamURLs(channels, getProviderParameter(id, fileType, "file.source"))
+			}
 		case "hdhr":
 			channels, err = makeInteraceFromHDHR(content, playlistName, id)
 		}
@@ -30,24 +77,61 @@ func parsePlaylist(filename, fileType string) (channels []any, err error) {
 	return
 }
 
+// resolveRelativeStreamURLs : Some playlists use stream URLs relative to the playlist (e.g. /live/123.ts).
+// They are resolved against the source URL of the provider, playlists from a local file are not changed.
+func resolveRelativeStreamURLs(channels []any, source string) {
+	base, err := url.Parse(source)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") {
+		return
+	}
+
+	for _, channel := range channels {
+		stream, ok := channel.(map[string]string)
+		if !ok || len(stream["url"]) == 0 {
+			continue
+		}
+
+		streamURL, err := url.Parse(stream["url"])
+		if err != nil || streamURL.IsAbs() {
+			continue
+		}
+
+		stream["url"] = base.ResolveReference(streamURL).String()
+	}
+}
+
+// prefixGroupWithProvider : Group title with the name of the provider (m3u.prefix.group.with.provider), streams without a group stay without one
+func prefixGroupWithProvider(provider, group string) string {
+	if len(provider) == 0 || len(group) == 0 {
+		return group
+	}
+	return provider + " / " + group
+}
+
 // Filter Streams
 // FilterThisStream checks if a stream should be filtered based on global filter rules.
 // It is used by benchmarks and potentially other parts of the application.
-func FilterThisStream(s any) (status bool) {
+func FilterThisStream(s any) (status bool, reason string) {
 	if len(Data.Filter) == 0 {
-		return false
+		return false, "no filter defined"
 	}
 
 	// status is false by default for a bool named return
 	stream, ok := s.(map[string]string)
 	if !ok {
 		// This should ideally not happen if s is always map[string]string
-		return false
+		return false, "invalid stream"
 	}
 
 	// Cache raw stream values. Normalize _values once.
 	rawStreamGroup, streamGroupOK := stream["group-title"]
 
+	// With m3u.prefix.group.with.provider the groups are listed with the provider name, rules for both titles match
+	var rawProviderGroup string
+	if Settings.M3UPrefixGroupWithProvider && streamGroupOK {
+		rawProviderGroup = prefixGroupWithProvider(stream["_file.m3u.name"], rawStreamGroup)
+	}
+
 	// Lazy initialization vars
 	var rawStreamValues string
 	var rawStreamValuesInit bool
@@ -79,6 +163,7 @@ func FilterThisStream(s any) (status bool) {
 
 		// Determine effective stream values based on case sensitivity
 		var effectiveStreamGroup = rawStreamGroup
+		var effectiveProviderGroup = rawProviderGroup
 		var effectiveStreamValues string
 
 		// Apply case insensitivity if needed
@@ -89,6 +174,7 @@ func FilterThisStream(s any) (status bool) {
 					lowerStreamGroupInit = true
 				}
 				effectiveStreamGroup = lowerStreamGroup
+				effectiveProviderGroup = strings.ToLower(rawProviderGroup)
 			}
 
 			if filter.Type == "custom-filter" {
@@ -116,7 +202,7 @@ func FilterThisStream(s any) (status bool) {
 		case "group-title":
 			searchTarget = effectiveStreamGroup // For group-title, conditions check against stream group
 			// Use precompiled rule
-			if streamGroupOK && effectiveStreamGroup == filter.CompiledRule {
+			if streamGroupOK && (effectiveStreamGroup == filter.CompiledRule || (len(effectiveProviderGroup) > 0 && effectiveProviderGroup == filter.CompiledRule)) {
 				match = true
 				stream["_preserve-mapping"] = strconv.FormatBool(filter.PreserveMapping)
 				stream["_starting-channel"] = filter.StartingChannel
@@ -134,18 +220,18 @@ func FilterThisStream(s any) (status bool) {
 			// `searchTarget` is already correctly cased. CompiledInclude/Exclude are also pre-cased if needed.
 			if len(filter.CompiledExclude) > 0 {
 				if !checkConditions(searchTarget, filter.PreparsedExclude, "exclude") {
-					return false // Fails exclude condition
+					return false, fmt.Sprintf("excluded by filter %q", filter.Rule) // Fails exclude condition
 				}
 			}
 			if len(filter.CompiledInclude) > 0 {
 				if !checkConditions(searchTarget, filter.PreparsedInclude, "include") {
-					return false // Fails include condition
+					return false, fmt.Sprintf("include condition of filter %q not met", filter.Rule) // Fails include condition
 				}
 			}
-			return true // Matches filter and all its conditions
+			return true, "" // Matches filter and all its conditions
 		}
 	}
-	return false // No filter matched
+	return false, "no filter matched" // No filter matched
 }
 
 // Conditions for the Filter
@@ -211,10 +297,52 @@ func containsWholeWord(s, substr string) bool {
 	}
 }
 
+// defaultOutputProfile : Profile of /m3u/xteve.m3u and /xmltv/xteve.xml, it uses the global settings
+const defaultOutputProfile = "xteve"
+
+var outputProfileNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// getOutputProfile : Returns the output profile (output.profiles) with the given name.
+// Unknown names return the default profile.
+func getOutputProfile(name string) (profile OutputProfile, ok bool) {
+	for _, p := range Settings.OutputProfiles {
+		if p.Name == name && isValidOutputProfileName(p.Name) {
+			return p, true
+		}
+	}
+
+	return OutputProfile{Name: defaultOutputProfile}, false
+}
+
+// isValidOutputProfileName : The name is used as file name and must not replace the default files
+func isValidOutputProfileName(name string) bool {
+	return name != defaultOutputProfile && outputProfileNameRegex.MatchString(name)
+}
+
+// includesGroup : Reports whether channels of the group are part of the profile
+func (p OutputProfile) includesGroup(groupTitle string) bool {
+	return len(p.Groups) == 0 || slices.Contains(p.Groups, groupTitle)
+}
+
+func (p OutputProfile) sortOrder() string {
+	return cmp.Or(p.SortOrder, Settings.M3USortOrder)
+}
+
+func (p OutputProfile) directURLs() bool {
+	switch p.Format {
+	case "direct":
+		return true
+	case "xteve":
+		return false
+	default:
+		return Settings.M3UDirectURLs
+	}
+}
+
 // Create xTeVe M3U file
 func buildM3U(groups []string) (m3u string, err error) {
 	var sb strings.Builder
-	err = buildM3UToWriter(&sb, groups)
+	err = buildM3UToWriter(&sb, groups, OutputProfile{Name: defaultOutputProfile})
 	if err != nil {
 		return "", err
 	}
@@ -229,29 +357,10 @@ func buildM3U(groups []string) (m3u string, err error) {
 
 // buildM3UToWriter writes M3U content to the provided io.Writer.
 // This allows streaming output to avoid large memory allocations.
-func buildM3UToWriter(w io.Writer, groups []string) (err error) {
+// Only the channels of the output profile are written.
+func buildM3UToWriter(w io.Writer, groups []string, profile OutputProfile) (err error) {
 	var imgc = Data.Cache.Images
 
-	// M3UChannelData is a slimmed-down version of XEPGChannelStruct
-	// containing only fields necessary for M3U generation and sorting.
-	// This reduces memory overhead significantly compared to copying the full struct.
-	type m3uChannelData struct {
-		XEPG        string
-		XChannelID  string
-		XName       string
-		TvgID       string
-		TvgLogo     string
-		XGroupTitle string
-		FileM3UID   string
-		URL         string
-	}
-
-	// Collect channels to sort
-	type channelWithNum struct {
-		channel m3uChannelData
-		num     float64
-	}
-
 	capacityEstimate := len(Data.XEPG.Channels)
 	if Settings.EpgSource == "PMS" {
 		capacityEstimate = len(Data.Streams.Active)
@@ -261,6 +370,7 @@ func buildM3UToWriter(w io.Writer, groups []string) (err error) {
 
 	switch Settings.EpgSource {
 	case "PMS":
+		var usedURLIDs = make(map[string]string)
 		for i, dsa := range Data.Streams.Active {
 			var stream, ok = dsa.(map[string]string)
 			if !ok {
@@ -275,6 +385,10 @@ func buildM3UToWriter(w io.Writer, groups []string) (err error) {
 			data.TvgLogo = stream["tvg-logo"]
 			data.URL = stream["url"]
 			data.FileM3UID = stream["_file.m3u.id"]
+			data.FileM3UName = stream["_file.m3u.name"]
+			data.EXTINF = stream["_extinf"]
+			data.URLID, _ = getStreamingURLID(data.FileM3UID, data.XName, data.XGroupTitle, stream["tvg-id"], stream["tvg-name"], stream["_uuid.key"], stream["_uuid.value"])
+			data.URLID, _ = uniqueStreamingURLID(usedURLIDs, data.URLID, data.FileM3UID, data.URL)
 
 			// Use tvg-id if present for the tvg-id attribute
 			if tvgID, ok := stream["tvg-id"]; ok && len(tvgID) > 0 {
@@ -291,7 +405,11 @@ func buildM3UToWriter(w io.Writer, groups []string) (err error) {
 				}
 			}
 
-			num, _ := strconv.ParseFloat(data.XChannelID, 64)
+			if !profile.includesGroup(data.XGroupTitle) {
+				continue
+			}
+
+			num, _ := parseChannelNumber(data.XChannelID)
 			tempChannels = append(tempChannels, channelWithNum{
 				channel: data,
 				num:     num,
@@ -300,14 +418,18 @@ func buildM3UToWriter(w io.Writer, groups []string) (err error) {
 
 	case "XEPG":
 		for _, xepgChannel := range Data.XEPG.Channels {
-			if xepgChannel.XActive {
+			if isChannelEnabled(xepgChannel) {
 				if len(groups) > 0 {
 					if !slices.Contains(groups, xepgChannel.XGroupTitle) {
 						continue // Not goto
 					}
 				}
 
-				num, _ := strconv.ParseFloat(xepgChannel.XChannelID, 64)
+				if !profile.includesGroup(xepgChannel.XGroupTitle) {
+					continue
+				}
+
+				num, _ := parseChannelNumber(xepgChannel.XChannelID)
 
 				// Create a slim copy of the data
 				data := m3uChannelData{
@@ -317,26 +439,37 @@ func buildM3UToWriter(w io.Writer, groups []string) (err error) {
 					TvgID:       xepgChannel.TvgID,
 					TvgLogo:     xepgChannel.TvgLogo,
 					XGroupTitle: xepgChannel.XGroupTitle,
+					XMLTVID:     getXMLTVChannelID(xepgChannel),
 					FileM3UID:   xepgChannel.FileM3UID,
+					FileM3UName: xepgChannel.FileM3UName,
 					URL:         xepgChannel.URL,
+					Radio:       xepgChannel.Radio,
+					EXTINF:      xepgChannel.EXTINF,
+
+					Catchup:       xepgChannel.Catchup,
+					CatchupSource: xepgChannel.CatchupSource,
+					CatchupDays:   xepgChannel.CatchupDays,
 				}
+				data.URLID, _ = getStreamingURLID(xepgChannel.FileM3UID, xepgChannel.Name, xepgChannel.GroupTitle, xepgChannel.TvgID, xepgChannel.TvgName, xepgChannel.UUIDKey, xepgChannel.UUIDValue)
 
 				tempChannels = append(tempChannels, channelWithNum{
 					channel: data,
 					num:     num,
+					mapped:  isMappedChannel(xepgChannel),
 				})
 			}
 		}
 	}
 
-	slices.SortFunc(tempChannels, func(a, b channelWithNum) int {
-		return cmp.Compare(a.num, b.num)
-	})
+	sortM3UChannels(tempChannels, profile.sortOrder())
+
+	tempChannels, _ = enforcePlexChannelLimit(tempChannels,
+		func(c channelWithNum) bool { return c.mapped },
+		func(c channelWithNum) string { return c.channel.XName })
 
 	// Create M3U Content
-	var xmltvURL = fmt.Sprintf("%s://%s/xmltv/xteve.xml", System.ServerProtocol.XML, System.Domain)
+	var xmltvURL = fmt.Sprintf("%s://%s/xmltv/%s.xml", System.ServerProtocol.XML, System.Domain, profile.Name)
 
-	// Optimized M3U Header construction
 	// Helper to handle write errors
 	write := func(s string) {
 		if err != nil {
@@ -345,11 +478,7 @@ func buildM3UToWriter(w io.Writer, groups []string) (err error) {
 		_, err = io.WriteString(w, s)
 	}
 
-	write(`#EXTM3U url-tvg="`)
-	write(xmltvURL)
-	write(`" x-tvg-url="`)
-	write(xmltvURL)
-	write("\"\n")
+	write(m3uHeader(xmltvURL))
 
 	for _, tc := range tempChannels {
 		if err != nil {
@@ -358,32 +487,63 @@ func buildM3UToWriter(w io.Writer, groups []string) (err error) {
 
 		channel := tc.channel
 
-		// Use TvgID for tvg-id if it exists, otherwise fall back to XChannelID
-		tvgID := channel.TvgID
+		// XEPG: The tvg-id matches the channel ID in the XMLTV file of xTeVe.
+		// PMS: Use TvgID for tvg-id if it exists, otherwise fall back to XChannelID
+		tvgID := channel.XMLTVID
+		if len(tvgID) == 0 {
+			tvgID = channel.TvgID
+		}
 		if len(tvgID) == 0 {
 			tvgID = channel.XChannelID
 		}
 
-		// Optimized EXTINF line construction
-		write(`#EXTINF:0 channelID="`)
-		write(channel.XEPG)
-		write(`" tvg-chno="`)
-		write(channel.XChannelID)
-		write(`" tvg-name="`)
-		write(channel.XName)
-		write(`" tvg-id="`)
-		write(tvgID)
-		write(`" tvg-logo="`)
-		write(imgc.Image.GetURL(channel.TvgLogo))
-		write(`" group-title="`)
-		write(channel.XGroupTitle)
-		write(`",`)
-		write(channel.XName)
-		write("\n")
-
-		var stream string
+		// m3u.direct.urls: The client connects directly to the provider, xTeVe is not involved in streaming
+		var stream = channel.URL
 		var streamErr error
-		stream, streamErr = createStreamingURL("M3U", channel.FileM3UID, channel.XChannelID, channel.XName, channel.URL)
+		if !profile.directURLs() {
+			stream, streamErr = createStreamingURL("M3U", channel.URLID, channel.FileM3UID, channel.XChannelID, channel.XName, channel.URL)
+		}
+
+		switch {
+		// m3u.passthrough.extinf: The #EXTINF line of the playlist, only the URL is replaced.
+		// The line keeps the catchup attributes of the provider, /catchup/ of xTeVe is not added.
+		case Settings.M3UPassthroughEXTINF && len(channel.EXTINF) > 0:
+			write(channel.EXTINF)
+			write("\n")
+
+		// m3u.format "samsung": Attributes in the order of the Samsung / Tizen IPTV apps
+		case Settings.M3UFormat == "samsung":
+			var catchup string
+			if streamErr == nil {
+				catchup = catchupAttributes(channel, stream, profile.directURLs())
+			}
+			write(samsungEXTINF(tvgID, decorateChannelName(channel.XName), imgc.Image.GetURL(channel.TvgLogo), channel.XChannelID, channel.XGroupTitle, channel.Radio, catchup))
+
+		default:
+			// Optimized EXTINF line construction
+			write(`#EXTINF:0 channelID="`)
+			write(channel.XEPG)
+			write(`" tvg-chno="`)
+			write(channel.XChannelID)
+			write(`" tvg-name="`)
+			write(decorateChannelName(channel.XName))
+			write(`" tvg-id="`)
+			write(tvgID)
+			write(`" tvg-logo="`)
+			write(imgc.Image.GetURL(channel.TvgLogo))
+			write(`" group-title="`)
+			write(channel.XGroupTitle)
+			if channel.Radio {
+				write(`" radio="true`)
+			}
+			if streamErr == nil {
+				write(catchupAttributes(channel, stream, profile.directURLs()))
+			}
+			write(`",`)
+			write(decorateChannelName(channel.XName))
+			write("\n")
+		}
+
 		if streamErr == nil {
 			write(stream)
 			write("\n")
@@ -392,3 +552,209 @@ func buildM3UToWriter(w io.Writer, groups []string) (err error) {
 
 	return err
 }
+
+var m3uHeaderValueReplacer = strings.NewReplacer(`"`, "'", "\r", "", "\n", "")
+
+// m3uHeader : #EXTM3U line with the XMLTV file of xTeVe (url-tvg, x-tvg-url), so that the clients find the EPG.
+// The attributes of m3u.header.attributes are added in alphabetical order, they replace url-tvg and x-tvg-url and an empty value removes them.
+func m3uHeader(xmltvURL string) string {
+	var names = []string{"url-tvg", "x-tvg-url"}
+	for _, name := range slices.Sorted(maps.Keys(Settings.M3UHeaderAttributes)) {
+		if !slices.Contains(names, name) && len(name) > 0 && !strings.ContainsAny(name, " \t\r\n\"=") {
+			names = append(names, name)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U")
+
+	for _, name := range names {
+		var value = xmltvURL
+		if v, ok := Settings.M3UHeaderAttributes[name]; ok {
+			if value = v; len(value) == 0 {
+				continue
+			}
+		}
+
+		b.WriteString(" ")
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(m3uHeaderValueReplacer.Replace(value))
+		b.WriteString(`"`)
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+// Number of lines of the websocket command previewM3U
+const (
+	defaultM3UPreviewLines = 50
+	maxM3UPreviewLines     = 1000
+)
+
+var errM3UPreviewComplete = errors.New("m3u preview complete")
+
+// m3uPreviewWriter keeps the first lines of the M3U and stops buildM3UToWriter afterwards
+type m3uPreviewWriter struct {
+	buf   strings.Builder
+	lines int
+}
+
+func (p *m3uPreviewWriter) Write(b []byte) (int, error) {
+	for i, c := range b {
+		if c == '\n' {
+			p.lines--
+			if p.lines == 0 {
+				p.buf.Write(b[:i+1])
+				return i + 1, errM3UPreviewComplete
+			}
+		}
+	}
+
+	return p.buf.Write(b)
+}
+
+// previewM3U returns the first lines of /m3u/xteve.m3u for the groups (websocket command previewM3U).
+// Read-only, xteve.m3u is not written.
+func previewM3U(groups []string, lines int) ([]string, error) {
+	if lines <= 0 {
+		lines = defaultM3UPreviewLines
+	}
+
+	var preview = m3uPreviewWriter{lines: min(lines, maxM3UPreviewLines)}
+	if err := buildM3UToWriter(&preview, groups, OutputProfile{Name: defaultOutputProfile}); err != nil && !errors.Is(err, errM3UPreviewComplete) {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(preview.buf.String(), "\n"), "\n"), nil
+}
+
+var (
+	samsungValueReplacer = strings.NewReplacer(`"`, "'", "\r", " ", "\n", " ")
+	samsungNameReplacer  = strings.NewReplacer("\r", " ", "\n", " ")
+)
+
+// samsungEXTINF : #EXTINF line for Samsung / Tizen IPTV apps (m3u.format "samsung").
+// The apps expect tvg-id before tvg-name and double quotes around every value, quotes and line breaks in the values are replaced.
+// catchup are the attributes of catchupAttributes, empty for channels without catchup.
+func samsungEXTINF(tvgID, name, logo, chno, group string, radio bool, catchup string) string {
+	var quote = samsungValueReplacer
+
+	var b strings.Builder
+	b.WriteString(`#EXTINF:-1 tvg-id="`)
+	b.WriteString(quote.Replace(tvgID))
+	b.WriteString(`" tvg-name="`)
+	b.WriteString(quote.Replace(name))
+	b.WriteString(`" tvg-logo="`)
+	b.WriteString(quote.Replace(logo))
+	b.WriteString(`" tvg-chno="`)
+	b.WriteString(quote.Replace(chno))
+	b.WriteString(`" group-title="`)
+	b.WriteString(quote.Replace(group))
+	if radio {
+		b.WriteString(`" radio="true`)
+	}
+	b.WriteString(catchup)
+	b.WriteString(`",`)
+	b.WriteString(samsungNameReplacer.Replace(name))
+	b.WriteString("\n")
+	return b.String()
+}
+
+// sortM3UChannels : Sorts the channels of the M3U output according to the sort order (m3u.sort.order).
+// The sort is stable and every mode ends with the same tie-breakers, so channels don't
+// shuffle between rebuilds even though they are collected from a map.
+func sortM3UChannels(channels []channelWithNum, order string) {
+	byNumber := func(a, b channelWithNum) int {
+		return cmp.Or(
+			a.num.Compare(b.num),
+			strings.Compare(a.channel.XName, b.channel.XName),
+			strings.Compare(a.channel.XEPG, b.channel.XEPG),
+		)
+	}
+
+	byName := func(a, b channelWithNum) int {
+		return cmp.Or(
+			strings.Compare(strings.ToLower(a.channel.XName), strings.ToLower(b.channel.XName)),
+			byNumber(a, b),
+		)
+	}
+
+	var compare func(a, b channelWithNum) int
+
+	switch order {
+	case "name":
+		compare = byName
+	case "group-then-name":
+		compare = func(a, b channelWithNum) int {
+			return cmp.Or(
+				compareGroups(a.channel.XGroupTitle, b.channel.XGroupTitle),
+				strings.Compare(strings.ToLower(a.channel.XGroupTitle), strings.ToLower(b.channel.XGroupTitle)),
+				byName(a, b),
+			)
+		}
+	case "provider":
+		compare = func(a, b channelWithNum) int {
+			return cmp.Or(
+				strings.Compare(strings.ToLower(a.channel.FileM3UName), strings.ToLower(b.channel.FileM3UName)),
+				strings.Compare(a.channel.FileM3UID, b.channel.FileM3UID),
+				byNumber(a, b),
+			)
+		}
+	default: // "channel-number"
+		compare = byNumber
+	}
+
+	var pinned = pinnedChannels()
+	slices.SortStableFunc(channels, func(a, b channelWithNum) int {
+		return cmp.Or(
+			cmp.Compare(pinRank(pinned, a.channel.XChannelID, a.channel.XName), pinRank(pinned, b.channel.XChannelID, b.channel.XName)),
+			compare(a, b),
+		)
+	})
+}
+
+// pinnedChannels : Position of the entries of channels.pinned
+func pinnedChannels() map[string]int {
+	var pinned = make(map[string]int, len(Settings.ChannelsPinned))
+	for i, entry := range Settings.ChannelsPinned {
+		if _, ok := pinned[entry]; !ok {
+			pinned[entry] = i
+		}
+	}
+	return pinned
+}
+
+// pinRank : Position of a pinned channel (by channel number or name), channels that are not pinned come after all pinned channels
+func pinRank(pinned map[string]int, channelID, name string) int {
+	if i, ok := pinned[channelID]; ok {
+		return i
+	}
+	if i, ok := pinned[name]; ok {
+		return i
+	}
+	return math.MaxInt
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// decodePlaylist : Converts a playlist with the given charset (e.g. ISO-8859-1) into UTF-8 and removes the UTF-8 BOM.
+// An empty charset means the playlist is already UTF-8.
+func decodePlaylist(content []byte, charset string) ([]byte, error) {
+	charset = strings.TrimSpace(charset)
+
+	if len(charset) > 0 && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "utf8") {
+		encoding, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported playlist encoding %q: %w", charset, err)
+		}
+
+		content, err = encoding.NewDecoder().Bytes(content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bytes.TrimPrefix(content, utf8BOM), nil
+}
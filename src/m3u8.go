@@ -1,10 +1,13 @@
 package src
 
 import (
+	"crypto/aes"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -49,11 +52,75 @@ func parseM3U8Parameter(line string, segment *Segment, stream *ThisStream, seque
 		}
 	} else if strings.HasPrefix(line, "#EXT-X-PLAYLIST-TYPE:") {
 		segment.PlaylistType = line[21:]
+	} else if strings.HasPrefix(line, "#EXT-X-KEY:") {
+		return parseM3U8Key(line[11:], stream)
 	}
 
 	return nil
 }
 
+// parseM3U8Attributes splits the attribute list of a tag (NAME=VALUE,NAME="VALUE")
+func parseM3U8Attributes(value string) map[string]string {
+	var attributes = make(map[string]string)
+
+	for len(value) > 0 {
+		name, rest, found := strings.Cut(value, "=")
+		if !found {
+			break
+		}
+
+		var attribute string
+		if strings.HasPrefix(rest, `"`) {
+			attribute, rest, _ = strings.Cut(rest[1:], `"`)
+			_, rest, _ = strings.Cut(rest, ",")
+		} else {
+			attribute, rest, _ = strings.Cut(rest, ",")
+		}
+
+		attributes[strings.TrimSpace(name)] = attribute
+		value = rest
+	}
+
+	return attributes
+}
+
+// parseM3U8Key parses #EXT-X-KEY, the key applies to the following segments of the playlist
+func parseM3U8Key(value string, stream *ThisStream) error {
+	var attributes = parseM3U8Attributes(value)
+
+	// Keys of DRM systems (e.g. Widevine) are listed next to the AES-128 key
+	if format, ok := attributes["KEYFORMAT"]; ok && format != "identity" {
+		return nil
+	}
+
+	var key = HLSKey{Method: strings.ToUpper(attributes["METHOD"])}
+	if key.Method == "NONE" {
+		stream.HLSKey = HLSKey{}
+		return nil
+	}
+
+	if uri := attributes["URI"]; len(uri) > 0 {
+		var keySegment Segment
+		parseM3U8URL(uri, &keySegment, stream)
+		key.URI = keySegment.URL
+	}
+
+	if iv := attributes["IV"]; len(iv) > 0 {
+		if len(iv) > 2 && (iv[:2] == "0x" || iv[:2] == "0X") {
+			iv = iv[2:]
+		}
+
+		var err error
+		key.IV, err = hex.DecodeString(iv)
+		if err != nil || len(key.IV) != aes.BlockSize {
+			return fmt.Errorf("invalid IV in #EXT-X-KEY: %s", attributes["IV"])
+		}
+	}
+
+	stream.HLSKey = key
+	return nil
+}
+
 // parseM3U8URL resolves the URL for a segment or playlist
 func parseM3U8URL(line string, segment *Segment, stream *ThisStream) {
 	// Optimization: Check prefixes to avoid expensive url.Parse calls.
@@ -90,6 +157,15 @@ func parseM3U8URL(line string, segment *Segment, stream *ThisStream) {
 	}
 }
 
+// isVODPlaylist reports whether an HLS playlist is complete (VOD) instead of live
+func isVODPlaylist(body string) bool {
+	return strings.Contains(body, "#EXT-X-ENDLIST") || strings.Contains(body, "#EXT-X-PLAYLIST-TYPE:VOD")
+}
+
+// hlsPlaylistTypes remembers for the streams that are played with the buffer whether the HLS playlist is VOD (stream URL -> bool).
+// The entry is removed when the stream ends.
+var hlsPlaylistTypes sync.Map
+
 func ParseM3U8(stream *ThisStream) (err error) {
 	var noNewSegment = false
 	var lastSegmentDuration float64
@@ -99,11 +175,12 @@ func ParseM3U8(stream *ThisStream) (err error) {
 	var m3u8Segments []Segment
 
 	stream.DynamicBandwidth = false
+	stream.HLSKey = HLSKey{}
 
 	// Optimization: Avoid formatting debug string unless debug level is sufficient
-	if System.Flag.Debug >= 3 {
+	if debugLevel(debugBuffer) >= 3 {
 		var debug = fmt.Sprintf(`M3U8 Playlist:`+"\n"+`%s`, stream.Body)
-		showDebug(debug, 3)
+		showSubsystemDebug(debugBuffer, debug, 3)
 	}
 
 	if strings.Contains(stream.Body, "#EXTM3U") {
@@ -163,6 +240,7 @@ func ParseM3U8(stream *ThisStream) (err error) {
 
 					if len(segment.URL) > 0 {
 						segment.Sequence = sequence
+						segment.Key = stream.HLSKey
 						m3u8Segments = append(m3u8Segments, segment)
 						sequence++
 					}
@@ -175,9 +253,13 @@ func ParseM3U8(stream *ThisStream) (err error) {
 		return
 	}
 
+	stream.VOD = isVODPlaylist(stream.Body)
+	if len(m3u8Segments) > 0 && len(stream.URL) > 0 {
+		hlsPlaylistTypes.Store(stream.URL, stream.VOD)
+	}
+
 	if len(m3u8Segments) > 0 {
-		isVOD := strings.Contains(stream.Body, "#EXT-X-ENDLIST") || strings.Contains(stream.Body, "#EXT-X-PLAYLIST-TYPE:VOD")
-		if !stream.Status && isVOD {
+		if !stream.Status && stream.VOD {
 			stream.Segment = m3u8Segments
 			return nil
 		}
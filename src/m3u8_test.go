@@ -92,3 +92,69 @@ func TestParseM3U8_VOD(t *testing.T) {
 		t.Fatalf("Expected first queued segment sequence to be 1, got %d", stream.Segment[0].Sequence)
 	}
 }
+
+func TestParseM3U8_PlaylistType(t *testing.T) {
+	Settings.BufferSize = 1
+	System.Flag.Debug = 0
+
+	tests := []struct {
+		name string
+		url  string
+		body string
+		vod  bool
+	}{
+		{"VOD with ENDLIST", "http://example.com/movie", "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:1\n#EXTINF:2.0,\n/seg1.ts\n#EXTINF:2.0,\n/seg2.ts\n#EXT-X-ENDLIST\n", true},
+		{"VOD playlist type", "http://example.com/episode", "#EXTM3U\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXT-X-TARGETDURATION:2\n#EXTINF:2.0,\n/seg1.ts\n", true},
+		{"live", "http://example.com/live", "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:7\n#EXTINF:2.0,\n/seg7.ts\n#EXTINF:2.0,\n/seg8.ts\n", false},
+		{"live event", "http://example.com/event", "#EXTM3U\n#EXT-X-PLAYLIST-TYPE:EVENT\n#EXT-X-TARGETDURATION:2\n#EXTINF:2.0,\n/seg1.ts\n#EXTINF:2.0,\n/seg2.ts\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { hlsPlaylistTypes.Delete(tt.url) })
+
+			stream := ThisStream{URL: tt.url, URLStreamingServer: "http://example.com", Body: tt.body}
+			if err := ParseM3U8(&stream); err != nil {
+				t.Fatalf("ParseM3U8 failed: %v", err)
+			}
+
+			if stream.VOD != tt.vod {
+				t.Errorf("Expected VOD to be %t, got %t", tt.vod, stream.VOD)
+			}
+
+			// The WebDAV categorization uses the type of the playlist instead of the URL
+			if got := isVOD(map[string]string{"url": tt.url}); got != tt.vod {
+				t.Errorf("Expected isVOD to be %t, got %t", tt.vod, got)
+			}
+		})
+	}
+
+	// Streams that were not played are categorized by the URL
+	if isVOD(map[string]string{"url": "http://example.com/other"}) {
+		t.Errorf("Expected unknown stream to be live")
+	}
+}
+
+func TestHLSPlaylistTypes_RemovedWithStream(t *testing.T) {
+	const playlistID, streamURL = "M_hls_types", "http://example.com/movie.m3u8"
+
+	for _, force := range []bool{false, true} {
+		playlist := &Playlist{
+			PlaylistID: playlistID,
+			Streams:    map[int]ThisStream{0: {URL: streamURL, MD5: "hls-types", PlaylistID: playlistID}},
+			Clients:    make(map[int]ThisClient),
+			Tuner:      1,
+		}
+		BufferInformation.Store(playlistID, playlist)
+		BufferClients.Store(playlistID+"hls-types", &ClientConnection{Connection: 1})
+		hlsPlaylistTypes.Store(streamURL, true)
+
+		killClientConnection(0, playlistID, force)
+
+		if _, ok := hlsPlaylistTypes.Load(streamURL); ok {
+			t.Errorf("force=%t: the playlist type was kept after the stream ended", force)
+		}
+		BufferInformation.Delete(playlistID)
+		BufferClients.Delete(playlistID + "hls-types")
+	}
+}
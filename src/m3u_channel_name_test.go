@@ -0,0 +1,39 @@
+package src
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelNamePrefixSuffix(t *testing.T) {
+	setupOutputProfileTest(t)
+	Settings.ChannelNamePrefix = "[xTeVe] "
+	Settings.ChannelNameSuffix = " (IPTV)"
+
+	// M3U
+	var sb strings.Builder
+	require.NoError(t, buildM3UToWriter(&sb, []string{}, OutputProfile{Name: defaultOutputProfile}))
+	assert.Equal(t, []string{"[xTeVe] Zeta News (IPTV)", "[xTeVe] Alpha Sport (IPTV)", "[xTeVe] Beta Movies (IPTV)"}, m3uChannelNames(sb.String()))
+	assert.Contains(t, sb.String(), `tvg-name="[xTeVe] Zeta News (IPTV)"`)
+
+	// XMLTV
+	channel := createChannelElements(Data.XEPG.Channels["x-ID.1"], nil)
+	require.Len(t, channel.DisplayNames, 1)
+	assert.Equal(t, "[xTeVe] Zeta News (IPTV)", channel.DisplayNames[0].Value)
+
+	// Lineup
+	System.File.URLS = System.Folder.Data + "urls.json"
+	content, err := getLineup("")
+	require.NoError(t, err)
+	var lineup []LineupStream
+	require.NoError(t, json.Unmarshal(content, &lineup))
+	require.NotEmpty(t, lineup)
+	assert.Equal(t, "[xTeVe] Zeta News (IPTV)", lineup[0].GuideName)
+
+	// The stored name is not changed
+	assert.Equal(t, "Zeta News", Data.XEPG.Channels["x-ID.1"].XName)
+}
@@ -0,0 +1,38 @@
+package src
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildM3U_Header(t *testing.T) {
+	setupOutputProfileTest(t)
+
+	header := func(profile string) string {
+		var sb strings.Builder
+		require.NoError(t, buildM3UToWriter(&sb, []string{}, OutputProfile{Name: profile}))
+		line, _, _ := strings.Cut(sb.String(), "\n")
+		return line
+	}
+
+	// The XMLTV file of xTeVe, also for the output profiles
+	assert.Equal(t, `#EXTM3U url-tvg="http://localhost:34400/xmltv/xteve.xml" x-tvg-url="http://localhost:34400/xmltv/xteve.xml"`, header(defaultOutputProfile))
+	assert.Equal(t, `#EXTM3U url-tvg="http://localhost:34400/xmltv/plex.xml" x-tvg-url="http://localhost:34400/xmltv/plex.xml"`, header("plex"))
+
+	Settings.M3UHeaderAttributes = map[string]string{
+		"tvg-shift": "0",
+		"charset":   "UTF-8",
+		"x-tvg-url": "",
+		"url-tvg":   "http://epg.example/guide.xml",
+		"catchup":   `"shift"`,
+		"bad name":  "ignored",
+	}
+	assert.Equal(t, `#EXTM3U url-tvg="http://epg.example/guide.xml" catchup="'shift'" charset="UTF-8" tvg-shift="0"`, header(defaultOutputProfile))
+
+	// Only the configured attributes are changed
+	Settings.M3UHeaderAttributes = map[string]string{"charset": "UTF-8"}
+	assert.Equal(t, `#EXTM3U url-tvg="http://localhost:34400/xmltv/xteve.xml" x-tvg-url="http://localhost:34400/xmltv/xteve.xml" charset="UTF-8"`, header(defaultOutputProfile))
+}
@@ -0,0 +1,79 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterThisStream_Reason(t *testing.T) {
+	oldData := Data
+	t.Cleanup(func() { Data = oldData })
+
+	Data.Filter = nil
+	status, reason := FilterThisStream(map[string]string{"name": "News 1", "group-title": "news"})
+	assert.False(t, status)
+	assert.Equal(t, "no filter defined", reason)
+
+	Data.Filter = []Filter{{
+		Type:             "group-title",
+		Rule:             "News {HD}",
+		CompiledRule:     "news",
+		CompiledInclude:  "hd",
+		PreparsedInclude: []string{"hd"},
+	}}
+
+	status, reason = FilterThisStream(map[string]string{"name": "Sport", "group-title": "Sport"})
+	assert.False(t, status)
+	assert.Equal(t, "no filter matched", reason)
+
+	status, reason = FilterThisStream(map[string]string{"name": "News 1", "group-title": "News"})
+	assert.False(t, status)
+	assert.Equal(t, `include condition of filter "News {HD}" not met`, reason)
+
+	Data.Filter = []Filter{{
+		Type:             "custom-filter",
+		Rule:             "news !{sd}",
+		CompiledRule:     "news",
+		CompiledExclude:  "sd",
+		PreparsedExclude: []string{"sd"},
+	}}
+
+	status, reason = FilterThisStream(map[string]string{"name": "News 1", "_values": "News 1 SD"})
+	assert.False(t, status)
+	assert.Equal(t, `excluded by filter "news !{sd}"`, reason)
+
+	status, reason = FilterThisStream(map[string]string{"name": "News 1", "_values": "News 1 HD"})
+	assert.True(t, status)
+	assert.Empty(t, reason)
+}
+
+func TestAPI_StreamsInactive(t *testing.T) {
+	oldData := Data
+	t.Cleanup(func() { Data = oldData })
+
+	Data.Streams.Inactive = []any{
+		map[string]string{"name": "Channel X", "group-title": "Sport", "_file.m3u.name": "Provider", "_filter.reason": "no filter matched"},
+	}
+
+	body, err := json.Marshal(map[string]string{"cmd": "streams.inactive"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/", bytes.NewBuffer(body))
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	API(w, req)
+
+	var response APIResponseStruct
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.True(t, response.Status, response.Error)
+	assert.Equal(t, []InactiveStreamStruct{
+		{Name: "Channel X", GroupTitle: "Sport", Playlist: "Provider", Reason: "no filter matched"},
+	}, response.StreamsInactive)
+}
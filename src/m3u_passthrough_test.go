@@ -0,0 +1,48 @@
+package src
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	m3u "xteve/src/internal/m3u-parser"
+)
+
+func TestBuildM3U_PassthroughEXTINF(t *testing.T) {
+	setupOutputProfileTest(t)
+
+	const extinf = `#EXTINF:-1 tvg-id="zeta.uk" tvg-name="Zeta" catchup="shift" catchup-days="7" tvg-rec="3" group-title="News",Zeta News HD`
+	var playlist = "#EXTM3U\n" + extinf + "\nhttp://provider.example/1.ts\n"
+
+	streams, err := m3u.MakeInterfaceFromM3U([]byte(playlist))
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+
+	// The original line is kept through the XEPG database
+	var m3uChannel M3UChannelStructXEPG
+	bindMapToM3UChannelStruct(streams[0].(map[string]string), &m3uChannel)
+	require.Equal(t, extinf, m3uChannel.EXTINF)
+
+	var channel = Data.XEPG.Channels["x-ID.1"]
+	channel.EXTINF = m3uChannel.EXTINF
+	Data.XEPG.Channels["x-ID.1"] = channel
+
+	build := func() []string {
+		var sb strings.Builder
+		require.NoError(t, buildM3UToWriter(&sb, []string{}, OutputProfile{Name: defaultOutputProfile}))
+		return strings.Split(strings.TrimSpace(sb.String()), "\n")
+	}
+
+	Settings.M3UPassthroughEXTINF = true
+	var lines = build()
+	require.Len(t, lines, 7)
+	assert.Equal(t, extinf, lines[1], "the attributes of the provider are reproduced exactly")
+	assert.True(t, strings.HasPrefix(lines[2], "http://localhost:34400/stream/"), "only the URL is replaced")
+	assert.True(t, strings.HasPrefix(lines[3], `#EXTINF:0 channelID="x-ID.2"`), "channels without the original line use the xTeVe attributes")
+
+	Settings.M3UPassthroughEXTINF = false
+	lines = build()
+	assert.True(t, strings.HasPrefix(lines[1], `#EXTINF:0 channelID="x-ID.1"`))
+}
@@ -0,0 +1,62 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewM3U(t *testing.T) {
+	setupOutputProfileTest(t)
+	Settings.AuthenticationWEB = false
+	System.ConfigurationWizard = false
+
+	full := func(groups []string) []string {
+		var sb strings.Builder
+		require.NoError(t, buildM3UToWriter(&sb, groups, OutputProfile{Name: defaultOutputProfile}))
+		return strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	}
+
+	var lines = full(nil)
+	require.Len(t, lines, 7)
+
+	preview, err := previewM3U(nil, 3)
+	require.NoError(t, err)
+	assert.Equal(t, lines[:3], preview, "the preview is the beginning of the full output")
+
+	preview, err = previewM3U(nil, 100)
+	require.NoError(t, err)
+	assert.Equal(t, lines, preview)
+
+	preview, err = previewM3U([]string{"Sport", "Movies"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, full([]string{"Sport", "Movies"}), preview)
+	assert.Len(t, preview, 5)
+
+	assert.NoFileExists(t, System.Folder.Data+"xteve.m3u", "the preview is read-only")
+
+	// Websocket command
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.WriteJSON(map[string]any{"cmd": "previewM3U", "groups": []string{"News"}, "lines": 2}))
+
+	var response struct {
+		Status     bool     `json:"status"`
+		M3UPreview []string `json:"m3uPreview"`
+	}
+	require.NoError(t, ws.ReadJSON(&response))
+	assert.True(t, response.Status)
+	assert.Equal(t, full([]string{"News"})[:2], response.M3UPreview)
+}
@@ -0,0 +1,50 @@
+package src
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildM3U_SamsungFormat(t *testing.T) {
+	setupOutputProfileTest(t)
+	Settings.M3UFormat = "samsung"
+
+	var channel = Data.XEPG.Channels["x-ID.1"]
+	channel.XGroupTitle = `UK: "Live" News`
+	channel.Radio = true
+	Data.XEPG.Channels["x-ID.1"] = channel
+
+	var sb strings.Builder
+	require.NoError(t, buildM3UToWriter(&sb, []string{}, OutputProfile{Name: defaultOutputProfile}))
+
+	var extinf []string
+	for line := range strings.Lines(sb.String()) {
+		if strings.HasPrefix(line, "#EXTINF") {
+			extinf = append(extinf, strings.TrimSpace(line))
+		}
+	}
+	require.Len(t, extinf, 3)
+
+	assert.Equal(t, `#EXTINF:-1 tvg-id="1" tvg-name="Zeta News" tvg-logo="" tvg-chno="1" group-title="UK: 'Live' News" radio="true",Zeta News`, extinf[0])
+
+	// The order of the attributes the Samsung apps expect
+	var attribute = regexp.MustCompile(`([a-z-]+)="[^"]*"`)
+	for _, line := range extinf {
+		var keys []string
+		for _, match := range attribute.FindAllStringSubmatch(line, -1) {
+			keys = append(keys, match[1])
+		}
+		assert.Equal(t, []string{"tvg-id", "tvg-name", "tvg-logo", "tvg-chno", "group-title"}, keys[:5], line)
+		assert.NotContains(t, line, "channelID")
+	}
+
+	// Default format
+	Settings.M3UFormat = "xteve"
+	sb.Reset()
+	require.NoError(t, buildM3UToWriter(&sb, []string{}, OutputProfile{Name: defaultOutputProfile}))
+	assert.Contains(t, sb.String(), `#EXTINF:0 channelID="x-ID.1" tvg-chno="1" tvg-name="Zeta News" tvg-id="1"`)
+}
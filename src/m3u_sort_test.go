@@ -2,6 +2,7 @@ package src
 
 import (
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"xteve/src/internal/imgcache"
@@ -45,7 +46,7 @@ func TestBuildM3U_Sorting(t *testing.T) {
 	System.ServerProtocol.XML = "http"
 	System.ServerProtocol.DVR = "http"
 	System.Domain = "localhost:34400"
-	System.Folder.Data = ""
+	System.Folder.Data = t.TempDir() + string(os.PathSeparator)
 
 	// Setup: Initialize caches
 	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
@@ -90,3 +91,121 @@ func TestBuildM3U_Sorting(t *testing.T) {
 		t.Errorf("Order incorrect: Channel 5.5 (idx %d) should be before Channel 10 (idx %d)", idx5, idx10)
 	}
 }
+
+func TestSortM3UChannels_Modes(t *testing.T) {
+	newChannel := func(id, num, name, group, provider string) channelWithNum {
+		n, _ := parseChannelNumber(num)
+		return channelWithNum{
+			channel: m3uChannelData{
+				XEPG:        id,
+				XChannelID:  num,
+				XName:       name,
+				XGroupTitle: group,
+				FileM3UID:   "M" + provider,
+				FileM3UName: provider,
+			},
+			num: n,
+		}
+	}
+
+	input := []channelWithNum{
+		newChannel("x1", "10", "Zeta", "News", "Beta"),
+		newChannel("x2", "2", "alpha", "Sports", "Alpha"),
+		newChannel("x3", "5.5", "Beta", "News", "Alpha"),
+		newChannel("x4", "1", "Gamma", "Sports", "Beta"),
+	}
+
+	tests := []struct {
+		order    string
+		expected []string
+	}{
+		{order: "", expected: []string{"x4", "x2", "x3", "x1"}},
+		{order: "channel-number", expected: []string{"x4", "x2", "x3", "x1"}},
+		{order: "name", expected: []string{"x2", "x3", "x4", "x1"}},
+		{order: "group-then-name", expected: []string{"x3", "x1", "x2", "x4"}},
+		{order: "provider", expected: []string{"x2", "x3", "x4", "x1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			// Run several times with a shuffled input to make sure the result is deterministic
+			for i := range len(input) {
+				channels := slices.Clone(input)
+				slices.Reverse(channels[i:])
+
+				sortM3UChannels(channels, tt.order)
+
+				var got = make([]string, 0, len(channels))
+				for _, c := range channels {
+					got = append(got, c.channel.XEPG)
+				}
+
+				if !slices.Equal(got, tt.expected) {
+					t.Errorf("sort order %q: expected %v, got %v", tt.order, tt.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSortM3UChannels_GroupOrder(t *testing.T) {
+	originalOrder := Settings.GroupOrder
+	t.Cleanup(func() { Settings.GroupOrder = originalOrder })
+	Settings.GroupOrder = []string{"Sports", "Movies"}
+
+	channels := []channelWithNum{
+		{channel: m3uChannelData{XEPG: "x1", XName: "A", XGroupTitle: "News"}},
+		{channel: m3uChannelData{XEPG: "x2", XName: "B", XGroupTitle: "Movies"}},
+		{channel: m3uChannelData{XEPG: "x3", XName: "C", XGroupTitle: "Kids"}},
+		{channel: m3uChannelData{XEPG: "x4", XName: "D", XGroupTitle: "Sports"}},
+		{channel: m3uChannelData{XEPG: "x5", XName: "A", XGroupTitle: "Sports"}},
+	}
+
+	sortM3UChannels(channels, "group-then-name")
+
+	var got []string
+	for _, c := range channels {
+		got = append(got, c.channel.XEPG)
+	}
+
+	if expected := []string{"x5", "x4", "x2", "x3", "x1"}; !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestSortM3UChannels_TieBreaker(t *testing.T) {
+	// Channels with the same number and name must still be ordered deterministically
+	channels := []channelWithNum{
+		{channel: m3uChannelData{XEPG: "x2", XName: "Same"}, num: channelNumber{Major: 1}},
+		{channel: m3uChannelData{XEPG: "x1", XName: "Same"}, num: channelNumber{Major: 1}},
+	}
+
+	sortM3UChannels(channels, "channel-number")
+
+	if channels[0].channel.XEPG != "x1" || channels[1].channel.XEPG != "x2" {
+		t.Errorf("expected tie to be broken by XEPG ID, got %s, %s", channels[0].channel.XEPG, channels[1].channel.XEPG)
+	}
+}
+
+func TestSortM3UChannels_Pinned(t *testing.T) {
+	originalPinned := Settings.ChannelsPinned
+	t.Cleanup(func() { Settings.ChannelsPinned = originalPinned })
+
+	// Pinned by name and by channel number, in the order of the setting
+	Settings.ChannelsPinned = []string{"Zeta", "5.5", "Unknown"}
+
+	for _, order := range []string{"channel-number", "name", "group-then-name", "provider"} {
+		channels := []channelWithNum{
+			{channel: m3uChannelData{XEPG: "x1", XChannelID: "10", XName: "Zeta", XGroupTitle: "News"}, num: channelNumber{Major: 10}},
+			{channel: m3uChannelData{XEPG: "x2", XChannelID: "2", XName: "alpha", XGroupTitle: "Sports"}, num: channelNumber{Major: 2}},
+			{channel: m3uChannelData{XEPG: "x3", XChannelID: "5.5", XName: "Beta", XGroupTitle: "News"}, num: channelNumber{Major: 5, Minor: 5}},
+			{channel: m3uChannelData{XEPG: "x4", XChannelID: "1", XName: "Gamma", XGroupTitle: "Sports"}, num: channelNumber{Major: 1}},
+		}
+
+		sortM3UChannels(channels, order)
+
+		if channels[0].channel.XEPG != "x1" || channels[1].channel.XEPG != "x3" {
+			t.Errorf("sort order %q: expected the pinned channels x1, x3 first, got %s, %s", order, channels[0].channel.XEPG, channels[1].channel.XEPG)
+		}
+	}
+}
@@ -2,10 +2,13 @@ package src
 
 import (
 	"os"
+	"strings"
 	"testing"
 	"xteve/src/internal/imgcache"
+	m3u "xteve/src/internal/m3u-parser"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFilterThisStream_GroupTitle_Bug(t *testing.T) {
@@ -36,7 +39,7 @@ func TestFilterThisStream_GroupTitle_Bug(t *testing.T) {
 	Data.Filter = []Filter{filter}
 
 	// Execute
-	result := FilterThisStream(stream)
+	result, _ := FilterThisStream(stream)
 
 	// Assert: This confirms that we are correctly matching within the Group Title
 	assert.True(t, result, "Stream should be matched by the filter")
@@ -66,7 +69,7 @@ func TestFilterThisStream_GroupTitle_Isolation(t *testing.T) {
 	Data.Filter = []Filter{filter}
 
 	// Execute
-	result := FilterThisStream(stream)
+	result, _ := FilterThisStream(stream)
 
 	// Assert: Should be False because we check against Group Title ("News"), which does not contain "Report".
 	assert.False(t, result, "Stream should NOT be matched because 'Report' is not in group-title")
@@ -92,7 +95,7 @@ func TestFilterThisStream_CustomFilter(t *testing.T) {
 	Data.Filter = []Filter{filter}
 
 	// Execute
-	result := FilterThisStream(stream)
+	result, _ := FilterThisStream(stream)
 
 	// Assert
 	assert.True(t, result, "Stream should be matched by the custom filter")
@@ -118,7 +121,7 @@ func TestFilterThisStream_GroupTitle_SpecialCharacters(t *testing.T) {
 	Data.Filter = []Filter{filter}
 
 	// Execute
-	result := FilterThisStream(stream)
+	result, _ := FilterThisStream(stream)
 
 	// Assert
 	assert.True(t, result, "Stream should be matched by the filter with special characters")
@@ -144,7 +147,7 @@ func TestFilterThisStream_GroupTitle_UnicodeCharacters(t *testing.T) {
 	Data.Filter = []Filter{filter}
 
 	// Execute
-	result := FilterThisStream(stream)
+	result, _ := FilterThisStream(stream)
 
 	// Assert
 	assert.True(t, result, "Stream should be matched by the filter with unicode characters")
@@ -178,8 +181,10 @@ func TestFilterThisStream_ExcludeExactPhrase(t *testing.T) {
 	Data.Filter = []Filter{filter}
 
 	// Execute and Assert
-	assert.True(t, FilterThisStream(streamToKeep), "CSPAN should be kept")
-	assert.False(t, FilterThisStream(streamToExclude), "CSPAN 2 should be excluded")
+	keep, _ := FilterThisStream(streamToKeep)
+	exclude, _ := FilterThisStream(streamToExclude)
+	assert.True(t, keep, "CSPAN should be kept")
+	assert.False(t, exclude, "CSPAN 2 should be excluded")
 }
 
 func TestBuildM3U_PMSSource(t *testing.T) {
@@ -209,7 +214,7 @@ func TestBuildM3U_PMSSource(t *testing.T) {
 	System.ServerProtocol.XML = "http"
 	System.ServerProtocol.DVR = "http"
 	System.Domain = "localhost:34400"
-	System.Folder.Data = "" // To avoid writing file
+	System.Folder.Data = t.TempDir() + string(os.PathSeparator) // buildM3U writes xteve.m3u
 
 	// Setup: Initialize caches
 	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
@@ -231,3 +236,303 @@ func TestBuildM3U_PMSSource(t *testing.T) {
 	// The failing assertion:
 	assert.Contains(t, m3u, `tvg-name="Channel 1"`, "M3U should contain channel 1")
 }
+
+func TestParsePlaylist_BOM(t *testing.T) {
+	originalSettings := Settings
+	t.Cleanup(func() { Settings = originalSettings })
+
+	tempDir := t.TempDir()
+	filename := tempDir + "/MBOM.m3u"
+	playlist := append([]byte{0xEF, 0xBB, 0xBF}, []byte("#EXTM3U\n#EXTINF:-1 tvg-id=\"one\" group-title=\"News\",Channel One\nhttp://example.com/1\n")...)
+	assert.NoError(t, os.WriteFile(filename, playlist, 0644))
+
+	channels, err := parsePlaylist(filename, "m3u")
+	assert.NoError(t, err)
+	if assert.Len(t, channels, 1) {
+		stream := channels[0].(map[string]string)
+		assert.Equal(t, "Channel One", stream["name"])
+		assert.Equal(t, "News", stream["group-title"])
+	}
+}
+
+func TestDecodePlaylist_Latin1(t *testing.T) {
+	// "Télé Café" and "Süd" encoded as ISO-8859-1
+	playlist := []byte("#EXTM3U\n#EXTINF:-1 group-title=\"S\xfcd\",T\xe9l\xe9 Caf\xe9\nhttp://example.com/1\n")
+
+	content, err := decodePlaylist(playlist, "ISO-8859-1")
+	assert.NoError(t, err)
+
+	channels, err := m3u.MakeInterfaceFromM3U(content)
+	assert.NoError(t, err)
+	if assert.Len(t, channels, 1) {
+		stream := channels[0].(map[string]string)
+		assert.Equal(t, "Télé Café", stream["name"])
+		assert.Equal(t, "Süd", stream["group-title"])
+	}
+
+	_, err = decodePlaylist(playlist, "no-such-charset")
+	assert.Error(t, err)
+}
+
+func TestBuildM3U_DirectURLs(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	Settings.EpgSource = "XEPG"
+	System.ServerProtocol.M3U = "http"
+	System.ServerProtocol.XML = "http"
+	System.Domain = "localhost:34400"
+	System.Folder.Data = t.TempDir() + string(os.PathSeparator)
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {
+			XActive:     true,
+			XChannelID:  "1001",
+			XName:       "Channel 1",
+			XGroupTitle: "News",
+			XEPG:        "x-ID.1",
+			FileM3UID:   "M1",
+			URL:         "http://provider.example/live/1.ts",
+		},
+	}
+
+	var err error
+	Data.Cache.Images, err = imgcache.New(t.TempDir(), "", false, NewHTTPClient())
+	assert.NoError(t, err)
+
+	Settings.M3UDirectURLs = false
+	proxied, err := buildM3U([]string{})
+	assert.NoError(t, err)
+	assert.Contains(t, proxied, "http://localhost:34400/stream/")
+	assert.NotContains(t, proxied, "http://provider.example/live/1.ts")
+
+	Settings.M3UDirectURLs = true
+	direct, err := buildM3U([]string{})
+	assert.NoError(t, err)
+	assert.Contains(t, direct, "\nhttp://provider.example/live/1.ts\n")
+	assert.NotContains(t, direct, "/stream/")
+
+	// Numbering and the XEPG attributes are the same in both files
+	assert.Contains(t, direct, `tvg-chno="1001"`)
+	assert.Equal(t, strings.SplitN(proxied, "\n", 3)[:2], strings.SplitN(direct, "\n", 3)[:2])
+}
+
+func TestCreateM3UFile_StableStreamURLs(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	Settings.EpgSource = "XEPG"
+	Settings.M3UDirectURLs = false
+	System.ServerProtocol.M3U = "http"
+	System.ServerProtocol.XML = "http"
+	System.Domain = "localhost:34400"
+	System.File.M3U = t.TempDir() + "/xteve.m3u"
+	System.File.URLS = t.TempDir() + "/urls.json"
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {
+			XActive: true, XChannelID: "1", XName: "Channel 1", XEPG: "x-ID.1", FileM3UID: "M1",
+			Name: "Channel 1", UUIDKey: "CUID", UUIDValue: "1001", URL: "http://provider.example/live/1.ts?token=a",
+		},
+		"x-ID.2": {
+			XActive: true, XChannelID: "2", XName: "Channel 2", XEPG: "x-ID.2", FileM3UID: "M1",
+			Name: "Channel 2", GroupTitle: "News", TvgID: "ch2", URL: "http://provider.example/live/2.ts?token=a",
+		},
+	}
+
+	var err error
+	Data.Cache.Images, err = imgcache.New(t.TempDir(), "", false, NewHTTPClient())
+	require.NoError(t, err)
+
+	streamURLs := func() []string {
+		require.NoError(t, createM3UFile())
+		content, err := os.ReadFile(System.File.M3U)
+		require.NoError(t, err)
+
+		var urls []string
+		for line := range strings.Lines(string(content)) {
+			if strings.Contains(line, "/stream/") {
+				urls = append(urls, strings.TrimSpace(line))
+			}
+		}
+		return urls
+	}
+
+	first := streamURLs()
+	require.Len(t, first, 2)
+	assert.Equal(t, first, streamURLs(), "unchanged channels keep their URL")
+
+	// The provider rotates the token, the /stream/ URL stays the same and points to the new URL
+	for id, channel := range Data.XEPG.Channels {
+		channel.URL = strings.Replace(channel.URL, "token=a", "token=b", 1)
+		Data.XEPG.Channels[id] = channel
+	}
+	assert.Equal(t, first, streamURLs())
+
+	streamInfo, err := getStreamInfo(strings.TrimPrefix(first[0], "http://localhost:34400/stream/"))
+	require.NoError(t, err)
+	assert.Equal(t, "http://provider.example/live/1.ts?token=b", streamInfo.URL)
+}
+
+func TestCreateM3UFile_RadioChannels(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	Settings.EpgSource = "XEPG"
+	Settings.M3UDirectURLs = false
+	Settings.MappingFirstChannel = 1000
+	System.ServerProtocol.M3U = "http"
+	System.ServerProtocol.XML = "http"
+	System.Domain = "localhost:34400"
+	System.File.M3U = t.TempDir() + "/xteve.m3u"
+	System.File.URLS = t.TempDir() + "/urls.json"
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+	Data.XEPG.Channels = make(map[string]XEPGChannelStruct)
+
+	var err error
+	Data.Cache.Images, err = imgcache.New(t.TempDir(), "", false, NewHTTPClient())
+	require.NoError(t, err)
+
+	playlist := `#EXTM3U
+#EXTINF:-1 tvg-id="tv" group-title="TV",TV Channel
+http://provider.example/live/tv.ts
+#EXTINF:-1 tvg-id="radio1" radio="true" group-title="Radio",Radio One
+http://provider.example/live/radio1
+#EXTINF:-1 tvg-id="radio2" group-title="Radio",Radio Two HD
+http://provider.example/radio/2.mp3?token=a
+`
+	streams, err := m3u.MakeInterfaceFromM3U([]byte(playlist))
+	require.NoError(t, err)
+
+	allChannelNumbers := make(map[channelNumber]bool)
+	for _, stream := range streams {
+		var m3uChannel M3UChannelStructXEPG
+		bindMapToM3UChannelStruct(stream.(map[string]string), &m3uChannel)
+		m3uChannel.FileM3UID = "M1"
+		processNewXEPGChannel(m3uChannel, allChannelNumbers)
+	}
+
+	radio := make(map[string]bool)
+	for _, channel := range Data.XEPG.Channels {
+		radio[channel.XName] = channel.Radio
+	}
+	assert.Equal(t, map[string]bool{"TV Channel": false, "Radio One": true, "Radio Two HD": true}, radio)
+
+	require.NoError(t, createM3UFile())
+	content, err := os.ReadFile(System.File.M3U)
+	require.NoError(t, err)
+
+	for line := range strings.Lines(string(content)) {
+		if !strings.HasPrefix(line, "#EXTINF") {
+			continue
+		}
+		if strings.Contains(line, "Radio") {
+			assert.Contains(t, line, ` radio="true"`)
+		} else {
+			assert.NotContains(t, line, "radio=")
+		}
+	}
+}
+
+func TestParsePlaylist_RelativeURLs(t *testing.T) {
+	originalSettings := Settings
+	t.Cleanup(func() { Settings = originalSettings })
+
+	tempDir := t.TempDir()
+	filename := tempDir + "/M1.m3u"
+	playlist := `#EXTM3U
+#EXTINF:-1 tvg-id="one",Absolute
+http://cdn.example.com/1.ts
+#EXTINF:-1 tvg-id="two",Root relative
+/live/2.ts
+#EXTINF:-1 tvg-id="three",Path relative
+live/3.ts?token=abc
+#EXTINF:-1 tvg-id="four",Parent
+../4.m3u8
+#EXTINF:-1 tvg-id="five",Scheme relative
+//other.example.com/5.ts
+`
+	require.NoError(t, os.WriteFile(filename, []byte(playlist), 0644))
+
+	urls := func() map[string]string {
+		channels, err := parsePlaylist(filename, "m3u")
+		require.NoError(t, err)
+
+		urls := make(map[string]string)
+		for _, channel := range channels {
+			stream := channel.(map[string]string)
+			urls[stream["name"]] = stream["url"]
+		}
+		return urls
+	}
+
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{"file.source": "https://provider.example/lists/playlist.m3u?user=a"}}
+	assert.Equal(t, map[string]string{
+		"Absolute":        "http://cdn.example.com/1.ts",
+		"Root relative":   "https://provider.example/live/2.ts",
+		"Path relative":   "https://provider.example/lists/live/3.ts?token=abc",
+		"Parent":          "https://provider.example/4.m3u8",
+		"Scheme relative": "https://other.example.com/5.ts",
+	}, urls())
+
+	// A local file has no base URL
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{"file.source": "/data/playlist.m3u"}}
+	assert.Equal(t, "/live/2.ts", urls()["Root relative"])
+}
+
+func TestCreateM3UFile_PMSStreamsWithSameAttributes(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	Settings.EpgSource = "PMS"
+	Settings.M3UDirectURLs = false
+	System.ServerProtocol.M3U = "http"
+	System.ServerProtocol.DVR = "http"
+	System.Domain = "localhost:34400"
+	System.File.M3U = t.TempDir() + "/xteve.m3u"
+	System.File.URLS = t.TempDir() + "/urls.json"
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+
+	// SD and HD feed with the same name
+	Data.Streams.Active = []any{
+		map[string]string{"name": "Zeta", "group-title": "News", "url": "http://provider.example/zeta-sd.ts", "_file.m3u.id": "M1"},
+		map[string]string{"name": "Zeta", "group-title": "News", "url": "http://provider.example/zeta-hd.ts", "_file.m3u.id": "M1"},
+	}
+
+	var err error
+	Data.Cache.Images, err = imgcache.New(t.TempDir(), "", false, NewHTTPClient())
+	require.NoError(t, err)
+
+	streamURLs := func() (urls []string) {
+		require.NoError(t, createM3UFile())
+		content, err := os.ReadFile(System.File.M3U)
+		require.NoError(t, err)
+
+		for line := range strings.Lines(string(content)) {
+			if strings.Contains(line, "/stream/") {
+				urls = append(urls, strings.TrimSpace(line))
+			}
+		}
+		return
+	}
+
+	urls := streamURLs()
+	require.Len(t, urls, 2)
+	assert.NotEqual(t, urls[0], urls[1])
+	assert.Equal(t, urls, streamURLs(), "the IDs are stable")
+
+	var streams []string
+	for _, u := range urls {
+		streamInfo, err := getStreamInfo(strings.TrimPrefix(u, "http://localhost:34400/stream/"))
+		require.NoError(t, err)
+		streams = append(streams, streamInfo.URL)
+	}
+	assert.ElementsMatch(t, []string{"http://provider.example/zeta-sd.ts", "http://provider.example/zeta-hd.ts"}, streams)
+
+	// lineup.json uses the same IDs
+	content, err := getLineup("")
+	require.NoError(t, err)
+	for _, u := range urls {
+		assert.Contains(t, string(content), u)
+	}
+}
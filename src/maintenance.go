@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -19,56 +21,133 @@ func maintenance() {
 	for {
 		var t = time.Now()
 
-		// Update the playlist and XMLTV files
 		if System.ScanInProgress == 0 {
-			for _, schedule := range Settings.Update {
-				if schedule == t.Format("1504") {
-					showInfo("Update:" + schedule)
-
-					// Create a backup
-					err := xTeVeAutoBackup()
-					if err != nil {
-						ShowError(err, 000)
-					}
-
-					// Update Playlist and XMLTV Files
-					if err := getProviderData(context.Background(), "m3u", ""); err != nil {
-						ShowError(err, 0)
-					}
-					if err := getProviderData(context.Background(), "hdhr", ""); err != nil {
-						ShowError(err, 0)
-					}
-
-					if Settings.EpgSource == "XEPG" {
-						if err := getProviderData(context.Background(), "xmltv", ""); err != nil {
-							ShowError(err, 0)
-						}
-					}
-
-					// Create database for DVR
-					err = buildDatabaseDVR()
-					if err != nil {
-						ShowError(err, 000)
-					}
-
-					if !Settings.CacheImages && System.ImageCachingInProgress == 0 {
-						if err := removeChildItems(System.Folder.ImagesCache); err != nil {
-							ShowError(err, 0)
-						}
-					}
-
-					// Create XEPG Files
-					Data.Cache.XMLTV = make(map[string]XMLTV)
-					if err := buildXEPG(false); err != nil {
-						ShowError(err, 0)
-					}
-				}
-			}
+			disableFailedChannels(t)
+			maintenanceTasks(t.Format("1504"))
 		}
 		time.Sleep(60 * time.Second)
 	}
 }
 
+// maintenanceTasks runs the backup and the provider updates that are scheduled for the time (HHMM)
+func maintenanceTasks(now string) {
+	// Create a backup, the global update times are used even if no provider is due
+	if slices.Contains(Settings.Update, now) {
+		if err := xTeVeAutoBackup(); err != nil {
+			ShowError(err, 000)
+		}
+	}
+
+	// Update the playlist and XMLTV files
+	var due = map[string][]string{
+		"m3u":  dueProviders(Settings.Files.M3U, now),
+		"hdhr": dueProviders(Settings.Files.HDHR, now),
+	}
+
+	if Settings.EpgSource == "XEPG" {
+		due["xmltv"] = dueProviders(Settings.Files.XMLTV, now)
+	}
+
+	if len(due["m3u"])+len(due["hdhr"])+len(due["xmltv"]) > 0 {
+		showInfo("Update:" + now)
+		scheduledUpdate(due)
+	}
+}
+
+// scheduledUpdate refreshes the given providers (file type -> provider IDs) and rebuilds the databases
+func scheduledUpdate(due map[string][]string) {
+	// Update Playlist and XMLTV Files
+	for _, fileType := range []string{"m3u", "hdhr", "xmltv"} {
+		for _, id := range due[fileType] {
+			if err := getProviderData(context.Background(), fileType, id); err != nil {
+				ShowError(err, 0)
+			}
+		}
+	}
+
+	// Create database for DVR
+	if err := buildDatabaseDVR(); err != nil {
+		ShowError(err, 000)
+	}
+
+	if !Settings.CacheImages && System.ImageCachingInProgress == 0 {
+		if err := removeChildItems(System.Folder.ImagesCache); err != nil {
+			ShowError(err, 0)
+		}
+	}
+
+	// Create XEPG Files
+	Data.Cache.XMLTV = make(map[string]XMLTV)
+	if err := buildXEPG(false); err != nil {
+		ShowError(err, 0)
+	}
+}
+
+// dueProviders returns the IDs of all providers whose schedule contains the time (HHMM).
+// Providers without their own "update.times" use the global update times.
+func dueProviders(files map[string]any, now string) (ids []string) {
+	for id, d := range files {
+		data, ok := d.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		schedule := providerUpdateTimes(data)
+		if len(schedule) == 0 {
+			schedule = Settings.Update
+		}
+
+		if slices.Contains(schedule, now) {
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+	return
+}
+
+// providerUpdateTimes returns the update times of a provider, []any after loading settings.json
+func providerUpdateTimes(data map[string]any) (times []string) {
+	switch value := data["update.times"].(type) {
+	case []string:
+		times = value
+	case []any:
+		for _, v := range value {
+			if s, ok := v.(string); ok {
+				times = append(times, s)
+			}
+		}
+	}
+
+	return
+}
+
+// parseUpdateTimes removes spaces from the values and checks the formatting of the time (0000 - 2359)
+func parseUpdateTimes(value any) (updateTimes []string, err error) {
+	values, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid type for update times: expected []any, got %T", value)
+	}
+
+	updateTimes = make([]string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid type in update times array: expected string, got %T", v)
+		}
+
+		s = strings.Replace(s, " ", "", -1)
+		if _, err = time.Parse("1504", s); err != nil {
+			ShowError(err, 1012)
+			return nil, err
+		}
+
+		updateTimes = append(updateTimes, s)
+	}
+
+	return
+}
+
 func randomTime(min, max int) int {
 	return rand.Intn(max-min) + min
 }
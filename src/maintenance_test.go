@@ -0,0 +1,184 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	oldSystem, oldSettings := System, Settings
+	t.Cleanup(func() { System, Settings = oldSystem, oldSettings })
+
+	System.ScanInProgress = 0
+	System.ConfigurationWizard = false
+	System.MaintenanceMode = false
+	Settings.AuthenticationWEB = false
+	Settings.AuthenticationAPI = false
+	Settings.AuthenticationPMS = false
+	Settings.Language = "en"
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{}}
+
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.WriteJSON(map[string]any{"cmd": "setMaintenanceMode", "maintenanceMode": true}))
+
+	var response struct {
+		Status          bool `json:"status"`
+		MaintenanceMode bool `json:"maintenanceMode"`
+	}
+	require.NoError(t, ws.ReadJSON(&response))
+	assert.True(t, response.Status)
+	assert.True(t, response.MaintenanceMode)
+	assert.True(t, System.MaintenanceMode)
+
+	// Web interface
+	req := httptest.NewRequest(http.MethodGet, "/web/", nil)
+	rr := httptest.NewRecorder()
+	Web(rr, req)
+	body, _ := io.ReadAll(rr.Body)
+	assert.Contains(t, string(body), "Maintenance")
+
+	// API
+	apiBody, err := json.Marshal(map[string]string{"cmd": "status"})
+	require.NoError(t, err)
+	req = httptest.NewRequest(http.MethodPost, "/api/", bytes.NewBuffer(apiBody))
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	API(rr, req)
+	assert.Equal(t, http.StatusLocked, rr.Code)
+
+	var apiResponse APIResponseStruct
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&apiResponse))
+	assert.False(t, apiResponse.Status)
+	assert.NotEmpty(t, apiResponse.Error)
+
+	// Clients of the tuner are not affected
+	req = httptest.NewRequest(http.MethodGet, "/discover.json", nil)
+	rr = httptest.NewRecorder()
+	Index(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Leave maintenance mode
+	require.NoError(t, ws.WriteJSON(map[string]any{"cmd": "setMaintenanceMode", "maintenanceMode": false}))
+	require.NoError(t, ws.ReadJSON(&response))
+	assert.False(t, response.MaintenanceMode)
+
+	req = httptest.NewRequest(http.MethodGet, "/web/", nil)
+	rr = httptest.NewRecorder()
+	Web(rr, req)
+	body, _ = io.ReadAll(rr.Body)
+	assert.NotContains(t, string(body), "xTeVe is updating the database")
+}
+
+func TestMaintenanceMode_MissingValue(t *testing.T) {
+	oldSystem, oldSettings := System, Settings
+	t.Cleanup(func() { System, Settings = oldSystem, oldSettings })
+
+	System.ConfigurationWizard = false
+	System.MaintenanceMode = false
+	Settings.AuthenticationWEB = false
+
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.WriteJSON(map[string]string{"cmd": "setMaintenanceMode"}))
+
+	var response ResponseStruct
+	require.NoError(t, ws.ReadJSON(&response))
+	assert.False(t, response.Status)
+	assert.False(t, System.MaintenanceMode)
+}
+
+func TestDueProviders(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	Settings.Update = []string{"0300"}
+
+	files := map[string]any{
+		"M_hourly": map[string]any{"update.times": []any{"0000", "0100", "0200", "0300"}},
+		"M_daily":  map[string]any{"update.times": []string{"0000"}},
+		"M_global": map[string]any{"name": "no own schedule"},
+		"M_empty":  map[string]any{"update.times": []any{}},
+		"M_broken": "not a map",
+	}
+
+	assert.Equal(t, []string{"M_daily", "M_hourly"}, dueProviders(files, "0000"))
+	assert.Equal(t, []string{"M_hourly"}, dueProviders(files, "0100"))
+	assert.Equal(t, []string{"M_empty", "M_global", "M_hourly"}, dueProviders(files, "0300"))
+	assert.Empty(t, dueProviders(files, "1200"))
+}
+
+func TestParseUpdateTimes(t *testing.T) {
+	times, err := parseUpdateTimes([]any{"00 00", "2359"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0000", "2359"}, times)
+
+	_, err = parseUpdateTimes([]any{"2460"})
+	assert.Error(t, err)
+
+	_, err = parseUpdateTimes([]any{1200})
+	assert.Error(t, err)
+
+	_, err = parseUpdateTimes("0000")
+	assert.Error(t, err)
+}
+
+func TestMaintenanceTasks_Backup(t *testing.T) {
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	configDir := t.TempDir() + string(os.PathSeparator)
+	for _, file := range SystemFiles {
+		require.NoError(t, os.WriteFile(configDir+file, []byte("{}"), 0644))
+	}
+
+	System.Folder.Config = configDir
+	System.Folder.ImagesUpload = configDir
+	System.Folder.Backup = t.TempDir() + string(os.PathSeparator)
+	Settings.BackupPath = ""
+	Settings.BackupKeep = 10
+	Settings.TLSMode = false
+	Settings.Update = []string{"0300"}
+	Settings.Files.M3U = map[string]any{}
+	Settings.Files.HDHR = map[string]any{}
+	Settings.Files.XMLTV = map[string]any{}
+
+	backups := func() []string {
+		files, err := filepath.Glob(System.Folder.Backup + "xteve_auto_backup_*.zip")
+		require.NoError(t, err)
+		return files
+	}
+
+	// Not scheduled
+	maintenanceTasks("1200")
+	assert.Empty(t, backups())
+
+	// No provider is due, the backup is created anyway
+	maintenanceTasks("0300")
+	assert.Len(t, backups(), 1)
+}
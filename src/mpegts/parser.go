@@ -3,6 +3,7 @@ package mpegts
 import (
 	"bytes"
 	"io"
+	"slices"
 )
 
 const (
@@ -95,6 +96,156 @@ func ExtractPCR(packet []byte) (pcr int64, ok bool) {
 	return base*300 + ext, true
 }
 
+// ExtractPTS extracts the Presentation Time Stamp from the PES header that
+// starts in an MPEG-TS packet. It returns (pts, true) when the packet starts a
+// PES packet with a PTS, or (0, false) otherwise. The value is in 90 kHz units.
+func ExtractPTS(packet []byte) (pts int64, ok bool) {
+	if len(packet) < PacketSize {
+		return 0, false
+	}
+	// Byte 1, bit 6: payload_unit_start_indicator.
+	if packet[1]&0x40 == 0 {
+		return 0, false
+	}
+	// Byte 3, bit 4: payload present.
+	if packet[3]&0x10 == 0 {
+		return 0, false
+	}
+
+	payload := packet[4:]
+	if packet[3]&0x20 != 0 {
+		// Skip the adaptation field.
+		if 1+int(packet[4]) >= len(payload) {
+			return 0, false
+		}
+		payload = payload[1+int(packet[4]):]
+	}
+
+	// PES start code prefix 0x000001, stream_id, PES_packet_length (2 bytes),
+	// flags (2 bytes), PES_header_data_length, then the 5-byte PTS.
+	if len(payload) < 14 || payload[0] != 0x00 || payload[1] != 0x00 || payload[2] != 0x01 {
+		return 0, false
+	}
+	// PTS_DTS_flags: 0b10 = PTS only, 0b11 = PTS and DTS.
+	if payload[7]&0x80 == 0 {
+		return 0, false
+	}
+
+	b := payload[9:14]
+	pts = int64(b[0]>>1&0x07)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 |
+		int64(b[3])<<7 | int64(b[4]>>1)
+	return pts, true
+}
+
+// RandomAccess reports whether the random_access_indicator of the packet's
+// adaptation field is set. Encoders set it on the first packet of a keyframe
+// (I-frame), a decoder can start with this packet.
+func RandomAccess(packet []byte) bool {
+	if len(packet) < PacketSize {
+		return false
+	}
+	// adaptation_field_control with an adaptation field of at least the flags byte.
+	if packet[3]&0x20 == 0 || packet[4] < 1 {
+		return false
+	}
+	// Byte 5 flags: bit 6 (0x40) is random_access_indicator.
+	return packet[5]&0x40 != 0
+}
+
+// ProgramMapPID returns the PID of the program map table (PMT) of the first
+// program in a PAT packet (PID 0). It returns (0, false) if the packet does
+// not start a PAT section.
+func ProgramMapPID(packet []byte) (pid uint16, ok bool) {
+	section := psiSection(packet, 0x00)
+	// 8-byte header, then 4 bytes per program up to the 4-byte CRC.
+	for i := 8; i+4 <= len(section)-4; i += 4 {
+		// Program number 0 is the network PID.
+		if section[i] == 0 && section[i+1] == 0 {
+			continue
+		}
+		return uint16(section[i+2]&0x1F)<<8 | uint16(section[i+3]), true
+	}
+	return 0, false
+}
+
+// VideoPID returns the PID of the first video stream (MPEG-1/2, MPEG-4,
+// H.264 or H.265) in a PMT packet. It returns (0, false) if the packet does
+// not start a PMT section with a video stream.
+func VideoPID(packet []byte) (pid uint16, ok bool) {
+	section := psiSection(packet, 0x02)
+	if len(section) < 16 {
+		return 0, false
+	}
+	// 12-byte header with the program_info_length, then the descriptors and
+	// 5 bytes per stream plus its ES_info_length up to the 4-byte CRC.
+	i := 12 + (int(section[10]&0x0F)<<8 | int(section[11]))
+	for i+5 <= len(section)-4 {
+		switch section[i] {
+		case 0x01, 0x02, 0x10, 0x1B, 0x24:
+			return uint16(section[i+1]&0x1F)<<8 | uint16(section[i+2]), true
+		}
+		i += 5 + (int(section[i+3]&0x0F)<<8 | int(section[i+4]))
+	}
+	return 0, false
+}
+
+// psiSection returns the PSI section with the table ID that starts in the
+// packet, or nil. Sections that continue in the next packet are not supported.
+func psiSection(packet []byte, tableID byte) []byte {
+	if len(packet) < PacketSize {
+		return nil
+	}
+	// payload_unit_start_indicator and payload present.
+	if packet[1]&0x40 == 0 || packet[3]&0x10 == 0 {
+		return nil
+	}
+
+	payload := packet[4:]
+	if packet[3]&0x20 != 0 {
+		// Skip the adaptation field.
+		if 1+int(packet[4]) >= len(payload) {
+			return nil
+		}
+		payload = payload[1+int(packet[4]):]
+	}
+
+	// pointer_field, then table_id and the 12-bit section_length.
+	if 1+int(payload[0])+3 > len(payload) {
+		return nil
+	}
+	section := payload[1+int(payload[0]):]
+	length := int(section[1]&0x0F)<<8 | int(section[2])
+	if section[0] != tableID || 3+length > len(section) {
+		return nil
+	}
+	return section[:3+length]
+}
+
+// PID returns the 13-bit packet identifier of an MPEG-TS packet.
+func PID(packet []byte) uint16 {
+	return uint16(packet[1]&0x1F)<<8 | uint16(packet[2])
+}
+
+// PIDCounter counts the packets per PID.
+type PIDCounter map[uint16]int
+
+// Add counts the given packet.
+func (c PIDCounter) Add(packet []byte) {
+	c[PID(packet)]++
+}
+
+// Missing returns the PIDs of c without packets in other, in ascending order.
+func (c PIDCounter) Missing(other PIDCounter) []uint16 {
+	var missing []uint16
+	for pid := range c {
+		if other[pid] == 0 {
+			missing = append(missing, pid)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
 // NextInto reads the next valid MPEG-TS packet into the provided buffer.
 // The buffer must have a length of at least PacketSize.
 // If no packet is available, it returns io.EOF.
@@ -3,6 +3,7 @@ package mpegts
 import (
 	"bytes"
 	"io"
+	"slices"
 	"testing"
 )
 
@@ -109,3 +110,149 @@ func TestParser_Next_CorruptedStream(t *testing.T) {
 		t.Errorf("expected io.EOF, got %v", err)
 	}
 }
+
+func TestPIDCounter(t *testing.T) {
+	packet := func(pid uint16) []byte {
+		p := make([]byte, PacketSize)
+		p[0] = SyncByte
+		p[1] = 0x40 | byte(pid>>8) // payload_unit_start_indicator must be ignored
+		p[2] = byte(pid)
+		return p
+	}
+
+	if pid := PID(packet(0x1FFF)); pid != 0x1FFF {
+		t.Errorf("expected PID 0x1FFF, got 0x%04X", pid)
+	}
+
+	in, out := PIDCounter{}, PIDCounter{}
+	for _, pid := range []uint16{0x0000, 0x0100, 0x0101, 0x0102, 0x0100} {
+		in.Add(packet(pid))
+	}
+	out.Add(packet(0x0100))
+
+	if in[0x0100] != 2 {
+		t.Errorf("expected 2 packets for PID 0x0100, got %d", in[0x0100])
+	}
+	if missing := in.Missing(out); !slices.Equal(missing, []uint16{0x0000, 0x0101, 0x0102}) {
+		t.Errorf("unexpected missing PIDs %v", missing)
+	}
+	if missing := out.Missing(in); len(missing) != 0 {
+		t.Errorf("unexpected missing PIDs %v", missing)
+	}
+}
+
+func TestRandomAccess(t *testing.T) {
+	packet := func(adaptation, length, flags byte) []byte {
+		p := make([]byte, PacketSize)
+		p[0] = SyncByte
+		p[3] = adaptation
+		p[4] = length
+		p[5] = flags
+		return p
+	}
+
+	if !RandomAccess(packet(0x30, 7, 0x50)) {
+		t.Error("expected a random access point with PCR")
+	}
+	if !RandomAccess(packet(0x20, 1, 0x40)) {
+		t.Error("expected a random access point without payload")
+	}
+	if RandomAccess(packet(0x30, 7, 0x10)) {
+		t.Error("unexpected random access point without the indicator")
+	}
+	if RandomAccess(packet(0x10, 0, 0x40)) {
+		t.Error("unexpected random access point without adaptation field")
+	}
+	if RandomAccess(packet(0x30, 0, 0x40)) {
+		t.Error("unexpected random access point with an empty adaptation field")
+	}
+	if RandomAccess(packet(0x30, 7, 0x40)[:100]) {
+		t.Error("unexpected random access point in a short packet")
+	}
+}
+
+func TestExtractPTS(t *testing.T) {
+	// Video packet that starts a PES packet with the given PTS
+	ptsPacket := func(pts int64) []byte {
+		packet := make([]byte, PacketSize)
+		packet[0] = SyncByte
+		packet[1] = 0x41 // payload_unit_start_indicator, PID 0x0100
+		packet[3] = 0x10 // payload only
+		copy(packet[4:], []byte{0x00, 0x00, 0x01, 0xE0, 0x00, 0x00, 0x80, 0x80, 0x05})
+		packet[13] = byte(pts>>29)&0x0E | 0x21
+		packet[14] = byte(pts >> 22)
+		packet[15] = byte(pts>>14) | 0x01
+		packet[16] = byte(pts >> 7)
+		packet[17] = byte(pts<<1) | 0x01
+		return packet
+	}
+
+	for _, pts := range []int64{0, 90000, 1<<33 - 1} {
+		got, ok := ExtractPTS(ptsPacket(pts))
+		if !ok || got != pts {
+			t.Errorf("ExtractPTS() = %d, %v, want %d, true", got, ok, pts)
+		}
+	}
+
+	// No PES header
+	packet := make([]byte, PacketSize)
+	packet[0] = SyncByte
+	packet[1] = 0x01
+	packet[3] = 0x10
+	if _, ok := ExtractPTS(packet); ok {
+		t.Error("ExtractPTS() found a PTS without a PES header")
+	}
+}
+
+// psiPacket returns a packet with the PID that starts the PSI section
+func psiPacket(pid uint16, tableID byte, data []byte) []byte {
+	packet := bytes.Repeat([]byte{0xFF}, PacketSize)
+	packet[0] = SyncByte
+	packet[1] = 0x40 | byte(pid>>8) // payload_unit_start_indicator
+	packet[2] = byte(pid)
+	packet[3] = 0x10 // payload only
+	packet[4] = 0    // pointer_field
+
+	length := 5 + len(data) + 4 // Header after section_length, data and CRC
+	section := append([]byte{tableID, 0xB0 | byte(length>>8), byte(length), 0x00, 0x01, 0xC1, 0x00, 0x00}, data...)
+	copy(packet[5:], append(section, 0, 0, 0, 0))
+	return packet
+}
+
+func TestProgramMapPID(t *testing.T) {
+	// Network PID, then program 1 with the PMT on PID 0x1000
+	pid, ok := ProgramMapPID(psiPacket(0, 0x00, []byte{0x00, 0x00, 0xE0, 0x10, 0x00, 0x01, 0xF0, 0x00}))
+	if !ok || pid != 0x1000 {
+		t.Errorf("ProgramMapPID() = %#x, %v, want 0x1000, true", pid, ok)
+	}
+
+	if _, ok := ProgramMapPID(psiPacket(0, 0x02, []byte{0x00, 0x01, 0xF0, 0x00})); ok {
+		t.Error("ProgramMapPID() found a PMT PID in a PMT")
+	}
+	if _, ok := ProgramMapPID(psiPacket(0, 0x00, nil)); ok {
+		t.Error("ProgramMapPID() found a PMT PID without programs")
+	}
+}
+
+func TestVideoPID(t *testing.T) {
+	// PCR PID and program_info_length with a 2-byte descriptor, then the streams
+	pmt := func(streams ...byte) []byte {
+		return psiPacket(0x1000, 0x02, append([]byte{0xE1, 0x00, 0xF0, 0x02, 0x0A, 0x00}, streams...))
+	}
+
+	// AAC audio on 0x101 with a 3-byte descriptor, H.264 video on 0x100
+	pid, ok := VideoPID(pmt(0x0F, 0xE1, 0x01, 0xF0, 0x03, 0x0A, 0x01, 0x00, 0x1B, 0xE1, 0x00, 0xF0, 0x00))
+	if !ok || pid != 0x100 {
+		t.Errorf("VideoPID() = %#x, %v, want 0x100, true", pid, ok)
+	}
+
+	if _, ok := VideoPID(pmt(0x0F, 0xE1, 0x01, 0xF0, 0x00)); ok {
+		t.Error("VideoPID() found a video stream in a radio PMT")
+	}
+
+	packet := pmt(0x1B, 0xE1, 0x00, 0xF0, 0x00)
+	packet[1] &^= 0x40
+	if _, ok := VideoPID(packet); ok {
+		t.Error("VideoPID() found a video stream in a packet that does not start the section")
+	}
+}
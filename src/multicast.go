@@ -0,0 +1,123 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	multicastPrefix      = "udp://@"
+	multicastReadTimeout = 10 * time.Second // No datagram for this time is a read error, the buffer retries or stops the stream
+	maxDatagramSize      = 65535
+	rtpVersion           = 2
+	rtpHeaderSize        = 12
+)
+
+// isNativeMulticast : Multicast stream (udp://@group:port) that is received by the buffer itself instead of UDPxy
+func isNativeMulticast(streamURL string) bool {
+	return Settings.AllowNativeMulticast && strings.HasPrefix(streamURL, multicastPrefix)
+}
+
+// openMulticastStream : Joins the multicast group of a udp://@group:port URL.
+// The datagrams are returned as body of a TS response, so that the buffer handles them like an HTTP stream.
+func openMulticastStream(ctx context.Context, streamURL string) (*http.Response, error) {
+	var group = strings.TrimSuffix(strings.TrimPrefix(streamURL, multicastPrefix), "/")
+
+	addr, err := net.ResolveUDPAddr("udp", group)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multicast address %q: %w", group, err)
+	}
+
+	if !addr.IP.IsMulticast() {
+		return nil, fmt.Errorf("%s is not a multicast address", addr.IP)
+	}
+
+	conn, err := net.ListenMulticastUDP("udp", nil, addr)
+	if err != nil {
+		return nil, fmt.Errorf("joining multicast group %s: %w", addr, err)
+	}
+
+	var reader = &multicastReader{conn: conn, buf: make([]byte, maxDatagramSize)}
+	reader.stop = context.AfterFunc(ctx, func() { conn.Close() })
+
+	var resp = &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "UDP",
+		Header:        http.Header{"Content-Type": []string{"video/mp2t"}},
+		Body:          reader,
+		ContentLength: -1,
+	}
+
+	return resp, nil
+}
+
+// multicastReader : Reads the MPEG-TS data of the datagrams, an RTP header is removed
+type multicastReader struct {
+	conn *net.UDPConn
+	stop func() bool
+	buf  []byte
+	data []byte
+}
+
+func (r *multicastReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		if err := r.conn.SetReadDeadline(time.Now().Add(multicastReadTimeout)); err != nil {
+			return 0, err
+		}
+
+		n, _, err := r.conn.ReadFromUDP(r.buf)
+		if err != nil {
+			return 0, err
+		}
+
+		r.data = stripRTPHeader(r.buf[:n])
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (r *multicastReader) Close() error {
+	r.stop()
+	return r.conn.Close()
+}
+
+// stripRTPHeader : Returns the payload of an RTP packet. Raw MPEG-TS datagrams start with the sync byte and are returned unchanged.
+func stripRTPHeader(datagram []byte) []byte {
+	if len(datagram) < rtpHeaderSize || datagram[0] == 0x47 || datagram[0]>>6 != rtpVersion {
+		return datagram
+	}
+
+	var size = rtpHeaderSize + 4*int(datagram[0]&0x0f) // CSRC identifiers
+
+	// Header extension
+	if datagram[0]&0x10 != 0 {
+		if len(datagram) < size+4 {
+			return nil
+		}
+		size += 4 + 4*(int(datagram[size+2])<<8|int(datagram[size+3]))
+	}
+
+	if len(datagram) < size {
+		return nil
+	}
+
+	var payload = datagram[size:]
+
+	// Padding
+	if datagram[0]&0x20 != 0 && len(payload) > 0 {
+		var padding = int(payload[len(payload)-1])
+		if padding > len(payload) {
+			return nil
+		}
+		payload = payload[:len(payload)-padding]
+	}
+
+	return payload
+}
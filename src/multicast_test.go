@@ -0,0 +1,105 @@
+package src
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"xteve/src/mpegts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsNativeMulticast(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	Settings.AllowNativeMulticast = false
+	assert.False(t, isNativeMulticast("udp://@239.1.1.1:1234"))
+
+	Settings.AllowNativeMulticast = true
+	assert.True(t, isNativeMulticast("udp://@239.1.1.1:1234"))
+	assert.False(t, isNativeMulticast("http://example.com/stream.ts"))
+
+	_, err := openMulticastStream(context.Background(), "udp://@192.168.1.1:1234")
+	assert.ErrorContains(t, err, "not a multicast address")
+	_, err = openMulticastStream(context.Background(), "udp://@239.1.1.1")
+	assert.Error(t, err)
+}
+
+func TestStripRTPHeader(t *testing.T) {
+	var ts = bytes.Repeat(append([]byte{mpegts.SyncByte}, make([]byte, mpegts.PacketSize-1)...), 7)
+
+	// Raw MPEG-TS
+	assert.Equal(t, ts, stripRTPHeader(ts))
+
+	// RTP, MP2T payload
+	var header = []byte{0x80, 33, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1}
+	assert.Equal(t, ts, stripRTPHeader(append(header, ts...)))
+
+	// RTP with a CSRC identifier, a header extension and padding
+	header = []byte{0xb1, 33, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 2, 0xbe, 0xde, 0, 1, 1, 2, 3, 4}
+	var datagram = append(append(header, ts...), 0, 0, 3)
+	assert.Equal(t, ts, stripRTPHeader(datagram))
+
+	// Truncated header
+	assert.Empty(t, stripRTPHeader([]byte{0x8f, 33, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1}))
+}
+
+func TestOpenMulticastStream(t *testing.T) {
+	const group = "239.255.42.99:41234"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resp, err := openMulticastStream(ctx, "udp://@"+group)
+	if err != nil {
+		t.Skipf("multicast is not available: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "video/mp2t", resp.Header.Get("Content-Type"))
+
+	addr, err := net.ResolveUDPAddr("udp", group)
+	require.NoError(t, err)
+	sender, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		t.Skipf("multicast is not available: %v", err)
+	}
+	defer sender.Close()
+
+	var packet = make([]byte, mpegts.PacketSize)
+	packet[0] = mpegts.SyncByte
+	var datagram = bytes.Repeat(packet, 7)
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sender.Write(datagram)
+			}
+		}
+	}()
+
+	var received = make([]byte, 2*len(datagram))
+	_, err = io.ReadFull(resp.Body, received)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Skip("no multicast datagrams received, multicast is not routed in this environment")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, append(datagram, datagram...), received)
+
+	// The stream is stopped with the context of the buffer
+	cancel()
+	_, err = io.ReadFull(resp.Body, received)
+	assert.Error(t, err)
+}
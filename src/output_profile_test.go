@@ -0,0 +1,134 @@
+package src
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"xteve/src/internal/imgcache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupOutputProfileTest(t *testing.T) {
+	t.Helper()
+
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	tmpDir := t.TempDir() + "/"
+	Settings.EpgSource = "XEPG"
+	Settings.M3USortOrder = "channel-number"
+	Settings.M3UDirectURLs = false
+	Settings.AuthenticationM3U = false
+	Settings.AuthenticationXML = false
+	System.ServerProtocol.M3U = "http"
+	System.ServerProtocol.XML = "http"
+	System.Domain = "localhost:34400"
+	System.Folder.Data = tmpDir
+	System.Folder.ImagesCache = tmpDir + "images/"
+	System.File.XML = tmpDir + "xteve.xml"
+	System.Compressed.GZxml = ""
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+	Data.Streams.Active = []any{"dummy"}
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {XActive: true, XChannelID: "1", XName: "Zeta News", XGroupTitle: "News", XEPG: "x-ID.1", FileM3UID: "M1", URL: "http://provider.example/1.ts"},
+		"x-ID.2": {XActive: true, XChannelID: "2", XName: "Alpha Sport", XGroupTitle: "Sport", XEPG: "x-ID.2", FileM3UID: "M1", URL: "http://provider.example/2.ts"},
+		"x-ID.3": {XActive: true, XChannelID: "3", XName: "Beta Movies", XGroupTitle: "Movies", XEPG: "x-ID.3", FileM3UID: "M1", URL: "http://provider.example/3.ts"},
+	}
+	Settings.OutputProfiles = []OutputProfile{
+		{Name: "plex", Groups: []string{"News", "Sport"}},
+		{Name: "kodi", Groups: []string{"Sport", "Movies"}, SortOrder: "name", Format: "direct"},
+		{Name: "xteve", Groups: []string{"News"}},
+	}
+
+	require.NoError(t, os.MkdirAll(System.Folder.ImagesCache, 0755))
+
+	var err error
+	Data.Cache.Images, err = imgcache.New(System.Folder.ImagesCache, "", false, NewHTTPClient())
+	require.NoError(t, err)
+}
+
+func m3uChannelNames(m3u string) (names []string) {
+	for line := range strings.Lines(m3u) {
+		if strings.HasPrefix(line, "#EXTINF") {
+			names = append(names, strings.TrimSpace(line[strings.LastIndex(line, ",")+1:]))
+		}
+	}
+	return
+}
+
+func TestBuildM3UToWriter_OutputProfiles(t *testing.T) {
+	setupOutputProfileTest(t)
+
+	build := func(name string) string {
+		profile, _ := getOutputProfile(name)
+		var sb strings.Builder
+		require.NoError(t, buildM3UToWriter(&sb, []string{}, profile))
+		return sb.String()
+	}
+
+	plex := build("plex")
+	assert.Equal(t, []string{"Zeta News", "Alpha Sport"}, m3uChannelNames(plex))
+	assert.Contains(t, plex, `url-tvg="http://localhost:34400/xmltv/plex.xml"`)
+	assert.Contains(t, plex, "http://localhost:34400/stream/")
+
+	kodi := build("kodi")
+	assert.Equal(t, []string{"Alpha Sport", "Beta Movies"}, m3uChannelNames(kodi))
+	assert.Contains(t, kodi, "\nhttp://provider.example/2.ts\n")
+	assert.NotContains(t, kodi, "/stream/")
+
+	// The default profile can't be replaced
+	assert.Equal(t, []string{"Zeta News", "Alpha Sport", "Beta Movies"}, m3uChannelNames(build("xteve")))
+	assert.Equal(t, build("xteve"), build("unknown"))
+}
+
+func TestCreateXMLTVFile_OutputProfiles(t *testing.T) {
+	setupOutputProfileTest(t)
+
+	require.NoError(t, createXMLTVFile())
+
+	channels := func(file string) (ids []string) {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		var xmltv XMLTV
+		require.NoError(t, xml.Unmarshal(content, &xmltv))
+		for _, channel := range xmltv.Channel {
+			ids = append(ids, channel.ID)
+		}
+		return
+	}
+
+	assert.ElementsMatch(t, []string{"1", "2", "3"}, channels(System.File.XML))
+	assert.ElementsMatch(t, []string{"1", "2"}, channels(System.Folder.Data+"plex.xml"))
+	assert.ElementsMatch(t, []string{"2", "3"}, channels(System.Folder.Data+"kodi.xml"))
+}
+
+func TestXTeVeHandler_OutputProfiles(t *testing.T) {
+	setupOutputProfileTest(t)
+	require.NoError(t, createXMLTVFile())
+
+	for _, tt := range []struct {
+		path     string
+		contains string
+		missing  string
+	}{
+		{"/m3u/plex.m3u", "Zeta News", "Beta Movies"},
+		{"/m3u/kodi.m3u", "Beta Movies", "Zeta News"},
+		{"/xmltv/plex.xml", "Zeta News", "Beta Movies"},
+		{"/xmltv/kodi.xml", "Beta Movies", "Zeta News"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = System.Domain
+		rr := httptest.NewRecorder()
+		xTeVe(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, tt.path)
+		assert.Contains(t, rr.Body.String(), tt.contains, tt.path)
+		assert.NotContains(t, rr.Body.String(), tt.missing, tt.path)
+	}
+}
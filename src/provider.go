@@ -1,13 +1,22 @@
 package src
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	m3u "xteve/src/internal/m3u-parser"
@@ -20,8 +29,9 @@ func getProviderData(ctx context.Context, fileType, fileID string) (err error) {
 	// var newProvider = false // Removed: Ineffectual assignment
 	var dataMap = make(map[string]any)
 
-	var saveDateFromProvider = func(fileSource, serverFileName, id string, body []byte) (err error) {
+	var saveDateFromProvider = func(fileSource, serverFileName, charset, id string, body []byte) (err error) {
 		var data = make(map[string]any)
+		var epgURL string
 
 		if value, ok := dataMap[id].(map[string]any); ok {
 			data = value
@@ -81,7 +91,22 @@ func getProviderData(ctx context.Context, fileType, fileID string) (err error) {
 		showInfo("Check File:" + fileSource)
 		switch fileType {
 		case "m3u":
-			_, err = m3u.MakeInterfaceFromM3U(body)
+			// The encoding of the provider settings overrides the charset of the server
+			if encoding, ok := data["encoding"].(string); ok && len(encoding) > 0 {
+				charset = encoding
+			}
+
+			body, err = decodePlaylist(body, charset)
+			if err != nil {
+				return
+			}
+
+			var channels []any
+			channels, err = m3u.MakeInterfaceFromM3U(body)
+			if err == nil && Settings.ProviderKeepLastGood {
+				err = checkM3UIntegrity(body, len(channels), getProviderCompatibility(data)["streams"])
+			}
+			epgURL = m3u.EPGURL(body)
 		case "hdhr":
 			_, err = jsonToInterface(string(body))
 		case "xmltv":
@@ -106,6 +131,13 @@ func getProviderData(ctx context.Context, fileType, fileID string) (err error) {
 			if v, ok := data["counter.download"].(float64); ok {
 				data["counter.download"] = v + 1
 			}
+
+			// EPG URL of the playlist header (epg.auto)
+			if auto, ok := data["epg.auto"].(bool); ok && auto && len(epgURL) > 0 {
+				if errEPG := updateLinkedEPG(ctx, id, data, epgURL); errEPG != nil {
+					ShowError(errEPG, 0)
+				}
+			}
 		}
 		return
 	}
@@ -123,6 +155,7 @@ func getProviderData(ctx context.Context, fileType, fileID string) (err error) {
 	}
 
 	for dataID, d := range dataMap {
+		var charset string
 		var data, ok = d.(map[string]any)
 		if !ok {
 			continue
@@ -145,31 +178,37 @@ func getProviderData(ctx context.Context, fileType, fileID string) (err error) {
 			}
 		}
 
+		// Linked EPG files are updated together with their playlist
+		if len(fileID) == 0 && !newProvider && isLinkedEPG(fileType, data) {
+			goto Done
+		}
+
 		switch fileType {
 		case "hdhr":
 			// Load from the HDHomeRun Tuner
 			showInfo("Tuner:" + fileSource)
 			var tunerURL = "http://" + fileSource + "/lineup.json"
-			serverFileName, body, err = downloadFileFromServer(ctx, tunerURL)
+			serverFileName, body, _, err = downloadProviderFile(ctx, tunerURL, fileSource)
 		default:
 			if strings.Contains(fileSource, "http://") || strings.Contains(fileSource, "https://") {
 				// Load from the Remote Server
 				showInfo("Download:" + fileSource)
-				serverFileName, body, err = downloadFileFromServer(ctx, fileSource)
+				serverFileName, body, charset, err = downloadProviderFile(ctx, fileSource, fileSource)
 			} else {
 				// Load a local File
 				showInfo("Open:" + fileSource)
 
-				err = checkFile(fileSource)
+				var source = expandProviderEnv(fileSource)
+				err = checkFile(source)
 				if err == nil {
-					body, err = readByteFromFile(fileSource)
+					body, err = readByteFromFile(source)
 					serverFileName = filepath.Base(fileSource)
 				}
 			}
 		}
 
 		if err == nil {
-			err = saveDateFromProvider(fileSource, serverFileName, dataID, body)
+			err = saveDateFromProvider(fileSource, serverFileName, charset, dataID, body)
 			if err == nil {
 				showInfo("Save File:" + fileSource + " [ID: " + dataID + "]")
 			}
@@ -239,16 +278,237 @@ func getProviderData(ctx context.Context, fileType, fileID string) (err error) {
 	return
 }
 
-// Limit the download size to 512MB to prevent DoS
+// providerEnvPrefix : Only environment variables with this prefix can be used in provider URLs
+const providerEnvPrefix = "IPTV_"
+
+var providerEnvRegex = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// expandProviderEnv : Replaces ${IPTV_...} in a provider URL with the value of the environment variable, e.g. for credentials.
+// Other and unset variables are kept as they are. Only the request uses the expanded URL, never the log or the settings.
+func expandProviderEnv(source string) string {
+	return providerEnvRegex.ReplaceAllStringFunc(source, func(placeholder string) string {
+		var name = placeholder[2 : len(placeholder)-1]
+		if strings.HasPrefix(name, providerEnvPrefix) {
+			if value, ok := os.LookupEnv(name); ok {
+				return value
+			}
+		}
+		return placeholder
+	})
+}
+
+// hideProviderURL : The errors of net/http (*url.Error) contain the requested URL with the expanded environment variables,
+// it is replaced by the URL of the settings without the password.
+func hideProviderURL(err error, providerURL string) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(providerURL)
+	}
+
+	var statusErr *providerStatusError
+	if errors.As(err, &statusErr) {
+		statusErr.URL = redactURL(providerURL)
+	}
+	return err
+}
+
+// providerStatusError : HTTP status of a failed provider download
+type providerStatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *providerStatusError) Error() string {
+	return fmt.Sprintf("%d: %s %s", e.StatusCode, e.URL, http.StatusText(e.StatusCode))
+}
+
+// retryProviderDownload : Only network errors and the HTTP status 5xx and 429 can go away with a retry.
+// Other errors (4xx, size limit, invalid URL) are returned at once.
+func retryProviderDownload(err error) bool {
+	var statusErr *providerStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Op != "parse"
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// updateLinkedEPG : Registers the EPG URL of a playlist (url-tvg) as XMLTV file of the playlist and updates it.
+// The ID of the XMLTV file is saved in the playlist settings (epg.xmltv).
+func updateLinkedEPG(ctx context.Context, m3uID string, m3uData map[string]any, epgURL string) (err error) {
+	if !strings.HasPrefix(epgURL, "http://") && !strings.HasPrefix(epgURL, "https://") {
+		return fmt.Errorf("EPG URL of the playlist is not a HTTP URL: %s", epgURL)
+	}
+
+	if Settings.Files.XMLTV == nil {
+		Settings.Files.XMLTV = make(map[string]any)
+	}
+
+	var xmltvID, _ = m3uData["epg.xmltv"].(string)
+	var xmltv, ok = Settings.Files.XMLTV[xmltvID].(map[string]any)
+	var newLink = !ok
+
+	if newLink {
+		var rStr string
+		if rStr, err = randomString(19); err != nil {
+			return
+		}
+
+		var name, _ = m3uData["name"].(string)
+		xmltvID = "X" + rStr
+		xmltv = map[string]any{"name": name + " (EPG)", "description": "url-tvg", "new": true, "linked.m3u": m3uID}
+		Settings.Files.XMLTV[xmltvID] = xmltv
+	}
+
+	// The provider can change the URL
+	xmltv["file.source"] = epgURL
+
+	if err = getProviderData(ctx, "xmltv", xmltvID); err != nil {
+		if newLink {
+			delete(Settings.Files.XMLTV, xmltvID)
+		}
+		return
+	}
+
+	m3uData["epg.xmltv"] = xmltvID
+	return
+}
+
+// isLinkedEPG : XMLTV file that was registered by a playlist (updateLinkedEPG) and is still updated by it.
+// If the playlist turns epg.auto off, the file is updated like the other XMLTV files.
+func isLinkedEPG(fileType string, data map[string]any) bool {
+	if fileType != "xmltv" {
+		return false
+	}
+
+	var m3uID, _ = data["linked.m3u"].(string)
+	m3uData, ok := Settings.Files.M3U[m3uID].(map[string]any)
+	if !ok {
+		return false
+	}
+
+	auto, _ := m3uData["epg.auto"].(bool)
+	return auto
+}
+
+// checkM3UIntegrity : Detects incomplete downloads, the previous file of the provider is kept (provider.keep.last.good).
+// A playlist with less than half of the channels of the last update is considered incomplete.
+func checkM3UIntegrity(body []byte, channels, previousChannels int) error {
+	var content = bytes.TrimSpace(body)
+
+	if !bytes.HasPrefix(content, []byte("#EXTM3U")) {
+		return errors.New("invalid M3U file, the file does not start with #EXTM3U")
+	}
+
+	// The last channel has no stream URL
+	if i := bytes.LastIndexByte(content, '\n'); bytes.HasPrefix(bytes.TrimSpace(content[i+1:]), []byte("#EXTINF")) {
+		return errors.New("incomplete M3U file, the stream URL of the last channel is missing")
+	}
+
+	if previousChannels > 0 && channels < previousChannels/2 {
+		return fmt.Errorf("incomplete M3U file, %d channels instead of %d", channels, previousChannels)
+	}
+	return nil
+}
+
+// Limit the download size to 512MB to prevent DoS, if provider.max.download.mb is not set
 var maxProviderDownloadSize int64 = 536870912
 
-func downloadFileFromServer(ctx context.Context, providerURL string) (filename string, body []byte, err error) {
-	_, err = url.ParseRequestURI(providerURL)
+// providerDownloadLimit : Max. size of a provider download in bytes (provider.max.download.mb)
+func providerDownloadLimit() int64 {
+	if Settings.ProviderMaxDownloadMB > 0 {
+		return int64(Settings.ProviderMaxDownloadMB) << 20
+	}
+	return maxProviderDownloadSize
+}
+
+// withProviderDownloadTimeout : The context of a provider download ends after provider.download.timeout.seconds
+func withProviderDownloadTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if Settings.ProviderDownloadTimeout > 0 {
+		return context.WithTimeout(ctx, time.Duration(Settings.ProviderDownloadTimeout)*time.Second)
+	}
+	return context.WithCancel(ctx)
+}
+
+// readProviderDownload : Reads the body of a provider download, it is aborted if it exceeds the size limit or the timeout
+func readProviderDownload(ctx context.Context, resp *http.Response) (body []byte, err error) {
+	var limit = providerDownloadLimit()
+
+	// Security: Check Content-Length to avoid starting download of obviously too large files
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("file too large: %d bytes (max: %d)", resp.ContentLength, limit)
+	}
+
+	// Security: Use LimitReader to enforce the size limit during download
+	// Read up to limit + 1 to detect truncation
+	body, err = io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("download timeout: exceeds %d seconds (%w)", Settings.ProviderDownloadTimeout, context.DeadlineExceeded)
+		}
+		return nil, err
+	}
+
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("file too large: exceeds %d bytes", limit)
+	}
+	return
+}
+
+// providerDownloads limits the number of concurrent provider downloads (provider.download.concurrency)
+var providerDownloads struct {
+	sync.Mutex
+	sem chan struct{}
+}
+
+// acquireProviderDownload blocks until a download slot is free. A limit of 0 disables the limit.
+func acquireProviderDownload(ctx context.Context, limit int) (release func(), err error) {
+	if limit <= 0 {
+		return func() {}, nil
+	}
+
+	providerDownloads.Lock()
+	if cap(providerDownloads.sem) != limit {
+		// Downloads that are still running release the slot of the old semaphore
+		providerDownloads.sem = make(chan struct{}, limit)
+	}
+	var sem = providerDownloads.sem
+	providerDownloads.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// downloadFileFromServer : Downloads a provider file, charset is taken from the Content-Type header of the server (if any)
+func downloadFileFromServer(ctx context.Context, providerURL string) (filename string, body []byte, charset string, err error) {
+	// Environment variables are only expanded for the request, errors and file names use the URL of the settings
+	defer func() { err = hideProviderURL(err, providerURL) }()
+
+	_, err = url.ParseRequestURI(expandProviderEnv(providerURL))
 	if err != nil {
 		return
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", providerURL, nil)
+	release, err := acquireProviderDownload(ctx, Settings.ProviderDownloadConcurrency)
+	if err != nil {
+		return
+	}
+	defer release()
+
+	ctx, cancel := withProviderDownloadTimeout(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", expandProviderEnv(providerURL), nil)
 	if err != nil {
 		return
 	}
@@ -263,7 +523,7 @@ func downloadFileFromServer(ctx context.Context, providerURL string) (filename s
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		err = fmt.Errorf("%d: %s "+http.StatusText(resp.StatusCode), resp.StatusCode, providerURL)
+		err = &providerStatusError{StatusCode: resp.StatusCode, URL: providerURL}
 		return
 	}
 
@@ -283,25 +543,153 @@ func downloadFileFromServer(ctx context.Context, providerURL string) (filename s
 		filename = cleanFilename[0]
 	}
 
-	// Security: Check Content-Length to avoid starting download of obviously too large files
-	if resp.ContentLength > maxProviderDownloadSize {
-		err = fmt.Errorf("file too large: %d bytes (max: %d)", resp.ContentLength, maxProviderDownloadSize)
+	if _, params, errMime := mime.ParseMediaType(resp.Header.Get("Content-Type")); errMime == nil {
+		charset = params["charset"]
+	}
+
+	body, err = readProviderDownload(ctx, resp)
+	return
+}
+
+// maxProviderRetryDelay : Upper limit of the increasing delay between two attempts of a provider download
+const maxProviderRetryDelay = 10 * time.Minute
+
+// providerRetryDelay : Delay after the failed attempt (0 = first attempt), doubled after every attempt (provider.refresh.delay)
+func providerRetryDelay(attempt int) time.Duration {
+	var delay = time.Duration(Settings.ProviderRefreshDelay) * time.Second
+	for range attempt {
+		delay *= 2
+		if delay >= maxProviderRetryDelay {
+			return maxProviderRetryDelay
+		}
+	}
+	return min(delay, maxProviderRetryDelay)
+}
+
+// downloadProviderFile : Downloads a provider file for the update, a failed download is retried provider.refresh.retries times
+// if the error can be temporary (retryProviderDownload).
+// name is only used for the log, it does not contain the expanded environment variables of the URL.
+func downloadProviderFile(ctx context.Context, providerURL, name string) (filename string, body []byte, charset string, err error) {
+	var attempts = max(Settings.ProviderRefreshRetries, 0) + 1
+
+	for attempt := 1; ; attempt++ {
+		filename, body, charset, err = downloadFileFromServer(ctx, providerURL)
+		if err == nil {
+			if attempt > 1 {
+				showInfo(fmt.Sprintf("Download:Attempt %d/%d succeeded (%s)", attempt, attempts, redactURL(name)))
+			}
+			return
+		}
+
+		if attempt >= attempts || ctx.Err() != nil || !retryProviderDownload(err) {
+			return
+		}
+
+		var delay = providerRetryDelay(attempt - 1)
+		showInfo(fmt.Sprintf("Download:Attempt %d/%d failed (%s): %v. Retry in %s", attempt, attempts, redactURL(name), err, delay))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", nil, "", ctx.Err()
+		}
+	}
+}
+
+// providerTestPreview : Number of channel names in the result of testProvider
+const providerTestPreview = 5
+
+// testProvider : Downloads a playlist or XMLTV file and checks the content, nothing is saved (websocket command testProvider).
+// The download uses the same HTTP client (SSRF protection), size limit and timeout as the provider updates.
+func testProvider(ctx context.Context, fileType, providerURL, userAgent string) (result ProviderTestStruct, err error) {
+	if fileType != "m3u" && fileType != "xmltv" {
+		return result, fmt.Errorf("unsupported provider type: %q", fileType)
+	}
+
+	defer func() { err = hideProviderURL(err, providerURL) }()
+
+	u, err := url.ParseRequestURI(expandProviderEnv(providerURL))
+	if err != nil {
 		return
 	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return result, fmt.Errorf("unsupported URL scheme: %q", u.Scheme)
+	}
 
-	// Security: Use LimitReader to enforce the size limit during download
-	// Read up to limit + 1 to detect truncation
-	lr := io.LimitReader(resp.Body, maxProviderDownloadSize+1)
-	body, err = io.ReadAll(lr)
+	ctx, cancel := withProviderDownloadTimeout(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return
 	}
+	req.Header.Set("User-Agent", cmp.Or(userAgent, Settings.UserAgent))
 
-	if int64(len(body)) > maxProviderDownloadSize {
-		err = fmt.Errorf("file too large: exceeds %d bytes", maxProviderDownloadSize)
-		// Clear body to free memory
-		body = nil
+	resp, err := NewHTTPClient().Do(req)
+	if err != nil {
 		return
 	}
+	defer resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("%d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	body, err := readProviderDownload(ctx, resp)
+	if err != nil {
+		return
+	}
+	result.Size = len(body)
+
+	body, err = extractGZIP(body, providerURL)
+	if err != nil {
+		return
+	}
+
+	switch fileType {
+	case "m3u":
+		var charset string
+		if _, params, errMime := mime.ParseMediaType(resp.Header.Get("Content-Type")); errMime == nil {
+			charset = params["charset"]
+		}
+
+		body, err = decodePlaylist(body, charset)
+		if err != nil {
+			return
+		}
+
+		if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("#EXTM3U")) {
+			return result, errors.New("not an M3U playlist, the content does not start with #EXTM3U")
+		}
+
+		var channels []any
+		channels, err = m3u.MakeInterfaceFromM3U(body)
+		if err != nil {
+			return
+		}
+
+		result.Channels = len(channels)
+		for _, channel := range channels[:min(len(channels), providerTestPreview)] {
+			if stream, ok := channel.(map[string]string); ok {
+				result.Preview = append(result.Preview, stream["name"])
+			}
+		}
+	case "xmltv":
+		var xmltv XMLTV
+		if err = xml.Unmarshal(body, &xmltv); err != nil {
+			return result, fmt.Errorf("not an XMLTV file: %w", err)
+		}
+
+		result.Channels = len(xmltv.Channel)
+		result.Programs = len(xmltv.Program)
+		for _, channel := range xmltv.Channel[:min(len(xmltv.Channel), providerTestPreview)] {
+			var name = channel.ID
+			if len(channel.DisplayNames) > 0 {
+				name = channel.DisplayNames[0].Value
+			}
+			result.Preview = append(result.Preview, name)
+		}
+	}
 	return
 }
@@ -0,0 +1,99 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProviderData_DownloadLimits(t *testing.T) {
+	t.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	// The playlist is streamed without Content-Length until the client aborts
+	var written atomic.Int64
+	var slow atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var chunk = []byte(strings.Repeat("#EXTINF:-1,Channel\nhttp://provider.example/1.ts\n", 1000))
+		if !slow.Load() {
+			_, _ = w.Write([]byte("#EXTM3U\n"))
+		}
+
+		for written.Load() < 64<<20 {
+			if slow.Load() {
+				select {
+				case <-time.After(100 * time.Millisecond):
+				case <-r.Context().Done():
+					return
+				}
+			}
+
+			n, err := w.Write(chunk)
+			written.Add(int64(n))
+			if err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir() + "/"
+	System.AppName = "xteve"
+	System.Folder.Data = tmpDir
+	System.File.Settings = tmpDir + "settings.json"
+	Settings.ProviderDownloadConcurrency = 0
+	Settings.ProviderRefreshRetries = 0
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{
+		"name":        "Provider",
+		"file.source": server.URL + "/playlist.m3u",
+		"file.xteve":  "M1.m3u",
+	}}
+
+	// The last good copy of the playlist
+	require.NoError(t, os.WriteFile(tmpDir+"M1.m3u", []byte(testPlaylist(3)), 0644))
+
+	Settings.ProviderMaxDownloadMB = 1
+	err := getProviderData(t.Context(), "m3u", "")
+	require.ErrorContains(t, err, "file too large: exceeds 1048576 bytes")
+	assert.Less(t, written.Load(), int64(64<<20), "the download is aborted")
+
+	content, err := os.ReadFile(tmpDir + "M1.m3u")
+	require.NoError(t, err)
+	assert.Equal(t, testPlaylist(3), string(content), "the last good copy is kept")
+
+	// Timeout
+	written.Store(0)
+	slow.Store(true)
+	Settings.ProviderMaxDownloadMB = 0
+	Settings.ProviderDownloadTimeout = 1
+
+	var start = time.Now()
+	err = getProviderData(t.Context(), "m3u", "")
+	require.ErrorContains(t, err, "download timeout: exceeds 1 seconds")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	content, err = os.ReadFile(tmpDir + "M1.m3u")
+	require.NoError(t, err)
+	assert.Equal(t, testPlaylist(3), string(content))
+}
+
+func TestProviderDownloadLimit(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	Settings.ProviderMaxDownloadMB = 2
+	assert.Equal(t, int64(2<<20), providerDownloadLimit())
+
+	Settings.ProviderMaxDownloadMB = 0
+	assert.Equal(t, maxProviderDownloadSize, providerDownloadLimit())
+}
@@ -0,0 +1,83 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandProviderEnv(t *testing.T) {
+	t.Setenv("IPTV_USER", "user")
+	t.Setenv("SECRET_TOKEN", "secret")
+
+	assert.Equal(t, "http://host/user/playlist.m3u", expandProviderEnv("http://host/${IPTV_USER}/playlist.m3u"))
+	assert.Equal(t, "http://host/${SECRET_TOKEN}/playlist.m3u", expandProviderEnv("http://host/${SECRET_TOKEN}/playlist.m3u"), "only whitelisted variables")
+	assert.Equal(t, "http://host/${IPTV_UNSET}/playlist.m3u", expandProviderEnv("http://host/${IPTV_UNSET}/playlist.m3u"))
+	assert.Equal(t, "http://host/$IPTV_USER/a$b", expandProviderEnv("http://host/$IPTV_USER/a$b"))
+}
+
+func TestGetProviderData_EnvCredentials(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+	t.Setenv("IPTV_USER", "john")
+	t.Setenv("IPTV_PASS", "s3cret")
+
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/john/s3cret/playlist.m3u" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(testPlaylist(2)))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir() + "/"
+	System.AppName = "xteve"
+	System.Folder.Data = tmpDir
+	System.File.Settings = tmpDir + "settings.json"
+	Settings.ProviderDownloadConcurrency = 0
+
+	var source = server.URL + "/${IPTV_USER}/${IPTV_PASS}/playlist.m3u"
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{"name": "Provider", "file.source": source}}
+
+	require.NoError(t, getProviderData(t.Context(), "m3u", "M1"))
+	assert.FileExists(t, tmpDir+"M1.m3u")
+
+	// The settings keep the placeholders
+	assert.Equal(t, source, Settings.Files.M3U["M1"].(map[string]any)["file.source"])
+
+	content, err := os.ReadFile(System.File.Settings)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "${IPTV_USER}/${IPTV_PASS}")
+	assert.NotContains(t, string(content), "s3cret")
+}
+
+func TestDownloadFileFromServer_HidesEnvCredentials(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+	t.Setenv("IPTV_PASS", "s3cret")
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	var closed = httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	defer server.Close()
+
+	for _, source := range []string{server.URL + "/${IPTV_PASS}/playlist.m3u", closed.URL + "/${IPTV_PASS}/playlist.m3u"} {
+		filename, _, _, err := downloadFileFromServer(t.Context(), source)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "${IPTV_PASS}", "the URL of the settings")
+		assert.NotContains(t, err.Error(), "s3cret")
+		assert.NotContains(t, filename, "s3cret")
+
+		_, err = testProvider(t.Context(), "m3u", source, "")
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "s3cret")
+	}
+}
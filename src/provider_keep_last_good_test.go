@@ -0,0 +1,95 @@
+package src
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPlaylist(channels int) string {
+	var sb strings.Builder
+	sb.WriteString("#EXTM3U\n")
+	for i := range channels {
+		fmt.Fprintf(&sb, "#EXTINF:-1 tvg-id=\"ch%d\" group-title=\"News\",Channel %d\nhttp://provider.example/%d.ts\n", i, i, i)
+	}
+	return sb.String()
+}
+
+func TestCheckM3UIntegrity(t *testing.T) {
+	assert.NoError(t, checkM3UIntegrity([]byte(testPlaylist(10)), 10, 10))
+	assert.NoError(t, checkM3UIntegrity([]byte(testPlaylist(6)), 6, 10))
+	assert.NoError(t, checkM3UIntegrity([]byte(testPlaylist(1)), 1, 0), "no previous update")
+
+	assert.Error(t, checkM3UIntegrity([]byte(testPlaylist(4)), 4, 10), "less than half of the channels")
+	assert.Error(t, checkM3UIntegrity([]byte("<html>Error</html>\n#EXTM3U\n"), 0, 0))
+	assert.Error(t, checkM3UIntegrity([]byte(testPlaylist(10)+"#EXTINF:-1,Channel 10"), 10, 10))
+}
+
+func TestGetProviderData_KeepLastGood(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if response == "reset" {
+			// Connection reset in the middle of the transfer
+			w.Header().Set("Content-Length", "100000")
+			_, _ = w.Write([]byte(testPlaylist(10)))
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir() + "/"
+	System.AppName = "xteve"
+	System.Folder.Data = tmpDir
+	System.File.Settings = tmpDir + "settings.json"
+	Settings.ProviderKeepLastGood = true
+	Settings.ProviderDownloadConcurrency = 0
+
+	var good = testPlaylist(10)
+	require.NoError(t, os.WriteFile(tmpDir+"M1.m3u", []byte(good), 0644))
+
+	for _, tt := range []struct {
+		name     string
+		response string
+	}{
+		{"connection reset", "reset"},
+		{"truncated entry", testPlaylist(9) + "#EXTINF:-1 tvg-id=\"ch9\" group-title=\"News\",Chan"},
+		{"missing channels", testPlaylist(3)},
+		{"error page", "<html><body>Service Unavailable</body></html>"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			response = tt.response
+			Settings.Files.M3U = map[string]any{"M1": map[string]any{
+				"name":          "Provider",
+				"file.source":   server.URL + "/playlist.m3u",
+				"file.xteve":    "M1.m3u",
+				"compatibility": map[string]any{"streams": float64(10)},
+			}}
+
+			assert.Error(t, getProviderData(t.Context(), "m3u", "M1"))
+
+			content, err := os.ReadFile(tmpDir + "M1.m3u")
+			require.NoError(t, err)
+			assert.Equal(t, good, string(content), "the last good playlist is kept")
+		})
+	}
+
+	// A complete playlist replaces the file
+	response = testPlaylist(8)
+	assert.NoError(t, getProviderData(t.Context(), "m3u", "M1"))
+	content, err := os.ReadFile(tmpDir + "M1.m3u")
+	require.NoError(t, err)
+	assert.Equal(t, testPlaylist(8), string(content))
+}
@@ -1,11 +1,15 @@
 package src
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestDownloadFileFromServer_Limit(t *testing.T) {
@@ -14,9 +18,10 @@ func TestDownloadFileFromServer_Limit(t *testing.T) {
 	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
 
 	// Temporarily reduce limit
-	originalLimit := maxProviderDownloadSize
+	originalLimit, originalSettings := maxProviderDownloadSize, Settings
 	maxProviderDownloadSize = 1024 // 1KB
-	defer func() { maxProviderDownloadSize = originalLimit }()
+	Settings.ProviderMaxDownloadMB = 0
+	defer func() { maxProviderDownloadSize, Settings = originalLimit, originalSettings }()
 
 	// Start test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -27,7 +32,7 @@ func TestDownloadFileFromServer_Limit(t *testing.T) {
 	defer server.Close()
 
 	// Call downloadFileFromServer
-	_, _, err := downloadFileFromServer(t.Context(), server.URL)
+	_, _, _, err := downloadFileFromServer(t.Context(), server.URL)
 
 	if err == nil {
 		t.Error("Expected error due to size limit, got nil")
@@ -45,9 +50,10 @@ func TestDownloadFileFromServer_ContentLengthLimit(t *testing.T) {
 	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
 
 	// Temporarily reduce limit
-	originalLimit := maxProviderDownloadSize
+	originalLimit, originalSettings := maxProviderDownloadSize, Settings
 	maxProviderDownloadSize = 1024 // 1KB
-	defer func() { maxProviderDownloadSize = originalLimit }()
+	Settings.ProviderMaxDownloadMB = 0
+	defer func() { maxProviderDownloadSize, Settings = originalLimit, originalSettings }()
 
 	// Start test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -59,7 +65,7 @@ func TestDownloadFileFromServer_ContentLengthLimit(t *testing.T) {
 	defer server.Close()
 
 	// Call downloadFileFromServer
-	_, _, err := downloadFileFromServer(t.Context(), server.URL)
+	_, _, _, err := downloadFileFromServer(t.Context(), server.URL)
 
 	if err == nil {
 		t.Error("Expected error due to size limit, got nil")
@@ -105,3 +111,80 @@ func TestHandleHLSStream_Limit(t *testing.T) {
 		t.Error("Expected error passed to errorHandler, got nil")
 	}
 }
+
+func TestDownloadFileFromServer_Charset(t *testing.T) {
+	// Bypass SSRF protection for test
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/x-mpegurl; charset=ISO-8859-1")
+		_, _ = w.Write([]byte("#EXTM3U\n"))
+	}))
+	defer server.Close()
+
+	_, _, charset, err := downloadFileFromServer(t.Context(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if charset != "ISO-8859-1" {
+		t.Errorf("Expected charset ISO-8859-1, got %q", charset)
+	}
+}
+
+func TestDownloadFileFromServer_Concurrency(t *testing.T) {
+	t.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	const limit = 2
+	Settings.ProviderDownloadConcurrency = limit
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("#EXTM3U\n"))
+	}))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for range limit + 2 {
+		wg.Go(func() {
+			if _, _, _, err := downloadFileFromServer(t.Context(), server.URL); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got > limit {
+		t.Errorf("Expected at most %d concurrent downloads, got %d", limit, got)
+	}
+}
+
+func TestAcquireProviderDownload_Canceled(t *testing.T) {
+	release, err := acquireProviderDownload(t.Context(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := acquireProviderDownload(ctx, 1); err == nil {
+		t.Error("Expected an error while all download slots are in use")
+	}
+}
@@ -0,0 +1,87 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProviderData_LinkedEPG(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() { Settings, System = oldSettings, oldSystem })
+
+	var epgDownloads atomic.Int64
+	var epg = `<tv><channel id="ch1"><display-name>Channel 1</display-name></channel></tv>`
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/playlist.m3u":
+			_, _ = w.Write([]byte("#EXTM3U url-tvg=\"" + server.URL + "/epg.xml\"\n#EXTINF:-1 tvg-id=\"ch1\",Channel 1\nhttp://provider.example/1.ts\n"))
+		case "/epg.xml":
+			epgDownloads.Add(1)
+			_, _ = w.Write([]byte(epg))
+		}
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir() + "/"
+	System.AppName = "xteve"
+	System.Folder.Data = tmpDir
+	System.File.Settings = tmpDir + "settings.json"
+	Settings.ProviderDownloadConcurrency = 0
+	Settings.Files.XMLTV = nil
+	Settings.Files.M3U = map[string]any{"M1": map[string]any{
+		"name":        "Provider",
+		"file.source": server.URL + "/playlist.m3u",
+		"epg.auto":    true,
+	}}
+
+	// The EPG URL of the header is registered as XMLTV file of the playlist
+	require.NoError(t, getProviderData(t.Context(), "m3u", "M1"))
+
+	var m3uData = Settings.Files.M3U["M1"].(map[string]any)
+	xmltvID, ok := m3uData["epg.xmltv"].(string)
+	require.True(t, ok, "the XMLTV file is linked to the playlist")
+
+	xmltv, ok := Settings.Files.XMLTV[xmltvID].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, server.URL+"/epg.xml", xmltv["file.source"])
+	assert.Equal(t, "M1", xmltv["linked.m3u"])
+	assert.Equal(t, "Provider (EPG)", xmltv["name"])
+	assert.FileExists(t, tmpDir+xmltvID+".xml")
+	assert.Equal(t, int64(1), epgDownloads.Load())
+
+	// An update of the playlist updates the EPG, the link is kept
+	epg = `<tv><channel id="ch2"><display-name>Channel 2</display-name></channel></tv>`
+	require.NoError(t, getProviderData(t.Context(), "m3u", "M1"))
+	assert.Equal(t, xmltvID, m3uData["epg.xmltv"])
+	assert.Len(t, Settings.Files.XMLTV, 1)
+	assert.Equal(t, int64(2), epgDownloads.Load())
+
+	content, err := os.ReadFile(tmpDir + xmltvID + ".xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "ch2")
+
+	// The update of all XMLTV files skips the linked file
+	require.NoError(t, getProviderData(t.Context(), "xmltv", ""))
+	assert.Equal(t, int64(2), epgDownloads.Load())
+
+	// Without epg.auto, the linked file is updated with the other XMLTV files
+	m3uData["epg.auto"] = false
+	require.NoError(t, getProviderData(t.Context(), "xmltv", ""))
+	assert.Equal(t, int64(3), epgDownloads.Load())
+
+	// The EPG is removed together with the playlist
+	deleteLocalProviderFiles("M1", "m3u")
+	assert.Empty(t, Settings.Files.XMLTV)
+	assert.NoFileExists(t, tmpDir+xmltvID+".xml")
+}
@@ -0,0 +1,102 @@
+package src
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestProvider(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+	Settings.UserAgent = "xTeVe"
+
+	var userAgent string
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		switch r.URL.Path {
+		case "/playlist.m3u":
+			var playlist = "#EXTM3U\n"
+			for _, name := range []string{"One", "Two", "Three", "Four", "Five", "Six"} {
+				playlist += "#EXTINF:-1 tvg-id=\"" + name + "\" group-title=\"Test\"," + name + "\nhttp://provider.example/" + name + ".ts\n"
+			}
+			w.Write([]byte(playlist))
+		case "/guide.xml":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><tv><channel id="ch1"><display-name>Channel 1</display-name></channel><channel id="ch2"></channel><programme channel="ch1" start="20240101000000 +0000" stop="20240101010000 +0000"><title>Show</title></programme></tv>`))
+		case "/html":
+			w.Write([]byte("<html><body>Login</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer mock.Close()
+
+	t.Run("SSRF protection", func(t *testing.T) {
+		os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+		_, err := testProvider(context.Background(), "m3u", mock.URL+"/playlist.m3u", "")
+		assert.Error(t, err, "loopback addresses are blocked like in the download path")
+	})
+
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	t.Cleanup(func() { os.Unsetenv("XTEVE_ALLOW_LOOPBACK") })
+
+	result, err := testProvider(context.Background(), "m3u", mock.URL+"/playlist.m3u", "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.Status)
+	assert.Positive(t, result.Size)
+	assert.Equal(t, 6, result.Channels)
+	assert.Equal(t, []string{"One", "Two", "Three", "Four", "Five"}, result.Preview)
+	assert.Equal(t, "xTeVe", userAgent)
+
+	result, err = testProvider(context.Background(), "xmltv", mock.URL+"/guide.xml", "VLC")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Channels)
+	assert.Equal(t, 1, result.Programs)
+	assert.Equal(t, []string{"Channel 1", "ch2"}, result.Preview)
+	assert.Equal(t, "VLC", userAgent)
+
+	result, err = testProvider(context.Background(), "m3u", mock.URL+"/html", "")
+	assert.ErrorContains(t, err, "#EXTM3U")
+	assert.Equal(t, http.StatusOK, result.Status)
+
+	result, err = testProvider(context.Background(), "xmltv", mock.URL+"/missing.xml", "")
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, result.Status)
+
+	_, err = testProvider(context.Background(), "hdhr", mock.URL+"/playlist.m3u", "")
+	assert.Error(t, err)
+	_, err = testProvider(context.Background(), "m3u", "file:///etc/passwd", "")
+	assert.Error(t, err)
+
+	// Websocket command
+	Settings.AuthenticationWEB = false
+	oldFiles := Settings.Files
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, ws.WriteJSON(map[string]string{"cmd": "testProvider", "type": "m3u", "url": mock.URL + "/playlist.m3u", "user-agent": "Kodi"}))
+
+	var response struct {
+		Status       bool                `json:"status"`
+		ProviderTest *ProviderTestStruct `json:"providerTest"`
+	}
+	require.NoError(t, ws.ReadJSON(&response))
+	assert.True(t, response.Status)
+	require.NotNil(t, response.ProviderTest)
+	assert.Equal(t, 6, response.ProviderTest.Channels)
+	assert.Equal(t, "Kodi", userAgent)
+	assert.Equal(t, oldFiles, Settings.Files, "nothing is saved")
+}
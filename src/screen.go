@@ -1,14 +1,64 @@
 package src
 
 import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"log"
 	"runtime"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
+type streamLogIDKey struct{}
+
+// withStreamLogID : Adds a short correlation ID for the log messages of a stream to the context.
+// The ID is taken from the trace ID if tracing is active, otherwise it is generated.
+func withStreamLogID(ctx context.Context) context.Context {
+	if streamLogID(ctx) != "" {
+		return ctx
+	}
+
+	var id string
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		id = sc.TraceID().String()[:8]
+	} else {
+		var b = make([]byte, 4)
+		_, _ = rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+
+	return context.WithValue(ctx, streamLogIDKey{}, id)
+}
+
+// streamLogID : Correlation ID of the context, empty if there is none
+func streamLogID(ctx context.Context) string {
+	id, _ := ctx.Value(streamLogIDKey{}).(string)
+	return id
+}
+
+func logIDPrefix(id string) string {
+	if id == "" {
+		return ""
+	}
+	return "[" + id + "] "
+}
+
 func showInfo(str string) {
+	logInfo("", str)
+}
+
+// showStreamInfo : Info message of a stream, prefixed with the correlation ID of the context
+func showStreamInfo(ctx context.Context, str string) {
+	logInfo(streamLogID(ctx), str)
+}
+
+func logInfo(id, str string) {
 	if System.Flag.Info {
 		return
 	}
@@ -25,7 +75,7 @@ func showInfo(str string) {
 
 		msg[0] = msg[0] + ":" + space
 
-		var logMsg = fmt.Sprintf("[%s] %s%s", System.Name, msg[0], msg[1])
+		var logMsg = fmt.Sprintf("[%s] %s%s%s", System.Name, logIDPrefix(id), msg[0], msg[1])
 
 		printLogOnScreen(logMsg, "info")
 
@@ -38,8 +88,59 @@ func showInfo(str string) {
 	}
 }
 
+// Subsystems with their own debug level (debug.levels)
+const (
+	debugBuffer    = "buffer"
+	debugWebDAV    = "webdav"
+	debugWebserver = "webserver"
+	debugXEPG      = "xepg"
+)
+
+// debugPrefixes : Message prefixes of the subsystems, used for debug messages without an explicit subsystem
+var debugPrefixes = []struct{ prefix, subsystem string }{
+	{"buffer", debugBuffer},
+	{"hls status", debugBuffer},
+	{"streaming status", debugBuffer},
+	{"web server", debugWebserver},
+	{"webdav", debugWebDAV},
+	{"xepg", debugXEPG},
+}
+
+// debugSubsystem : Subsystem of a debug message by its prefix, empty if the prefix is unknown
+func debugSubsystem(str string) string {
+	var prefix, _, _ = strings.Cut(strings.ToLower(str), ":")
+	for _, p := range debugPrefixes {
+		if strings.HasPrefix(prefix, p.prefix) {
+			return p.subsystem
+		}
+	}
+	return ""
+}
+
+// debugLevel : Debug level of a subsystem. debug.levels overrides the global level (-debug) for the listed subsystems.
+func debugLevel(subsystem string) int {
+	if level, ok := Settings.DebugLevels[subsystem]; ok && len(subsystem) > 0 {
+		return level
+	}
+	return System.Flag.Debug
+}
+
 func showDebug(str string, level int) {
-	if System.Flag.Debug < level {
+	logDebug(debugSubsystem(str), "", str, level)
+}
+
+// showSubsystemDebug : Debug message of a subsystem, for messages without a known prefix
+func showSubsystemDebug(subsystem, str string, level int) {
+	logDebug(subsystem, "", str, level)
+}
+
+// showStreamDebug : Debug message of a stream, prefixed with the correlation ID of the context
+func showStreamDebug(ctx context.Context, str string, level int) {
+	logDebug(debugBuffer, streamLogID(ctx), str, level)
+}
+
+func logDebug(subsystem, id, str string, level int) {
+	if debugLevel(subsystem) < level {
 		return
 	}
 
@@ -54,7 +155,7 @@ func showDebug(str string, level int) {
 		}
 		msg[0] = msg[0] + ":" + space
 
-		var logMsg = fmt.Sprintf("[DEBUG] %s%s", msg[0], msg[1])
+		var logMsg = fmt.Sprintf("[DEBUG] %s%s%s", logIDPrefix(id), msg[0], msg[1])
 
 		printLogOnScreen(logMsg, "debug")
 
@@ -172,3 +273,25 @@ func logCleanUp() {
 	}
 	WebScreenLog.Log = logs
 }
+
+// writeLogFile writes the log from RAM with a short system information header (/download/logs)
+func writeLogFile(w io.Writer) (err error) {
+	Lock.RLock()
+	var activeStreams = activeStreamCount()
+	Lock.RUnlock()
+
+	var bw = bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s %s (Build: %s)\n", System.Name, System.Version, System.Build)
+	fmt.Fprintf(bw, "OS / Arch:      %s / %s\n", System.OS, System.ARCH)
+	fmt.Fprintf(bw, "Active streams: %d\n", activeStreams)
+	fmt.Fprintf(bw, "Created:        %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	WebScreenLog.Mu.RLock()
+	for _, line := range WebScreenLog.Log {
+		bw.WriteString(line)
+		bw.WriteByte('\n')
+	}
+	WebScreenLog.Mu.RUnlock()
+
+	return bw.Flush()
+}
@@ -0,0 +1,142 @@
+package src
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// captureScreenLog : Returns a function that reads the log entries written since the call
+func captureScreenLog(t *testing.T) func() []string {
+	t.Helper()
+
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+	Settings.LogEntriesRAM = 500
+
+	WebScreenLog.Mu.Lock()
+	oldLog := WebScreenLog.Log
+	WebScreenLog.Log = nil
+	WebScreenLog.Mu.Unlock()
+
+	t.Cleanup(func() {
+		WebScreenLog.Mu.Lock()
+		WebScreenLog.Log = oldLog
+		WebScreenLog.Mu.Unlock()
+	})
+
+	return func() []string {
+		WebScreenLog.Mu.Lock()
+		defer WebScreenLog.Mu.Unlock()
+
+		var entries []string
+		for _, entry := range WebScreenLog.Log {
+			entries = append(entries, strings.ReplaceAll(entry, "&nbsp;", " "))
+		}
+		return entries
+	}
+}
+
+func TestStreamLogID(t *testing.T) {
+	oldSystem := System
+	t.Cleanup(func() { System = oldSystem })
+	System.Name = "xTeVe"
+	System.Flag.Info = false
+	System.Flag.Debug = 1
+
+	logs := captureScreenLog(t)
+
+	ctx := withStreamLogID(context.Background())
+	id := streamLogID(ctx)
+	assert.Regexp(t, "^[0-9a-f]{8}$", id)
+	assert.Equal(t, id, streamLogID(withStreamLogID(ctx)), "an existing ID is kept")
+	assert.NotEqual(t, id, streamLogID(withStreamLogID(context.Background())))
+
+	// The ID is derived from the trace
+	traceID, err := trace.TraceIDFromHex("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID}))
+	assert.Equal(t, "01234567", streamLogID(withStreamLogID(traced)))
+
+	showStreamInfo(ctx, "Streaming Status:Test")
+	showStreamDebug(ctx, "Streaming Status:Test", 1)
+	showInfo("Streaming Status:Test")
+
+	entries := logs()
+	require.Len(t, entries, 3)
+	assert.Contains(t, entries[0], "[xTeVe] ["+id+"] Streaming Status:       Test")
+	assert.Contains(t, entries[1], "[DEBUG] ["+id+"] Streaming Status:       Test")
+	assert.Contains(t, entries[2], "[xTeVe] Streaming Status:       Test", "the global format is unchanged")
+}
+
+func TestBufferingStream_LogCorrelationID(t *testing.T) {
+	newSource, get := setupLingerTest(t, 0)
+	System.Name = "xTeVe"
+	System.Flag.Info = false
+	logs := captureScreenLog(t)
+
+	source, _ := newSource()
+	get(source.URL)
+	require.Eventually(t, func() bool { return activeTuners("M1") == 0 }, 5*time.Second, 50*time.Millisecond)
+
+	var ids = make(map[string]bool)
+	var pattern = regexp.MustCompile(`\[xTeVe\] \[([0-9a-f]{8})\] Streaming (Status|Type|URL):`)
+	for _, entry := range logs() {
+		if match := pattern.FindStringSubmatch(entry); match != nil {
+			ids[match[1]] = true
+		}
+	}
+
+	assert.Len(t, ids, 1, "all log lines of the stream have the same ID")
+}
+
+func TestDebugLevels(t *testing.T) {
+	t.Cleanup(setupMappingTestGlobals())
+	System.Flag.Debug = 1
+	System.File.XEPG = filepath.Join(t.TempDir(), "xepg.json")
+
+	logs := captureScreenLog(t)
+	Settings.DebugLevels = map[string]int{debugBuffer: 3, debugXEPG: 0}
+	Settings.DefaultMissingEPG = "-"
+
+	assert.Equal(t, debugBuffer, debugSubsystem("Buffer Status:Done"))
+	assert.Equal(t, debugWebserver, debugSubsystem("Web Server Request:Path: /"))
+	assert.Empty(t, debugSubsystem("Save Setting:Key"))
+
+	messages := func() (messages []string) {
+		for _, entry := range logs() {
+			if _, message, ok := strings.Cut(entry, "[DEBUG] "); ok {
+				messages = append(messages, strings.Join(strings.Fields(message), " "))
+			}
+		}
+		return
+	}
+
+	showDebug("Buffer Status:Level 3", 3)                             // buffer: 3
+	showStreamDebug(context.Background(), "Connection to:Level 3", 3) // Stream messages belong to the buffer
+	showDebug("Web server:Level 1", 1)                                // Global level 1
+	showDebug("Web server:Level 2", 2)
+	showSubsystemDebug(debugBuffer, "Request:Level 2", 2)
+	showDebug("Save Setting:Level 1", 1)
+
+	// xepg: 0, the mapping is not logged
+	Data.XEPG.Channels["x-ID.1"] = XEPGChannelStruct{Name: "Zeta", TvgID: "channel1.tvg.id", XmltvFile: "-", XMapping: "-"}
+	require.NoError(t, mapping())
+	assert.Equal(t, []string{"Buffer Status: Level 3", "Connection to: Level 3", "Web server: Level 1", "Request: Level 2", "Save Setting: Level 1"}, messages())
+
+	// xepg: 2 with the global level 0
+	System.Flag.Debug = 0
+	Settings.DebugLevels = map[string]int{debugXEPG: 2}
+	Data.XEPG.Channels["x-ID.1"] = XEPGChannelStruct{Name: "Zeta", TvgID: "channel1.tvg.id", XmltvFile: "-", XMapping: "-"}
+	require.NoError(t, mapping())
+	showDebug("Web server:Level 1", 1)
+	assert.Equal(t, "Channel Mapping: Zeta -> test_provider.xml (channel1.tvg.id)", messages()[5])
+	assert.Len(t, messages(), 6)
+}
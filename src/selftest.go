@@ -0,0 +1,100 @@
+package src
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"strings"
+)
+
+// selfTestAssets : Files of the web interface that are needed at runtime, apart from the templates
+var selfTestAssets = []string{"html/video/stream-limit.bin", "html/favicon.ico"}
+
+// SelfTest : Checks the embedded web interface (-selftest). Broken builds are detected before they are deployed.
+// Every check is printed, the error lists all failed checks. Missing JavaScript files already stop xTeVe in init().
+func SelfTest(w io.Writer) error {
+	var failed []string
+
+	check := func(name string, err error) {
+		if err != nil {
+			failed = append(failed, name)
+			fmt.Fprintf(w, "FAIL  %s: %v\n", name, err)
+			return
+		}
+		fmt.Fprintf(w, "OK    %s\n", name)
+	}
+
+	for _, asset := range selfTestAssets {
+		content, err := webUI.ReadFile(asset)
+		if err == nil && len(content) == 0 {
+			err = errors.New("empty file")
+		}
+		check(asset, err)
+	}
+
+	// Language files
+	var languages = make(map[string]map[string]any)
+	langFiles, err := fs.Glob(webUI, "html/lang/*.json")
+	if err == nil && len(langFiles) == 0 {
+		err = errors.New("no language files")
+	}
+	check("html/lang", err)
+
+	for _, file := range langFiles {
+		var lang map[string]any
+		content, err := webUI.ReadFile(file)
+		if err == nil {
+			err = json.Unmarshal(content, &lang)
+		}
+		if err == nil {
+			var language LanguageUI
+			err = bindToStruct(lang, &language)
+		}
+		if err == nil {
+			languages[file] = lang
+		}
+		check(file, err)
+	}
+
+	if _, ok := languages["html/lang/en.json"]; !ok {
+		check("html/lang/en.json", errors.New("the fallback language is missing"))
+	}
+
+	// Templates, rendered like in the Web handler with every language
+	err = fs.WalkDir(webUI, "html", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(getContentType(file))
+		if mediaType != "text/html" && mediaType != "application/javascript" {
+			return nil
+		}
+
+		content, err := webUI.ReadFile(file)
+		if err != nil {
+			check(file, err)
+			return nil
+		}
+
+		for langFile, lang := range languages {
+			if _, err := renderTemplate(string(content), lang); err != nil {
+				check(file, fmt.Errorf("%s: %w", path.Base(langFile), err))
+				return nil
+			}
+		}
+		check(file, nil)
+		return nil
+	})
+	check("templates", err)
+
+	fmt.Fprintf(w, "Self-test: %d failed\n", len(failed))
+	if len(failed) > 0 {
+		return fmt.Errorf("self-test failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfTest(t *testing.T) {
+	var out strings.Builder
+	require.NoError(t, SelfTest(&out), out.String())
+
+	assert.Contains(t, out.String(), "OK    html/lang/en.json")
+	assert.Contains(t, out.String(), "OK    html/video/stream-limit.bin")
+	assert.Contains(t, out.String(), "OK    html/index.html")
+	assert.Contains(t, out.String(), "Self-test: 0 failed")
+
+	// The asset checked by the self-test is served
+	rr := httptest.NewRecorder()
+	Index(rr, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	result, err := renderTemplate("<p>{{.title}}</p>", map[string]any{"title": "xTeVe"})
+	require.NoError(t, err)
+	assert.Equal(t, "<p>xTeVe</p>", result)
+
+	_, err = renderTemplate("<p>{{.title</p>", nil)
+	assert.Error(t, err)
+
+	_, err = renderTemplate("<p>{{.title.name}}</p>", map[string]any{"title": 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSettings(t *testing.T) {
+	base := SettingsStruct{
+		Port:         "34400",
+		EpgSource:    "XEPG",
+		Tuner:        1,
+		M3USortOrder: "channel-number",
+	}
+	base.Files.M3U = map[string]any{"M1": map[string]any{"name": "Provider"}}
+
+	tests := []struct {
+		name     string
+		modify   func(s *SettingsStruct)
+		expected settingsChanges
+	}{
+		{name: "no changes", modify: func(s *SettingsStruct) {}},
+		{name: "tuner only", modify: func(s *SettingsStruct) { s.Tuner = 4 }},
+		{name: "port", modify: func(s *SettingsStruct) { s.Port = "34500" }, expected: settingsChanges{Webserver: true}},
+		{name: "tls", modify: func(s *SettingsStruct) { s.TLSMode = true }, expected: settingsChanges{Webserver: true}},
+		{name: "epg source", modify: func(s *SettingsStruct) { s.EpgSource = "PMS" }, expected: settingsChanges{Database: true}},
+		{name: "provider", modify: func(s *SettingsStruct) {
+			s.Files.M3U = map[string]any{"M1": map[string]any{"name": "Renamed"}}
+		}, expected: settingsChanges{Database: true}},
+		{name: "sort order", modify: func(s *SettingsStruct) { s.M3USortOrder = "name" }, expected: settingsChanges{Files: true}},
+		{name: "generate progid", modify: func(s *SettingsStruct) { s.XMLTVGenerateProgID = true }, expected: settingsChanges{Files: true}},
+		{name: "channel name prefix", modify: func(s *SettingsStruct) { s.ChannelNamePrefix = "HD " }, expected: settingsChanges{Files: true}},
+		{name: "channel name suffix", modify: func(s *SettingsStruct) { s.ChannelNameSuffix = " (UK)" }, expected: settingsChanges{Files: true}},
+		{name: "default channel logo", modify: func(s *SettingsStruct) { s.DefaultChannelLogo = "http://logo.example/default.png" }, expected: settingsChanges{Files: true}},
+		{name: "source ids", modify: func(s *SettingsStruct) { s.XMLTVUseSourceIDs = true }, expected: settingsChanges{Files: true}},
+		{name: "direct urls", modify: func(s *SettingsStruct) { s.M3UDirectURLs = true }, expected: settingsChanges{Files: true}},
+		{name: "group renames", modify: func(s *SettingsStruct) {
+			s.GroupRenames = map[string]string{"Sports HD": "Sports"}
+		}, expected: settingsChanges{Database: true}},
+		{name: "mapping name rules", modify: func(s *SettingsStruct) {
+			s.MappingNameRules = []MappingNameRule{{Pattern: " HD$"}}
+		}, expected: settingsChanges{Database: true}},
+		{name: "prefer source chno", modify: func(s *SettingsStruct) { s.PreferSourceChno = true }, expected: settingsChanges{Database: true}},
+		{name: "retain missing days", modify: func(s *SettingsStruct) { s.XepgRetainMissingDays = 7 }, expected: settingsChanges{Database: true}},
+		{name: "tolerant parse", modify: func(s *SettingsStruct) { s.XMLTVTolerantParse = true }, expected: settingsChanges{Database: true}},
+		{name: "group order", modify: func(s *SettingsStruct) { s.GroupOrder = []string{"News", "Sports"} }, expected: settingsChanges{Files: true}},
+		{name: "output profiles", modify: func(s *SettingsStruct) {
+			s.OutputProfiles = []OutputProfile{{Name: "kitchen"}}
+		}, expected: settingsChanges{Files: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var newSettings SettingsStruct
+			require.NoError(t, bindToStruct(base, &newSettings))
+			tt.modify(&newSettings)
+
+			assert.Equal(t, tt.expected, diffSettings(base, newSettings))
+		})
+	}
+}
+
+func TestAPISettingsReload(t *testing.T) {
+	originalSettings := Settings
+	originalSystem := System
+	originalVFS := bufferVFS
+	t.Cleanup(func() {
+		Settings = originalSettings
+		System = originalSystem
+		bufferVFS = originalVFS
+	})
+
+	System.Folder.Config = t.TempDir() + string(os.PathSeparator)
+	System.Folder.Temp = t.TempDir() + string(os.PathSeparator)
+	System.File.Settings = System.Folder.Config + "settings.json"
+	require.NoError(t, saveMapToJSONFile(System.File.Settings, map[string]any{}))
+
+	_, err := loadSettings()
+	require.NoError(t, err)
+	require.Equal(t, 1, Settings.Tuner)
+
+	// Change the settings file externally
+	settingsMap, err := loadJSONFileToMap(System.File.Settings)
+	require.NoError(t, err)
+	settingsMap["tuner"] = 3
+	require.NoError(t, saveMapToJSONFile(System.File.Settings, settingsMap))
+
+	req := httptest.NewRequest("POST", "/api/", bytes.NewBufferString(`{"cmd":"settings.reload"}`))
+	req.RemoteAddr = "127.0.0.1:1234"
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	API(w, req)
+
+	var response APIResponseStruct
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Status, response.Error)
+	assert.Equal(t, []string{"settings"}, response.Reloaded)
+	assert.Equal(t, 3, Settings.Tuner)
+}
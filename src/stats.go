@@ -0,0 +1,110 @@
+package src
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	statsHistoryWindow = time.Hour    // Time span of the samples returned by the API command stats.history
+	statsHistoryFile   = "stats.json" // In the cache folder, with stats.persist
+)
+
+// statsHistory : Samples of the tuner usage, ordered by time. Samples older than stats.retention.hours are removed.
+type statsHistory struct {
+	sync.Mutex
+	samples []StatsSampleStruct
+}
+
+var (
+	streamingStats      statsHistory
+	streamingStatsStart sync.Once
+)
+
+// add appends a sample and removes the samples that are older than the retention
+func (h *statsHistory) add(sample StatsSampleStruct, retention time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.samples = append(h.samples, sample)
+
+	var oldest = sample.Time - int64(retention.Seconds())
+	var expired = 0
+	for expired < len(h.samples) && h.samples[expired].Time < oldest {
+		expired++
+	}
+	h.samples = slices.Delete(h.samples, 0, expired)
+}
+
+// since returns a copy of the samples recorded at or after t
+func (h *statsHistory) since(t time.Time) []StatsSampleStruct {
+	h.Lock()
+	defer h.Unlock()
+
+	var i, _ = slices.BinarySearchFunc(h.samples, t.Unix(), func(sample StatsSampleStruct, t int64) int {
+		return int(sample.Time - t)
+	})
+	return slices.Clone(h.samples[i:])
+}
+
+// getTunerStatus : Active tuners, available tuners and the sum of the measured bandwidth of all streams in the buffer
+func getTunerStatus() (active, all, bandwidth int64) {
+	Lock.RLock()
+	defer Lock.RUnlock()
+
+	BufferInformation.Range(func(_, v any) bool {
+		if playlist, ok := v.(*Playlist); ok {
+			active += int64(len(playlist.Streams))
+			all += int64(playlist.Tuner)
+			for _, stream := range playlist.Streams {
+				bandwidth += int64(stream.NetworkBandwidth)
+			}
+		}
+		return true
+	})
+	return
+}
+
+// recordStatsSample : Adds the current tuner usage to the history, with stats.persist the history is saved
+func recordStatsSample(now time.Time) {
+	var sample = StatsSampleStruct{Time: now.Unix()}
+	sample.TunerActive, _, sample.Bandwidth = getTunerStatus()
+
+	streamingStats.add(sample, time.Duration(Settings.StatsRetentionHours)*time.Hour)
+
+	if Settings.StatsPersist {
+		if err := saveMapToJSONFile(System.Folder.Cache+statsHistoryFile, streamingStats.since(time.Time{})); err != nil {
+			ShowError(err, 0)
+		}
+	}
+}
+
+// startStatsSampling : Records a sample every stats.interval.seconds, the history of the last run is loaded with stats.persist.
+// The sampling is started once, a restart of the web server does not start it again.
+func startStatsSampling() {
+	streamingStatsStart.Do(func() {
+		if Settings.StatsIntervalSeconds <= 0 {
+			return
+		}
+
+		if Settings.StatsPersist {
+			var samples []StatsSampleStruct
+			if err := loadJSONFile(System.Folder.Cache+statsHistoryFile, &samples); err == nil {
+				var retention = time.Duration(Settings.StatsRetentionHours) * time.Hour
+				for _, sample := range samples {
+					streamingStats.add(sample, retention)
+				}
+			}
+		}
+
+		go func() {
+			ticker := time.NewTicker(time.Duration(Settings.StatsIntervalSeconds) * time.Second)
+			defer ticker.Stop()
+
+			for now := range ticker.C {
+				recordStatsSample(now)
+			}
+		}()
+	})
+}
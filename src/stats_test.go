@@ -0,0 +1,83 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsHistory_AddAndPrune(t *testing.T) {
+	var history statsHistory
+	var start = time.Unix(1700000000, 0)
+
+	// One sample per minute for three hours, two hours are kept
+	for i := range 180 {
+		history.add(StatsSampleStruct{Time: start.Add(time.Duration(i) * time.Minute).Unix(), TunerActive: int64(i)}, 2*time.Hour)
+	}
+
+	var all = history.since(time.Time{})
+	require.Len(t, all, 121)
+	assert.Equal(t, int64(59), all[0].TunerActive, "samples older than the retention are removed")
+	assert.Equal(t, int64(179), all[len(all)-1].TunerActive)
+
+	var lastHour = history.since(start.Add(179 * time.Minute).Add(-time.Hour))
+	require.Len(t, lastHour, 61)
+	assert.Equal(t, int64(119), lastHour[0].TunerActive)
+
+	// The returned samples are a copy
+	lastHour[0].TunerActive = -1
+	assert.Equal(t, int64(119), history.since(start.Add(119 * time.Minute))[0].TunerActive)
+}
+
+func TestRecordStatsSample(t *testing.T) {
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() {
+		Settings, System = oldSettings, oldSystem
+		streamingStats = statsHistory{}
+	})
+	streamingStats = statsHistory{}
+
+	System.Folder.Cache = t.TempDir() + string(os.PathSeparator)
+	Settings.StatsRetentionHours = 24
+	Settings.StatsPersist = true
+
+	BufferInformation.Store("M1", &Playlist{
+		PlaylistID: "M1",
+		Tuner:      2,
+		Streams: map[int]ThisStream{
+			0: {NetworkBandwidth: 4000000},
+			1: {NetworkBandwidth: 1000000},
+		},
+	})
+	t.Cleanup(func() { BufferInformation.Delete("M1") })
+
+	var now = time.Now()
+	recordStatsSample(now.Add(-2 * time.Hour))
+	recordStatsSample(now.Add(-time.Minute))
+
+	// The samples are persisted
+	var saved []StatsSampleStruct
+	require.NoError(t, loadJSONFile(System.Folder.Cache+statsHistoryFile, &saved))
+	require.Len(t, saved, 2)
+	assert.Equal(t, StatsSampleStruct{Time: now.Add(-time.Minute).Unix(), TunerActive: 2, Bandwidth: 5000000}, saved[1])
+
+	// The API returns the last hour
+	req := httptest.NewRequest("POST", "/api/", bytes.NewBufferString(`{"cmd":"stats.history"}`))
+	req.RemoteAddr = "127.0.0.1:1234"
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	API(w, req)
+
+	var response APIResponseStruct
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.True(t, response.Status, response.Error)
+	require.Len(t, response.StatsHistory, 1)
+	assert.Equal(t, int64(2), response.StatsHistory[0].TunerActive)
+	assert.Equal(t, int64(5000000), response.StatsHistory[0].Bandwidth)
+}
@@ -0,0 +1,56 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamErrorClip(t *testing.T) {
+	oldSettings, oldStreamingURLS := Settings, Data.Cache.StreamingURLS
+	t.Cleanup(func() { Settings, Data.Cache.StreamingURLS = oldSettings, oldStreamingURLS })
+
+	Data.Cache.StreamingURLS = map[string]StreamInfo{"known": {URL: "http://provider.example/1.ts"}}
+
+	clip := filepath.Join(t.TempDir(), "error.ts")
+	content := []byte{0x47, 0x40, 0x00, 0x10}
+	require.NoError(t, os.WriteFile(clip, content, 0644))
+
+	request := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/stream/unknown", nil)
+		req.RequestURI = "/stream/unknown"
+		Stream(rr, req)
+		return rr
+	}
+
+	// Default: Text 404
+	rr := request()
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	Settings.StreamErrorClip = clip
+	rr = request()
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "video/mp2t", rr.Header().Get("Content-Type"))
+	assert.Equal(t, content, rr.Body.Bytes())
+	assert.Equal(t, "4", rr.Header().Get("Content-Length"))
+
+	// Range requests of media players are served from the file
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/stream/unknown", nil)
+	req.RequestURI = "/stream/unknown"
+	req.Header.Set("Range", "bytes=2-")
+	Stream(rr, req)
+	assert.Equal(t, http.StatusPartialContent, rr.Code)
+	assert.Equal(t, content[2:], rr.Body.Bytes())
+
+	// A missing clip falls back to the 404
+	Settings.StreamErrorClip = filepath.Join(t.TempDir(), "missing.ts")
+	rr = request()
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
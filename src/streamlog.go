@@ -0,0 +1,130 @@
+package src
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Size of the stream log (stream.log.path) before it is rotated, and the number of rotated files to keep
+const (
+	streamLogMaxSize = 10 << 20
+	streamLogBackups = 3
+)
+
+// streamLogEntry : One line of the stream log
+type streamLogEntry struct {
+	path string
+	line string
+}
+
+// streamLogSession : Start time, client and transferred bytes of a running stream
+type streamLogSession struct {
+	channelName string
+	clientIP    string
+	start       time.Time
+	bytes       atomic.Int64
+}
+
+var (
+	streamLogEntries  = make(chan streamLogEntry, 256)
+	streamLogWriter   sync.Once
+	streamLogSessions sync.Map // playlistID:streamID -> *streamLogSession
+)
+
+func streamLogKey(playlistID string, streamID int) string {
+	return playlistID + ":" + strconv.Itoa(streamID)
+}
+
+// logStreamStart records the start of a stream. Does nothing if stream.log.path is not set.
+func logStreamStart(playlistID string, streamID int, channelName, clientIP string) {
+	if len(Settings.StreamLogPath) == 0 {
+		return
+	}
+
+	var session = &streamLogSession{channelName: channelName, clientIP: clientIP, start: time.Now()}
+	streamLogSessions.Store(streamLogKey(playlistID, streamID), session)
+	writeStreamLog("start", session, 0)
+}
+
+// logStreamStop records the end of a stream with the transferred bytes and the duration
+func logStreamStop(playlistID string, streamID int) {
+	s, ok := streamLogSessions.LoadAndDelete(streamLogKey(playlistID, streamID))
+	if !ok || len(Settings.StreamLogPath) == 0 {
+		return
+	}
+
+	var session = s.(*streamLogSession)
+	writeStreamLog("stop", session, time.Since(session.start))
+}
+
+// addStreamLogBytes adds the bytes sent to a client to the running stream
+func addStreamLogBytes(playlistID string, streamID int, n int) {
+	if s, ok := streamLogSessions.Load(streamLogKey(playlistID, streamID)); ok {
+		s.(*streamLogSession).bytes.Add(int64(n))
+	}
+}
+
+// writeStreamLog queues a line for the stream log. Never blocks, the entry is dropped if the queue is full.
+func writeStreamLog(event string, session *streamLogSession, duration time.Duration) {
+	streamLogWriter.Do(func() { go processStreamLog() })
+
+	var line = fmt.Sprintf("%s %s client=%s channel=%s bytes=%d duration=%s\n",
+		time.Now().Format(time.RFC3339), event, session.clientIP, strconv.Quote(session.channelName),
+		session.bytes.Load(), duration.Round(time.Second))
+
+	select {
+	case streamLogEntries <- streamLogEntry{path: Settings.StreamLogPath, line: line}:
+	default:
+		showDebug("Stream Log:Queue is full, entry dropped", 1)
+	}
+}
+
+func processStreamLog() {
+	for entry := range streamLogEntries {
+		if err := appendStreamLog(entry.path, entry.line); err != nil {
+			ShowError(err, 0)
+		}
+	}
+}
+
+// appendStreamLog appends a line to the stream log and rotates it once it exceeds streamLogMaxSize
+func appendStreamLog(path, line string) (err error) {
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > streamLogMaxSize {
+		if err = rotateStreamLog(path); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+
+	if _, err = f.WriteString(line); err != nil {
+		f.Close()
+		return
+	}
+
+	return f.Close()
+}
+
+// rotateStreamLog renames stream.log -> stream.log.1 -> stream.log.2 ..., the oldest file is removed
+func rotateStreamLog(path string) (err error) {
+	var oldest = fmt.Sprintf("%s.%d", path, streamLogBackups)
+	if err = os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	for i := streamLogBackups - 1; i > 0; i-- {
+		var src = fmt.Sprintf("%s.%d", path, i)
+		if err = os.Rename(src, fmt.Sprintf("%s.%d", path, i+1)); err != nil && !os.IsNotExist(err) {
+			return
+		}
+	}
+
+	return os.Rename(path, path+".1")
+}
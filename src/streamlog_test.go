@@ -0,0 +1,73 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamLog_StartStop(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	Settings.StreamLogPath = filepath.Join(t.TempDir(), "stream.log")
+
+	logStreamStart("M1", 0, "Test Channel", "192.168.1.10")
+	addStreamLogBytes("M1", 0, 1000)
+	addStreamLogBytes("M1", 0, 500)
+	logStreamStop("M1", 0)
+
+	var content string
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(Settings.StreamLogPath)
+		content = string(data)
+		return err == nil && strings.Count(content, "\n") == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	assert.Contains(t, lines[0], ` start client=192.168.1.10 channel="Test Channel" bytes=0`)
+	assert.Contains(t, lines[1], ` stop client=192.168.1.10 channel="Test Channel" bytes=1500 duration=0s`)
+
+	_, ok := streamLogSessions.Load(streamLogKey("M1", 0))
+	assert.False(t, ok)
+}
+
+func TestStreamLog_Disabled(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	Settings.StreamLogPath = ""
+
+	logStreamStart("M1", 1, "Test Channel", "192.168.1.10")
+	_, ok := streamLogSessions.Load(streamLogKey("M1", 1))
+	assert.False(t, ok)
+}
+
+func TestAppendStreamLog_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.log")
+
+	require.NoError(t, os.WriteFile(path, make([]byte, streamLogMaxSize), 0644))
+	require.NoError(t, os.WriteFile(path+".1", []byte("old 1"), 0644))
+	require.NoError(t, os.WriteFile(path+".3", []byte("oldest"), 0644))
+
+	require.NoError(t, appendStreamLog(path, "new line\n"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new line\n", string(data))
+
+	info, err := os.Stat(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(streamLogMaxSize), info.Size())
+
+	data, err = os.ReadFile(path + ".2")
+	require.NoError(t, err)
+	assert.Equal(t, "old 1", string(data))
+
+	assert.NoFileExists(t, path+".3")
+}
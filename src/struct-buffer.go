@@ -8,6 +8,7 @@ type Playlist struct {
 	PlaylistID   string
 	PlaylistName string
 	Tuner        int
+	MaxStreams   int // max.concurrent.streams of the provider, 0 = only the tuner limit
 
 	Clients map[int]ThisClient
 	Streams map[int]ThisStream
@@ -23,6 +24,7 @@ type ThisStream struct {
 	ChannelName      string
 	Error            string
 	Folder           string
+	Format           string // x-stream-format of the channel, empty: Content-Type of the streaming server
 	MD5              string
 	NetworkBandwidth int
 	PlaylistID       string
@@ -43,12 +45,16 @@ type ThisStream struct {
 
 	// Is only used for HLS / M3U8
 	Body             string
+	BandwidthSamples []int // Last measured bandwidths, used for a moving average (hls.bandwidth.smoothing.samples)
 	Duration         float64
 	DynamicBandwidth bool
 	HLS              bool
+	HLSKey           HLSKey            // Current #EXT-X-KEY of the playlist, applies to the following segments
+	HLSKeys          map[string][]byte // Downloaded AES-128 keys (key URI -> key), hls.decrypt
 	LastSequence     int64
 	M3U8URL          string
 	Sequence         int64
+	VOD              bool // #EXT-X-PLAYLIST-TYPE:VOD or #EXT-X-ENDLIST, the playlist is complete and not polled again
 	TimeDiff             float64
 	TimeEnd              time.Time
 	TimeStart            time.Time
@@ -81,6 +87,7 @@ type SegmentInfo struct {
 // Segment : URL Segments (HLS / M3U8)
 type Segment struct {
 	Duration     float64
+	Key          HLSKey
 	PlaylistType string
 	Sequence     int64
 	URL          string
@@ -90,6 +97,13 @@ type Segment struct {
 	}
 }
 
+// HLSKey : Encryption of HLS segments (#EXT-X-KEY)
+type HLSKey struct {
+	Method string // NONE, AES-128 or SAMPLE-AES
+	URI    string
+	IV     []byte // Without IV, the media sequence number of the segment is used
+}
+
 // DynamicStream : Stream Information with dynamic Bandwidth
 type DynamicStream struct {
 	Bandwidth int
@@ -100,6 +114,7 @@ type DynamicStream struct {
 type ClientConnection struct {
 	Connection int
 	Error      error
+	Linger     *time.Timer // Teardown of a stream without clients (stream.linger.seconds)
 }
 
 // BandwidthCalculation : Bandwidth Calculation for the Stream
@@ -41,6 +41,7 @@ type Discover struct {
 // LineupStatus : HDHR Lineup status /lineup_status.json
 type LineupStatus struct {
 	ScanInProgress int      `json:"ScanInProgress"`
+	Progress       int      `json:"Progress,omitempty"`
 	ScanPossible   int      `json:"ScanPossible"`
 	Source         string   `json:"Source"`
 	SourceList     []string `json:"SourceList"`
@@ -54,4 +55,7 @@ type LineupStream struct {
 	GuideName   string `json:"GuideName"`
 	GuideNumber string `json:"GuideNumber"`
 	URL         string `json:"URL"`
+
+	mapped bool // Channel has an EPG mapping (plex.channel.limit.enforce)
+	pin    int  // Position in channels.pinned
 }
@@ -69,6 +69,7 @@ type SystemStruct struct {
 	IPAddressesV4Host      []string // Every IPv4 address available except loopback and link-local
 	IPAddressesV4Raw       []net.IP // Every IPv4 address available in net.IP format
 	IPAddressesV6          []string // Every IPv6 address available
+	MaintenanceMode        bool     // Set by the operator, locks the web interface and the API
 	Name                   string
 	OS                     string
 	ScanInProgress         int
@@ -176,6 +177,20 @@ type Filter struct {
 	PreparsedExclude []string `json:"-"`
 }
 
+// OutputProfile : Additional M3U and XMLTV output for a client (output.profiles), served at /m3u/<name>.m3u and /xmltv/<name>.xml
+type OutputProfile struct {
+	Name      string   `json:"name"`
+	Groups    []string `json:"groups,omitempty"`     // Group titles of the channels, empty for all channels
+	SortOrder string   `json:"sort.order,omitempty"` // Same values as m3u.sort.order, empty uses m3u.sort.order
+	Format    string   `json:"format,omitempty"`     // "xteve" or "direct" (stream URLs of the provider), empty uses m3u.direct.urls
+}
+
+// MappingNameRule : Regex replacement for channel names before the automatic EPG mapping (mapping.name.rules)
+type MappingNameRule struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace"`
+}
+
 // XEPGChannelStruct : XEPG Structure
 type XEPGChannelStruct struct {
 	FileM3UID                     string         `json:"_file.m3u.id"`
@@ -187,14 +202,21 @@ type XEPGChannelStruct struct {
 	TvgLogo                       string         `json:"tvg-logo"`
 	TvgName                       string         `json:"tvg-name"`
 	TvgShift                      string         `json:"tvg-shift"`
+	Catchup                       string         `json:"catchup,omitempty"`
+	CatchupSource                 string         `json:"catchup-source,omitempty"`
+	CatchupDays                   string         `json:"catchup-days,omitempty"`
+	Radio                         bool           `json:"radio,omitempty"` // Audio-only channel (isRadioChannel)
 	UpdateChannelNameRegex        string         `json:"update-channel-name-regex"`
 	UpdateChannelNameByGroupRegex string         `json:"update-channel-name-by-group-regex"`
 	URL                           string         `json:"url"`
 	UUIDKey                       string         `json:"_uuid.key"`
 	UUIDValue                     string         `json:"_uuid.value,omitempty"`
 	Values                        string         `json:"_values"`
+	EXTINF                        string         `json:"_extinf,omitempty"` // Original #EXTINF line of the playlist (m3u.passthrough.extinf)
 	XActive                       bool           `json:"x-active"`
+	XAutoDisabled                 bool           `json:"x-auto-disabled,omitempty"` // Disabled by auto.disable.dead.channels, XUserDisabled is set as well
 	XCategory                     string         `json:"x-category"`
+	XUserDisabled                 bool           `json:"x-user-disabled"` // Disabled in the WebUI, mapping() does not re-activate the channel
 	XChannelID                    string         `json:"x-channelID"`
 	XEPG                          string         `json:"x-epg"`
 	XGroupTitle                   string         `json:"x-group-title"`
@@ -205,9 +227,21 @@ type XEPGChannelStruct struct {
 	XUpdateChannelName            bool           `json:"x-update-channel-name"`
 	XUpdateChannelGroup           bool           `json:"x-update-channel-group"`
 	XDescription                  string         `json:"x-description"`
+	XStreamFormat                 string         `json:"x-stream-format,omitempty"` // "ts" or "hls" instead of the Content-Type of the streaming server (buffer)
 	XTimeshift                    string         `json:"x-timeshift"`
+	LastSeen                      int64          `json:"_last.seen,omitempty"` // Unix time of the last playlist update that contained the channel
+	Missing                       bool           `json:"_missing,omitempty"`   // Missing from the playlist, kept for xepg.retain.missing.days
 	CompiledNameRegex             *regexp.Regexp `json:"-"`
 	CompiledGroupRegex            *regexp.Regexp `json:"-"`
+
+	// Further EPG sources, their programmes fill the gaps of x-xmltv-file / x-mapping
+	XMappings []XEPGMappingStruct `json:"x-mappings,omitempty"`
+}
+
+// XEPGMappingStruct : Additional EPG source of a channel (x-mappings)
+type XEPGMappingStruct struct {
+	XmltvFile string `json:"x-xmltv-file"`
+	XMapping  string `json:"x-mapping"`
 }
 
 // M3UChannelStructXEPG : M3U Structure for XEPG
@@ -220,11 +254,17 @@ type M3UChannelStructXEPG struct {
 	TvgID           string `json:"tvg-id"`
 	TvgLogo         string `json:"tvg-logo"`
 	TvgName         string `json:"tvg-name"`
+	TvgChno         string `json:"tvg-chno"`
 	TvgShift        string `json:"tvg-shift"`
+	Catchup         string `json:"catchup"`
+	CatchupSource   string `json:"catchup-source"`
+	CatchupDays     string `json:"catchup-days"`
+	Radio           string `json:"radio"`
 	URL             string `json:"url"`
 	UUIDKey         string `json:"_uuid.key"`
 	UUIDValue       string `json:"_uuid.value"`
 	Values          string `json:"_values"`
+	EXTINF          string `json:"_extinf"`
 	PreserveMapping string `json:"_preserve-mapping"`
 	StartingChannel string `json:"_starting-channel"`
 }
@@ -269,29 +309,50 @@ type Notification struct {
 
 // SettingsStruct : Content of settings.json
 type SettingsStruct struct {
-	AuthenticationAPI     bool     `json:"authentication.api"`
-	AuthenticationM3U     bool     `json:"authentication.m3u"`
-	AuthenticationPMS     bool     `json:"authentication.pms"`
-	AuthenticationWEB     bool     `json:"authentication.web"`
-	AuthenticationXML     bool     `json:"authentication.xml"`
-	BackupKeep            int      `json:"backup.keep"`
-	BackupPath            string   `json:"backup.path"`
-	Buffer                string   `json:"buffer"`
-	BufferSize            int      `json:"buffer.size.kb"`
-	BufferTimeout         float64  `json:"buffer.timeout"`
-	BufferSegments        int      `json:"buffer.segments"`
-	BufferClientTimeout   float64  `json:"buffer.client.timeout"`
-	StreamRetryEnabled    bool     `json:"stream.retry.enabled"`
-	StreamMaxRetries      int      `json:"stream.max.retries"`
-	StreamRetryDelay      int      `json:"stream.retry.delay"`
-	CacheImages           bool     `json:"cache.images"`
-	ClearXMLTVCache       bool     `json:"clearXMLTVCache"`
-	DefaultMissingEPG     string   `json:"defaultMissingEPG"`
-	DisallowURLDuplicates bool     `json:"disallowURLDuplicates"`
-	EnableMappedChannels  bool     `json:"enableMappedChannels"`
-	EpgSource             string   `json:"epgSource"`
-	FileM3U               []string `json:"file,omitempty"`  // In the Wizard, the M3U is saved in a Slice
-	FileXMLTV             []string `json:"xmltv,omitempty"` // Old Storage System of the provider XML File Slice (Required for the conversion to the new one)
+	APISocketOnly               bool     `json:"api.socket.only"` // The API is only available via the local Unix socket, /api/ over TCP responds with 403
+	AuthenticationAPI           bool     `json:"authentication.api"`
+	AuthenticationM3U           bool     `json:"authentication.m3u"`
+	AuthenticationPMS           bool     `json:"authentication.pms"`
+	AuthenticationWEB           bool     `json:"authentication.web"`
+	AuthenticationXML           bool     `json:"authentication.xml"`
+	BackupKeep                  int      `json:"backup.keep"`
+	BackupPath                  string   `json:"backup.path"`
+	Buffer                      string   `json:"buffer"`
+	BufferSize                  int      `json:"buffer.size.kb"`
+	BufferTimeout               float64  `json:"buffer.timeout"`
+	BufferSegments              int      `json:"buffer.segments"`
+	BufferCleanupOnStart        bool     `json:"buffer.cleanup.on.start"`
+	BufferClientTimeout         float64  `json:"buffer.client.timeout"`
+	BufferRetainSegments        int      `json:"buffer.retain.segments"`  // Segment files kept per client after they were sent
+	StreamLingerSeconds         int      `json:"stream.linger.seconds"`   // Buffering continues for N seconds after the last client disconnected
+	StreamLogPath               string   `json:"stream.log.path"`         // Stream access log (empty = disabled)
+	StreamClientChunkKB         int      `json:"stream.client.chunk.kb"`  // Max. size of a write to the client (0 = whole segment)
+	StreamClientPacingMS        int      `json:"stream.client.pacing.ms"` // Min. time between two writes to the client (0 = no pacing)
+	StreamErrorClip             string   `json:"stream.error.clip"`       // MPEG-TS file that is played for an unknown stream (empty = 404)
+	StreamRetryEnabled          bool     `json:"stream.retry.enabled"`
+	StreamMaxRetries            int      `json:"stream.max.retries"`
+	StreamRetryDelay            int      `json:"stream.retry.delay"`
+	StreamStallDetectSeconds    int      `json:"stream.stall.detect.seconds"` // A stream without a new PTS for N seconds is reconnected (0 = disabled)
+	UpstreamKeepAlive           bool     `json:"upstream.keepalive"`          // Connections to the streaming server are reused (HLS playlists and segments)
+	UpstreamMaxIdleConnsPerHost int      `json:"upstream.max.idle.conns.per.host"`
+	AutoDisableDeadChannels     bool     `json:"auto.disable.dead.channels"`              // Channels that fail to stream repeatedly are disabled
+	AutoDisableFailures         int      `json:"auto.disable.dead.channels.failures"`     // Failed connections that disable a channel
+	AutoDisableWindowHours      int      `json:"auto.disable.dead.channels.window.hours"` // Only failures within this time are counted
+	CacheImages                 bool     `json:"cache.images"`
+	ImagesCacheTTLHours         int      `json:"images.cache.ttl.hours"` // Cached images are downloaded again after N hours (0 = never)
+	ClearXMLTVCache             bool     `json:"clearXMLTVCache"`
+	DefaultChannelLogo          string   `json:"default.channel.logo"` // Logo for channels without TvgLogo and XMLTV icon
+	DefaultMissingEPG           string   `json:"defaultMissingEPG"`
+	DisallowURLDuplicates       bool     `json:"disallowURLDuplicates"`
+	EnableMappedChannels        bool     `json:"enableMappedChannels"`
+	EpgSource                   string   `json:"epgSource"`
+	FFmpegPath                  string   `json:"ffmpeg.path"`     // FFmpeg for the stream thumbnails (empty = ffmpeg from PATH)
+	FileM3U                     []string `json:"file,omitempty"`  // In the Wizard, the M3U is saved in a Slice
+	FileXMLTV                   []string `json:"xmltv,omitempty"` // Old Storage System of the provider XML File Slice (Required for the conversion to the new one)
+
+	StatsIntervalSeconds int  `json:"stats.interval.seconds"` // Sampling interval of the tuner usage history (0 = disabled)
+	StatsRetentionHours  int  `json:"stats.retention.hours"`
+	StatsPersist         bool `json:"stats.persist"` // The history is saved in the cache folder and loaded at the start
 
 	Files struct {
 		HDHR  map[string]any `json:"hdhr"`
@@ -299,27 +360,65 @@ type SettingsStruct struct {
 		XMLTV map[string]any `json:"xmltv"`
 	} `json:"files"`
 
-	FilesUpdate               bool          `json:"files.update"`
-	Filter                    map[int64]any `json:"filter"`
-	HostIP                    string        `json:"hostIP"`   // IP chosen in web client. Used to form m3u and xml files.
-	HostName                  string        `json:"hostName"` // Hostname chosen in web client. Used to form m3u and xml files.
-	Key                       string        `json:"key,omitempty"`
-	Language                  string        `json:"language"`
-	LogEntriesRAM             int           `json:"log.entries.ram"`
-	M3U8AdaptiveBandwidthMBPS int           `json:"m3u8.adaptive.bandwidth.mbps"`
-	MappingFirstChannel       float64       `json:"mapping.first.channel"`
-	Port                      string        `json:"port"`
-	SSDP                      bool          `json:"ssdp"`
-	StoreBufferInRAM          bool          `json:"storeBufferInRAM"`
-	TempPath                  string        `json:"temp.path"`
-	TLSMode                   bool          `json:"tlsMode"`
-	Tuner                     int           `json:"tuner"`
-	Update                    []string      `json:"update"`
-	UserAgent                 string        `json:"user.agent"`
-	UUID                      string        `json:"uuid"`
-	UDPxy                     string        `json:"udpxy"`
-	Version                   string        `json:"version"`
-	XepgReplaceMissingImages  bool          `json:"xepg.replace.missing.images"`
+	FilesUpdate                  bool              `json:"files.update"`
+	GroupOrder                   []string          `json:"group.order"`   // Display order of the groups (saveGroupOrder)
+	DebugLevels                  map[string]int    `json:"debug.levels"`  // Debug level per subsystem (buffer, webdav, webserver, xepg), overrides -debug
+	GroupRenames                 map[string]string `json:"group.renames"` // Group titles that are renamed on every rebuild (renameGroup)
+	HLSBandwidthSmoothingSamples int               `json:"hls.bandwidth.smoothing.samples"`
+	HLSDecrypt                   bool              `json:"hls.decrypt"` // AES-128 encrypted HLS segments (#EXT-X-KEY) are decrypted by the buffer
+	Filter                       map[int64]any     `json:"filter"`
+	HostIP                       string            `json:"hostIP"`   // IP chosen in web client. Used to form m3u and xml files.
+	HostName                     string            `json:"hostName"` // Hostname chosen in web client. Used to form m3u and xml files.
+	Key                          string            `json:"key,omitempty"`
+	Language                     string            `json:"language"`
+	LogEntriesRAM                int               `json:"log.entries.ram"`
+	M3U8AdaptiveBandwidthMBPS    int               `json:"m3u8.adaptive.bandwidth.mbps"`
+	M3UDirectURLs                bool              `json:"m3u.direct.urls"`        // Original stream URLs in the M3U instead of /stream/
+	M3UFormat                    string            `json:"m3u.format"`             // Attributes of the #EXTINF lines: "xteve" or "samsung"
+	M3UHeaderAttributes          map[string]string `json:"m3u.header.attributes"`  // Further attributes of the #EXTM3U line, replace url-tvg and x-tvg-url
+	M3UPassthroughEXTINF         bool              `json:"m3u.passthrough.extinf"` // Original #EXTINF line of the playlist instead of the xTeVe attributes
+	M3USortOrder                 string            `json:"m3u.sort.order"`
+	ChannelsPinned               []string          `json:"channels.pinned"` // Channel numbers or names that come first in the output, in this order
+	M3UPrefixGroupWithProvider   bool              `json:"m3u.prefix.group.with.provider"`
+	MappingFirstChannel          float64           `json:"mapping.first.channel"`
+	MappingNameRules             []MappingNameRule `json:"mapping.name.rules"` // Applied to channel and XMLTV names for the automatic mapping
+	OutputProfiles               []OutputProfile   `json:"output.profiles"`
+	PlexChannelLimitEnforce      bool              `json:"plex.channel.limit.enforce"`
+	PreferSourceChno             bool              `json:"prefer.source.chno"`                // Use tvg-chno of the playlist as channel number for new channels
+	ProviderDownloadConcurrency  int               `json:"provider.download.concurrency"`     // Concurrent provider downloads (0 = unlimited)
+	ProviderKeepLastGood         bool              `json:"provider.keep.last.good"`           // Incomplete M3U downloads don't replace the previous file
+	ProviderRefreshRetries       int               `json:"provider.refresh.retries"`          // Retries of a failed provider download during an update (0 = no retry)
+	ProviderRefreshDelay         int               `json:"provider.refresh.delay"`            // Seconds before the first retry, doubled for every further retry
+	ProviderMaxDownloadMB        int               `json:"provider.max.download.mb"`          // Larger provider downloads are aborted, the previous file is kept
+	ProviderDownloadTimeout      int               `json:"provider.download.timeout.seconds"` // Max. duration of a provider download (0 = no timeout)
+	Port                         string            `json:"port"`
+	SSDP                         bool              `json:"ssdp"`
+	StoreBufferInRAM             bool              `json:"storeBufferInRAM"`
+	TempPath                     string            `json:"temp.path"`
+	TunerLimitRedirectURL        string            `json:"tuner.limit.redirect.url"` // Target of tuner.limit.response "redirect-to-url"
+	TunerLimitResponse           string            `json:"tuner.limit.response"`     // "clip", "503" or "redirect-to-url"
+	TLSMode                      bool              `json:"tlsMode"`
+	Tuner                        int               `json:"tuner"`
+	Update                       []string          `json:"update"`
+	UserAgent                    string            `json:"user.agent"`
+	UUID                         string            `json:"uuid"`
+	UDPxy                        string            `json:"udpxy"`
+	UnknownPathBehavior          string            `json:"unknown.path.behavior"`  // "capability", "404" or "redirect-to-web"
+	AllowNativeMulticast         bool              `json:"allow.native.multicast"` // udp://@ streams are received by the buffer if no UDPxy is set
+	Version                      string            `json:"version"`
+	WebDAVGroupSeparator         string            `json:"webdav.group.separator"`      // Splits the group titles into nested WebDAV directories (empty = disabled)
+	WSRateLimit                  int               `json:"ws.rate.limit"`               // Expensive websocket commands per minute and connection (0 = unlimited)
+	XepgRetainMissingDays        int               `json:"xepg.retain.missing.days"`    // Channels missing from the playlist are kept (inactive) for N days before they are deleted
+	XepgResolveChnoConflicts     bool              `json:"xepg.resolve.chno.conflicts"` // Duplicate channel numbers in a saved mapping get the next free number instead of rejecting the mapping
+	XepgReplaceMissingImages     bool              `json:"xepg.replace.missing.images"`
+	XMLTVCategoryBlacklist       []string          `json:"xmltv.category.blacklist"`
+	XMLTVCategoryWhitelist       []string          `json:"xmltv.category.whitelist"`
+	XMLTVGenerateProgID          bool              `json:"xmltv.generate.progid"` // Stable dd_progid for programs without one
+	XMLTVDedupePrograms          bool              `json:"xmltv.dedupe.programs"` // Duplicated and overlapping programmes of a channel are removed
+	XMLTVUseSourceIDs            bool              `json:"xmltv.use.source.ids"`  // Channel IDs of the XMLTV source instead of the channel numbers
+	XMLTVTolerantParse           bool              `json:"xmltv.tolerant.parse"`  // Skip malformed channels and programmes instead of rejecting the XMLTV file
+	ChannelNamePrefix            string            `json:"channel.name.prefix"`   // Added to the channel names in the output (M3U, XMLTV, lineup)
+	ChannelNameSuffix            string            `json:"channel.name.suffix"`
 }
 
 // LanguageUI : Language for the WebUI
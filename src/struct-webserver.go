@@ -14,43 +14,81 @@ type RequestStruct struct {
 	// Mapping
 	EpgMapping map[string]any `json:"epgMapping,omitempty"`
 
+	// Rename Group
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+
+	// Group Order
+	GroupOrder []string `json:"groupOrder,omitempty"`
+
 	// Restore
 	Base64 string `json:"base64,omitempty"`
 
+	// Maintenance mode
+	MaintenanceMode *bool `json:"maintenanceMode,omitempty"`
+
+	// Now / Next
+	XChannelID string `json:"x-channelID,omitempty"`
+
+	// M3U preview
+	Groups []string `json:"groups,omitempty"`
+	Lines  int      `json:"lines,omitempty"`
+
+	// Test Provider
+	Type      string `json:"type,omitempty"`
+	URL       string `json:"url,omitempty"`
+	UserAgent string `json:"user-agent,omitempty"`
+
 	// New Values for the Settings (settings.json)
 	Settings struct {
-		API                      *bool     `json:"api,omitempty"`
-		AuthenticationAPI        *bool     `json:"authentication.api,omitempty"`
-		AuthenticationM3U        *bool     `json:"authentication.m3u,omitempty"`
-		AuthenticationPMS        *bool     `json:"authentication.pms,omitempty"`
-		AuthenticationWEP        *bool     `json:"authentication.web,omitempty"`
-		AuthenticationXML        *bool     `json:"authentication.xml,omitempty"`
-		BackupKeep               *int      `json:"backup.keep,omitempty"`
-		BackupPath               *string   `json:"backup.path,omitempty"`
-		Buffer                   *string   `json:"buffer,omitempty"`
-		BufferSize               *int      `json:"buffer.size.kb,omitempty"`
-		BufferSegments           *int      `json:"buffer.segments,omitempty"`
-		BufferTimeout            *float64  `json:"buffer.timeout,omitempty"`
-		CacheImages              *bool     `json:"cache.images,omitempty"`
-		ClearXMLTVCache          *bool     `json:"clearXMLTVCache,omitempty"`
-		DefaultMissingEPG        *string   `json:"defaultMissingEPG,omitempty"`
-		DisallowURLDuplicates    *bool     `json:"disallowURLDuplicates,omitempty"`
-		EnableMappedChannels     *bool     `json:"enableMappedChannels,omitempty"`
-		EpgSource                *string   `json:"epgSource,omitempty"`
-		FilesUpdate              *bool     `json:"files.update,omitempty"`
-		HostIP                   *string   `json:"hostIP,omitempty"` // IP chosen in web client. Used to form m3u and xml files.
-		HostName                 *string   `json:"hostName"`         // Hostname chosen in web client. Used to form m3u and xml files.
-		TempPath                 *string   `json:"temp.path,omitempty"`
-		TLSMode                  *bool     `json:"tlsMode,omitempty"`
-		Tuner                    *int      `json:"tuner,omitempty"`
-		UDPxy                    *string   `json:"udpxy,omitempty"`
-		Update                   *[]string `json:"update,omitempty"`
-		UserAgent                *string   `json:"user.agent,omitempty"`
-		XepgReplaceMissingImages *bool     `json:"xepg.replace.missing.images,omitempty"`
-		XteveAutoUpdate          *bool     `json:"xteveAutoUpdate,omitempty"`
-		SchemeM3U                *string   `json:"scheme.m3u,omitempty"`
-		SchemeXML                *string   `json:"scheme.xml,omitempty"`
-		StoreBufferInRAM         *bool     `json:"storeBufferInRAM,omitempty"`
+		API                          *bool     `json:"api,omitempty"`
+		AuthenticationAPI            *bool     `json:"authentication.api,omitempty"`
+		AuthenticationM3U            *bool     `json:"authentication.m3u,omitempty"`
+		AuthenticationPMS            *bool     `json:"authentication.pms,omitempty"`
+		AuthenticationWEP            *bool     `json:"authentication.web,omitempty"`
+		AuthenticationXML            *bool     `json:"authentication.xml,omitempty"`
+		BackupKeep                   *int      `json:"backup.keep,omitempty"`
+		BackupPath                   *string   `json:"backup.path,omitempty"`
+		Buffer                       *string   `json:"buffer,omitempty"`
+		BufferSize                   *int      `json:"buffer.size.kb,omitempty"`
+		BufferCleanupOnStart         *bool     `json:"buffer.cleanup.on.start,omitempty"`
+		BufferSegments               *int      `json:"buffer.segments,omitempty"`
+		BufferTimeout                *float64  `json:"buffer.timeout,omitempty"`
+		CacheImages                  *bool     `json:"cache.images,omitempty"`
+		ChannelNamePrefix            *string   `json:"channel.name.prefix,omitempty"`
+		ChannelNameSuffix            *string   `json:"channel.name.suffix,omitempty"`
+		ClearXMLTVCache              *bool     `json:"clearXMLTVCache,omitempty"`
+		DefaultChannelLogo           *string   `json:"default.channel.logo,omitempty"`
+		DefaultMissingEPG            *string   `json:"defaultMissingEPG,omitempty"`
+		DisallowURLDuplicates        *bool     `json:"disallowURLDuplicates,omitempty"`
+		EnableMappedChannels         *bool     `json:"enableMappedChannels,omitempty"`
+		EpgSource                    *string   `json:"epgSource,omitempty"`
+		FilesUpdate                  *bool     `json:"files.update,omitempty"`
+		HLSBandwidthSmoothingSamples *int      `json:"hls.bandwidth.smoothing.samples,omitempty"`
+		HostIP                       *string   `json:"hostIP,omitempty"` // IP chosen in web client. Used to form m3u and xml files.
+		HostName                     *string   `json:"hostName"`         // Hostname chosen in web client. Used to form m3u and xml files.
+		PlexChannelLimitEnforce      *bool     `json:"plex.channel.limit.enforce,omitempty"`
+		PreferSourceChno             *bool     `json:"prefer.source.chno,omitempty"`
+		ProviderDownloadConcurrency  *int      `json:"provider.download.concurrency,omitempty"`
+		M3UDirectURLs                *bool     `json:"m3u.direct.urls,omitempty"`
+		M3UFormat                    *string   `json:"m3u.format,omitempty"`
+		M3UPassthroughEXTINF         *bool     `json:"m3u.passthrough.extinf,omitempty"`
+		M3USortOrder                 *string   `json:"m3u.sort.order,omitempty"`
+		M3UPrefixGroupWithProvider   *bool     `json:"m3u.prefix.group.with.provider,omitempty"`
+		TempPath                     *string   `json:"temp.path,omitempty"`
+		TLSMode                      *bool     `json:"tlsMode,omitempty"`
+		Tuner                        *int      `json:"tuner,omitempty"`
+		UDPxy                        *string   `json:"udpxy,omitempty"`
+		Update                       *[]string `json:"update,omitempty"`
+		UserAgent                    *string   `json:"user.agent,omitempty"`
+		WSRateLimit                  *int      `json:"ws.rate.limit,omitempty"`
+		XepgReplaceMissingImages     *bool     `json:"xepg.replace.missing.images,omitempty"`
+		XMLTVCategoryBlacklist       *[]string `json:"xmltv.category.blacklist,omitempty"`
+		XMLTVCategoryWhitelist       *[]string `json:"xmltv.category.whitelist,omitempty"`
+		XteveAutoUpdate              *bool     `json:"xteveAutoUpdate,omitempty"`
+		SchemeM3U                    *string   `json:"scheme.m3u,omitempty"`
+		SchemeXML                    *string   `json:"scheme.xml,omitempty"`
+		StoreBufferInRAM             *bool     `json:"storeBufferInRAM,omitempty"`
 	} `json:"settings,omitempty"`
 
 	// Upload Logo
@@ -78,21 +116,28 @@ type RequestStruct struct {
 // ResponseStruct : Responses to the Client (WEB)
 type ResponseStruct struct {
 	ClientInfo struct {
-		ARCH      string `json:"arch"`
-		Branch    string `json:"branch,omitempty"`
-		DVR       string `json:"DVR"`
-		EpgSource string `json:"epgSource"`
-		Errors    int    `json:"errors"`
-		M3U       string `json:"m3u-url"`
-		OS        string `json:"os"`
-		Streams   string `json:"streams"`
-		UUID      string `json:"uuid"`
-		Version   string `json:"version"`
-		Warnings  int    `json:"warnings"`
-		XEPGCount int64  `json:"xepg"`
-		XML       string `json:"xepg-url"`
+		ActiveStreams int    `json:"active-streams"` // Updated by streamStatusUpdate
+		ARCH          string `json:"arch"`
+		Branch        string `json:"branch,omitempty"`
+		DVR           string `json:"DVR"`
+		EpgSource     string `json:"epgSource"`
+		Errors        int    `json:"errors"`
+		M3U           string `json:"m3u-url"`
+		OS            string `json:"os"`
+		Streams       string `json:"streams"`
+		UUID          string `json:"uuid"`
+		Version       string `json:"version"`
+		Warnings      int    `json:"warnings"`
+		XEPGCount     int64  `json:"xepg"`
+		XML           string `json:"xepg-url"`
 	} `json:"clientInfo,omitempty"`
 
+	EffectiveFilters []EffectiveFilterStruct `json:"effectiveFilters,omitempty"`
+	ProviderTest     *ProviderTestStruct     `json:"providerTest,omitempty"`
+	NowNext          *NowNextStruct          `json:"nowNext,omitempty"`
+	M3UPreview       []string                `json:"m3uPreview,omitempty"`
+	ChannelImport    *ChannelImportStruct    `json:"channelImport,omitempty"`
+
 	Data struct {
 		Playlist struct {
 			M3U struct {
@@ -109,53 +154,169 @@ type ResponseStruct struct {
 		}
 	} `json:"data"`
 
-	Alert               string              `json:"alert,omitempty"`
-	ConfigurationWizard bool                `json:"configurationWizard"`
-	Error               string              `json:"err,omitempty"`
-	IPAddressesV4Host   []string            `json:"ipAddressesV4Host"` // Every IPv4 address to display in web client
-	Log                 *WebScreenLogStruct `json:"log"`
-	LogoURL             string              `json:"logoURL,omitempty"`
-	OpenLink            string              `json:"openLink,omitempty"`
-	OpenMenu            string              `json:"openMenu,omitempty"`
-	Reload              bool                `json:"reload,omitempty"`
-	Settings            SettingsStruct      `json:"settings"`
-	Status              bool                `json:"status"`
-	Token               string              `json:"token,omitempty"`
-	Users               map[string]any      `json:"users,omitempty"`
-	Wizard              int                 `json:"wizard,omitempty"`
-	XEPG                map[string]any      `json:"xepg"`
+	Alert               string                `json:"alert,omitempty"`
+	ConfigurationWizard bool                  `json:"configurationWizard"`
+	Error               string                `json:"err,omitempty"`
+	IPAddressesV4Host   []string              `json:"ipAddressesV4Host"` // Every IPv4 address to display in web client
+	ImagesRemoved       int                   `json:"imagesRemoved,omitempty"`
+	Log                 *WebScreenLogStruct   `json:"log"`
+	LogoURL             string                `json:"logoURL,omitempty"`
+	MaintenanceMode     bool                  `json:"maintenanceMode"`
+	OpenLink            string                `json:"openLink,omitempty"`
+	OpenMenu            string                `json:"openMenu,omitempty"`
+	ProviderStats       []ProviderStatsStruct `json:"providerStats,omitempty"`
+	Reload              bool                  `json:"reload,omitempty"`
+	Settings            SettingsStruct        `json:"settings"`
+	Status              bool                  `json:"status"`
+	Token               string                `json:"token,omitempty"`
+	Users               map[string]any        `json:"users,omitempty"`
+	Wizard              int                   `json:"wizard,omitempty"`
+	XEPG                map[string]any        `json:"xepg"`
 
 	Notification map[string]Notification `json:"notification,omitempty"`
 }
 
 // APIRequestStruct : Request via the API interface
 type APIRequestStruct struct {
-	Cmd      string `json:"cmd"`
-	Password string `json:"password"`
-	Token    string `json:"token"`
-	Username string `json:"username"`
+	BackupBase string `json:"backup.base"` // Base64 encoded ZIP archive that is compared instead of the current files (backup.diff)
+	BackupData string `json:"backup.data"` // Base64 encoded ZIP archive (backup.diff)
+	Cmd        string `json:"cmd"`
+	EpgSource  string `json:"epg.source"`
+	Key        string `json:"key"`
+	Password   string `json:"password"`
+	Path       string `json:"path"` // Folder on the server (backup.create)
+	Token      string `json:"token"`
+	Username   string `json:"username"`
+	Value      any    `json:"value"`
+
+	Channel  string   `json:"channel"`  // XEPG ID (stream.thumbnail)
+	Channels []string `json:"channels"` // XEPG IDs (channels.reenable)
 }
 
 // APIResponseStruct : Response to the Client (API)
 type APIResponseStruct struct {
-	EpgSource             string `json:"epg.source,omitempty"`
-	Error                 string `json:"err,omitempty"`
-	OtelExporterEndpoint  string `json:"otel.exporter.endpoint,omitempty"`
-	OtelExporterType      string `json:"otel.exporter.type,omitempty"`
-	Status                bool   `json:"status"`
-	ActiveHTTPConnections int64  `json:"active.http.connections"`
-	StreamsActive         int64  `json:"streams.active,omitempty"`
-	StreamsAll            int64  `json:"streams.all,omitempty"`
-	StreamsXepg           int64  `json:"streams.xepg,omitempty"`
-	Token                 string `json:"token,omitempty"`
-	TunerActive           int64  `json:"tuners.active"`
-	TunerAll              int64  `json:"tuners.all"`
-	URLDvr                string `json:"url.dvr,omitempty"`
-	URLM3U                string `json:"url.m3u,omitempty"`
-	URLWebDAV             string `json:"url.webdav,omitempty"`
-	URLXepg               string `json:"url.xepg,omitempty"`
-	VersionAPI            string `json:"version.api,omitempty"`
-	VersionXteve          string `json:"version.xteve,omitempty"`
+	BackupData            string   `json:"backup.data,omitempty"` // Base64 encoded ZIP archive
+	BackupFile            string   `json:"backup.file,omitempty"`
+	Channels              []string `json:"channels,omitempty"` // Re-enabled XEPG IDs (channels.reenable)
+	ChannelsLineup        int      `json:"channels.lineup,omitempty"`
+	EpgSource             string   `json:"epg.source,omitempty"`
+	Error                 string   `json:"err,omitempty"`
+	Key                   string   `json:"key,omitempty"`
+	OtelExporterEndpoint  string   `json:"otel.exporter.endpoint,omitempty"`
+	OtelExporterType      string   `json:"otel.exporter.type,omitempty"`
+	Reloaded              []string `json:"reloaded,omitempty"`
+	Status                bool     `json:"status"`
+	ActiveHTTPConnections int64    `json:"active.http.connections"`
+	StreamsActive         int64    `json:"streams.active,omitempty"`
+	StreamsAll            int64    `json:"streams.all,omitempty"`
+	StreamsXepg           int64    `json:"streams.xepg,omitempty"`
+	Token                 string   `json:"token,omitempty"`
+	TunerActive           int64    `json:"tuners.active"`
+	TunerAll              int64    `json:"tuners.all"`
+	Thumbnail             string   `json:"thumbnail,omitempty"` // /images/ URL of the JPEG (stream.thumbnail)
+	URLDvr                string   `json:"url.dvr,omitempty"`
+	URLM3U                string   `json:"url.m3u,omitempty"`
+	URLWebDAV             string   `json:"url.webdav,omitempty"`
+	URLXepg               string   `json:"url.xepg,omitempty"`
+	Value                 any      `json:"value,omitempty"`
+	VersionAPI            string   `json:"version.api,omitempty"`
+	VersionXteve          string   `json:"version.xteve,omitempty"`
+
+	BackupDiff      []BackupDiffStruct     `json:"backup.diff,omitempty"`
+	Files           map[string]int64       `json:"files,omitempty"` // File name and size in bytes (API command files.regenerate)
+	StatsHistory    []StatsSampleStruct    `json:"stats.history,omitempty"`
+	StreamsInactive []InactiveStreamStruct `json:"streams.inactive,omitempty"`
+	XEPGValidation  *XEPGValidationStruct  `json:"xepg.validation,omitempty"`
+}
+
+// BackupDiffStruct : Difference between the current files and a backup (API command backup.diff)
+type BackupDiffStruct struct {
+	File   string `json:"file"`
+	Key    string `json:"key"`    // Path of the key in the file, separated by "/" (e.g. files/m3u/M1/name)
+	Change string `json:"change"` // "added", "removed" or "changed" by a restore of the backup
+	Old    any    `json:"old,omitempty"`
+	New    any    `json:"new,omitempty"`
+}
+
+// StatsSampleStruct : Tuner usage at a point in time (API command stats.history)
+type StatsSampleStruct struct {
+	Time        int64 `json:"time"` // Unix time
+	TunerActive int64 `json:"tuners.active"`
+	Bandwidth   int64 `json:"bandwidth"` // Sum of the measured network bandwidth of the streams
+}
+
+// InactiveStreamStruct : Stream that was filtered out (API command streams.inactive)
+type InactiveStreamStruct struct {
+	Name       string `json:"name"`
+	GroupTitle string `json:"group-title"`
+	Playlist   string `json:"playlist"`
+	Reason     string `json:"reason"`
+}
+
+// XEPGValidationStruct : Report of the API command xepg.validate
+type XEPGValidationStruct struct {
+	MissingFile    []XEPGValidationChannel `json:"missing.file"`    // Mapped to an XMLTV file that does not exist
+	MissingChannel []XEPGValidationChannel `json:"missing.channel"` // Mapped to a channel ID that is not in the XMLTV file
+	NoEPG          []XEPGValidationChannel `json:"no.epg"`          // Active channels without mapping
+}
+
+// XEPGValidationChannel : Channel in the XEPG validation report
+type XEPGValidationChannel struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Channel   string `json:"channel"`
+	XmltvFile string `json:"x-xmltv-file,omitempty"`
+	XMapping  string `json:"x-mapping,omitempty"`
+}
+
+// ProviderStatsStruct : Compatibility of a provider file (websocket command getProviderStats)
+type ProviderStatsStruct struct {
+	ID            string         `json:"id"`
+	Name          string         `json:"name"`
+	Type          string         `json:"type"` // m3u, hdhr, xmltv
+	LastUpdate    string         `json:"last.update"`
+	Compatibility map[string]int `json:"compatibility"` // tvg.id, group.title, stream.id in percent, streams | xmltv.channels, xmltv.programs
+	Active        int            `json:"active"`        // Streams that passed the filter (m3u, hdhr)
+}
+
+// ProviderTestStruct : Result of the websocket command testProvider
+type ProviderTestStruct struct {
+	Status   int      `json:"status"`   // HTTP status code
+	Size     int      `json:"size"`     // Bytes
+	Channels int      `json:"channels"` // Streams (m3u) or channels (xmltv)
+	Programs int      `json:"programs,omitempty"`
+	Preview  []string `json:"preview"` // Names of the first channels
+}
+
+// NowNextStruct : Current and next programme of a channel (websocket command nowNext)
+type NowNextStruct struct {
+	Channel string                `json:"x-channelID"`
+	Now     *NowNextProgramStruct `json:"now,omitempty"`
+	Next    *NowNextProgramStruct `json:"next,omitempty"`
+}
+
+// NowNextProgramStruct : Programme of the websocket command nowNext, the times in RFC 3339
+type NowNextProgramStruct struct {
+	Title string `json:"title"`
+	Start string `json:"start"`
+	Stop  string `json:"stop"`
+}
+
+// EffectiveFilterStruct : Filter rule as it is applied to the streams, after the include and exclude words of a group filter are combined
+type EffectiveFilterStruct struct {
+	Type            string `json:"type"` // group-title, custom-filter
+	Rule            string `json:"rule"` // e.g. Sports {Include} !{Exclude}
+	CaseSensitive   bool   `json:"caseSensitive"`
+	PreserveMapping bool   `json:"preserveMapping"`
+	StartingChannel string `json:"startingChannel"`
+}
+
+// StreamStatusUpdateStruct : Pushed to the web interface when a stream starts or stops
+type StreamStatusUpdateStruct struct {
+	Cmd     string `json:"cmd"`
+	Channel string `json:"channel"`
+	Action  string `json:"action"` // start, stop
+	Active  int    `json:"active"` // Active streams of all playlists
 }
 
 // WebScreenLogStruct : Logs are saved in RAM and made available for the Web Interface
@@ -165,3 +326,35 @@ type WebScreenLogStruct struct {
 	Log      []string     `json:"log"`
 	Warnings int          `json:"warnings"`
 }
+
+// ChannelExportStruct : Mapping of a channel in /channels.json, imported by another instance (websocket command importFromInstance)
+type ChannelExportStruct struct {
+	TvgID         string              `json:"tvg-id"`
+	Name          string              `json:"name"` // Channel name of the playlist
+	XActive       bool                `json:"x-active"`
+	XName         string              `json:"x-name"`
+	XChannelID    string              `json:"x-channelID"`
+	XCategory     string              `json:"x-category"`
+	XGroupTitle   string              `json:"x-group-title"`
+	XDescription  string              `json:"x-description"`
+	XTimeshift    string              `json:"x-timeshift"`
+	XStreamFormat string              `json:"x-stream-format,omitempty"`
+	XmltvFile     string              `json:"x-xmltv-file"`
+	XMapping      string              `json:"x-mapping"`
+	XMappings     []XEPGMappingStruct `json:"x-mappings,omitempty"`
+}
+
+// ChannelImportStruct : Result of the websocket command importFromInstance
+type ChannelImportStruct struct {
+	Imported  []string                `json:"imported"`  // XEPG IDs of the updated channels
+	Unmatched []string                `json:"unmatched"` // Channels of the other instance without a local channel
+	Conflicts []ChannelImportConflict `json:"conflicts"`
+}
+
+// ChannelImportConflict : Channel of the other instance that was not imported
+type ChannelImportConflict struct {
+	ID     string `json:"id,omitempty"` // XEPG ID of the local channel
+	TvgID  string `json:"tvg-id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
@@ -3,6 +3,7 @@ package src
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"reflect"
 	"strconv"
@@ -99,23 +100,50 @@ func loadSettings() (settings SettingsStruct, err error) {
 	defaults["buffer.timeout"] = 500
 	defaults["buffer.segments"] = 3
 	defaults["buffer.client.timeout"] = 60000
+	defaults["buffer.retain.segments"] = 20
+	defaults["auto.disable.dead.channels"] = false
+	defaults["auto.disable.dead.channels.failures"] = 5
+	defaults["auto.disable.dead.channels.window.hours"] = 24
+	defaults["upstream.keepalive"] = true
+	defaults["upstream.max.idle.conns.per.host"] = 8
+	defaults["stats.interval.seconds"] = 60
+	defaults["stats.retention.hours"] = 24
+	defaults["buffer.cleanup.on.start"] = true
 	defaults["buffer"] = "-"
 	defaults["cache.images"] = false
 	defaults["clearXMLTVCache"] = false
+	defaults["default.channel.logo"] = ""
 	defaults["defaultMissingEPG"] = "-"
 	defaults["disallowURLDuplicates"] = false
 	defaults["enableMappedChannels"] = false
 	defaults["epgSource"] = "PMS"
+	defaults["ffmpeg.path"] = ""
 	defaults["files.update"] = true
 	defaults["files"] = dataMap
 	defaults["filter"] = make(map[string]any)
 	defaults["hostIP"] = "" // Will be set in resolveHostIP()
+	defaults["group.renames"] = make(map[string]any)
+	defaults["hls.bandwidth.smoothing.samples"] = 5
+	defaults["hls.decrypt"] = false
 	defaults["hostName"] = ""
 	defaults["language"] = "en"
 	defaults["log.entries.ram"] = 500
 	defaults["m3u8.adaptive.bandwidth.mbps"] = 10
+	defaults["m3u.format"] = "xteve"
+	defaults["m3u.header.attributes"] = make(map[string]any)
+	defaults["m3u.sort.order"] = "channel-number"
 	defaults["mapping.first.channel"] = 1000
+	defaults["plex.channel.limit.enforce"] = false
 	defaults["port"] = "34400"
+	defaults["prefer.source.chno"] = false
+	defaults["provider.download.concurrency"] = 4
+	defaults["provider.download.timeout.seconds"] = 0
+	defaults["provider.keep.last.good"] = true
+	defaults["provider.max.download.mb"] = 512
+	defaults["provider.refresh.retries"] = 0
+	defaults["provider.refresh.delay"] = 30
+	defaults["tuner.limit.response"] = "clip"
+	defaults["unknown.path.behavior"] = "capability"
 	defaults["ssdp"] = true
 	defaults["storeBufferInRAM"] = false
 	defaults["temp.path"] = System.Folder.Temp
@@ -131,7 +159,10 @@ func loadSettings() (settings SettingsStruct, err error) {
 	}
 	defaults["uuid"] = uuid
 	defaults["version"] = System.DBVersion
+	defaults["ws.rate.limit"] = 20
 	defaults["xepg.replace.missing.images"] = true
+	defaults["xmltv.category.blacklist"] = []string{}
+	defaults["xmltv.category.whitelist"] = []string{}
 	defaults["xteveAutoUpdate"] = true
 	defaults["stream.retry.enabled"] = true
 	defaults["stream.max.retries"] = 5
@@ -205,9 +236,32 @@ func saveSettings(settings SettingsStruct) (err error) {
 	return
 }
 
+// joinHostPort returns host:port for URLs. IPv6 literals are enclosed in brackets, the zone is escaped (%25).
+func joinHostPort(host, port string) string {
+	host = strings.Replace(strings.Trim(host, "[]"), "%25", "%", 1)
+	if strings.Contains(host, ":") {
+		host = strings.Replace(host, "%", "%25", 1)
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// normalizeDomain keeps the brackets of IPv6 literals in a Host header, also if the port is missing
+func normalizeDomain(domain string) string {
+	if host, port, err := net.SplitHostPort(domain); err == nil {
+		return joinHostPort(host, port)
+	}
+
+	if host := strings.Trim(domain, "[]"); strings.Contains(host, ":") {
+		host = strings.Replace(host, "%25", "%", 1)
+		return "[" + strings.Replace(host, "%", "%25", 1) + "]"
+	}
+
+	return domain
+}
+
 // Enable access via the Domain
 func setGlobalDomain(domain string) {
-	System.Domain = domain
+	System.Domain = normalizeDomain(domain)
 
 	if Settings.TLSMode {
 		System.ServerProtocol.API = "https"
@@ -271,32 +325,56 @@ func setDeviceID() {
 	}
 }
 
+// getStreamingURLID : ID of the /stream/ URL. It is derived from the UUID of the channel or the attributes used by
+// generateChannelHash, so the URL stays the same after a rebuild, even if the provider changes the stream URL.
+func getStreamingURLID(playlistID, name, groupTitle, tvgID, tvgName, uuidKey, uuidValue string) (string, error) {
+	if len(uuidKey) > 0 && len(uuidValue) > 0 {
+		return getMD5(strings.Join([]string{playlistID, uuidKey, uuidValue}, "\x00"))
+	}
+
+	return getMD5(strings.Join([]string{playlistID, name, groupTitle, tvgID, tvgName}, "\x00"))
+}
+
+// uniqueStreamingURLID : PMS, streams with the same attributes (e.g. SD / HD or backup feeds) have the same ID of getStreamingURLID.
+// used holds the stream URLs of the IDs of a build, if the ID already belongs to another stream URL the hash of the stream URL is used.
+func uniqueStreamingURLID(used map[string]string, urlID, playlistID, streamURL string) (string, error) {
+	if other, ok := used[urlID]; ok && other != streamURL {
+		var err error
+		if urlID, err = getMD5(fmt.Sprintf("%s-%s", playlistID, streamURL)); err != nil {
+			return "", err
+		}
+	}
+
+	used[urlID] = streamURL
+	return urlID, nil
+}
+
 // Convert Provider Streaming URL to xTeVe Streaming URL
-func createStreamingURL(streamingType, playlistID, channelNumber, channelName, url string) (streamingURL string, err error) {
-	var streamInfo StreamInfo
+func createStreamingURL(streamingType, urlID, playlistID, channelNumber, channelName, url string) (streamingURL string, err error) {
 	var serverProtocol string
 
 	if len(Data.Cache.StreamingURLS) == 0 {
 		Data.Cache.StreamingURLS = make(map[string]StreamInfo)
 	}
 
-	urlID, err := getMD5(fmt.Sprintf("%s-%s", playlistID, url))
-	if err != nil {
-		return "", err
+	if len(urlID) == 0 {
+		urlID, err = getMD5(fmt.Sprintf("%s-%s", playlistID, url))
+		if err != nil {
+			return "", err
+		}
 	}
 
-	if s, ok := Data.Cache.StreamingURLS[urlID]; ok {
-		streamInfo = s
-	} else {
-		streamInfo.URL = url
-		streamInfo.Name = channelName
-		streamInfo.PlaylistID = playlistID
-		streamInfo.ChannelNumber = channelNumber
-		streamInfo.URLid = urlID
-
-		Data.Cache.StreamingURLS[urlID] = streamInfo
+	// The stream information is always updated, the ID stays the same if the provider changes the URL
+	var streamInfo = StreamInfo{
+		URL:           url,
+		Name:          channelName,
+		PlaylistID:    playlistID,
+		ChannelNumber: channelNumber,
+		URLid:         urlID,
 	}
 
+	Data.Cache.StreamingURLS[urlID] = streamInfo
+
 	switch streamingType {
 	case "DVR":
 		serverProtocol = System.ServerProtocol.DVR
@@ -0,0 +1,59 @@
+package src
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetGlobalDomain_URLs(t *testing.T) {
+	oldSystem, oldSettings, oldData := System, Settings, Data
+	t.Cleanup(func() { System, Settings, Data = oldSystem, oldSettings, oldData })
+
+	Settings.EpgSource = "XEPG"
+	System.ServerProtocol.M3U = "http"
+	System.ServerProtocol.XML = "http"
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+
+	tests := []struct {
+		host         string
+		expectDomain string
+		expectHost   string
+		expectPort   string
+	}{
+		{"192.168.1.10:34400", "192.168.1.10:34400", "192.168.1.10", "34400"},
+		{"xteve.local:34400", "xteve.local:34400", "xteve.local", "34400"},
+		{"xteve.local", "xteve.local", "xteve.local", ""},
+		{"[::1]:34400", "[::1]:34400", "::1", "34400"},
+		{"[2001:db8::10]", "[2001:db8::10]", "2001:db8::10", ""},
+		{"2001:db8::10", "[2001:db8::10]", "2001:db8::10", ""},
+		{"[fe80::1%25eth0]:34400", "[fe80::1%25eth0]:34400", "fe80::1%eth0", "34400"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			setGlobalDomain(tt.host)
+			assert.Equal(t, tt.expectDomain, System.Domain)
+
+			streamURL, err := createStreamingURL("M3U", "", "M1", "1000", "Channel", "http://provider.example/1.ts")
+			require.NoError(t, err)
+
+			for _, rawURL := range []string{System.Addresses.XML, streamURL} {
+				u, err := url.Parse(rawURL)
+				require.NoError(t, err, rawURL)
+				assert.Equal(t, tt.expectHost, u.Hostname(), rawURL)
+				assert.Equal(t, tt.expectPort, u.Port(), rawURL)
+			}
+		})
+	}
+}
+
+func TestJoinHostPort(t *testing.T) {
+	assert.Equal(t, "192.168.1.10:34400", joinHostPort("192.168.1.10", "34400"))
+	assert.Equal(t, "xteve.local:34400", joinHostPort("xteve.local", "34400"))
+	assert.Equal(t, "[::1]:34400", joinHostPort("::1", "34400"))
+	assert.Equal(t, "[::1]:34400", joinHostPort("[::1]", "34400"))
+	assert.Equal(t, "[fe80::1%25eth0]:34400", joinHostPort("fe80::1%eth0", "34400"))
+}
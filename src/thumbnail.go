@@ -0,0 +1,259 @@
+package src
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"xteve/src/mpegts"
+)
+
+const (
+	thumbnailTimeout       = 20 * time.Second
+	thumbnailMaxAge        = 5 * time.Minute // A cached thumbnail is used again within this time
+	maxThumbnailReadBytes  = 8 << 20         // Bytes of the stream that are searched for a keyframe
+	thumbnailKeyframeBytes = 1 << 20         // Bytes from the keyframe on that are handed to FFmpeg
+)
+
+var errFFmpegNotAvailable = errors.New("ffmpeg is not available, set ffmpeg.path in settings.json")
+
+// getFFmpegPath returns ffmpeg.path or ffmpeg from PATH
+func getFFmpegPath() (string, error) {
+	var name = Settings.FFmpegPath
+	if len(name) == 0 {
+		name = "ffmpeg"
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", errFFmpegNotAvailable
+	}
+	return path, nil
+}
+
+// createStreamThumbnail : Preview image of a channel (API command stream.thumbnail).
+// xTeVe reads the stream like a client, the first keyframe is converted by FFmpeg to a JPEG file in the image cache.
+func createStreamThumbnail(ctx context.Context, xepgID, clientIP string) (thumbnailURL string, err error) {
+	ffmpeg, err := getFFmpegPath()
+	if err != nil {
+		return
+	}
+
+	channel, ok := Data.XEPG.Channels[xepgID]
+	if !ok || Settings.EpgSource != "XEPG" {
+		return "", fmt.Errorf("channel not found: %s", xepgID)
+	}
+
+	md5, err := getMD5(channel.URL)
+	if err != nil {
+		return
+	}
+
+	var fileName = "thumbnail_" + md5 + ".jpg"
+	var file = System.Folder.ImagesCache + fileName
+	thumbnailURL = fmt.Sprintf("%s://%s/images/%s", System.ServerProtocol.WEB, System.Domain, fileName)
+
+	if info, err := os.Stat(file); err == nil && time.Since(info.ModTime()) < thumbnailMaxAge {
+		return thumbnailURL, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, thumbnailTimeout)
+	defer cancel()
+
+	image, err := extractStreamThumbnail(ctx, ffmpeg, channel, clientIP)
+	if err != nil {
+		return "", fmt.Errorf("thumbnail of %s: %w", channel.XName, err)
+	}
+
+	if err = writeByteToFile(file, image); err != nil {
+		return "", err
+	}
+	return
+}
+
+// extractStreamThumbnail returns the JPEG image of the first keyframe of the channel's stream.
+// FFmpeg gets the data of the stream on stdin, for MPEG-TS streams from the keyframe on.
+func extractStreamThumbnail(ctx context.Context, ffmpeg string, channel XEPGChannelStruct, clientIP string) ([]byte, error) {
+	stream, err := openThumbnailStream(ctx, channel, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	var body = bufio.NewReaderSize(stream, 2*mpegts.PacketSize)
+	var args = []string{"-hide_banner", "-loglevel", "error"}
+	var stdin io.Reader
+
+	// The xTeVe buffer delivers HLS streams as MPEG-TS
+	start, _ := body.Peek(mpegts.PacketSize + 1)
+	var mpegTS = channel.XStreamFormat == "ts" || len(start) > mpegts.PacketSize && start[0] == mpegts.SyncByte && start[mpegts.PacketSize] == mpegts.SyncByte
+
+	if mpegTS {
+		data, err := readKeyframe(body)
+		if err != nil {
+			return nil, err
+		}
+		stdin = bytes.NewReader(data)
+		args = append(args, "-f", "mpegts", "-i", "pipe:0")
+	} else {
+		// MP4 and Matroska
+		stdin = io.LimitReader(body, maxThumbnailReadBytes)
+		args = append(args, "-i", "pipe:0")
+	}
+
+	var stdout, stderr bytes.Buffer
+	var cmd = exec.CommandContext(ctx, ffmpeg, append(args, "-frames:v", "1", "-f", "image2", "-c:v", "mjpeg", "pipe:1")...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, &stdout, &stderr
+
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	if !bytes.HasPrefix(stdout.Bytes(), []byte{0xFF, 0xD8}) {
+		return nil, errors.New("ffmpeg: no image was created")
+	}
+	return stdout.Bytes(), nil
+}
+
+// openThumbnailStream : Stream of the channel for the thumbnail.
+// With the xTeVe buffer the thumbnail uses a tuner like a client, a running stream of the channel is shared.
+// Without the buffer the stream is requested from the provider, HLS playlists are not supported.
+func openThumbnailStream(ctx context.Context, channel XEPGChannelStruct, clientIP string) (io.ReadCloser, error) {
+	if Settings.Buffer != "xteve" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, channel.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		setUpstreamHeaders(req)
+
+		resp, err := NewHTTPClient().Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		switch {
+		case resp.StatusCode != http.StatusOK:
+			resp.Body.Close()
+			return nil, fmt.Errorf("%d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		case channel.XStreamFormat == "hls" || isHLSContentType(contentType):
+			resp.Body.Close()
+			return nil, errors.New("HLS streams require the xTeVe buffer")
+		}
+		return resp.Body, nil
+	}
+
+	playlist, stream, _, streamID, newStream, err := reserveStreamSlot(channel.FileM3UID, channel.URL, channel.XName, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/stream/", nil)
+	if err != nil {
+		cancel()
+		killClientConnection(streamID, channel.FileM3UID, false)
+		return nil, err
+	}
+
+	// The buffer writes the stream to the pipe, the slot is released when the pipe is closed
+	reader, writer := io.Pipe()
+	context.AfterFunc(ctx, func() { reader.CloseWithError(ctx.Err()) })
+
+	go func() {
+		sendBufferedStream(channel.FileM3UID, playlist, stream, streamID, newStream, &thumbnailWriter{header: make(http.Header), pipe: writer}, r)
+		writer.Close()
+	}()
+
+	return &thumbnailStream{PipeReader: reader, cancel: cancel}, nil
+}
+
+// thumbnailWriter : Response writer of the buffer for a thumbnail
+type thumbnailWriter struct {
+	header http.Header
+	pipe   *io.PipeWriter
+}
+
+func (w *thumbnailWriter) Header() http.Header         { return w.header }
+func (w *thumbnailWriter) Write(b []byte) (int, error) { return w.pipe.Write(b) }
+func (w *thumbnailWriter) WriteHeader(int)             {}
+
+// thumbnailStream : Close ends the client connection of the thumbnail to the buffer
+type thumbnailStream struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (s *thumbnailStream) Close() error {
+	s.cancel()
+	return s.PipeReader.Close()
+}
+
+// readKeyframe reads the MPEG-TS stream up to the first keyframe (random access point) of the video stream and returns the packets from there.
+// The video stream is taken from the PMT, the PAT and PMT are sent in front of the keyframe.
+func readKeyframe(r io.Reader) ([]byte, error) {
+	var parser = mpegts.NewParser()
+	var packet = make([]byte, mpegts.PacketSize)
+	var buffer = make([]byte, 32*1024)
+	var pat, pmt []byte
+	var pmtPID, videoPID uint16
+	var keyframe bytes.Buffer
+	var read int
+
+	for keyframe.Len() < thumbnailKeyframeBytes {
+		if err := parser.NextInto(packet); err == nil {
+			switch pid := mpegts.PID(packet); {
+			case keyframe.Len() > 0:
+				keyframe.Write(packet)
+			case pid == 0:
+				if p, ok := mpegts.ProgramMapPID(packet); ok {
+					pat, pmtPID = slices.Clone(packet), p
+				}
+			case pat != nil && pid == pmtPID:
+				if p, ok := mpegts.VideoPID(packet); ok {
+					pmt, videoPID = slices.Clone(packet), p
+				}
+			case pmt != nil && pid == videoPID && mpegts.RandomAccess(packet):
+				keyframe.Write(pat)
+				keyframe.Write(pmt)
+				keyframe.Write(packet)
+			}
+			continue
+		}
+
+		if keyframe.Len() == 0 && read >= maxThumbnailReadBytes {
+			break
+		}
+
+		n, err := r.Read(buffer)
+		if n == 0 && err != nil {
+			break
+		}
+		read += n
+		parser.Write(buffer[:n])
+	}
+
+	if keyframe.Len() == 0 {
+		return nil, errors.New("no keyframe found in the stream")
+	}
+	return keyframe.Bytes(), nil
+}
+
+// isHLSContentType : Content-Types of HLS playlists
+func isHLSContentType(contentType string) bool {
+	switch strings.ToLower(contentType) {
+	case "application/x-mpegurl", "application/vnd.apple.mpegurl", "audio/mpegurl", "audio/x-mpegurl":
+		return true
+	}
+	return false
+}
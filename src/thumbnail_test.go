@@ -0,0 +1,172 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"xteve/src/mpegts"
+)
+
+func TestReadKeyframe(t *testing.T) {
+	packet := func(pid uint16, counter byte, randomAccess bool) []byte {
+		p := make([]byte, mpegts.PacketSize)
+		p[0] = mpegts.SyncByte
+		p[1], p[2] = byte(pid>>8), byte(pid)
+		p[3] = 0x30 | counter
+		p[4] = 1
+		if randomAccess {
+			p[5] = 0x40
+		}
+		return p
+	}
+
+	// PSI packet with the section after the header (section_length, 5 bytes) and without CRC
+	psi := func(pid uint16, tableID byte, data ...byte) []byte {
+		p := bytes.Repeat([]byte{0xFF}, mpegts.PacketSize)
+		p[0], p[1], p[2], p[3] = mpegts.SyncByte, 0x40|byte(pid>>8), byte(pid), 0x10
+		copy(p[4:], append([]byte{0x00, tableID, 0xB0, byte(5 + len(data) + 4), 0x00, 0x01, 0xC1, 0x00, 0x00}, data...))
+		return p
+	}
+	var pat = psi(0, 0x00, 0x00, 0x01, 0xF0, 0x00)                                                                  // PMT on PID 0x1000
+	var pmt = psi(0x1000, 0x02, 0xE1, 0x00, 0xF0, 0x00, 0x0F, 0xE1, 0x01, 0xF0, 0x00, 0x1B, 0xE1, 0x00, 0xF0, 0x00) // Audio 0x101, video 0x100
+
+	var stream bytes.Buffer
+	stream.WriteString("garbage")
+	stream.Write(packet(0x100, 0, true)) // Before the PMT
+	stream.Write(pat)
+	stream.Write(pmt)
+	for i := range 10 {
+		stream.Write(packet(0x101, byte(i), i == 2)) // The audio stream is not a keyframe
+		stream.Write(packet(0x100, byte(i), i == 5))
+	}
+
+	keyframe, err := readKeyframe(bytes.NewReader(stream.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, keyframe, (2+9)*mpegts.PacketSize, "PAT and PMT, then the packets from the keyframe on")
+	assert.Equal(t, pat, keyframe[:mpegts.PacketSize])
+	assert.Equal(t, pmt, keyframe[mpegts.PacketSize:2*mpegts.PacketSize])
+	assert.Equal(t, packet(0x100, 5, true), keyframe[2*mpegts.PacketSize:3*mpegts.PacketSize])
+
+	_, err = readKeyframe(bytes.NewReader(append(packet(0x100, 0, true), packet(0x101, 1, true)...)))
+	assert.Error(t, err, "without PMT")
+}
+
+func TestOpenThumbnailStream(t *testing.T) {
+	newSource, _ := setupLingerTest(t, 0)
+	source, connections := newSource()
+
+	var channel = XEPGChannelStruct{XName: "Zeta News", FileM3UID: "M1", URL: source.URL + "/1.ts"}
+	read := func(stream io.Reader) {
+		_, err := io.ReadFull(stream, make([]byte, mpegts.PacketSize))
+		require.NoError(t, err)
+	}
+
+	// The thumbnail uses a tuner of the provider
+	stream, err := openThumbnailStream(t.Context(), channel, "127.0.0.1")
+	require.NoError(t, err)
+	read(stream)
+	assert.Equal(t, 1, activeTuners("M1"))
+
+	_, err = openThumbnailStream(t.Context(), XEPGChannelStruct{XName: "Alpha Sport", FileM3UID: "M1", URL: source.URL + "/2.ts"}, "127.0.0.1")
+	assert.ErrorIs(t, err, errTunerLimitReached)
+
+	// The running stream of the channel is shared
+	shared, err := openThumbnailStream(t.Context(), channel, "127.0.0.1")
+	require.NoError(t, err)
+	read(shared)
+	require.NoError(t, shared.Close())
+	assert.Equal(t, int32(1), connections.Load())
+
+	require.NoError(t, stream.Close())
+	require.Eventually(t, func() bool { return activeTuners("M1") == 0 }, 5*time.Second, 50*time.Millisecond)
+
+	// Without the buffer, HLS playlists would be read by FFmpeg itself
+	Settings.Buffer = "-"
+	playlist := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		_, _ = w.Write([]byte("#EXTM3U\n"))
+	}))
+	defer playlist.Close()
+
+	_, err = openThumbnailStream(t.Context(), XEPGChannelStruct{FileM3UID: "M1", URL: playlist.URL + "/index.m3u8"}, "127.0.0.1")
+	assert.ErrorContains(t, err, "HLS")
+}
+
+func TestStreamThumbnailAPI(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+
+	setupOutputProfileTest(t)
+	Settings.AuthenticationAPI = false
+	System.ServerProtocol.WEB = "http"
+
+	thumbnail := func(channel string) APIResponseStruct {
+		req := httptest.NewRequest("POST", "/api/", bytes.NewBufferString(`{"cmd":"stream.thumbnail","channel":"`+channel+`"}`))
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		API(w, req)
+
+		var response APIResponseStruct
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	// Without FFmpeg
+	Settings.FFmpegPath = t.TempDir() + "/ffmpeg"
+	var response = thumbnail("x-ID.1")
+	assert.False(t, response.Status)
+	assert.Equal(t, errFFmpegNotAvailable.Error(), response.Error)
+
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		t.Skip("ffmpeg is not installed")
+	}
+	Settings.FFmpegPath = ffmpeg
+
+	// MPEG-TS stream with keyframes (random access points) every 5 frames
+	stream, err := exec.Command(ffmpeg, "-hide_banner", "-loglevel", "error", "-f", "lavfi", "-i", "testsrc=size=64x48:rate=25", "-t", "2",
+		"-c:v", "mpeg2video", "-g", "5", "-f", "mpegts", "pipe:1").Output()
+	require.NoError(t, err)
+
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "video/mp2t")
+		_, _ = w.Write(stream)
+	}))
+	defer server.Close()
+
+	var channel = Data.XEPG.Channels["x-ID.1"]
+	channel.URL = server.URL + "/live.ts"
+	Data.XEPG.Channels["x-ID.1"] = channel
+
+	response = thumbnail("x-ID.1")
+	require.True(t, response.Status, response.Error)
+	require.Regexp(t, `^http://localhost:34400/images/thumbnail_[0-9a-f]{32}\.jpg$`, response.Thumbnail)
+
+	u, err := url.Parse(response.Thumbnail)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	Images(rr, httptest.NewRequest(http.MethodGet, u.Path, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/jpeg", http.DetectContentType(rr.Body.Bytes()))
+
+	// The cached image is used again
+	assert.Equal(t, response.Thumbnail, thumbnail("x-ID.1").Thumbnail)
+	assert.Equal(t, int64(1), requests.Load())
+
+	assert.False(t, thumbnail("x-ID.9").Status)
+}
@@ -340,17 +340,25 @@ func randomString(n int) (string, error) {
 }
 
 func parseTemplate(content string, tmpMap map[string]any) (result string) {
-	t := template.Must(template.New("template").Parse(content))
-
-	var tpl bytes.Buffer
-
-	if err := t.Execute(&tpl, tmpMap); err != nil {
+	result, err := renderTemplate(content, tmpMap)
+	if err != nil {
 		ShowError(err, 0)
 	}
-	result = tpl.String()
 	return
 }
 
+// renderTemplate : parseTemplate, the error is returned instead of logged (-selftest)
+func renderTemplate(content string, tmpMap map[string]any) (string, error) {
+	t, err := template.New("template").Parse(content)
+	if err != nil {
+		return "", err
+	}
+
+	var tpl bytes.Buffer
+	err = t.Execute(&tpl, tmpMap)
+	return tpl.String(), err
+}
+
 func getMD5(str string) (string, error) {
 	md5Hasher := md5.New()
 	if _, err := md5Hasher.Write([]byte(str)); err != nil {
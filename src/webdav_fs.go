@@ -64,6 +64,10 @@ const (
 type WebDAVFS struct {
 }
 
+// webdavStartTime is the modification time of virtual entries without an underlying file.
+// It must not change between calls, otherwise clients re-fetch the listing endlessly.
+var webdavStartTime = time.Now().Truncate(time.Second)
+
 // mkDirInfo implements os.FileInfo for a directory
 type mkDirInfo struct {
 	name    string
@@ -131,6 +135,8 @@ func (fs *WebDAVFS) OpenFile(ctx context.Context, name string, flag int, perm os
 		return nil, os.ErrNotExist
 	}
 
+	parts = joinGroupParts(ctx, parts)
+
 	switch len(parts) {
 	case 1:
 		return fs.openHashDir(ctx, hash)
@@ -180,10 +186,7 @@ func (fs *WebDAVFS) openHashSubDir(ctx context.Context, hash, sub string) (webda
 }
 
 func (fs *WebDAVFS) groupExists(ctx context.Context, hash, group string) bool {
-	groups := getGroupsForHash(ctx, hash)
-	return slices.ContainsFunc(groups, func(g string) bool {
-		return sanitizeGroupName(g) == group
-	})
+	return slices.Contains(getGroupDirs(ctx, hash), group)
 }
 
 func (fs *WebDAVFS) openOnDemandGroupDir(ctx context.Context, hash, sub, group string) (webdav.File, error) {
@@ -338,7 +341,7 @@ func (fs *WebDAVFS) Stat(ctx context.Context, name string) (_ os.FileInfo, err e
 	name = strings.TrimSuffix(name, "/")
 
 	if name == "" {
-		return &mkDirInfo{name: "", modTime: time.Now()}, nil
+		return &mkDirInfo{name: "", modTime: getWebDAVRootModTime()}, nil
 	}
 
 	parts := strings.Split(name, "/")
@@ -352,6 +355,7 @@ func (fs *WebDAVFS) Stat(ctx context.Context, name string) (_ os.FileInfo, err e
 	}
 
 	modTime := getM3UModTime(hash)
+	parts = joinGroupParts(ctx, parts)
 
 	switch len(parts) {
 	case 1:
@@ -361,7 +365,7 @@ func (fs *WebDAVFS) Stat(ctx context.Context, name string) (_ os.FileInfo, err e
 	case 3:
 		// Group dir
 		if parts[1] == dirOnDemand && fs.groupExists(ctx, hash, parts[2]) {
-			return &mkDirInfo{name: parts[2], modTime: modTime}, nil
+			return &mkDirInfo{name: path.Base(parts[2]), modTime: modTime}, nil
 		}
 	case 4:
 		// Series or Individual dir
@@ -739,14 +743,14 @@ func (d *webdavDir) collectInfos(ctx context.Context) ([]os.FileInfo, error) {
 		return d.readDirRoot()
 	}
 
-	parts := strings.Split(d.name, "/")
+	parts := joinGroupParts(ctx, strings.Split(d.name, "/"))
 
 	// We can try to extract hash from parts[0] if available
 	var modTime time.Time
 	if len(parts) > 0 {
 		modTime = getM3UModTime(parts[0])
 	} else {
-		modTime = time.Now()
+		modTime = webdavStartTime
 	}
 
 	switch len(parts) {
@@ -796,9 +800,8 @@ func (d *webdavDir) readDirOnDemand(ctx context.Context, hash, sub string, modTi
 		return nil, nil
 	}
 	var infos []os.FileInfo
-	groups := getGroupsForHash(ctx, hash)
-	for _, g := range groups {
-		infos = append(infos, &mkDirInfo{name: sanitizeGroupName(g), modTime: modTime})
+	for _, g := range getSubGroups(ctx, hash, "") {
+		infos = append(infos, &mkDirInfo{name: g, modTime: modTime})
 	}
 	return infos, nil
 }
@@ -819,6 +822,11 @@ func (d *webdavDir) readDirOnDemandGroup(ctx context.Context, hash, sub, group s
 		infos = append(infos, &mkDirInfo{name: dirSeries, modTime: modTime})
 	}
 
+	// Nested groups (webdav.group.separator)
+	for _, g := range getSubGroups(ctx, hash, group) {
+		infos = append(infos, &mkDirInfo{name: g, modTime: modTime})
+	}
+
 	return infos, nil
 }
 
@@ -951,7 +959,7 @@ func (d *webdavDir) Stat() (_ os.FileInfo, err error) {
 	name := d.name
 	if name == "" {
 		// Root
-		return &mkDirInfo{name: "", modTime: time.Now()}, nil
+		return &mkDirInfo{name: "", modTime: getWebDAVRootModTime()}, nil
 	}
 
 	parts := strings.Split(name, "/")
@@ -959,7 +967,7 @@ func (d *webdavDir) Stat() (_ os.FileInfo, err error) {
 	if len(parts) > 0 {
 		modTime = getM3UModTime(parts[0])
 	} else {
-		modTime = time.Now()
+		modTime = webdavStartTime
 	}
 
 	return &mkDirInfo{name: path.Base(name), modTime: modTime}, nil
@@ -1230,6 +1238,7 @@ func (s *webdavStream) openStream(offset int64) (err error) {
 				s.usingCache = true
 				s.cacheComplete = meta.Complete
 				span.SetAttributes(attribute.Bool("webdav.cache_hit", true))
+				showSubsystemDebug(debugWebDAV, fmt.Sprintf("Open Stream:%s from the cache (offset %d)", s.name, offset), 2)
 				return nil
 			} else {
 				f.Close()
@@ -1253,6 +1262,7 @@ func (s *webdavStream) openStream(offset int64) (err error) {
 					s.usingCache = true
 					s.cacheComplete = true // tail cache covers to end of file
 					span.SetAttributes(attribute.Bool("webdav.tail_cache_hit", true))
+					showSubsystemDebug(debugWebDAV, fmt.Sprintf("Open Stream:%s from the tail cache (offset %d)", s.name, offset), 2)
 					return nil
 				} else {
 					f.Close()
@@ -1288,6 +1298,8 @@ func (s *webdavStream) openStream(offset int64) (err error) {
 
 	req.Header.Set("User-Agent", Settings.UserAgent)
 
+	showSubsystemDebug(debugWebDAV, fmt.Sprintf("Open Stream:%s from the provider (offset %d)", s.name, offset), 2)
+
 	// Use a default client or one from System if available
 	client := NewHTTPClient()
 	resp, err := client.Do(req)
@@ -1303,16 +1315,30 @@ func (s *webdavStream) openStream(offset int64) (err error) {
 
 	// Robustness: If we requested a range (offset > 0) but got 200 OK, the server
 	// ignored the Range header (or dropped it on redirect). We must manually skip bytes.
-	if offset > 0 && resp.StatusCode == http.StatusOK {
-		span.AddEvent("webdav.range_ignored_by_upstream", trace.WithAttributes(
-			attribute.Int64("bytes_to_skip", offset),
-		))
-		// Discard the prefix we didn't want
-		_, err := io.CopyN(io.Discard, resp.Body, offset)
-		if err != nil {
-			resp.Body.Close()
-			span.RecordError(err)
-			return fmt.Errorf("failed to skip %d bytes: %w", offset, err)
+	// A 206 response has to start at the requested offset, an earlier start is skipped as well.
+	if offset > 0 {
+		var skip = offset
+		if resp.StatusCode == http.StatusPartialContent {
+			start, ok := parseStartFromRange(resp)
+			if !ok || start > offset {
+				resp.Body.Close()
+				return fmt.Errorf("upstream returned Content-Range %q for offset %d", resp.Header.Get("Content-Range"), offset)
+			}
+			skip = offset - start
+		}
+
+		if skip > 0 {
+			span.AddEvent("webdav.range_ignored_by_upstream", trace.WithAttributes(
+				attribute.Int64("bytes_to_skip", skip),
+			))
+			showSubsystemDebug(debugWebDAV, fmt.Sprintf("Open Stream:Range ignored by the provider, %d bytes of %s skipped", skip, s.name), 2)
+			// Discard the prefix we didn't want
+			_, err := io.CopyN(io.Discard, resp.Body, skip)
+			if err != nil {
+				resp.Body.Close()
+				span.RecordError(err)
+				return fmt.Errorf("failed to skip %d bytes: %w", skip, err)
+			}
 		}
 	}
 
@@ -1697,6 +1723,23 @@ func parseTotalSizeFromRange(resp *http.Response) int64 {
 	return 0
 }
 
+// parseStartFromRange extracts the first byte position from the Content-Range header of a 206 Partial Content response (bytes X-Y/TOTAL).
+func parseStartFromRange(resp *http.Response) (int64, bool) {
+	cr, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return 0, false
+	}
+	startStr, _, ok := strings.Cut(cr, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(startStr), 10, 64)
+	if err != nil || start < 0 {
+		return 0, false
+	}
+	return start, true
+}
+
 // Improved ensureMetadata that checks M3U first
 func ensureMetadataOptimized(ctx context.Context, hash string, files []FileStreamInfo) {
 	ctx, span := otel.Tracer("webdav").Start(ctx, "ensureMetadataOptimized")
@@ -1804,11 +1847,26 @@ func getM3UModTime(hash string) time.Time {
 	realPath := filepath.Join(System.Folder.Data, hash+".m3u")
 	info, err := os.Stat(realPath)
 	if err != nil {
-		return time.Now()
+		return webdavStartTime
 	}
 	return info.ModTime()
 }
 
+// getWebDAVRootModTime returns the newest modification time of all playlists
+func getWebDAVRootModTime() time.Time {
+	var modTime time.Time
+	for hash := range Settings.Files.M3U {
+		if t := getM3UModTime(hash); t.After(modTime) {
+			modTime = t
+		}
+	}
+
+	if modTime.IsZero() {
+		return webdavStartTime
+	}
+	return modTime
+}
+
 var sanitizeRegex = regexp.MustCompile(`[^a-zA-Z0-9.\-_ ():]`)
 var seriesRegex = regexp.MustCompile(`(?i)^(.*?)[_.\s]*S(\d{1,3})[_.\s]*E\d{1,3}`)
 
@@ -1852,6 +1910,68 @@ func sanitizeGroupName(name string) string {
 	return strings.ReplaceAll(name, "/", "_")
 }
 
+// groupPath : Directory of a group below "On Demand".
+// With webdav.group.separator the group is split into nested directories ("Movies / Action" -> "Movies/Action").
+func groupPath(group string) string {
+	if Settings.WebDAVGroupSeparator == "" {
+		return sanitizeGroupName(group)
+	}
+
+	var dirs []string
+	for dir := range strings.SplitSeq(group, Settings.WebDAVGroupSeparator) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, sanitizeGroupName(dir))
+		}
+	}
+
+	if len(dirs) == 0 {
+		return sanitizeGroupName(group)
+	}
+	return strings.Join(dirs, "/")
+}
+
+// getGroupDirs : Directories of all groups, including the parent directories of nested groups
+func getGroupDirs(ctx context.Context, hash string) []string {
+	var dirs = make(map[string]bool)
+	for _, g := range getGroupsForHash(ctx, hash) {
+		for dir := groupPath(g); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(dirs))
+}
+
+// getSubGroups : Names of the directories directly below a group directory, the top level directories for an empty group
+func getSubGroups(ctx context.Context, hash, group string) []string {
+	var subGroups []string
+	for _, dir := range getGroupDirs(ctx, hash) {
+		parent := path.Dir(dir)
+		if parent == "." {
+			parent = ""
+		}
+		if parent == group {
+			subGroups = append(subGroups, path.Base(dir))
+		}
+	}
+	return subGroups
+}
+
+// joinGroupParts : Joins the directories of a nested group in the path to one part, so that parts[2] is always the group.
+// The longest matching group wins, the remaining parts (Series, Individual, files) keep their positions.
+func joinGroupParts(ctx context.Context, parts []string) []string {
+	if len(parts) < 4 || parts[1] != dirOnDemand || Settings.WebDAVGroupSeparator == "" {
+		return parts
+	}
+
+	dirs := getGroupDirs(ctx, parts[0])
+	for end := len(parts); end > 3; end-- {
+		if group := strings.Join(parts[2:end], "/"); slices.Contains(dirs, group) {
+			return slices.Concat(parts[:2], []string{group}, parts[end:])
+		}
+	}
+	return parts
+}
+
 func getExtensionFromURL(urlStr string) string {
 	// Optimization: Avoid url.Parse which allocates.
 	// Strip query parameters and fragments manually.
@@ -1890,7 +2010,7 @@ func getStreamsForGroup(ctx context.Context, hash, group string) []map[string]st
 					g = "Uncategorized"
 				}
 
-				if sanitizeGroupName(g) == group {
+				if groupPath(g) == group {
 					results = append(results, stream)
 				}
 			}
@@ -2285,8 +2405,14 @@ func findSeriesStream(ctx context.Context, hash, group, series, seasonStr, filen
 }
 
 func isVOD(stream map[string]string) bool {
-	// 1. Check extension first (priority over duration)
 	urlStr := stream["url"]
+
+	// 0. The type of the HLS playlist, if the stream was already played with the buffer
+	if vod, ok := hlsPlaylistTypes.Load(urlStr); ok {
+		return vod.(bool)
+	}
+
+	// 1. Check extension first (priority over duration)
 	ext := strings.ToLower(getExtensionFromURL(urlStr))
 
 	// Optimization: Use O(1) map lookup instead of O(N) slice search
@@ -0,0 +1,56 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebDAVStableModTime(t *testing.T) {
+	origFolderData := System.Folder.Data
+	origFilesM3U := Settings.Files.M3U
+	t.Cleanup(func() {
+		System.Folder.Data = origFolderData
+		Settings.Files.M3U = origFilesM3U
+	})
+
+	System.Folder.Data = t.TempDir()
+	Settings.Files.M3U = map[string]any{
+		"M_missing": map[string]any{},
+		"M_present": map[string]any{},
+	}
+
+	m3uTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m3uPath := filepath.Join(System.Folder.Data, "M_present.m3u")
+	require.NoError(t, os.WriteFile(m3uPath, []byte("#EXTM3U\n"), 0644))
+	require.NoError(t, os.Chtimes(m3uPath, m3uTime, m3uTime))
+
+	fs := &WebDAVFS{}
+	ctx := t.Context()
+
+	for _, name := range []string{"/", "/M_missing", "/M_missing/On Demand", "/M_present", "/M_present/On Demand"} {
+		first, err := fs.Stat(ctx, name)
+		require.NoError(t, err, name)
+
+		time.Sleep(10 * time.Millisecond)
+
+		second, err := fs.Stat(ctx, name)
+		require.NoError(t, err, name)
+		assert.True(t, first.ModTime().Equal(second.ModTime()), "modtime of %s changed between calls", name)
+	}
+
+	info, err := fs.Stat(ctx, "/M_present")
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(m3uTime))
+
+	dir := &webdavDir{name: "M_missing", ctx: ctx}
+	first, err := dir.Stat()
+	require.NoError(t, err)
+	second, err := dir.Stat()
+	require.NoError(t, err)
+	assert.True(t, first.ModTime().Equal(second.ModTime()))
+}
@@ -0,0 +1,98 @@
+package src
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebDAVFS_NestedGroups(t *testing.T) {
+	oldSettings, oldSystem, oldStreams, oldFetch := Settings, System, Data.Streams.All, fetchRemoteMetadataFunc
+	t.Cleanup(func() {
+		Settings, System, Data.Streams.All, fetchRemoteMetadataFunc = oldSettings, oldSystem, oldStreams, oldFetch
+		ClearWebDAVCache("")
+	})
+
+	System.Folder.Data = t.TempDir()
+	fetchRemoteMetadataFunc = func(ctx context.Context, urlStr string) (FileMeta, error) {
+		return FileMeta{Size: 1024, ModTime: time.Unix(1700000000, 0)}, nil
+	}
+
+	const hash = "nested"
+	Settings.Files.M3U = map[string]any{hash: map[string]any{"name": "Nested"}}
+	require.NoError(t, os.WriteFile(filepath.Join(System.Folder.Data, hash+".m3u"), []byte("#EXTM3U"), 0644))
+
+	vod := func(group, name string) map[string]string {
+		return map[string]string{"_file.m3u.id": hash, "group-title": group, "name": name, "url": "http://example.com/" + name + ".mp4"}
+	}
+	Data.Streams.All = []any{
+		vod("Movies", "Classic"),
+		vod("Movies / Action", "Heat"),
+		vod("Movies / Action / 80s", "Predator"),
+		vod("Movies / Comedy", "Airplane"),
+		vod("Kids / Cartoons", "Show S01E01"),
+		vod("News/Live", "Report"),
+	}
+
+	fs := &WebDAVFS{}
+	ctx := context.Background()
+
+	readDir := func(name string) []string {
+		t.Helper()
+		f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
+		require.NoError(t, err, name)
+		defer f.Close()
+
+		infos, err := f.Readdir(0)
+		require.NoError(t, err, name)
+
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		return names
+	}
+
+	// Without separator, every group is one directory
+	ClearWebDAVCache("")
+	assert.Equal(t, []string{"Kids _ Cartoons", "Movies", "Movies _ Action", "Movies _ Action _ 80s", "Movies _ Comedy", "News_Live"}, readDir("/nested/On Demand"))
+
+	Settings.WebDAVGroupSeparator = " / "
+	ClearWebDAVCache("")
+
+	assert.Equal(t, []string{"Kids", "Movies", "News_Live"}, readDir("/nested/On Demand"))
+	assert.Equal(t, []string{dirIndividual, "Action", "Comedy"}, readDir("/nested/On Demand/Movies"))
+	assert.Equal(t, []string{dirIndividual, "80s"}, readDir("/nested/On Demand/Movies/Action"))
+	assert.Equal(t, []string{"Heat.mp4"}, readDir("/nested/On Demand/Movies/Action/Individual"))
+	assert.Equal(t, []string{"Predator.mp4"}, readDir("/nested/On Demand/Movies/Action/80s/Individual"))
+	assert.Equal(t, []string{"Cartoons"}, readDir("/nested/On Demand/Kids"), "a parent without streams only contains the subgroups")
+	assert.Equal(t, []string{dirSeries}, readDir("/nested/On Demand/Kids/Cartoons"))
+	assert.Equal(t, []string{"Season 1"}, readDir("/nested/On Demand/Kids/Cartoons/Series/Show"))
+	assert.Equal(t, []string{"Show - S01E01.mp4"}, readDir("/nested/On Demand/Kids/Cartoons/Series/Show/Season 1"))
+
+	info, err := fs.Stat(ctx, "/nested/On Demand/Movies/Action")
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Equal(t, "Action", info.Name())
+
+	info, err = fs.Stat(ctx, "/nested/On Demand/Movies/Action/80s/Individual/Predator.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1024), info.Size())
+
+	f, err := fs.OpenFile(ctx, "/nested/On Demand/Kids/Cartoons/Series/Show/Season 1/Show - S01E01.mp4", os.O_RDONLY, 0)
+	require.NoError(t, err)
+	stream, ok := f.(*webdavStream)
+	require.True(t, ok)
+	assert.Equal(t, "http://example.com/Show S01E01.mp4", stream.targetURL)
+	f.Close()
+
+	for _, name := range []string{"/nested/On Demand/Movies/Drama", "/nested/On Demand/Movies/Action/Individual/Predator.mp4"} {
+		_, err = fs.Stat(ctx, name)
+		assert.ErrorIs(t, err, os.ErrNotExist, name)
+	}
+}
@@ -0,0 +1,95 @@
+package src
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebDAVStreamSeekRange(t *testing.T) {
+	os.Setenv("XTEVE_ALLOW_LOOPBACK", "true")
+	defer os.Unsetenv("XTEVE_ALLOW_LOOPBACK")
+	os.Setenv("XTEVE_DISABLE_CACHE", "true")
+	defer os.Unsetenv("XTEVE_DISABLE_CACHE")
+
+	origFolderCache, origFolderTemp := System.Folder.Cache, System.Folder.Temp
+	t.Cleanup(func() {
+		System.Folder.Cache, System.Folder.Temp = origFolderCache, origFolderTemp
+		globalFileCache = nil
+		globalFileCacheOnce = sync.Once{}
+	})
+	System.Folder.Cache = t.TempDir() + "/no-cache"
+	System.Folder.Temp = System.Folder.Cache
+	globalFileCache = nil
+	globalFileCacheOnce = sync.Once{}
+
+	const content = "0123456789abcdefghij"
+
+	// The path selects the behaviour of the server: honor the Range, ignore it or answer with another range
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.Header.Get("Range"), "bytes="), "-"))
+		switch r.URL.Path {
+		case "/ignore":
+			start = 0
+		case "/earlier":
+			start -= 3
+		case "/later":
+			start += 3
+		}
+
+		if start == 0 {
+			w.Write([]byte(content))
+			return
+		}
+
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(content[start:]))
+	}))
+	defer server.Close()
+
+	read := func(path string) (string, error) {
+		s := &webdavStream{ctx: context.Background(), name: "test.mp4", targetURL: server.URL + path, stream: map[string]string{}}
+		defer s.Close()
+
+		pos, err := s.Seek(10, io.SeekStart)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), pos)
+
+		data, err := io.ReadAll(s)
+		return string(data), err
+	}
+
+	for _, path := range []string{"/range", "/ignore", "/earlier"} {
+		data, err := read(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, content[10:], data, path)
+	}
+
+	// A range after the requested offset can not be used
+	_, err := read("/later")
+	assert.ErrorContains(t, err, "Content-Range")
+}
+
+func TestParseStartFromRange(t *testing.T) {
+	for header, expected := range map[string]int64{"bytes 5-9/10": 5, "bytes 0-0/*": 0} {
+		start, ok := parseStartFromRange(&http.Response{Header: http.Header{"Content-Range": []string{header}}})
+		assert.True(t, ok, header)
+		assert.Equal(t, expected, start, header)
+	}
+
+	for _, header := range []string{"", "bytes */10", "items 5-9/10", "bytes -5-9/10"} {
+		_, ok := parseStartFromRange(&http.Response{Header: http.Header{"Content-Range": []string{header}}})
+		assert.False(t, ok, header)
+	}
+}
@@ -2,6 +2,7 @@ package src
 
 import (
 	"bufio"
+	"bytes"
 	"cmp"
 	"context"
 	"encoding/json"
@@ -77,19 +78,22 @@ func init() {
 
 // StartWebserver : Start the Webserver
 func StartWebserver(startupSpan trace.Span) (err error) {
+	startStatsSampling()
+
 	for {
 		showInfo("Web server:" + "Starting")
 
-		showInfo("DVR IP:" + Settings.HostIP + ":" + Settings.Port)
+		var hostPort = joinHostPort(Settings.HostIP, Settings.Port)
+		showInfo("DVR IP:" + hostPort)
 
 		var ips = len(System.IPAddressesV4) + len(System.IPAddressesV6) - 1
 		switch ips {
 		case 0:
-			showHighlight(fmt.Sprintf("Web Interface:%s://%s:%s/web/", System.ServerProtocol.WEB, Settings.HostIP, Settings.Port))
+			showHighlight(fmt.Sprintf("Web Interface:%s://%s/web/", System.ServerProtocol.WEB, hostPort))
 		case 1:
-			showHighlight(fmt.Sprintf("Web Interface:%s://%s:%s/web/ | xTeVe is also available via the other %d IP.", System.ServerProtocol.WEB, Settings.HostIP, Settings.Port, ips))
+			showHighlight(fmt.Sprintf("Web Interface:%s://%s/web/ | xTeVe is also available via the other %d IP.", System.ServerProtocol.WEB, hostPort, ips))
 		default:
-			showHighlight(fmt.Sprintf("Web Interface:%s://%s:%s/web/ | xTeVe is also available via the other %d IP's.", System.ServerProtocol.WEB, Settings.HostIP, Settings.Port, len(System.IPAddressesV4)+len(System.IPAddressesV6)-1))
+			showHighlight(fmt.Sprintf("Web Interface:%s://%s/web/ | xTeVe is also available via the other %d IP's.", System.ServerProtocol.WEB, hostPort, len(System.IPAddressesV4)+len(System.IPAddressesV6)-1))
 		}
 
 		var port = Settings.Port
@@ -205,7 +209,7 @@ func Index(w http.ResponseWriter, r *http.Request) {
 	case "/favicon.ico":
 		_, childSpan := otel.Tracer("webserver").Start(r.Context(), "favicon")
 		defer childSpan.End()
-		response, err = webUI.ReadFile("favicon.ico")
+		response, err = webUI.ReadFile("html/favicon.ico")
 		if err != nil {
 			childSpan.RecordError(err)
 			httpStatusError(w, r, 404)
@@ -220,7 +224,12 @@ func Index(w http.ResponseWriter, r *http.Request) {
 	case "/discover.json":
 		_, childSpan := otel.Tracer("webserver").Start(r.Context(), "discover")
 		defer childSpan.End()
-		response, err = getDiscover()
+		var provider = r.URL.Query().Get("provider")
+		if _, ok := getProviderFileType(provider); len(provider) > 0 && !ok {
+			httpStatusError(w, r, 404)
+			return
+		}
+		response, err = getDiscover(provider)
 		if err != nil {
 			childSpan.RecordError(err)
 		}
@@ -245,11 +254,58 @@ func Index(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 		}
-		response, err = getLineup()
+		var provider = r.URL.Query().Get("provider")
+		if _, ok := getProviderFileType(provider); len(provider) > 0 && !ok {
+			httpStatusError(w, r, 404)
+			return
+		}
+		response, err = getLineup(provider)
+		if err != nil {
+			childSpan.RecordError(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+	case "/channels.json":
+		_, childSpan := otel.Tracer("webserver").Start(r.Context(), "channels")
+		defer childSpan.End()
+		if Settings.AuthenticationPMS {
+			_, err := basicAuth(r, "authentication.pms")
+			if err != nil {
+				childSpan.RecordError(err)
+				ShowError(err, 000)
+				httpStatusError(w, r, 403)
+				return
+			}
+		}
+		response, err = getChannelsJSON()
 		if err != nil {
 			childSpan.RecordError(err)
 		}
 		w.Header().Set("Content-Type", "application/json")
+	case "/lineup.post":
+		_, childSpan := otel.Tracer("webserver").Start(r.Context(), "lineup_post")
+		defer childSpan.End()
+		if Settings.AuthenticationPMS {
+			_, err := basicAuth(r, "authentication.pms")
+			if err != nil {
+				childSpan.RecordError(err)
+				ShowError(err, 000)
+				httpStatusError(w, r, 403)
+				return
+			}
+		}
+		if r.Method != http.MethodPost {
+			httpStatusError(w, r, 405)
+			return
+		}
+		switch r.URL.Query().Get("scan") {
+		case "start":
+			startLineupScan()
+		case "abort":
+			// A running scan can not be aborted, the client polls lineup_status.json until it is done
+		default:
+			httpStatusError(w, r, 400)
+			return
+		}
 	case "/device.xml", "/capability":
 		_, childSpan := otel.Tracer("webserver").Start(r.Context(), "capability")
 		defer childSpan.End()
@@ -261,6 +317,19 @@ func Index(w http.ResponseWriter, r *http.Request) {
 	default:
 		_, childSpan := otel.Tracer("webserver").Start(r.Context(), "default")
 		defer childSpan.End()
+
+		// Unknown paths (unknown.path.behavior), the root path is always answered with the capability
+		if path != "/" {
+			switch Settings.UnknownPathBehavior {
+			case "404":
+				httpStatusError(w, r, 404)
+				return
+			case "redirect-to-web":
+				http.Redirect(w, r, "/web/", http.StatusFound)
+				return
+			}
+		}
+
 		response, err = getCapability()
 		if err != nil {
 			childSpan.RecordError(err)
@@ -289,7 +358,9 @@ func Stream(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		trace.SpanFromContext(r.Context()).RecordError(err)
 		ShowError(err, 1203)
-		httpStatusError(w, r, 404)
+		if !serveStreamErrorClip(w, r) {
+			httpStatusError(w, r, 404)
+		}
 		return
 	}
 
@@ -338,6 +409,56 @@ func Stream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Catchup : Web Server /catchup/<stream ID>/<start>/<duration>, programmes of the past (catchup / timeshift of the provider)
+func Catchup(w http.ResponseWriter, r *http.Request) {
+	streamInfo, catchupURL, window, err := getCatchupStream(strings.TrimPrefix(r.URL.Path, "/catchup/"), time.Now())
+	if err != nil {
+		trace.SpanFromContext(r.Context()).RecordError(err)
+		ShowError(err, 0)
+		if errors.Is(err, errInvalidCatchup) {
+			httpStatusError(w, r, 400)
+			return
+		}
+		httpStatusError(w, r, 404)
+		return
+	}
+
+	showInfo(fmt.Sprintf("Catchup:%s [%s]", streamInfo.Name, window))
+	showInfo(fmt.Sprintf("Client User-Agent:%s", r.Header.Get("User-Agent")))
+
+	switch Settings.Buffer {
+	case "-":
+		http.Redirect(w, r, catchupURL, http.StatusFound)
+		showInfo("Streaming Info:URL was passed to the client.")
+	default:
+		bufferingStream(streamInfo.PlaylistID, catchupURL, streamInfo.Name, w, r)
+	}
+}
+
+// serveStreamErrorClip : Plays the stream.error.clip instead of the text 404, so that media players display something
+func serveStreamErrorClip(w http.ResponseWriter, r *http.Request) bool {
+	if len(Settings.StreamErrorClip) == 0 {
+		return false
+	}
+
+	f, err := os.Open(Settings.StreamErrorClip)
+	if err != nil {
+		ShowError(err, 0)
+		return false
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		ShowError(err, 0)
+		return false
+	}
+
+	w.Header().Set("Content-Type", "video/mp2t")
+	http.ServeContent(w, r, filepath.Base(Settings.StreamErrorClip), info.ModTime(), f)
+	return true
+}
+
 // Auto : HDHR routing (is currently not used)
 func Auto(w http.ResponseWriter, r *http.Request) {
 	// Optimization: Use strings.TrimPrefix to avoid unnecessary string allocations during routing.
@@ -361,6 +482,45 @@ func Auto(w http.ResponseWriter, r *http.Request) {
 	*/
 }
 
+// precompressedXMLTV returns the gzip copy of the XMLTV file written by createXMLTVFile, if it is up to date
+func precompressedXMLTV(file string) (string, bool) {
+	if len(System.Compressed.GZxml) == 0 || filepath.Clean(file) != filepath.Clean(System.File.XML) {
+		return "", false
+	}
+
+	xmlInfo, err := os.Stat(file)
+	if err != nil {
+		return "", false
+	}
+	gzInfo, err := os.Stat(System.Compressed.GZxml)
+	if err != nil || gzInfo.ModTime().Before(xmlInfo.ModTime()) {
+		return "", false
+	}
+
+	return System.Compressed.GZxml, true
+}
+
+// acceptsGzip reports whether the Accept-Encoding header of the client allows gzip (gzip;q=0 does not)
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for encoding := range strings.SplitSeq(header, ",") {
+			name, params, _ := strings.Cut(encoding, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "gzip" && name != "*" {
+				continue
+			}
+
+			if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+				if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
 // xTeVe : Web Server /xmltv/ and /m3u/
 func xTeVe(w http.ResponseWriter, r *http.Request) {
 	var requestType, groupTitle, file, contentType string
@@ -389,6 +549,26 @@ func xTeVe(w http.ResponseWriter, r *http.Request) {
 		_, childSpan := otel.Tracer("webserver").Start(r.Context(), "xmltv")
 		defer childSpan.End()
 
+		// XMLTV data of a single channel
+		if id, ok := strings.CutPrefix(path, "xmltv/channel/"); ok {
+			var buffer bytes.Buffer
+			if err := writeChannelXMLTV(&buffer, strings.TrimSuffix(id, ".xml")); err != nil {
+				childSpan.RecordError(err)
+				if errors.Is(err, errXMLTVChannelNotFound) {
+					httpStatusError(w, r, 404)
+				} else {
+					httpStatusError(w, r, 500)
+				}
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			if _, writeErr := buffer.WriteTo(w); writeErr != nil {
+				log.Printf("Error streaming response in xTeVe handler: %v", writeErr)
+			}
+			return
+		}
+
 		file = System.Folder.Data + filepath.Base(path)
 		platformFile := getPlatformFile(file)
 
@@ -398,6 +578,15 @@ func xTeVe(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// The XMLTV file is also written as gzip, it is sent as it is to clients that accept it
+		if gzFile, ok := precompressedXMLTV(platformFile); ok && acceptsGzip(r) {
+			w.Header().Set("Vary", "Accept-Encoding")
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.Header().Set("Content-Encoding", "gzip")
+			http.ServeFile(w, r, gzFile)
+			return
+		}
+
 		f, err := os.Open(platformFile)
 		if err != nil {
 			childSpan.RecordError(err)
@@ -453,8 +642,11 @@ func xTeVe(w http.ResponseWriter, r *http.Request) {
 
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 
+		// /m3u/<profile>.m3u, unknown names return the default M3U file
+		profile, _ := getOutputProfile(strings.TrimSuffix(filepath.Base(path), ".m3u"))
+
 		bw := bufio.NewWriter(w)
-		err = buildM3UToWriter(bw, groups)
+		err = buildM3UToWriter(bw, groups, profile)
 		if err != nil {
 			childSpan.RecordError(err)
 			ShowError(err, 000)
@@ -560,6 +752,102 @@ func checkLoginRateLimitWithTime(ip string, now time.Time) bool {
 	return loginRateLimiter.attempts[ip] <= 10
 }
 
+// wsExpensiveCommands : Websocket commands that rebuild the database, download provider files or do other network or disk work
+var wsExpensiveCommands = []string{
+	"saveFilesM3U", "updateFileM3U",
+	"saveFilesHDHR", "updateFileHDHR",
+	"saveFilesXMLTV", "updateFileXMLTV",
+	"saveFilter", "saveEpgMapping", "renameGroup", "saveGroupOrder",
+	"testProvider", "previewM3U", "importFromInstance",
+	"uploadLogoFromURL", "clearImageCache",
+}
+
+// wsRateLimiter is a token bucket that limits the expensive commands of a single websocket connection.
+// The bucket holds up to ratePerMinute tokens and is refilled continuously.
+type wsRateLimiter struct {
+	tokens float64
+	last   time.Time
+}
+
+// allow reports whether a command may be executed. A rate of 0 or less disables the limiter.
+func (l *wsRateLimiter) allow(now time.Time, ratePerMinute int) bool {
+	if ratePerMinute <= 0 {
+		return true
+	}
+
+	var capacity = float64(ratePerMinute)
+
+	if l.last.IsZero() {
+		l.tokens = capacity
+	} else {
+		l.tokens = min(capacity, l.tokens+now.Sub(l.last).Minutes()*capacity)
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// wsConnection serializes all writes to a websocket, responses and pushed messages
+// are written from different goroutines.
+type wsConnection struct {
+	*websocket.Conn
+	writeMu       sync.Mutex
+	authenticated atomic.Bool
+}
+
+// WriteJSON : Thread-safe replacement of websocket.Conn.WriteJSON
+func (c *wsConnection) WriteJSON(v any) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		return err
+	}
+	return c.Conn.WriteJSON(v)
+}
+
+// wsConnections : Registry of all open websocket connections of the web interface
+var wsConnections sync.Map
+
+// streamStatusUpdates is consumed by a single goroutine so the order of the updates is kept
+var streamStatusUpdates = make(chan StreamStatusUpdateStruct, 64)
+var streamStatusBroadcaster sync.Once
+
+// pushStreamStatus queues a streamStatusUpdate for all websocket connections. The caller holds Lock.
+func pushStreamStatus(channelName, action string) {
+	streamStatusBroadcaster.Do(func() { go broadcastStreamStatus() })
+
+	var update = StreamStatusUpdateStruct{
+		Cmd:     "streamStatusUpdate",
+		Channel: channelName,
+		Action:  action,
+		Active:  activeStreamCount(),
+	}
+
+	select {
+	case streamStatusUpdates <- update:
+	default: // Nobody keeps up with the updates, the next one contains the current count anyway
+	}
+}
+
+func broadcastStreamStatus() {
+	for update := range streamStatusUpdates {
+		wsConnections.Range(func(k, _ any) bool {
+			if conn, ok := k.(*wsConnection); ok && conn.authenticated.Load() {
+				if err := conn.WriteJSON(&update); err != nil {
+					showDebug(fmt.Sprintf("Web server:Stream status update could not be sent: %v", err), 2)
+				}
+			}
+			return true
+		})
+	}
+}
+
 // isPrivateIP checks if an IP address is private or loopback
 func isPrivateIP(ip net.IP) bool {
 	if ip.IsLoopback() || ip.IsPrivate() {
@@ -614,7 +902,7 @@ func WS(w http.ResponseWriter, r *http.Request) {
 
 	u := websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
 
-	conn, err := u.Upgrade(w, r, w.Header())
+	rawConn, err := u.Upgrade(w, r, w.Header())
 
 	if err != nil {
 		ShowError(err, 0)
@@ -624,13 +912,19 @@ func WS(w http.ResponseWriter, r *http.Request) {
 	// The connection has been hijacked. ConnState will receive StateHijacked and will NOT receive StateClosed.
 	// We must manually decrement the counter when this handler exits.
 	defer atomic.AddInt64(&activeHTTPConnections, -1)
-	defer conn.Close()
+	defer rawConn.Close()
+
+	var conn = &wsConnection{Conn: rawConn}
+	wsConnections.Store(conn, struct{}{})
+	defer wsConnections.Delete(conn)
 
 	// Security: Limit WebSocket message size to 32MB to prevent DoS (Unrestricted Resource Consumption)
 	conn.SetReadLimit(33554432)
 
 	setGlobalDomain(r.Host)
 
+	var limiter wsRateLimiter
+
 	for {
 		var request RequestStruct
 		var response ResponseStruct
@@ -683,6 +977,21 @@ func WS(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		// Only connections that passed the authentication receive pushed messages
+		conn.authenticated.Store(true)
+
+		if slices.Contains(wsExpensiveCommands, request.Cmd) && !limiter.allow(time.Now(), Settings.WSRateLimit) {
+			showDebug(fmt.Sprintf("Web server:Websocket command %s rejected by the rate limiter", request.Cmd), 1)
+			response.Status = false
+			response.Error = "too many requests"
+
+			if errWrite := conn.WriteJSON(&response); errWrite != nil {
+				log.Printf("Error writing JSON response (rate limit): %v", errWrite)
+				break
+			}
+			continue
+		}
+
 		switch request.Cmd {
 		// Read Data
 		case "getServerConfig":
@@ -696,6 +1005,32 @@ func WS(w http.ResponseWriter, r *http.Request) {
 			continue
 		case "loadFiles":
 			// response.Response = Settings.Files
+		case "getProviderStats":
+			response.ProviderStats = getProviderStats()
+		case "getEffectiveFilters":
+			response.EffectiveFilters = getEffectiveFilters()
+		case "testProvider":
+			var result ProviderTestStruct
+			result, err = testProvider(r.Context(), request.Type, request.URL, request.UserAgent)
+			response.ProviderTest = &result
+		case "nowNext":
+			var nowNext NowNextStruct
+			nowNext, err = getNowNext(request.XChannelID, time.Now())
+			response.NowNext = &nowNext
+		case "previewM3U":
+			response.M3UPreview, err = previewM3U(request.Groups, request.Lines)
+		case "importFromInstance":
+			var result ChannelImportStruct
+			result, err = importFromInstance(r.Context(), request.URL)
+			response.ChannelImport = &result
+		case "setMaintenanceMode":
+			if request.MaintenanceMode == nil {
+				err = errors.New("maintenanceMode is missing")
+				break
+			}
+
+			System.MaintenanceMode = *request.MaintenanceMode
+			showInfo(fmt.Sprintf("Maintenance mode:%t", System.MaintenanceMode))
 
 		// Save Data
 		case "saveSettings":
@@ -781,6 +1116,16 @@ func WS(w http.ResponseWriter, r *http.Request) {
 			}
 		case "saveEpgMapping":
 			err = saveXEpgMapping(request)
+		case "renameGroup":
+			err = renameGroup(request.From, request.To)
+			if err == nil {
+				response.OpenMenu = strconv.Itoa(slices.Index(System.WEB.Menu, "mapping"))
+			}
+		case "saveGroupOrder":
+			err = saveGroupOrder(request.GroupOrder)
+			if err == nil {
+				response.OpenMenu = strconv.Itoa(slices.Index(System.WEB.Menu, "mapping"))
+			}
 		case "saveUserData":
 			err = saveUserData(request)
 			if err == nil {
@@ -841,6 +1186,21 @@ func WS(w http.ResponseWriter, r *http.Request) {
 				}
 				// If err from uploadLogo was not nil, it will be handled by the generic error handling below.
 			}
+		case "uploadLogoFromURL":
+			response.LogoURL, err = uploadLogoFromURL(r.Context(), request.URL)
+			if err == nil {
+				if errWrite := conn.WriteJSON(&response); errWrite != nil {
+					log.Printf("Error writing JSON response (uploadLogoFromURL): %v", errWrite)
+					break
+				}
+				continue
+			}
+		case "clearImageCache":
+			response.ImagesRemoved, err = clearImageCache()
+		case "uploadServerCert":
+			err = uploadServerCert(request.Base64)
+		case "uploadServerKey":
+			err = uploadServerKey(request.Base64)
 		case "saveWizard":
 			nextStep, errNew := saveWizard(request)
 
@@ -976,7 +1336,7 @@ func Web(w http.ResponseWriter, r *http.Request) {
 			file = requestFile + "index.html"
 		}
 
-		if System.ScanInProgress == 1 {
+		if System.ScanInProgress == 1 || System.MaintenanceMode {
 			file = requestFile + "maintenance.html"
 		}
 
@@ -1100,6 +1460,12 @@ func API(w http.ResponseWriter, r *http.Request) {
 	isUnixSocket := strings.HasPrefix(r.RemoteAddr, "@") || r.RemoteAddr == ""
 
 	if !isUnixSocket {
+		// With api.socket.only, the API is only available via the local Unix socket
+		if Settings.APISocketOnly {
+			http.Error(w, "Forbidden - API access is restricted to the Unix socket.", http.StatusForbidden)
+			return
+		}
+
 		// For TCP connections, enforce loopback restriction
 		host, _, err := net.SplitHostPort(r.RemoteAddr)
 		if err != nil {
@@ -1264,6 +1630,13 @@ func API(w http.ResponseWriter, r *http.Request) {
 		response.Token = token
 	}
 
+	// Maintenance mode locks the API, streaming is not affected
+	if System.MaintenanceMode {
+		w.WriteHeader(http.StatusLocked)
+		responseAPIError(errors.New("xTeVe is in maintenance mode"))
+		return
+	}
+
 	switch request.Cmd {
 	case "login": // Nothing has to be handed over
 	case "status":
@@ -1280,17 +1653,10 @@ func API(w http.ResponseWriter, r *http.Request) {
 		response.URLXepg = System.ServerProtocol.XML + "://" + System.Domain + "/xmltv/xteve.xml"
 		response.OtelExporterType = os.Getenv("OTEL_EXPORTER_TYPE")
 		response.OtelExporterEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-
-		Lock.RLock()
-		BufferInformation.Range(func(k, v any) bool {
-			if playlist, ok := v.(*Playlist); ok {
-				response.TunerActive += int64(len(playlist.Streams))
-				response.TunerAll += int64(playlist.Tuner)
-			}
-			return true
-		})
-		Lock.RUnlock()
+		response.TunerActive, response.TunerAll, _ = getTunerStatus()
 		log.Printf("API Status: Found %d active tuners.", response.TunerActive)
+	case "stats.history":
+		response.StatsHistory = streamingStats.since(time.Now().Add(-statsHistoryWindow))
 	case "update.m3u":
 		err = getProviderData(context.WithoutCancel(r.Context()), "m3u", "")
 		if err != nil {
@@ -1316,6 +1682,35 @@ func API(w http.ResponseWriter, r *http.Request) {
 		}
 	case "update.xepg":
 		err = buildXEPG(false)
+	case "files.regenerate":
+		response.Files, err = regenerateFiles()
+	case "settings.reload":
+		response.Reloaded, err = reloadSettings()
+	case "channels.reenable":
+		response.Channels, err = reenableDeadChannels(request.Channels)
+	case "streams.inactive":
+		response.StreamsInactive = getInactiveStreams()
+	case "stream.thumbnail":
+		response.Thumbnail, err = createStreamThumbnail(r.Context(), request.Channel, getClientIP(r))
+	case "settings.get":
+		response.Key = request.Key
+		response.Value, err = getSetting(request.Key)
+	case "settings.set":
+		response.Key = request.Key
+		response.Value, err = setSetting(request.Key, request.Value)
+	case "epg.setSource":
+		response.ChannelsLineup, err = setEPGSource(request.EpgSource)
+		response.EpgSource = Settings.EpgSource
+		response.StreamsActive = int64(len(Data.Streams.Active))
+		response.StreamsAll = int64(len(Data.Streams.All))
+		response.StreamsXepg = int64(Data.XEPG.XEPGCount)
+	case "xepg.validate":
+		var validation = validateXEPGMappings()
+		response.XEPGValidation = &validation
+	case "backup.create":
+		response.BackupFile, response.BackupData, err = xteveBackupAPI(request.Path)
+	case "backup.diff":
+		response.BackupDiff, err = xteveBackupDiff(request.BackupData, request.BackupBase)
 	default:
 		err = errors.New(getErrMsg(5000))
 	}
@@ -1374,6 +1769,16 @@ func Download(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var path = r.URL.Path
+
+	if path == "/download/logs" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename="+System.AppName+"_log_"+time.Now().Format("20060102_150405")+".txt")
+		if err := writeLogFile(w); err != nil {
+			log.Printf("Error streaming log download: %v", err)
+		}
+		return
+	}
+
 	var file = System.Folder.Temp + filepath.Base(path)
 	platformFile := getPlatformFile(file)
 
@@ -1411,6 +1816,9 @@ func (rs *ResponseStruct) setDefaultResponseData(data bool) {
 	rs.ClientInfo.OS = System.OS
 	rs.ClientInfo.Streams = fmt.Sprintf("%d / %d", len(Data.Streams.Active), len(Data.Streams.All))
 	rs.ClientInfo.UUID = Settings.UUID
+	Lock.RLock()
+	rs.ClientInfo.ActiveStreams = activeStreamCount()
+	Lock.RUnlock()
 	WebScreenLog.Mu.RLock()
 	rs.ClientInfo.Errors = WebScreenLog.Errors
 	rs.ClientInfo.Warnings = WebScreenLog.Warnings
@@ -1419,6 +1827,7 @@ func (rs *ResponseStruct) setDefaultResponseData(data bool) {
 	rs.Settings.HostIP = Settings.HostIP
 	rs.Notification = System.Notification
 	rs.Log = &WebScreenLog
+	rs.MaintenanceMode = System.MaintenanceMode
 	rs.ClientInfo.Version = fmt.Sprintf("%s (%s)", System.Version, System.Build)
 
 	if data {
@@ -1509,6 +1918,7 @@ func newHTTPHandler() http.Handler {
 
 	handleFunc("/", Index)
 	handleFunc("/stream/", Stream)
+	handleFunc("/catchup/", Catchup)
 	handleFunc("/xmltv/", xTeVe)
 	handleFunc("/m3u/", xTeVe)
 	handleFunc("/data/", WS)
@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -57,3 +58,47 @@ func TestDownloadHandler_StreamsAndDeletes(t *testing.T) {
 		t.Errorf("File %s should be deleted after download, but error is: %v", fullPath, err)
 	}
 }
+
+func TestDownloadHandler_Logs(t *testing.T) {
+	oldSettings, oldSystem := Settings, System
+	t.Cleanup(func() {
+		Settings, System = oldSettings, oldSystem
+	})
+
+	WebScreenLog.Mu.Lock()
+	oldLog := WebScreenLog.Log
+	WebScreenLog.Log = []string{"2024-01-01 00:00:00 [xTeVe] first entry", "2024-01-01 00:00:01 [xTeVe] second entry"}
+	WebScreenLog.Mu.Unlock()
+	t.Cleanup(func() {
+		WebScreenLog.Mu.Lock()
+		WebScreenLog.Log = oldLog
+		WebScreenLog.Mu.Unlock()
+	})
+
+	System.Name, System.Version, System.OS, System.ARCH = "xTeVe", "2.5.0", "linux", "amd64"
+
+	Settings.AuthenticationWEB = true
+	w := httptest.NewRecorder()
+	Download(w, httptest.NewRequest("GET", "/download/logs", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %v", w.Code)
+	}
+
+	Settings.AuthenticationWEB = false
+	w = httptest.NewRecorder()
+	Download(w, httptest.NewRequest("GET", "/download/logs", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, got %v", w.Code)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment; filename=") {
+		t.Errorf("Unexpected Content-Disposition %q", cd)
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{"xTeVe 2.5.0", "linux / amd64", "Active streams: 0", "first entry\n", "second entry\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Log download does not contain %q:\n%s", want, body)
+		}
+	}
+}
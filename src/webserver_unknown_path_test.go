@@ -0,0 +1,44 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex_UnknownPathBehavior(t *testing.T) {
+	oldSettings := Settings
+	t.Cleanup(func() { Settings = oldSettings })
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		Index(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	for _, mode := range []string{"", "capability"} {
+		Settings.UnknownPathBehavior = mode
+		rr := request("/this/path/does/not/exist")
+		assert.Equal(t, http.StatusOK, rr.Code, mode)
+		assert.Equal(t, "application/xml", rr.Header().Get("Content-Type"), mode)
+		assert.Contains(t, rr.Body.String(), "<root", mode)
+	}
+
+	Settings.UnknownPathBehavior = "404"
+	rr := request("/this/path/does/not/exist")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	Settings.UnknownPathBehavior = "redirect-to-web"
+	rr = request("/this/path/does/not/exist")
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "/web/", rr.Header().Get("Location"))
+
+	// The root path and the known paths are not affected
+	for _, mode := range []string{"404", "redirect-to-web"} {
+		Settings.UnknownPathBehavior = mode
+		assert.Equal(t, http.StatusOK, request("/").Code, mode)
+		assert.Equal(t, http.StatusOK, request("/device.xml").Code, mode)
+	}
+}
@@ -3,12 +3,14 @@ package src
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWebSocket_ReadLimit(t *testing.T) {
@@ -95,3 +97,89 @@ func TestWebSocket_ReadLimit(t *testing.T) {
 		t.Log("Did not get error (connection remained open), vulnerability present.")
 	}
 }
+
+func TestWSRateLimiter(t *testing.T) {
+	var limiter wsRateLimiter
+	now := time.Now()
+
+	// The bucket starts full
+	for i := range 3 {
+		assert.True(t, limiter.allow(now, 3), "command %d should be allowed", i)
+	}
+	assert.False(t, limiter.allow(now, 3), "bucket should be empty")
+
+	// 20 seconds refill one token at 3 commands per minute
+	now = now.Add(20 * time.Second)
+	assert.True(t, limiter.allow(now, 3))
+	assert.False(t, limiter.allow(now, 3))
+
+	// A rate of 0 disables the limiter
+	assert.True(t, limiter.allow(now, 0))
+}
+
+func TestWSExpensiveCommands(t *testing.T) {
+	source, err := os.ReadFile("webserver.go")
+	require.NoError(t, err)
+
+	// Commands that do network or disk work
+	var expensive = []string{
+		"saveFilesM3U", "updateFileM3U", "saveFilesHDHR", "updateFileHDHR", "saveFilesXMLTV", "updateFileXMLTV",
+		"saveFilter", "saveEpgMapping", "renameGroup", "saveGroupOrder",
+		"testProvider", "previewM3U", "importFromInstance", "uploadLogoFromURL", "clearImageCache",
+	}
+
+	for _, cmd := range expensive {
+		assert.Contains(t, wsExpensiveCommands, cmd)
+	}
+
+	// Every command of the list exists
+	for _, cmd := range wsExpensiveCommands {
+		assert.Contains(t, string(source), `case "`+cmd+`":`)
+	}
+}
+
+func TestWebSocket_RateLimit(t *testing.T) {
+	originalAuth := Settings.AuthenticationWEB
+	originalWizard := System.ConfigurationWizard
+	originalRate := Settings.WSRateLimit
+
+	t.Cleanup(func() {
+		Settings.AuthenticationWEB = originalAuth
+		System.ConfigurationWizard = originalWizard
+		Settings.WSRateLimit = originalRate
+	})
+
+	Settings.AuthenticationWEB = false
+	System.ConfigurationWizard = false
+	Settings.WSRateLimit = 2
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WS(w, r)
+	}))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to websocket: %v", err)
+	}
+	defer ws.Close()
+
+	send := func(cmd string) ResponseStruct {
+		var response ResponseStruct
+		assert.NoError(t, ws.WriteJSON(map[string]string{"cmd": cmd}))
+		assert.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+		assert.NoError(t, ws.ReadJSON(&response))
+		return response
+	}
+
+	// Rapid rebuild commands: the first two are allowed, the third is rejected
+	assert.NotEqual(t, "too many requests", send("updateFileM3U").Error)
+	assert.NotEqual(t, "too many requests", send("updateFileM3U").Error)
+
+	response := send("updateFileM3U")
+	assert.False(t, response.Status)
+	assert.Equal(t, "too many requests", response.Error)
+
+	// Cheap commands are not throttled
+	assert.True(t, send("updateLog").Status)
+}
@@ -0,0 +1,75 @@
+package src
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocket_StreamStatusPush(t *testing.T) {
+	originalAuth := Settings.AuthenticationWEB
+	originalWizard := System.ConfigurationWizard
+	t.Cleanup(func() {
+		Settings.AuthenticationWEB = originalAuth
+		System.ConfigurationWizard = originalWizard
+	})
+
+	Settings.AuthenticationWEB = false
+	System.ConfigurationWizard = false
+
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	// The first command authenticates the connection
+	require.NoError(t, ws.WriteJSON(map[string]string{"cmd": "getServerConfig"}))
+	_, _, err = ws.ReadMessage()
+	require.NoError(t, err)
+
+	Lock.Lock()
+	pushStreamStatus("Channel 1", "start")
+	active := activeStreamCount()
+	Lock.Unlock()
+
+	var update StreamStatusUpdateStruct
+	require.NoError(t, ws.ReadJSON(&update))
+	assert.Equal(t, StreamStatusUpdateStruct{Cmd: "streamStatusUpdate", Channel: "Channel 1", Action: "start", Active: active}, update)
+}
+
+func TestWebSocket_StreamStatusPushUnauthenticated(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(WS))
+	defer s.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	// Wait until the connection is registered
+	assert.Eventually(t, func() bool {
+		var registered bool
+		wsConnections.Range(func(_, _ any) bool {
+			registered = true
+			return false
+		})
+		return registered
+	}, 5*time.Second, 10*time.Millisecond)
+
+	Lock.Lock()
+	pushStreamStatus("Channel 1", "stop")
+	Lock.Unlock()
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+	_, _, err = ws.ReadMessage()
+	assert.Error(t, err, "connections without a command must not receive pushed messages")
+}
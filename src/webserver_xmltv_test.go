@@ -0,0 +1,87 @@
+package src
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":                       false,
+		"gzip":                   true,
+		"deflate, gzip;q=0.5":    true,
+		"GZIP":                   true,
+		"br, *":                  true,
+		"gzip;q=0":               false,
+		"gzip; q=0, deflate":     false,
+		"deflate, br, identity":  false,
+		"x-gzip-something, br":   false,
+		"identity;q=1, *;q=0.1":  true,
+		"identity;q=1, *;q=0.00": false,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/xmltv/xteve.xml", nil)
+		if header != "" {
+			r.Header.Set("Accept-Encoding", header)
+		}
+		assert.Equal(t, want, acceptsGzip(r), header)
+	}
+}
+
+func TestXTeVeHandler_XMLTVGzip(t *testing.T) {
+	setupOutputProfileTest(t)
+	System.Compressed.GZxml = System.Folder.Data + "xteve.xml.gz"
+	require.NoError(t, createXMLTVFile())
+
+	xml, err := os.ReadFile(System.File.XML)
+	require.NoError(t, err)
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Host = System.Domain
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rr := httptest.NewRecorder()
+		xTeVe(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, path)
+		return rr
+	}
+
+	// A client that accepts gzip gets the compressed file
+	rr := get("/xmltv/xteve.xml", "gzip, deflate")
+	assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept-Encoding", rr.Header().Get("Vary"))
+
+	gz, err := gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, string(xml), string(content))
+
+	// Other clients get the XML
+	rr = get("/xmltv/xteve.xml", "")
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Equal(t, string(xml), rr.Body.String())
+
+	// Output profiles have no compressed copy
+	rr = get("/xmltv/plex.xml", "gzip")
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	assert.Contains(t, rr.Body.String(), "<tv")
+
+	// The .gz file is still a download of the gzip file
+	rr = get("/xmltv/xteve.xml.gz", "gzip")
+	assert.Empty(t, rr.Header().Get("Content-Encoding"))
+	gz, err = gzip.NewReader(rr.Body)
+	require.NoError(t, err)
+	content, err = io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, string(xml), string(content))
+}
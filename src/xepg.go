@@ -2,19 +2,25 @@ package src
 
 import (
 	"bufio"
+	"bytes"
+	"cmp"
 	"compress/gzip"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
+	"math"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"runtime"
 	"slices"
 
+	"hash/fnv"
 	"hash/maphash"
 	"strconv"
 	"strings"
@@ -39,6 +45,33 @@ func toLowerReplaceSpace(s string) string {
 	return b.String()
 }
 
+// mappingNameRule : Compiled rule of mapping.name.rules
+type mappingNameRule struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// compileMappingNameRules compiles the rules of mapping.name.rules, invalid patterns are skipped
+func compileMappingNameRules(rules []MappingNameRule) (compiled []mappingNameRule) {
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			ShowError(fmt.Errorf("mapping.name.rules: invalid pattern %q: %w", rule.Pattern, err), 0)
+			continue
+		}
+		compiled = append(compiled, mappingNameRule{re: re, replace: rule.Replace})
+	}
+	return
+}
+
+// normalizeMappingName applies the mapping.name.rules to a channel name and removes spaces and case for the name matching
+func normalizeMappingName(name string, rules []mappingNameRule) string {
+	for _, rule := range rules {
+		name = rule.re.ReplaceAllString(name, rule.replace)
+	}
+	return toLowerReplaceSpace(name)
+}
+
 // equalFoldNoSpaces compares two strings case-insensitively, ignoring spaces, without allocating.
 // It correctly handles international characters using unicode.SimpleFold.
 func equalFoldNoSpaces(s, t string) bool {
@@ -120,7 +153,7 @@ func checkXMLCompatibility(id string, body []byte) (err error) {
 	var xmltv XMLTV
 	var compatibility = make(map[string]int)
 
-	err = xml.Unmarshal(body, &xmltv)
+	err = decodeXMLTV(bytes.NewReader(body), &xmltv, id)
 	if err != nil {
 		return
 	}
@@ -132,6 +165,13 @@ func checkXMLCompatibility(id string, body []byte) (err error) {
 	return
 }
 
+// newImageCache : Image cache for the channel logos and posters with the settings from settings.json
+func newImageCache() (*imgcache.Cache, error) {
+	cache, err := imgcache.New(System.Folder.ImagesCache, fmt.Sprintf("%s://%s/images/", System.ServerProtocol.WEB, System.Domain), Settings.CacheImages, NewHTTPClient())
+	cache.TTL = time.Duration(Settings.ImagesCacheTTLHours) * time.Hour
+	return cache, err
+}
+
 // Create XEPG Data
 func buildXEPG(background bool) error { // Added error return type
 	if System.ScanInProgress == 1 {
@@ -140,7 +180,7 @@ func buildXEPG(background bool) error { // Added error return type
 	System.ScanInProgress = 1
 	var err error // Keep for local error handling before returning
 
-	Data.Cache.Images, err = imgcache.New(System.Folder.ImagesCache, fmt.Sprintf("%s://%s/images/", System.ServerProtocol.WEB, System.Domain), Settings.CacheImages, NewHTTPClient())
+	Data.Cache.Images, err = newImageCache()
 	if err != nil {
 		ShowError(err, 0)
 		// Decide if this is fatal for buildXEPG
@@ -245,7 +285,7 @@ func buildXEPG(background bool) error { // Added error return type
 		// getLineup() // Assuming getLineup() modifies globals and doesn't return error, or handles its own.
 		// If getLineup can fail and that failure should be propagated, it needs to return error.
 		// For now, assume it matches original behavior.
-		if _, err := getLineup(); err != nil {
+		if _, err := getLineup(""); err != nil {
 			ShowError(err, 0)
 		}
 		System.ScanInProgress = 0
@@ -329,7 +369,7 @@ func createXEPGDatabase() (err error) {
 	showInfo("XEPG:" + "Update database")
 
 	// Optimization: Pre-allocate slice capacity based on loaded channels
-	var allChannelNumbers = make(map[float64]bool, len(Data.XEPG.Channels))
+	var allChannelNumbers = make(map[channelNumber]bool, len(Data.XEPG.Channels))
 
 	// Delete Channel with missing Channel Numbers.
 	for id, xepgChannel := range Data.XEPG.Channels {
@@ -337,7 +377,7 @@ func createXEPGDatabase() (err error) {
 			delete(Data.XEPG.Channels, id)
 		}
 
-		if xChannelID, err := strconv.ParseFloat(xepgChannel.XChannelID, 64); err == nil {
+		if xChannelID, ok := parseChannelNumber(xepgChannel.XChannelID); ok {
 			allChannelNumbers[xChannelID] = true
 		}
 	}
@@ -533,14 +573,81 @@ func generateNewXEPGID() (xepgID string) {
 	}
 }
 
-// findFreeChannelNumber finds the next available channel number.
-func findFreeChannelNumber(allChannelNumbers map[float64]bool, startingChannel ...string) (xChannelID string) {
-	// slices.Sort(*allChannelNumbers) -> Removed as we use a map now
+// channelNumber is a channel number with an optional DVB/ATSC subchannel (major.minor, e.g. 5.1).
+// The minor number is an integer: 5.1 and 5.10 are different channels and 5.2 is sorted before 5.10.
+type channelNumber struct {
+	Major int
+	Minor int
+	Sub   bool
+}
+
+// parseChannelNumber parses "5" and "5.1". Other formats and 0 are invalid.
+func parseChannelNumber(s string) (number channelNumber, ok bool) {
+	var major, minor, sub = strings.Cut(strings.TrimSpace(s), ".")
+
+	var err error
+	if number.Major, err = parseChannelNumberPart(major); err != nil {
+		return channelNumber{}, false
+	}
 
-	var firstFreeNumber float64 = Settings.MappingFirstChannel
+	if sub {
+		number.Sub = true
+		if number.Minor, err = parseChannelNumberPart(minor); err != nil {
+			return channelNumber{}, false
+		}
+	}
+
+	return number, number.Major > 0 || number.Minor > 0
+}
+
+// parseChannelNumberPart accepts only digits, strconv.Atoi would also accept signs.
+func parseChannelNumberPart(s string) (int, error) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid channel number: %q", s)
+	}
+	return strconv.Atoi(s)
+}
+
+func (n channelNumber) String() string {
+	if n.Sub {
+		return strconv.Itoa(n.Major) + "." + strconv.Itoa(n.Minor)
+	}
+	return strconv.Itoa(n.Major)
+}
+
+// Compare orders by major, then minor number. 5 is sorted before 5.0.
+func (n channelNumber) Compare(other channelNumber) int {
+	return cmp.Or(
+		cmp.Compare(n.Major, other.Major),
+		compareBool(n.Sub, other.Sub),
+		cmp.Compare(n.Minor, other.Minor),
+	)
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// compareChannelNumbers orders channel numbers numerically. Invalid numbers are sorted first.
+func compareChannelNumbers(a, b string) int {
+	var numberA, _ = parseChannelNumber(a)
+	var numberB, _ = parseChannelNumber(b)
+	return numberA.Compare(numberB)
+}
+
+// findFreeChannelNumber finds the next available channel number.
+// A subchannel as starting channel continues with the next minor number (5.1, 5.2, ...).
+func findFreeChannelNumber(allChannelNumbers map[channelNumber]bool, startingChannel ...string) (xChannelID string) {
+	var firstFreeNumber, _ = parseChannelNumber(strconv.FormatFloat(Settings.MappingFirstChannel, 'f', -1, 64))
 	if len(startingChannel) > 0 && startingChannel[0] != "" {
-		var startNum, err = strconv.ParseFloat(startingChannel[0], 64)
-		if err == nil && startNum > 0 {
+		if startNum, ok := parseChannelNumber(startingChannel[0]); ok {
 			firstFreeNumber = startNum
 		}
 	}
@@ -548,14 +655,29 @@ func findFreeChannelNumber(allChannelNumbers map[float64]bool, startingChannel .
 	for {
 		// O(1) lookup
 		if !allChannelNumbers[firstFreeNumber] {
-			xChannelID = fmt.Sprintf("%g", firstFreeNumber)
 			allChannelNumbers[firstFreeNumber] = true
-			return
+			return firstFreeNumber.String()
+		}
+
+		if firstFreeNumber.Sub {
+			firstFreeNumber.Minor++
+		} else {
+			firstFreeNumber.Major++
 		}
-		firstFreeNumber++
 	}
 }
 
+// claimChannelNumber reserves the given channel number if it is valid and not in use yet.
+func claimChannelNumber(allChannelNumbers map[channelNumber]bool, channelNumber string) (xChannelID string, ok bool) {
+	var number, valid = parseChannelNumber(channelNumber)
+	if !valid || allChannelNumbers[number] {
+		return "", false
+	}
+
+	allChannelNumbers[number] = true
+	return number.String(), true
+}
+
 // generateChannelHash creates a hash for a channel based on its attributes.
 func generateChannelHash(h *maphash.Hash, m3uID, name, groupTitle, tvgID, tvgName, uuidKey, uuidValue string) uint64 {
 	h.Reset()
@@ -586,6 +708,8 @@ func processExistingXEPGChannel(m3uChannel M3UChannelStructXEPG, currentXEPGID s
 		}
 	}
 
+	xepgChannel.EXTINF = m3uChannel.EXTINF
+
 	// Update GroupTitle
 	xepgChannel.GroupTitle = m3uChannel.GroupTitle
 
@@ -598,14 +722,26 @@ func processExistingXEPGChannel(m3uChannel M3UChannelStructXEPG, currentXEPGID s
 		xepgChannel.TvgLogo = m3uChannel.TvgLogo
 	}
 
+	xepgChannel.Radio = isRadioChannel(m3uChannel)
+
+	// The provider can add or remove catchup
+	xepgChannel.Catchup, xepgChannel.CatchupSource, xepgChannel.CatchupDays = m3uChannel.Catchup, m3uChannel.CatchupSource, m3uChannel.CatchupDays
+
 	Data.XEPG.Channels[currentXEPGID] = xepgChannel
 	return
 }
 
 // processNewXEPGChannel creates a new channel in the XEPG database.
-func processNewXEPGChannel(m3uChannel M3UChannelStructXEPG, allChannelNumbers map[float64]bool) {
+func processNewXEPGChannel(m3uChannel M3UChannelStructXEPG, allChannelNumbers map[channelNumber]bool) {
 	var xepg = generateNewXEPGID()
 	xChannelID := func() string {
+		// The channel number of the provider (tvg-chno) is used as long as it is free
+		if m3uChannel.PreserveMapping == "true" || Settings.PreferSourceChno {
+			if xChannelID, ok := claimChannelNumber(allChannelNumbers, m3uChannel.TvgChno); ok {
+				return xChannelID
+			}
+		}
+
 		if m3uChannel.PreserveMapping == "true" {
 			return findFreeChannelNumber(allChannelNumbers, m3uChannel.UUIDValue)
 		} else {
@@ -617,6 +753,7 @@ func processNewXEPGChannel(m3uChannel M3UChannelStructXEPG, allChannelNumbers ma
 	newChannel.FileM3UName = m3uChannel.FileM3UName
 	newChannel.FileM3UPath = m3uChannel.FileM3UPath
 	newChannel.Values = m3uChannel.Values
+	newChannel.EXTINF = m3uChannel.EXTINF
 	newChannel.GroupTitle = m3uChannel.GroupTitle
 	newChannel.Name = m3uChannel.Name
 	newChannel.TvgID = m3uChannel.TvgID
@@ -628,6 +765,10 @@ func processNewXEPGChannel(m3uChannel M3UChannelStructXEPG, allChannelNumbers ma
 		newChannel.TvgShift = m3uChannel.TvgShift
 	}
 	newChannel.URL = m3uChannel.URL
+	newChannel.Radio = isRadioChannel(m3uChannel)
+	newChannel.Catchup = m3uChannel.Catchup
+	newChannel.CatchupSource = m3uChannel.CatchupSource
+	newChannel.CatchupDays = m3uChannel.CatchupDays
 	newChannel.XmltvFile = ""
 	newChannel.XMapping = ""
 
@@ -646,18 +787,40 @@ func processNewXEPGChannel(m3uChannel M3UChannelStructXEPG, allChannelNumbers ma
 	Data.XEPG.Channels[xepg] = newChannel
 }
 
+// radioExtensions : File extensions of audio-only streams
+var radioExtensions = []string{".aac", ".flac", ".m4a", ".mp3", ".oga", ".ogg", ".opus"}
+
+// isRadioChannel : Audio-only channel, marked in the playlist (radio="true") or detected by the file extension of the stream URL
+func isRadioChannel(m3uChannel M3UChannelStructXEPG) bool {
+	if strings.EqualFold(strings.TrimSpace(m3uChannel.Radio), "true") {
+		return true
+	}
+
+	u, err := url.Parse(m3uChannel.URL)
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(radioExtensions, strings.ToLower(path.Ext(u.Path)))
+}
+
 // Automatically assign Channels and check the Mapping
 func mapping() (err error) {
 	showInfo("XEPG:" + "Map channels")
 
+	var nameRules = compileMappingNameRules(Settings.MappingNameRules)
+
 	// Build optimization index for name-based matching
 	var nameIndex = make(map[string]xmltvNameMatch)
 	if len(Data.XMLTV.Mapping) > 0 {
 		for file, xmltvChannels := range Data.XMLTV.Mapping {
 			for _, channel := range xmltvChannels {
 				for _, dn := range channel.DisplayNames {
-					// Normalize: remove all spaces and lowercase
-					solid := toLowerReplaceSpace(dn.Value)
+					// Normalize: apply mapping.name.rules, remove all spaces and lowercase
+					solid := normalizeMappingName(dn.Value, nameRules)
+					if len(solid) == 0 {
+						continue
+					}
 					nameIndex[solid] = xmltvNameMatch{
 						XmltvFile: file,
 						XMapping:  channel.ID,
@@ -668,10 +831,17 @@ func mapping() (err error) {
 		}
 	}
 
+	// auto.disable.dead.channels: The channels of the dead streams are disabled with the mapping
+	var dead = getDeadStreams(time.Now())
+	disableDeadChannels(Data.XEPG.Channels, dead)
+
 	for xepgID, xepgChannel := range Data.XEPG.Channels {
-		xepgChannel, _ = performAutomaticChannelMapping(xepgChannel, xepgID, nameIndex)
+		var mapped bool
+		if xepgChannel, mapped = performAutomaticChannelMapping(xepgChannel, xepgID, nameIndex, nameRules); mapped {
+			showSubsystemDebug(debugXEPG, fmt.Sprintf("Channel Mapping:%s -> %s (%s)", xepgChannel.Name, xepgChannel.XmltvFile, xepgChannel.XMapping), 2)
+		}
 
-		if Settings.EnableMappedChannels && (xepgChannel.XmltvFile != "-" || xepgChannel.XMapping != "-") {
+		if Settings.EnableMappedChannels && !xepgChannel.XUserDisabled && (xepgChannel.XmltvFile != "-" || xepgChannel.XMapping != "-") {
 			xepgChannel.XActive = true
 		}
 
@@ -684,12 +854,13 @@ func mapping() (err error) {
 	if err != nil {
 		return
 	}
+	deadChannels.clear(dead)
 	return
 }
 
 // performAutomaticChannelMapping attempts to automatically map an inactive channel.
 // It returns the (potentially modified) channel and a boolean indicating if a mapping was made.
-func performAutomaticChannelMapping(xepgChannel XEPGChannelStruct, _ string, nameIndex map[string]xmltvNameMatch) (XEPGChannelStruct, bool) {
+func performAutomaticChannelMapping(xepgChannel XEPGChannelStruct, _ string, nameIndex map[string]xmltvNameMatch, nameRules []mappingNameRule) (XEPGChannelStruct, bool) {
 	mappingMade := false
 	// Values can be "-", therefore len <= 1.
 	// Only attempt automatic mapping if BOTH XmltvFile and XMapping are unassigned.
@@ -722,7 +893,7 @@ func performAutomaticChannelMapping(xepgChannel XEPGChannelStruct, _ string, nam
 		// Phase 2: Check for Name match
 		// Optimization: Use index if available (O(1))
 		if len(nameIndex) > 0 {
-			xepgNameSolid := toLowerReplaceSpace(xepgChannel.Name)
+			xepgNameSolid := normalizeMappingName(xepgChannel.Name, nameRules)
 			if match, ok := nameIndex[xepgNameSolid]; ok {
 				xepgChannel.XmltvFile = match.XmltvFile
 				xepgChannel.XMapping = match.XMapping
@@ -735,6 +906,7 @@ func performAutomaticChannelMapping(xepgChannel XEPGChannelStruct, _ string, nam
 		} else {
 			// Fallback: Linear scan (O(N*M))
 			mappingFound := false
+			xepgNameSolid := normalizeMappingName(xepgChannel.Name, nameRules)
 
 			for file, xmltvChannels := range Data.XMLTV.Mapping {
 				if mappingFound {
@@ -748,7 +920,14 @@ func performAutomaticChannelMapping(xepgChannel XEPGChannelStruct, _ string, nam
 					}
 
 					for _, currentDisplayName := range xmltvChannel.DisplayNames {
-						if equalFoldNoSpaces(currentDisplayName.Value, xepgChannel.Name) {
+						var match bool
+						if len(nameRules) > 0 {
+							match = len(xepgNameSolid) > 0 && normalizeMappingName(currentDisplayName.Value, nameRules) == xepgNameSolid
+						} else {
+							match = equalFoldNoSpaces(currentDisplayName.Value, xepgChannel.Name)
+						}
+
+						if match {
 							xepgChannel.XmltvFile = file
 							xepgChannel.XMapping = xmltvChannel.ID
 							mappingMade = true
@@ -769,6 +948,11 @@ func performAutomaticChannelMapping(xepgChannel XEPGChannelStruct, _ string, nam
 // verifyExistingChannelMappings checks assigned XMLTV files and channels for active mappings.
 // It returns the (potentially modified) channel.
 func verifyExistingChannelMappings(xepgChannel XEPGChannelStruct) XEPGChannelStruct {
+	if xepgChannel.XUserDisabled {
+		xepgChannel.XActive = false
+		return xepgChannel
+	}
+
 	if !xepgChannel.XActive {
 		return xepgChannel
 	}
@@ -808,21 +992,118 @@ func verifyExistingChannelMappings(xepgChannel XEPGChannelStruct) XEPGChannelStr
 	return xepgChannel
 }
 
+// validateXEPGMappings runs the checks of verifyExistingChannelMappings for all active channels without changing them
+func validateXEPGMappings() (validation XEPGValidationStruct) {
+	validation.MissingFile = []XEPGValidationChannel{}
+	validation.MissingChannel = []XEPGValidationChannel{}
+	validation.NoEPG = []XEPGValidationChannel{}
+
+	for xepgID, xepgChannel := range Data.XEPG.Channels {
+		if !xepgChannel.XActive {
+			continue
+		}
+
+		var channel = XEPGValidationChannel{
+			ID:        xepgID,
+			Name:      xepgChannel.XName,
+			Channel:   xepgChannel.XChannelID,
+			XmltvFile: xepgChannel.XmltvFile,
+			XMapping:  xepgChannel.XMapping,
+		}
+
+		if !isMappedChannel(xepgChannel) {
+			channel.XmltvFile, channel.XMapping = "", ""
+			validation.NoEPG = append(validation.NoEPG, channel)
+			continue
+		}
+
+		if xepgChannel.XmltvFile == "xTeVe Dummy" {
+			continue
+		}
+
+		xmltvFileMapping, fileExists := Data.XMLTV.Mapping[xepgChannel.XmltvFile]
+		if !fileExists {
+			validation.MissingFile = append(validation.MissingFile, channel)
+			continue
+		}
+
+		if _, channelExists := xmltvFileMapping[xepgChannel.XMapping]; !channelExists {
+			validation.MissingChannel = append(validation.MissingChannel, channel)
+		}
+	}
+
+	for _, channels := range [][]XEPGValidationChannel{validation.MissingFile, validation.MissingChannel, validation.NoEPG} {
+		slices.SortFunc(channels, func(a, b XEPGValidationChannel) int {
+			return cmp.Or(compareChannelNumbers(a.Channel, b.Channel), strings.Compare(a.Name, b.Name))
+		})
+	}
+
+	return
+}
+
+// isChannelEnabled reports whether a channel is part of the output (M3U, XMLTV, lineup).
+// A channel disabled by the user stays excluded even if it is mapped.
+// Channels that are missing from the playlist are not part of the output until they are available again.
+func isChannelEnabled(xepgChannel XEPGChannelStruct) bool {
+	return xepgChannel.XActive && !xepgChannel.XUserDisabled && !xepgChannel.Missing
+}
+
+// applyUserDisabled sets XUserDisabled for a channel saved from the WebUI.
+// Deactivating a channel marks it as disabled by the user, activating it clears the flag.
+func applyUserDisabled(oldChannel, newChannel XEPGChannelStruct) XEPGChannelStruct {
+	if newChannel.XActive {
+		newChannel.XUserDisabled = false
+		newChannel.XAutoDisabled = false
+		return newChannel
+	}
+
+	newChannel.XUserDisabled = newChannel.XUserDisabled || oldChannel.XActive || oldChannel.XUserDisabled
+	return newChannel
+}
+
+// getChannelLogo returns the logo of a channel: TvgLogo -> icon of the mapped XMLTV channel -> default.channel.logo
+func getChannelLogo(xepgChannel XEPGChannelStruct) string {
+	if len(xepgChannel.TvgLogo) > 0 {
+		return xepgChannel.TvgLogo
+	}
+
+	if channelData, ok := Data.XMLTV.Mapping[xepgChannel.XmltvFile][xepgChannel.XMapping]; ok && len(channelData.Icon) > 0 {
+		return channelData.Icon
+	}
+
+	return Settings.DefaultChannelLogo
+}
+
+// getXMLTVChannelID returns the channel ID in the XMLTV file and the tvg-id in the M3U file.
+// With xmltv.use.source.ids it is the channel ID of the mapped XMLTV file, otherwise the channel number.
+func getXMLTVChannelID(xepgChannel XEPGChannelStruct) string {
+	if Settings.XMLTVUseSourceIDs && isMappedChannel(xepgChannel) && xepgChannel.XmltvFile != "xTeVe Dummy" {
+		return xepgChannel.XMapping
+	}
+	return xepgChannel.XChannelID
+}
+
 // createChannelElements generates an XMLTV channel element.
 func createChannelElements(xepgChannel XEPGChannelStruct, imgc *imgcache.Cache) *Channel {
 	var channel Channel
-	channel.ID = xepgChannel.XChannelID
+	channel.ID = getXMLTVChannelID(xepgChannel)
+	var logo = getChannelLogo(xepgChannel)
 	// Check if imgc is not nil and if the GetURL function is assigned within imgc.Image
 	if imgc != nil && imgc.Image.GetURL != nil {
-		channel.Icon = Icon{Src: imgc.Image.GetURL(xepgChannel.TvgLogo)}
+		channel.Icon = Icon{Src: imgc.Image.GetURL(logo)}
 	} else {
-		// Fallback: use TvgLogo directly if no image cache or GetURL func is available.
-		channel.Icon = Icon{Src: xepgChannel.TvgLogo}
+		// Fallback: use the logo directly if no image cache or GetURL func is available.
+		channel.Icon = Icon{Src: logo}
 	}
-	channel.DisplayNames = append(channel.DisplayNames, DisplayName{Value: xepgChannel.XName})
+	channel.DisplayNames = append(channel.DisplayNames, DisplayName{Value: decorateChannelName(xepgChannel.XName)})
 	return &channel
 }
 
+// decorateChannelName : Channel name with channel.name.prefix and channel.name.suffix, only used for the output
+func decorateChannelName(name string) string {
+	return Settings.ChannelNamePrefix + name + Settings.ChannelNameSuffix
+}
+
 // createProgramElements generates XMLTV program elements for a channel.
 // It's a wrapper around getProgramData.
 func createProgramElements(xepgChannel XEPGChannelStruct, programs *[]*Program) error {
@@ -832,8 +1113,6 @@ func createProgramElements(xepgChannel XEPGChannelStruct, programs *[]*Program)
 // Create XMLTV File
 func createXMLTVFile() (err error) {
 	// Image Cache
-	var imgc = Data.Cache.Images // This is *imgcache.Cache. imgc itself will be passed.
-
 	Data.Cache.ImagesFiles = []string{}
 	Data.Cache.ImagesURLS = []string{}
 	Data.Cache.ImagesCache = []string{}
@@ -852,15 +1131,50 @@ func createXMLTVFile() (err error) {
 		return
 	}
 
-	showInfo("XEPG:" + fmt.Sprintf("Create XMLTV file (%s)", System.File.XML))
+	err = writeXMLTVFile(OutputProfile{Name: defaultOutputProfile}, System.File.XML, System.Compressed.GZxml)
+	if err != nil {
+		return err
+	}
+
+	// output.profiles: /xmltv/<profile>.xml
+	for _, profile := range Settings.OutputProfiles {
+		if !isValidOutputProfileName(profile.Name) {
+			ShowError(fmt.Errorf("output.profiles: invalid profile name %q", profile.Name), 0)
+			continue
+		}
+
+		err = writeXMLTVFile(profile, System.Folder.Data+profile.Name+".xml", "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeXMLTVFile : Writes the channels of the output profile to the XMLTV file and the optional GZIP file
+func writeXMLTVFile(profile OutputProfile, file, gzFileName string) (err error) {
+	var imgc = Data.Cache.Images // This is *imgcache.Cache. imgc itself will be passed.
+
+	showInfo("XEPG:" + fmt.Sprintf("Create XMLTV file (%s)", file))
 
 	var xepgXML XMLTV
 	xepgXML.Generator = System.Name
 
 	xepgXML.Source = fmt.Sprintf("%s - %s.%s", System.Name, System.Version, System.Build)
 
-	for _, xepgChannel := range Data.XEPG.Channels {
-		if xepgChannel.XActive {
+	// Channels that are mapped to the same source channel share its ID (xmltv.use.source.ids),
+	// the channel and its programs are only written once
+	var channelIDs = make(map[string]bool)
+
+	for _, id := range slices.Sorted(maps.Keys(Data.XEPG.Channels)) {
+		var xepgChannel = Data.XEPG.Channels[id]
+		if isChannelEnabled(xepgChannel) && profile.includesGroup(xepgChannel.XGroupTitle) {
+			if channelIDs[getXMLTVChannelID(xepgChannel)] {
+				continue
+			}
+			channelIDs[getXMLTVChannelID(xepgChannel)] = true
+
 			// Create Channel Element
 			channelElement := createChannelElements(xepgChannel, imgc) // Pass the whole imgc *imgcache.Cache
 			xepgXML.Channel = append(xepgXML.Channel, channelElement)
@@ -882,7 +1196,7 @@ func createXMLTVFile() (err error) {
 	var writers []io.Writer
 
 	// 1. XML File
-	f, err := os.Create(getPlatformFile(System.File.XML))
+	f, err := os.Create(getPlatformFile(file))
 	if err != nil {
 		return err
 	}
@@ -893,9 +1207,9 @@ func createXMLTVFile() (err error) {
 	var gzFile *os.File
 	var gzWriter *gzip.Writer
 
-	if len(System.Compressed.GZxml) > 0 {
-		showInfo("XEPG:" + fmt.Sprintf("Compress XMLTV file (%s)", System.Compressed.GZxml))
-		gzFile, err = os.Create(getPlatformFile(System.Compressed.GZxml))
+	if len(gzFileName) > 0 {
+		showInfo("XEPG:" + fmt.Sprintf("Compress XMLTV file (%s)", gzFileName))
+		gzFile, err = os.Create(getPlatformFile(gzFileName))
 		if err != nil {
 			return err
 		}
@@ -932,6 +1246,74 @@ func createXMLTVFile() (err error) {
 	return nil
 }
 
+// errXMLTVChannelNotFound : The channel of /xmltv/channel/<id>.xml does not exist or is not enabled
+var errXMLTVChannelNotFound = errors.New("XMLTV channel not found")
+
+// writeChannelXMLTV : Writes the channel and programme elements of a single channel (/xmltv/channel/<x-channelID>.xml).
+// The data is created on demand, like the channel in the XMLTV file.
+func writeChannelXMLTV(w io.Writer, xChannelID string) (err error) {
+	var xepgXML XMLTV
+	xepgXML.Generator = System.Name
+	xepgXML.Source = fmt.Sprintf("%s - %s.%s", System.Name, System.Version, System.Build)
+
+	for _, id := range slices.Sorted(maps.Keys(Data.XEPG.Channels)) {
+		var xepgChannel = Data.XEPG.Channels[id]
+		if !isChannelEnabled(xepgChannel) || (xepgChannel.XChannelID != xChannelID && getXMLTVChannelID(xepgChannel) != xChannelID) {
+			continue
+		}
+
+		xepgXML.Channel = append(xepgXML.Channel, createChannelElements(xepgChannel, Data.Cache.Images))
+		if err = createProgramElements(xepgChannel, &xepgXML.Program); err != nil {
+			return
+		}
+		break
+	}
+
+	if len(xepgXML.Channel) == 0 {
+		return errXMLTVChannelNotFound
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("  ", "    ")
+	return enc.Encode(xepgXML)
+}
+
+// getXMLTVChannelPrograms : Programmes of a channel of a local XMLTV file, using the index of the file (xmltvProgramIndices)
+func getXMLTVChannelPrograms(xmltvFile, channelID string) (programs []*Program, err error) {
+	var xmltv XMLTV
+
+	err = getLocalXMLTV(xmltvFile, &xmltv)
+	if err != nil {
+		return
+	}
+
+	// Use index to find programs efficiently
+	xmltvProgramMutex.RLock()
+	fileIndex, exists := xmltvProgramIndices[xmltvFile]
+	xmltvProgramMutex.RUnlock()
+
+	if !exists {
+		// Build index for this file
+		xmltvProgramMutex.Lock()
+		// Double check locking
+		if _, ok := xmltvProgramIndices[xmltvFile]; !ok {
+			newIndex := make(map[string][]*Program)
+			for _, p := range xmltv.Program {
+				newIndex[p.Channel] = append(newIndex[p.Channel], p)
+			}
+			xmltvProgramIndices[xmltvFile] = newIndex
+		}
+		fileIndex = xmltvProgramIndices[xmltvFile]
+		xmltvProgramMutex.Unlock()
+	}
+
+	return fileIndex[channelID], nil
+}
+
 // Create Program Data (createXMLTVFile)
 func getProgramData(xepgChannel XEPGChannelStruct, acc *[]*Program) (err error) {
 	var xmltvFile = System.Folder.Data + xepgChannel.XmltvFile
@@ -944,40 +1326,35 @@ func getProgramData(xepgChannel XEPGChannelStruct, acc *[]*Program) (err error)
 		xmltv = createDummyProgram(xepgChannel)
 		programs = xmltv.Program
 	} else {
-		err = getLocalXMLTV(xmltvFile, &xmltv)
+		programs, err = getXMLTVChannelPrograms(xmltvFile, channelID)
 		if err != nil {
 			return
 		}
+	}
 
-		// Use index to find programs efficiently
-		xmltvProgramMutex.RLock()
-		fileIndex, exists := xmltvProgramIndices[xmltvFile]
-		xmltvProgramMutex.RUnlock()
-
-		if !exists {
-			// Build index for this file
-			xmltvProgramMutex.Lock()
-			// Double check locking
-			if _, ok := xmltvProgramIndices[xmltvFile]; !ok {
-				newIndex := make(map[string][]*Program)
-				for _, p := range xmltv.Program {
-					newIndex[p.Channel] = append(newIndex[p.Channel], p)
-				}
-				xmltvProgramIndices[xmltvFile] = newIndex
+	// Further EPG sources of the channel (x-mappings)
+	if len(xepgChannel.XMappings) > 0 {
+		var sources = [][]*Program{programs}
+		for _, mapping := range xepgChannel.XMappings {
+			if mapping.XmltvFile == "-" || mapping.XMapping == "-" || mapping.XmltvFile == "xTeVe Dummy" || len(mapping.XmltvFile) == 0 {
+				continue
 			}
-			fileIndex = xmltvProgramIndices[xmltvFile]
-			xmltvProgramMutex.Unlock()
-		}
 
-		if pList, ok := fileIndex[channelID]; ok {
-			programs = pList
+			additional, errSource := getXMLTVChannelPrograms(System.Folder.Data+mapping.XmltvFile, mapping.XMapping)
+			if errSource != nil {
+				ShowError(fmt.Errorf("%s: EPG source %s: %w", xepgChannel.XName, mapping.XmltvFile, errSource), 0)
+				continue
+			}
+			sources = append(sources, additional)
 		}
+		programs = mergeProgramSources(sources...)
 	}
 
 	// Pre-calculate uppercase channel name to avoid repeated calls in getVideo
 	// and extract other fields to avoid passing the whole struct
 	upperChannelName := strings.ToUpper(xepgChannel.XName)
 	xCategory := xepgChannel.XCategory
+	channelLogo := getChannelLogo(xepgChannel)
 
 	// Optimization: Pre-allocate slice capacity to avoid reallocations
 	if len(programs) > 0 {
@@ -985,13 +1362,13 @@ func getProgramData(xepgChannel XEPGChannelStruct, acc *[]*Program) (err error)
 	}
 
 	// Optimization: Parse timeshift once outside the loop
-	timeshift, _ := strconv.Atoi(xepgChannel.XTimeshift)
+	timeshift := parseTimeshift(xepgChannel.XTimeshift)
 
 	for _, xmltvProgram := range programs {
 		// No need to check channelID match again, index guarantees it
 		var program = &Program{}
 		// Channel ID
-		program.Channel = xepgChannel.XChannelID
+		program.Channel = getXMLTVChannelID(xepgChannel)
 
 		program.Start = adjustProgramTime(xmltvProgram.Start, timeshift)
 		program.Stop = adjustProgramTime(xmltvProgram.Stop, timeshift)
@@ -1021,7 +1398,7 @@ func getProgramData(xepgChannel XEPGChannelStruct, acc *[]*Program) (err error)
 		program.Country = xmltvProgram.Country
 
 		// Program icon
-		getPoster(program, xmltvProgram)
+		getPoster(program, xmltvProgram, channelLogo)
 
 		// Language
 		program.Language = xmltvProgram.Language
@@ -1029,8 +1406,12 @@ func getProgramData(xepgChannel XEPGChannelStruct, acc *[]*Program) (err error)
 		// Episodes numbers
 		getEpisodeNum(program, xmltvProgram, xCategory)
 
-		// Video
-		getVideo(program, xmltvProgram, upperChannelName)
+		// Video, the quality is not inferred from the name of radio channels
+		if xepgChannel.Radio {
+			program.Video = xmltvProgram.Video
+		} else {
+			getVideo(program, xmltvProgram, upperChannelName)
+		}
 
 		// Date
 		program.Date = xmltvProgram.Date
@@ -1049,10 +1430,229 @@ func getProgramData(xepgChannel XEPGChannelStruct, acc *[]*Program) (err error)
 
 		*acc = append(*acc, program)
 	}
+
+	if Settings.XMLTVDedupePrograms && len(programs) > 1 {
+		var channelPrograms = dedupePrograms((*acc)[len(*acc)-len(programs):])
+		*acc = append((*acc)[:len(*acc)-len(programs)], channelPrograms...)
+	}
+	return
+}
+
+// mergeProgramSources : Programmes of the first source, completed by the programmes of the further sources that don't overlap the programmes already included.
+// The result is sorted by start. Programmes with an invalid time are only kept from the first source.
+func mergeProgramSources(sources ...[]*Program) []*Program {
+	type timedProgram struct {
+		program     *Program
+		start, stop time.Time
+		latestStop  time.Time // Latest stop of this and all earlier programmes
+	}
+
+	var included []timedProgram // Sorted by start
+	var invalid []*Program
+
+	for i, programs := range sources {
+		for _, program := range programs {
+			start, okStart := parseXMLTVTime(program.Start)
+			stop, okStop := parseXMLTVTime(program.Stop)
+			if !okStart || !okStop {
+				if i == 0 {
+					invalid = append(invalid, program)
+				}
+				continue
+			}
+
+			var n int
+			if i == 0 {
+				// After programmes with the same start, the order of the source is kept
+				n, _ = slices.BinarySearchFunc(included, start, func(t timedProgram, start time.Time) int { return cmp.Or(t.start.Compare(start), -1) })
+			} else {
+				// The programmes that start before the stop overlap, if one of them ends after the start
+				n, _ = slices.BinarySearchFunc(included, stop, func(t timedProgram, stop time.Time) int { return t.start.Compare(stop) })
+				if n > 0 && included[n-1].latestStop.After(start) {
+					continue
+				}
+			}
+
+			included = slices.Insert(included, n, timedProgram{program: program, start: start, stop: stop})
+			for k := n; k < len(included); k++ {
+				var latestStop = included[k].stop
+				if k > 0 && included[k-1].latestStop.After(latestStop) {
+					latestStop = included[k-1].latestStop
+				}
+				if k > n && included[k].latestStop.Equal(latestStop) {
+					break
+				}
+				included[k].latestStop = latestStop
+			}
+		}
+	}
+
+	var merged = make([]*Program, 0, len(included)+len(invalid))
+	for _, t := range included {
+		merged = append(merged, t.program)
+	}
+	return append(merged, invalid...)
+}
+
+// parseXMLTVTime : Start or stop time of a programme ("20060102150405 +0000"), the timezone offset is optional
+func parseXMLTVTime(s string) (time.Time, bool) {
+	for _, layout := range []string{"20060102150405 -0700", "20060102150405"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dedupePrograms : Removes duplicated and overlapping programmes of a channel (xmltv.dedupe.programs).
+// Of programmes with the same start, stop and title, the one with the most data is kept.
+// A programme that lies within the previous one is removed, a programme that starts before the previous one ends shortens it.
+// Programmes with an invalid time are kept unchanged.
+func dedupePrograms(programs []*Program) []*Program {
+	type timedProgram struct {
+		program     *Program
+		start, stop time.Time
+		valid       bool
+	}
+
+	var timed = make([]timedProgram, 0, len(programs))
+	for _, program := range programs {
+		start, okStart := parseXMLTVTime(program.Start)
+		stop, okStop := parseXMLTVTime(program.Stop)
+		timed = append(timed, timedProgram{program: program, start: start, stop: stop, valid: okStart && okStop})
+	}
+
+	slices.SortStableFunc(timed, func(a, b timedProgram) int {
+		return a.start.Compare(b.start)
+	})
+
+	var result = make([]*Program, 0, len(programs))
+	var prev *timedProgram
+
+	for i := range timed {
+		var current = &timed[i]
+		if !current.valid {
+			result = append(result, current.program)
+			continue
+		}
+
+		switch {
+		case prev == nil || !current.start.Before(prev.stop):
+			// No overlap
+
+		case current.start.Equal(prev.start) && current.stop.Equal(prev.stop) && programTitle(current.program) == programTitle(prev.program):
+			// Duplicate, the programme with more data replaces the previous one
+			if programRichness(current.program) > programRichness(prev.program) {
+				result[slices.Index(result, prev.program)] = current.program
+				prev = current
+			}
+			continue
+
+		case !current.stop.After(prev.stop):
+			// Within the previous programme
+			continue
+
+		case current.start.Equal(prev.start):
+			// Same start, the longer programme is kept
+			result[slices.Index(result, prev.program)] = current.program
+			prev = current
+			continue
+
+		default:
+			// Overlap, the previous programme ends with the start of the current one
+			prev.program.Stop = current.program.Start
+			prev.stop = current.start
+		}
+
+		result = append(result, current.program)
+		prev = current
+	}
+
+	return result
+}
+
+// programTitle : All titles of a programme, used to compare programmes
+func programTitle(program *Program) string {
+	var titles = make([]string, 0, len(program.Title))
+	for _, title := range program.Title {
+		titles = append(titles, title.Lang+":"+title.Value)
+	}
+	return strings.Join(titles, "\n")
+}
+
+// programRichness : Number of populated fields of a programme
+func programRichness(program *Program) (n int) {
+	var v = reflect.ValueOf(*program)
+	for i := range v.NumField() {
+		var field = v.Field(i)
+		if field.Kind() == reflect.Slice && field.Len() == 0 {
+			continue
+		}
+		if !field.IsZero() {
+			n++
+		}
+	}
 	return
 }
 
-// adjustProgramTime adjusts the timezone of a program start/stop time string.
+// parseTimeshift returns the tvg-shift of a channel in minutes.
+// The shift is given in hours, fractions are allowed ("2", "-1.5", "+0.5"). The formats "+/-HH:MM" and duration ("90m", "-1h30m") are also accepted.
+// Invalid shifts, NaN, Inf and shifts of more than 24 hours are ignored (0).
+func parseTimeshift(s string) int {
+	const maxMinutes = 24 * 60
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	var minutes int
+
+	if hh, mm, found := strings.Cut(s, ":"); found {
+		hours, errH := strconv.Atoi(hh)
+		m, errM := strconv.Atoi(mm)
+		if errH != nil || errM != nil || m < 0 || m >= 60 || hours < -24 || hours > 24 {
+			return 0
+		}
+		minutes = hours*60 + m
+		if strings.HasPrefix(hh, "-") {
+			minutes = hours*60 - m
+		}
+	} else if hours, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64); err == nil {
+		if math.IsNaN(hours) || math.IsInf(hours, 0) || math.Abs(hours) > 24 {
+			return 0
+		}
+		minutes = int(math.Round(hours * 60))
+	} else if d, err := time.ParseDuration(s); err == nil {
+		minutes = int(d.Round(time.Minute) / time.Minute)
+	}
+
+	if minutes < -maxMinutes || minutes > maxMinutes {
+		return 0
+	}
+
+	return minutes
+}
+
+// parseTimezoneOffset returns a timezone offset in the format "+hhmm" or "-hhmm" in minutes.
+func parseTimezoneOffset(offset string) (minutes int, ok bool) {
+	if len(offset) != 5 || (offset[0] != '+' && offset[0] != '-') {
+		return 0, false
+	}
+
+	hhmm, err := strconv.Atoi(offset[1:])
+	if err != nil || hhmm < 0 {
+		return 0, false
+	}
+
+	minutes = hhmm/100*60 + hhmm%100
+	if offset[0] == '-' {
+		minutes = -minutes
+	}
+	return minutes, true
+}
+
+// adjustProgramTime adjusts the timezone of a program start/stop time string by timeshift minutes.
 // t format is expected to be "YYYYMMDDhhmmss +ZZZZ".
 func adjustProgramTime(t string, timeshift int) string {
 	if timeshift == 0 {
@@ -1064,8 +1664,11 @@ func adjustProgramTime(t string, timeshift int) string {
 		return t
 	}
 
-	tzStart, _ := strconv.Atoi(after)
-	newTz := tzStart + timeshift*100
+	offset, ok := parseTimezoneOffset(after)
+	if !ok {
+		return t
+	}
+	offset += timeshift
 
 	// Optimization: Use strings.Builder and manual formatting to avoid fmt.Sprintf allocations.
 	// This reduces allocations from 2 to 1 (result string) per call.
@@ -1074,23 +1677,25 @@ func adjustProgramTime(t string, timeshift int) string {
 	b.WriteString(before)
 	b.WriteByte(' ')
 
-	if newTz < 0 {
+	if offset < 0 {
 		b.WriteByte('-')
-		newTz = -newTz
+		offset = -offset
 	} else {
 		b.WriteByte('+')
 	}
 
-	if newTz < 10 {
+	// hhmm, the minutes are carried over into the hours
+	var hhmm = offset/60*100 + offset%60
+	if hhmm < 10 {
 		b.WriteString("000")
-	} else if newTz < 100 {
+	} else if hhmm < 100 {
 		b.WriteString("00")
-	} else if newTz < 1000 {
+	} else if hhmm < 1000 {
 		b.WriteString("0")
 	}
 
 	var buf [10]byte
-	b.Write(strconv.AppendInt(buf[:0], int64(newTz), 10))
+	b.Write(strconv.AppendInt(buf[:0], int64(hhmm), 10))
 
 	return b.String()
 }
@@ -1124,7 +1729,7 @@ func createDummyProgram(xepgChannel XEPGChannelStruct) (dummyXMLTV XMLTV) {
 			epg.Channel = xepgChannel.XMapping
 			epg.Start = epgStartTime.Format("20060102150405") + offset
 			epg.Stop = epgStopTime.Format("20060102150405") + offset
-			epg.Title = append(epg.Title, &Title{Value: xepgChannel.XName + " (" + epgStartTime.Weekday().String()[0:2] + ". " + epgStartTime.Format("15:04") + " - " + epgStopTime.Format("15:04") + ")", Lang: "en"})
+			epg.Title = append(epg.Title, &Title{Value: dummyProgramTitle(xepgChannel.XName, epgStartTime, epgStopTime), Lang: "en"})
 
 			if len(xepgChannel.XDescription) == 0 {
 				epg.Desc = append(epg.Desc, &Desc{Value: "xTeVe: (" + strconv.Itoa(dummyLength) + " Minutes) " + epgStartTime.Weekday().String() + " " + epgStartTime.Format("15:04") + " - " + epgStopTime.Format("15:04"), Lang: "en"})
@@ -1133,7 +1738,7 @@ func createDummyProgram(xepgChannel XEPGChannelStruct) (dummyXMLTV XMLTV) {
 			}
 
 			if Settings.XepgReplaceMissingImages {
-				poster.Src = imgc.Image.GetURL(xepgChannel.TvgLogo)
+				poster.Src = imgc.Image.GetURL(getChannelLogo(xepgChannel))
 				epg.Poster = append(epg.Poster, poster)
 			}
 
@@ -1148,11 +1753,94 @@ func createDummyProgram(xepgChannel XEPGChannelStruct) (dummyXMLTV XMLTV) {
 	return
 }
 
+// dummyProgramTitle : Title of a programme of the xTeVe Dummy, e.g. "Channel (Mo. 10:00 - 11:00)"
+func dummyProgramTitle(name string, start, stop time.Time) string {
+	return name + " (" + start.Weekday().String()[0:2] + ". " + start.Format("15:04") + " - " + stop.Format("15:04") + ")"
+}
+
+// getNowNext : Current and next programme of a channel (websocket command nowNext).
+// The channel is found by its XEPG ID or its channel number (x-channelID).
+func getNowNext(xChannelID string, now time.Time) (nowNext NowNextStruct, err error) {
+	var xepgChannel XEPGChannelStruct
+	var found bool
+
+	for id, channel := range Data.XEPG.Channels {
+		if id == xChannelID || channel.XChannelID == xChannelID {
+			xepgChannel, found = channel, true
+			break
+		}
+	}
+
+	if !found {
+		return nowNext, fmt.Errorf("channel not found: %s", xChannelID)
+	}
+
+	nowNext.Channel = xepgChannel.XChannelID
+
+	if xepgChannel.XmltvFile == "-" || xepgChannel.XMapping == "-" || len(xepgChannel.XmltvFile) == 0 {
+		return nowNext, fmt.Errorf("channel has no EPG: %s", xChannelID)
+	}
+
+	var nowNextProgram = func(title string, start, stop time.Time) *NowNextProgramStruct {
+		return &NowNextProgramStruct{Title: title, Start: start.Format(time.RFC3339), Stop: stop.Format(time.RFC3339)}
+	}
+
+	// xTeVe Dummy: Programmes of the same length from midnight
+	if xepgChannel.XmltvFile == "xTeVe Dummy" {
+		var dummyLength int
+		if dummyLength, err = strconv.Atoi(strings.Split(xepgChannel.XMapping, "_")[0]); err != nil || dummyLength <= 0 {
+			return nowNext, fmt.Errorf("invalid dummy EPG: %s", xepgChannel.XMapping)
+		}
+
+		var length = time.Duration(dummyLength) * time.Minute
+		var midnight = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		var start = midnight.Add(now.Sub(midnight) / length * length)
+
+		nowNext.Now = nowNextProgram(dummyProgramTitle(xepgChannel.XName, start, start.Add(length)), start, start.Add(length))
+		nowNext.Next = nowNextProgram(dummyProgramTitle(xepgChannel.XName, start.Add(length), start.Add(2*length)), start.Add(length), start.Add(2*length))
+		return
+	}
+
+	programs, err := getXMLTVChannelPrograms(System.Folder.Data+xepgChannel.XmltvFile, xepgChannel.XMapping)
+	if err != nil {
+		return
+	}
+
+	var timeshift = parseTimeshift(xepgChannel.XTimeshift)
+	var nextStart time.Time
+
+	var title = func(program *Program) string {
+		if len(program.Title) > 0 {
+			return program.Title[0].Value
+		}
+		return ""
+	}
+
+	for _, program := range programs {
+		start, okStart := parseXMLTVTime(adjustProgramTime(program.Start, timeshift))
+		stop, okStop := parseXMLTVTime(adjustProgramTime(program.Stop, timeshift))
+		if !okStart || !okStop {
+			continue
+		}
+
+		switch {
+		case !start.After(now) && stop.After(now):
+			nowNext.Now = nowNextProgram(title(program), start, stop)
+		case start.After(now) && (nextStart.IsZero() || start.Before(nextStart)):
+			nextStart = start
+			nowNext.Next = nowNextProgram(title(program), start, stop)
+		}
+	}
+	return
+}
+
 // Expand Categories (createXMLTVFile)
 func getCategory(program *Program, xmltvProgram *Program, xCategory string) {
+	var filtered = len(Settings.XMLTVCategoryWhitelist) > 0 || len(Settings.XMLTVCategoryBlacklist) > 0
+
 	// Optimization: If no extra category is needed, reuse the source slice.
 	// This avoids allocating a new slice header and backing array.
-	if len(xCategory) == 0 {
+	if len(xCategory) == 0 && !filtered {
 		program.Category = xmltvProgram.Category
 		return
 	}
@@ -1164,7 +1852,19 @@ func getCategory(program *Program, xmltvProgram *Program, xCategory string) {
 
 	// Direct append to avoid allocations.
 	// xmltvProgram.Category elements are immutable so we can safely share pointers.
-	program.Category = append(program.Category, xmltvProgram.Category...)
+	if filtered {
+		for _, category := range xmltvProgram.Category {
+			if isCategoryAllowed(category.Value) {
+				program.Category = append(program.Category, category)
+			}
+		}
+	} else {
+		program.Category = append(program.Category, xmltvProgram.Category...)
+	}
+
+	if len(xCategory) == 0 {
+		return
+	}
 
 	category := &Category{}
 	category.Value = xCategory
@@ -1172,8 +1872,25 @@ func getCategory(program *Program, xmltvProgram *Program, xCategory string) {
 	program.Category = append(program.Category, category)
 }
 
+// isCategoryAllowed : Checks a program category against the whitelist and blacklist (xmltv.category.*)
+// An empty whitelist allows every category that is not blacklisted.
+func isCategoryAllowed(category string) bool {
+	var match = func(list []string) bool {
+		return slices.ContainsFunc(list, func(s string) bool {
+			return strings.EqualFold(strings.TrimSpace(s), strings.TrimSpace(category))
+		})
+	}
+
+	if len(Settings.XMLTVCategoryWhitelist) > 0 && !match(Settings.XMLTVCategoryWhitelist) {
+		return false
+	}
+
+	return !match(Settings.XMLTVCategoryBlacklist)
+}
+
 // Load the Poster Cover Program from the XMLTV File
-func getPoster(program *Program, xmltvProgram *Program) {
+// getPoster copies the program icons. With xepg.replace.missing.images, a program without icon gets the channel logo.
+func getPoster(program *Program, xmltvProgram *Program, channelLogo string) {
 	var imgc = Data.Cache.Images
 
 	// Optimization: Pre-allocate slice capacity
@@ -1193,7 +1910,7 @@ func getPoster(program *Program, xmltvProgram *Program) {
 	if Settings.XepgReplaceMissingImages {
 		if len(xmltvProgram.Poster) == 0 {
 			var poster Poster
-			poster.Src = imgc.Image.GetURL(poster.Src)
+			poster.Src = imgc.Image.GetURL(channelLogo)
 			program.Poster = append(program.Poster, poster)
 		}
 	}
@@ -1249,6 +1966,47 @@ func getEpisodeNum(program *Program, xmltvProgram *Program, xCategory string) {
 			}
 		}
 	}
+
+	if Settings.XMLTVGenerateProgID && !slices.ContainsFunc(program.EpisodeNum, func(e *EpisodeNum) bool { return e.System == "dd_progid" }) {
+		if progID := getProgID(xmltvProgram, xCategory); progID != nil {
+			program.EpisodeNum = append(program.EpisodeNum, progID)
+		}
+	}
+}
+
+// getProgID : Stable dd_progid for programs without one (xmltv.generate.progid). DVRs like Plex use it to detect recordings of the same program.
+// Movies are identified by the title, all other programs by the title and the start time.
+func getProgID(xmltvProgram *Program, xCategory string) *EpisodeNum {
+	var title string
+	for _, t := range xmltvProgram.Title {
+		if title = strings.ToLower(strings.TrimSpace(t.Value)); len(title) > 0 {
+			break
+		}
+	}
+
+	if len(title) == 0 {
+		return nil
+	}
+
+	// The full 64 bit hash, different programs must not share an ID
+	var hash = func(s string) uint64 {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(s))
+		return h.Sum64()
+	}
+
+	var series = hash(title)
+
+	if xCategory == "Movie" {
+		return &EpisodeNum{System: "dd_progid", Value: fmt.Sprintf("MV%016x.0000", series)}
+	}
+
+	var start = xmltvProgram.Start
+	if idx := strings.IndexByte(start, ' '); idx != -1 {
+		start = start[:idx]
+	}
+
+	return &EpisodeNum{System: "dd_progid", Value: fmt.Sprintf("EP%016x.%016x", series, hash(title+"\x00"+start))}
 }
 
 // Create Video Parameters (createXMLTVFile)
@@ -1287,7 +2045,7 @@ func getLocalXMLTV(file string, xmltv *XMLTV) (err error) {
 
 		// Parse XML File
 		// Optimization: Stream decode to avoid loading entire file into memory
-		err = xml.NewDecoder(f).Decode(xmltv)
+		err = decodeXMLTV(f, xmltv, filepath.Base(file))
 		if err != nil {
 			return err
 		}
@@ -1298,6 +2056,130 @@ func getLocalXMLTV(file string, xmltv *XMLTV) (err error) {
 	return
 }
 
+// decodeXMLTV : Decodes an XMLTV file. With xmltv.tolerant.parse, malformed channels and programmes are skipped
+// instead of rejecting the whole file, the number of skipped elements is logged.
+func decodeXMLTV(r io.ReadSeeker, xmltv *XMLTV, name string) error {
+	if !Settings.XMLTVTolerantParse {
+		return xml.NewDecoder(r).Decode(xmltv)
+	}
+
+	var base int64
+	var skipped int
+	var root bool
+
+	for {
+		d := xml.NewDecoder(r)
+		d.Strict = false
+		d.Entity = xml.HTMLEntity
+
+		inElement, err := decodeXMLTVElements(d, xmltv, &root)
+		if err == nil {
+			break
+		}
+
+		// Continue with the next channel or programme after the error
+		offset := max(base+d.InputOffset(), base+1)
+		base, err = seekNextXMLTVElement(r, offset)
+		if errors.Is(err, io.EOF) {
+			if inElement {
+				skipped++
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		skipped++
+	}
+
+	if !root {
+		return errors.New("not an XMLTV file: <tv> element is missing")
+	}
+
+	if skipped > 0 {
+		showInfo(fmt.Sprintf("XMLTV:%d malformed elements skipped (%s)", skipped, name))
+	}
+	return nil
+}
+
+// decodeXMLTVElements : Decodes the channels and programmes one by one. inElement reports whether an error occurred inside a channel or programme.
+func decodeXMLTVElements(d *xml.Decoder, xmltv *XMLTV, root *bool) (inElement bool, err error) {
+	for {
+		token, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "tv":
+			*root = true
+			xmltv.XMLName = start.Name
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "generator-info-name":
+					xmltv.Generator = attr.Value
+				case "source-info-name":
+					xmltv.Source = attr.Value
+				}
+			}
+		case "channel":
+			var channel = &Channel{}
+			if err = d.DecodeElement(channel, &start); err != nil {
+				return true, err
+			}
+			xmltv.Channel = append(xmltv.Channel, channel)
+		case "programme":
+			var program = &Program{}
+			if err = d.DecodeElement(program, &start); err != nil {
+				return true, err
+			}
+			xmltv.Program = append(xmltv.Program, program)
+		default:
+			if err = d.Skip(); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+// seekNextXMLTVElement : Moves the reader to the next <channel> or <programme> element after offset and returns its position
+func seekNextXMLTVElement(r io.ReadSeeker, offset int64) (int64, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var br = bufio.NewReader(r)
+	var pos = offset
+
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		pos++
+
+		if b != '<' {
+			continue
+		}
+
+		for _, name := range []string{"channel", "programme"} {
+			peek, _ := br.Peek(len(name) + 1)
+			if len(peek) == len(name)+1 && string(peek[:len(name)]) == name && strings.IndexByte(" \t\r\n>/", peek[len(name)]) >= 0 {
+				_, err = r.Seek(pos-1, io.SeekStart)
+				return pos - 1, err
+			}
+		}
+	}
+}
+
 // Create M3U File
 func createM3UFile() error { // Added error return type
 	showInfo("XEPG:" + fmt.Sprintf("Create M3U file (%s)", System.File.M3U))
@@ -1311,7 +2193,7 @@ func createM3UFile() error { // Added error return type
 	defer f.Close()
 
 	bw := bufio.NewWriter(f)
-	err = buildM3UToWriter(bw, []string{})
+	err = buildM3UToWriter(bw, []string{}, OutputProfile{Name: defaultOutputProfile})
 	if err != nil {
 		ShowError(err, 000)
 		return err
@@ -1326,6 +2208,7 @@ func createM3UFile() error { // Added error return type
 		ShowError(err, 000) // Show error, but also return it
 		return err
 	}
+	updateStreamFormats(Data.XEPG.Channels)
 	return nil
 }
 
@@ -1337,12 +2220,36 @@ func cleanupXEPG() {
 	showInfo("XEPG:" + "Cleanup database")
 	Data.XEPG.XEPGCount = 0
 
-	maps.DeleteFunc(Data.XEPG.Channels, func(_ string, xepgChannel XEPGChannelStruct) bool {
-		if !slices.Contains(Data.Cache.Streams.Active, xepgChannel.Name+xepgChannel.FileM3UID) ||
-			!slices.Contains(sourceIDs, xepgChannel.FileM3UID) {
+	var now = time.Now()
+	var retention = time.Duration(Settings.XepgRetainMissingDays) * 24 * time.Hour
+
+	maps.DeleteFunc(Data.XEPG.Channels, func(id string, xepgChannel XEPGChannelStruct) bool {
+		if !slices.Contains(sourceIDs, xepgChannel.FileM3UID) {
 			return true
 		}
-		if xepgChannel.XActive {
+
+		if !slices.Contains(Data.Cache.Streams.Active, xepgChannel.Name+xepgChannel.FileM3UID) {
+			// The channel is kept with its mapping in case the provider only dropped it temporarily
+			if retention <= 0 || xepgChannel.LastSeen == 0 || now.Sub(time.Unix(xepgChannel.LastSeen, 0)) > retention {
+				return true
+			}
+
+			if !xepgChannel.Missing {
+				showInfo("XEPG:" + fmt.Sprintf("Channel '%s' is missing from the playlist, it is kept for %d days", xepgChannel.Name, Settings.XepgRetainMissingDays))
+			}
+			xepgChannel.Missing = true
+			Data.XEPG.Channels[id] = xepgChannel
+			return false
+		}
+
+		if xepgChannel.Missing {
+			showInfo("XEPG:" + fmt.Sprintf("Channel '%s' is available again", xepgChannel.Name))
+		}
+		xepgChannel.Missing = false
+		xepgChannel.LastSeen = now.Unix()
+		Data.XEPG.Channels[id] = xepgChannel
+
+		if isChannelEnabled(xepgChannel) {
 			Data.XEPG.XEPGCount++
 		}
 		return false
@@ -1399,9 +2306,24 @@ func bindMapToM3UChannelStruct(data map[string]string, target *M3UChannelStructX
 	if val, ok := data["tvg-name"]; ok {
 		target.TvgName = val
 	}
+	if val, ok := data["tvg-chno"]; ok {
+		target.TvgChno = val
+	}
 	if val, ok := data["tvg-shift"]; ok {
 		target.TvgShift = val
 	}
+	if val, ok := data["catchup"]; ok {
+		target.Catchup = val
+	}
+	if val, ok := data["catchup-source"]; ok {
+		target.CatchupSource = val
+	}
+	if val, ok := data["catchup-days"]; ok {
+		target.CatchupDays = val
+	}
+	if val, ok := data["radio"]; ok {
+		target.Radio = val
+	}
 	if val, ok := data["url"]; ok {
 		target.URL = val
 	}
@@ -1414,6 +2336,9 @@ func bindMapToM3UChannelStruct(data map[string]string, target *M3UChannelStructX
 	if val, ok := data["_values"]; ok {
 		target.Values = val
 	}
+	if val, ok := data["_extinf"]; ok {
+		target.EXTINF = val
+	}
 	if val, ok := data["_preserve-mapping"]; ok {
 		target.PreserveMapping = val
 	}
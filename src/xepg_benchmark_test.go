@@ -7,7 +7,7 @@ import (
 
 func BenchmarkAdjustProgramTime(b *testing.B) {
 	t := "20241225120000 +0000"
-	timeshift := 2
+	timeshift := 120
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		adjustProgramTime(t, timeshift)
@@ -0,0 +1,173 @@
+package src
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessNewXEPGChannel_TvgChno(t *testing.T) {
+	teardown := setupGlobalStateForTest()
+	defer teardown()
+
+	Settings.MappingFirstChannel = 1000
+	Settings.PreferSourceChno = true
+
+	allChannelNumbers := map[channelNumber]bool{{Major: 5}: true}
+	Data.XEPG.Channels = make(map[string]XEPGChannelStruct)
+
+	channels := []M3UChannelStructXEPG{
+		{Name: "Free", TvgChno: "7"},
+		{Name: "Collision with existing", TvgChno: "5"},
+		{Name: "Collision with new", TvgChno: "7"},
+		{Name: "Decimal", TvgChno: " 7.1 "},
+		{Name: "Invalid", TvgChno: "abc"},
+		{Name: "Negative", TvgChno: "-3"},
+		{Name: "Collision with starting channel", TvgChno: "7", StartingChannel: "2000"},
+	}
+
+	var got = make(map[string]string)
+	for _, channel := range channels {
+		processNewXEPGChannel(channel, allChannelNumbers)
+		for _, c := range Data.XEPG.Channels {
+			if c.Name == channel.Name {
+				got[c.Name] = c.XChannelID
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]string{
+		"Free":                            "7",
+		"Collision with existing":         "1000",
+		"Collision with new":              "1001",
+		"Decimal":                         "7.1",
+		"Invalid":                         "1002",
+		"Negative":                        "1003",
+		"Collision with starting channel": "2000",
+	}, got)
+}
+
+func TestProcessNewXEPGChannel_TvgChnoDisabled(t *testing.T) {
+	teardown := setupGlobalStateForTest()
+	defer teardown()
+
+	Settings.MappingFirstChannel = 1000
+	Settings.PreferSourceChno = false
+
+	allChannelNumbers := make(map[channelNumber]bool)
+	Data.XEPG.Channels = make(map[string]XEPGChannelStruct)
+
+	processNewXEPGChannel(M3UChannelStructXEPG{Name: "Ignored", TvgChno: "7"}, allChannelNumbers)
+	processNewXEPGChannel(M3UChannelStructXEPG{Name: "Preserved", TvgChno: "8", UUIDValue: "3000", PreserveMapping: "true"}, allChannelNumbers)
+
+	var got = make(map[string]string)
+	for _, c := range Data.XEPG.Channels {
+		got[c.Name] = c.XChannelID
+	}
+
+	assert.Equal(t, map[string]string{"Ignored": "1000", "Preserved": "8"}, got)
+}
+
+func TestChannelNumber_Subchannels(t *testing.T) {
+	for input, want := range map[string]string{"5": "5", "5.1": "5.1", "5.10": "5.10", " 5.01 ": "5.1", "0.3": "0.3"} {
+		number, ok := parseChannelNumber(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, want, number.String(), input)
+	}
+	for _, input := range []string{"", "0", "abc", "-3", "+5", "5.", ".5", "5.1.2", "1e3"} {
+		_, ok := parseChannelNumber(input)
+		assert.False(t, ok, input)
+	}
+
+	// Ordering: Major, then minor number
+	channels := []string{"5.10", "10", "5.2", "5", "5.1", "4.20"}
+	slices.SortFunc(channels, compareChannelNumbers)
+	assert.Equal(t, []string{"4.20", "5", "5.1", "5.2", "5.10", "10"}, channels)
+
+	// Collision: 5.1 and 5.10 are different channels
+	allChannelNumbers := make(map[channelNumber]bool)
+	for _, number := range []string{"5.1", "5.10"} {
+		xChannelID, ok := claimChannelNumber(allChannelNumbers, number)
+		assert.True(t, ok, number)
+		assert.Equal(t, number, xChannelID)
+	}
+	_, ok := claimChannelNumber(allChannelNumbers, "5.01")
+	assert.False(t, ok, "5.01 is the same channel as 5.1")
+
+	// The next free subchannel
+	assert.Equal(t, "5.2", findFreeChannelNumber(allChannelNumbers, "5.1"))
+	assert.Equal(t, "5.11", findFreeChannelNumber(allChannelNumbers, "5.10"))
+}
+
+func TestResolveChannelNumberConflicts_Subchannels(t *testing.T) {
+	teardown := setupGlobalStateForTest()
+	defer teardown()
+
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{}
+	channels := map[string]XEPGChannelStruct{
+		"x-ID.1": {XActive: true, XChannelID: "5.1", XName: "One"},
+		"x-ID.2": {XActive: true, XChannelID: "5.10", XName: "Ten"},
+		"x-ID.3": {XActive: true, XChannelID: "5.2", XName: "Two"},
+	}
+
+	Settings.XepgResolveChnoConflicts = false
+	require.NoError(t, resolveChannelNumberConflicts(channels), "5.1 and 5.10 are different channels")
+
+	channels["x-ID.3"] = XEPGChannelStruct{XActive: true, XChannelID: "5.01", XName: "Two"}
+	err := resolveChannelNumberConflicts(channels)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "5.1 (One, Two)")
+
+	Settings.XepgResolveChnoConflicts = true
+	require.NoError(t, resolveChannelNumberConflicts(channels))
+	assert.Equal(t, "5.1", channels["x-ID.1"].XChannelID)
+	assert.Equal(t, "5.2", channels["x-ID.3"].XChannelID)
+	assert.Equal(t, "5.10", channels["x-ID.2"].XChannelID)
+}
+
+func TestSaveXEpgMapping_DuplicateChannelNumbers(t *testing.T) {
+	teardown := setupGlobalStateForTest()
+	defer teardown()
+
+	System.File.XEPG = t.TempDir() + "/xepg.json"
+	Settings.MappingFirstChannel = 1000
+
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {XActive: true, XChannelID: "5", XName: "One"},
+		"x-ID.2": {XActive: true, XChannelID: "6", XName: "Two"},
+	}
+
+	// Imported mapping: Channel Three and Two are moved to the channel number of One
+	mapping := map[string]any{
+		"x-ID.1": map[string]any{"x-active": true, "x-channelID": "5", "x-name": "One"},
+		"x-ID.2": map[string]any{"x-active": true, "x-channelID": "5", "x-name": "Two"},
+		"x-ID.3": map[string]any{"x-active": true, "x-channelID": "5", "x-name": "Three"},
+		"x-ID.4": map[string]any{"x-active": false, "x-channelID": "6", "x-name": "Inactive"},
+	}
+
+	t.Run("rejected", func(t *testing.T) {
+		Settings.XepgResolveChnoConflicts = false
+
+		err := saveXEpgMapping(RequestStruct{EpgMapping: mapping})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "5 (One, Two, Three)")
+		assert.NoFileExists(t, System.File.XEPG, "the mapping is not saved")
+		assert.Equal(t, "6", Data.XEPG.Channels["x-ID.2"].XChannelID)
+	})
+
+	t.Run("resolved", func(t *testing.T) {
+		Settings.XepgResolveChnoConflicts = true
+
+		var channels map[string]XEPGChannelStruct
+		require.NoError(t, bindToStruct(mapping, &channels))
+		require.NoError(t, resolveChannelNumberConflicts(channels))
+
+		// One keeps its number, the others get the next free numbers (6 is used by the inactive channel)
+		assert.Equal(t, "5", channels["x-ID.1"].XChannelID)
+		assert.Equal(t, "7", channels["x-ID.2"].XChannelID)
+		assert.Equal(t, "8", channels["x-ID.3"].XChannelID)
+		assert.Equal(t, "6", channels["x-ID.4"].XChannelID)
+	})
+}
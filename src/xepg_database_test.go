@@ -5,7 +5,6 @@ import (
 	"fmt" // Added for fmt.Sprintf in panic
 	"hash/maphash"
 	"os"
-	"strconv"
 	"testing"
 	// "strings" // Removed as it's unused now
 )
@@ -112,7 +111,7 @@ func TestFindFreeChannelNumber(t *testing.T) {
 	teardown := setupGlobalStateForTest()
 	defer teardown()
 
-	allChannelNumbers := make(map[float64]bool)
+	allChannelNumbers := make(map[channelNumber]bool)
 	Settings.MappingFirstChannel = 1000 // Ensure testSettings is used via System
 
 	// Test 1: Empty allChannelNumbers
@@ -120,59 +119,59 @@ func TestFindFreeChannelNumber(t *testing.T) {
 	if ch1 != "1000" {
 		t.Errorf("Expected channel number 1000, got %s", ch1)
 	}
-	if !allChannelNumbers[1000.0] {
+	if !allChannelNumbers[channelNumber{Major: 1000}] {
 		t.Errorf("Expected 1000 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 
 	// Test 2: With existing numbers
-	allChannelNumbers = map[float64]bool{1000: true, 1001: true, 1003: true}
+	allChannelNumbers = map[channelNumber]bool{{Major: 1000}: true, {Major: 1001}: true, {Major: 1003}: true}
 	ch2 := findFreeChannelNumber(allChannelNumbers)
 	if ch2 != "1002" {
 		t.Errorf("Expected channel number 1002, got %s", ch2)
 	}
-	if !allChannelNumbers[1002.0] {
+	if !allChannelNumbers[channelNumber{Major: 1002}] {
 		t.Errorf("Expected 1002 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 
 	// Test 3: With startingChannel hint
-	allChannelNumbers = map[float64]bool{1000: true, 1001: true, 1003: true, 1002: true}
+	allChannelNumbers = map[channelNumber]bool{{Major: 1000}: true, {Major: 1001}: true, {Major: 1003}: true, {Major: 1002}: true}
 	ch3 := findFreeChannelNumber(allChannelNumbers, "1005")
 	if ch3 != "1005" {
 		t.Errorf("Expected channel number 1005, got %s", ch3)
 	}
-	if !allChannelNumbers[1005.0] {
+	if !allChannelNumbers[channelNumber{Major: 1005}] {
 		t.Errorf("Expected 1005 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 
 	// Test 4: Starting channel hint is already taken
-	allChannelNumbers = map[float64]bool{1000: true, 1001: true, 1003: true, 1002: true, 1005: true}
+	allChannelNumbers = map[channelNumber]bool{{Major: 1000}: true, {Major: 1001}: true, {Major: 1003}: true, {Major: 1002}: true, {Major: 1005}: true}
 	ch4 := findFreeChannelNumber(allChannelNumbers, "1003") // 1003 is taken, next should be 1004
 	if ch4 != "1004" {
 		t.Errorf("Expected channel number 1004, got %s", ch4)
 	}
-	if !allChannelNumbers[1004.0] {
+	if !allChannelNumbers[channelNumber{Major: 1004}] {
 		t.Errorf("Expected 1004 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 
 	// Test 5: Starting channel hint is empty string
-	allChannelNumbers = map[float64]bool{1000: true, 1001: true, 1002: true, 1003: true, 1004: true, 1005: true}
+	allChannelNumbers = map[channelNumber]bool{{Major: 1000}: true, {Major: 1001}: true, {Major: 1002}: true, {Major: 1003}: true, {Major: 1004}: true, {Major: 1005}: true}
 	Settings.MappingFirstChannel = 1000
 	ch5 := findFreeChannelNumber(allChannelNumbers, "")
 	if ch5 != "1006" {
 		t.Errorf("Expected channel number 1006 when starting hint is empty, got %s. Numbers: %v", ch5, allChannelNumbers)
 	}
-	if !allChannelNumbers[1006.0] {
+	if !allChannelNumbers[channelNumber{Major: 1006}] {
 		t.Errorf("Expected 1006 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 
 	// Test 6: Settings.MappingFirstChannel is higher
-	allChannelNumbers = make(map[float64]bool)
+	allChannelNumbers = make(map[channelNumber]bool)
 	Settings.MappingFirstChannel = 2000
 	ch6 := findFreeChannelNumber(allChannelNumbers)
 	if ch6 != "2000" {
 		t.Errorf("Expected channel number 2000, got %s", ch6)
 	}
-	if !allChannelNumbers[2000.0] {
+	if !allChannelNumbers[channelNumber{Major: 2000}] {
 		t.Errorf("Expected 2000 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 }
@@ -351,7 +350,7 @@ func TestProcessNewXEPGChannel(t *testing.T) {
 	teardown := setupGlobalStateForTest()
 	defer teardown()
 
-	allChannelNumbers := make(map[float64]bool)
+	allChannelNumbers := make(map[channelNumber]bool)
 	Settings.MappingFirstChannel = 2000
 
 	valuesMap := map[string]string{"attr1": "val1"}
@@ -406,9 +405,8 @@ func TestProcessNewXEPGChannel(t *testing.T) {
 	if newChannel.XChannelID != "2005" {
 		t.Errorf("Expected XChannelID to be '2005', got '%s'", newChannel.XChannelID)
 	}
-	expectedChannelNumFloat, _ := strconv.ParseFloat("2005", 64)
-	if !allChannelNumbers[expectedChannelNumFloat] {
-		t.Errorf("Expected %.1f to be added to allChannelNumbers. Got: %v", expectedChannelNumFloat, allChannelNumbers)
+	if !allChannelNumbers[channelNumber{Major: 2005}] {
+		t.Errorf("Expected 2005 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 
 	if newChannel.Name != m3uChannel.Name {
@@ -436,7 +434,7 @@ func TestProcessNewXEPGChannel(t *testing.T) {
 		TvgShift:        "",
 	}
 	Data.XEPG.Channels = make(map[string]XEPGChannelStruct)
-	allChannelNumbers = make(map[float64]bool)
+	allChannelNumbers = make(map[channelNumber]bool)
 	processNewXEPGChannel(m3uChannel2, allChannelNumbers)
 
 	var newXEPGID2 string
@@ -466,7 +464,7 @@ func TestProcessNewXEPGChannel(t *testing.T) {
 	if newChannel2.XChannelID != "2500" {
 		t.Errorf("Expected XChannelID to be '2500', got '%s'", newChannel2.XChannelID)
 	}
-	if !allChannelNumbers[2500.0] {
+	if !allChannelNumbers[channelNumber{Major: 2500}] {
 		t.Errorf("Expected 2500 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 	if newChannel2.TvgShift != "0" {
@@ -483,7 +481,7 @@ func TestProcessNewXEPGChannel(t *testing.T) {
 		PreserveMapping: "false",
 	}
 	Data.XEPG.Channels = make(map[string]XEPGChannelStruct)
-	allChannelNumbers = make(map[float64]bool)
+	allChannelNumbers = make(map[channelNumber]bool)
 	Settings.MappingFirstChannel = 3000
 	processNewXEPGChannel(m3uChannel3, allChannelNumbers)
 
@@ -509,7 +507,7 @@ func TestProcessNewXEPGChannel(t *testing.T) {
 	if newChannel3.XChannelID != "3000" {
 		t.Errorf("Expected XChannelID to be '3000', got '%s'", newChannel3.XChannelID)
 	}
-	if !allChannelNumbers[3000.0] {
+	if !allChannelNumbers[channelNumber{Major: 3000}] {
 		t.Errorf("Expected 3000 to be added to allChannelNumbers. Got: %v", allChannelNumbers)
 	}
 }
@@ -0,0 +1,68 @@
+package src
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupePrograms(t *testing.T) {
+	oldSettings, oldSystem, oldCache := Settings, System, Data.Cache.XMLTV
+	t.Cleanup(func() { Settings, System, Data.Cache.XMLTV = oldSettings, oldSystem, oldCache })
+
+	System.Folder.Data = t.TempDir() + "/"
+	var file = System.Folder.Data + "dedupe.xml"
+	t.Cleanup(func() {
+		xmltvProgramMutex.Lock()
+		delete(xmltvProgramIndices, file)
+		xmltvProgramMutex.Unlock()
+	})
+
+	program := func(start, stop, title string) *Program {
+		return &Program{Channel: "ch1", Start: start + " +0000", Stop: stop + " +0000", Title: []*Title{{Value: title}}}
+	}
+
+	var rich = program("20240101100000", "20240101110000", "News")
+	rich.Desc = []*Desc{{Value: "The news"}}
+
+	Data.Cache.XMLTV = map[string]XMLTV{file: {Program: []*Program{
+		program("20240101120000", "20240101130000", "Movie"),
+		program("20240101100000", "20240101110000", "News"),
+		rich, // Duplicate with a description
+		program("20240101100000", "20240101110000", "News"),
+		program("20240101103000", "20240101104500", "Weather"), // Within News
+		program("20240101110000", "20240101123000", "Sports"),  // Overlaps Movie
+		program("20240101130000", "20240101140000", "Late Show"),
+		program("20240101140000", "20240101150000", "Talk"),
+		program("20240101140000", "20240101160000", "Talk Extended"), // Same start, longer
+	}}}
+
+	var channel = XEPGChannelStruct{XmltvFile: "dedupe.xml", XMapping: "ch1", XChannelID: "1", XName: "Channel 1"}
+
+	Settings.XMLTVDedupePrograms = false
+	var all []*Program
+	require.NoError(t, getProgramData(channel, &all))
+	assert.Len(t, all, 9)
+
+	Settings.XMLTVDedupePrograms = true
+	var other = []*Program{{Channel: "other"}} // Programmes of other channels are not changed
+	require.NoError(t, getProgramData(channel, &other))
+	require.Equal(t, "other", other[0].Channel)
+
+	var programs = other[1:]
+	var titles []string
+	for i, p := range programs {
+		titles = append(titles, p.Title[0].Value)
+		if i > 0 {
+			assert.LessOrEqual(t, programs[i-1].Stop, p.Start, "programmes do not overlap")
+		}
+	}
+	assert.Equal(t, []string{"News", "Sports", "Movie", "Late Show", "Talk Extended"}, titles)
+	assert.Equal(t, "The news", programs[0].Desc[0].Value, "the duplicate with more data is kept")
+	assert.Equal(t, "20240101120000 +0000", programs[1].Stop, "the overlapping programme is shortened")
+
+	// Programmes with invalid times are kept
+	var invalid = &Program{Start: "invalid", Stop: "invalid"}
+	assert.Len(t, dedupePrograms([]*Program{program("20240101100000", "20240101110000", "News"), invalid}), 2)
+}
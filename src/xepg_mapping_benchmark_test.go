@@ -70,7 +70,7 @@ func BenchmarkPerformAutomaticChannelMapping(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = performAutomaticChannelMapping(targetChannel, "testID", nil)
+		_, _ = performAutomaticChannelMapping(targetChannel, "testID", nil, nil)
 	}
 }
 
@@ -156,6 +156,6 @@ func BenchmarkPerformAutomaticChannelMapping_WithIndex(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = performAutomaticChannelMapping(targetChannel, "testID", nameIndex)
+		_, _ = performAutomaticChannelMapping(targetChannel, "testID", nameIndex, nil)
 	}
 }
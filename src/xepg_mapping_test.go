@@ -275,7 +275,7 @@ func TestPerformAutomaticChannelMapping(t *testing.T) {
 				}
 			}
 
-			resultChannel, mappingMade := performAutomaticChannelMapping(tt.initialChannel, xepgID, nameIndex)
+			resultChannel, mappingMade := performAutomaticChannelMapping(tt.initialChannel, xepgID, nameIndex, nil)
 
 			if mappingMade != tt.expectedMappingMade {
 				t.Errorf("performAutomaticChannelMapping mappingMade: got %v, want %v", mappingMade, tt.expectedMappingMade)
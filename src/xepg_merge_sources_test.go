@@ -0,0 +1,86 @@
+package src
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProgramData_MergeSources(t *testing.T) {
+	oldSettings, oldSystem, oldCache := Settings, System, Data.Cache.XMLTV
+	t.Cleanup(func() { Settings, System, Data.Cache.XMLTV = oldSettings, oldSystem, oldCache })
+
+	System.Folder.Data = t.TempDir() + "/"
+	var primaryFile, secondaryFile = System.Folder.Data + "primary.xml", System.Folder.Data + "secondary.xml"
+	t.Cleanup(func() {
+		xmltvProgramMutex.Lock()
+		delete(xmltvProgramIndices, primaryFile)
+		delete(xmltvProgramIndices, secondaryFile)
+		xmltvProgramMutex.Unlock()
+	})
+
+	program := func(channel, start, stop, title string) *Program {
+		return &Program{Channel: channel, Start: "20240101" + start + "00 +0000", Stop: "20240101" + stop + "00 +0000", Title: []*Title{{Value: title}}}
+	}
+
+	// The primary source has gaps from 12:00 to 14:00 and after 15:00
+	Data.Cache.XMLTV = map[string]XMLTV{
+		primaryFile: {Program: []*Program{
+			program("news", "1400", "1500", "Primary 14"),
+			program("news", "1000", "1100", "Primary 10"),
+			program("news", "1100", "1200", "Primary 11"),
+		}},
+		secondaryFile: {Program: []*Program{
+			program("news.de", "0900", "1000", "Secondary 09"),
+			program("news.de", "1030", "1130", "Secondary 10:30"), // Overlaps the primary source
+			program("news.de", "1200", "1300", "Secondary 12"),
+			program("news.de", "1300", "1400", "Secondary 13"),
+			program("news.de", "1330", "1430", "Secondary 13:30"), // Overlaps the secondary source
+			program("news.de", "1500", "1600", "Secondary 15"),
+		}},
+	}
+
+	var channel = XEPGChannelStruct{XChannelID: "1", XName: "News", XmltvFile: "primary.xml", XMapping: "news"}
+
+	// A single mapping is used as it is
+	var programs []*Program
+	require.NoError(t, getProgramData(channel, &programs))
+	assert.Len(t, programs, 3)
+
+	channel.XMappings = []XEPGMappingStruct{
+		{XmltvFile: "-", XMapping: "-"},
+		{XmltvFile: "secondary.xml", XMapping: "news.de"},
+		{XmltvFile: "missing.xml", XMapping: "news"},
+	}
+
+	programs = nil
+	require.NoError(t, getProgramData(channel, &programs))
+
+	var titles []string
+	for i, p := range programs {
+		titles = append(titles, p.Title[0].Value)
+		assert.Equal(t, "1", p.Channel)
+		if i > 0 {
+			assert.Equal(t, programs[i-1].Stop, p.Start, "the guide is continuous")
+		}
+	}
+	assert.Equal(t, []string{"Secondary 09", "Primary 10", "Primary 11", "Secondary 12", "Secondary 13", "Primary 14", "Secondary 15"}, titles)
+}
+
+func TestMergeProgramSources_LongProgramme(t *testing.T) {
+	program := func(start, stop, title string) *Program {
+		return &Program{Start: "20240101" + start + "00 +0000", Stop: "20240101" + stop + "00 +0000", Title: []*Title{{Value: title}}}
+	}
+
+	var merged = mergeProgramSources(
+		[]*Program{program("1000", "1400", "Primary Film"), program("1100", "1200", "Primary 11")},
+		[]*Program{program("0900", "1000", "Secondary 09"), program("1230", "1300", "Secondary 12:30"), program("1400", "1500", "Secondary 14")},
+	)
+
+	var titles []string
+	for _, p := range merged {
+		titles = append(titles, p.Title[0].Value)
+	}
+	assert.Equal(t, []string{"Secondary 09", "Primary Film", "Primary 11", "Secondary 14"}, titles, "the film overlaps 12:30")
+}
@@ -0,0 +1,71 @@
+package src
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeMappingName(t *testing.T) {
+	rules := compileMappingNameRules([]MappingNameRule{
+		{Pattern: `^(?i)(US|UK)\s*[|:]\s*`, Replace: ""},
+		{Pattern: `(?i)\s+(HD|FHD|UHD|4K|SD)$`, Replace: ""},
+		{Pattern: `[^\pL\pN ]`, Replace: ""},
+	})
+
+	assert.Equal(t, "cnn", normalizeMappingName("US| CNN HD", rules))
+	assert.Equal(t, "bbcone", normalizeMappingName("UK: BBC One FHD", rules))
+	assert.Equal(t, "e", normalizeMappingName("E!", rules))
+	assert.Equal(t, "us|cnnhd", normalizeMappingName("US| CNN HD", nil), "without rules only spaces and case are ignored")
+}
+
+func TestCompileMappingNameRules_InvalidPattern(t *testing.T) {
+	rules := compileMappingNameRules([]MappingNameRule{
+		{Pattern: `(`, Replace: ""},
+		{Pattern: `HD$`, Replace: ""},
+	})
+
+	assert.Len(t, rules, 1)
+}
+
+func TestMapping_NameRules(t *testing.T) {
+	t.Cleanup(setupMappingTestGlobals())
+
+	Settings.DefaultMissingEPG = "-"
+	Data.XMLTV.Mapping["guide.xml"] = map[string]XMLTVChannelMapping{
+		"cnn.us":  {ID: "cnn.us", DisplayNames: []DisplayName{{Value: "CNN"}}},
+		"bbc1.uk": {ID: "bbc1.uk", DisplayNames: []DisplayName{{Value: "BBC One"}}},
+		"e.us":    {ID: "e.us", DisplayNames: []DisplayName{{Value: "E!"}}},
+	}
+
+	channels := map[string]string{
+		"x-ID.1": "US| CNN HD",
+		"x-ID.2": "UK: BBC One FHD",
+		"x-ID.3": "E! 4K",
+	}
+
+	run := func(rules []MappingNameRule) (matched int) {
+		Settings.MappingNameRules = rules
+		Data.XEPG.Channels = make(map[string]XEPGChannelStruct)
+		for id, name := range channels {
+			Data.XEPG.Channels[id] = XEPGChannelStruct{Name: name, XmltvFile: "-", XMapping: "-"}
+		}
+
+		assert.NoError(t, mapping())
+
+		for _, channel := range Data.XEPG.Channels {
+			if channel.XmltvFile == "guide.xml" {
+				matched++
+			}
+		}
+		return
+	}
+
+	assert.Equal(t, 0, run(nil))
+
+	assert.Equal(t, 3, run([]MappingNameRule{
+		{Pattern: `^(?i)(US|UK)\s*[|:]\s*`, Replace: ""},
+		{Pattern: `(?i)\s+(HD|FHD|UHD|4K|SD)$`, Replace: ""},
+	}))
+	assert.Equal(t, "bbc1.uk", Data.XEPG.Channels["x-ID.2"].XMapping)
+}
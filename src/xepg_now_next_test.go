@@ -0,0 +1,82 @@
+package src
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNowNext(t *testing.T) {
+	oldSystem, oldChannels, oldCache := System, Data.XEPG.Channels, Data.Cache.XMLTV
+	t.Cleanup(func() { System, Data.XEPG.Channels, Data.Cache.XMLTV = oldSystem, oldChannels, oldCache })
+
+	System.Folder.Data = t.TempDir() + "/"
+	var file = System.Folder.Data + "guide.xml"
+	t.Cleanup(func() {
+		xmltvProgramMutex.Lock()
+		delete(xmltvProgramIndices, file)
+		xmltvProgramMutex.Unlock()
+	})
+
+	program := func(channel, start, stop, title string) *Program {
+		return &Program{Channel: channel, Start: start + " +0000", Stop: stop + " +0000", Title: []*Title{{Value: title, Lang: "en"}}}
+	}
+
+	// Not sorted by time
+	Data.Cache.XMLTV = map[string]XMLTV{file: {Program: []*Program{
+		program("ch1", "20240101120000", "20240101130000", "Movie"),
+		program("ch1", "20240101100000", "20240101110000", "News"),
+		program("ch2", "20240101110000", "20240101120000", "Other channel"),
+		program("ch1", "20240101130000", "20240101140000", "Late Show"),
+		program("ch1", "20240101110000", "20240101120000", "Sports"),
+	}}}
+
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {XChannelID: "1", XName: "Channel 1", XmltvFile: "guide.xml", XMapping: "ch1"},
+		"x-ID.2": {XChannelID: "2", XName: "Dummy", XmltvFile: "xTeVe Dummy", XMapping: "30_Minutes"},
+		"x-ID.3": {XChannelID: "3", XName: "No EPG", XmltvFile: "-", XMapping: "-"},
+		"x-ID.4": {XChannelID: "4", XName: "Shifted", XmltvFile: "guide.xml", XMapping: "ch1", XTimeshift: "+1"},
+	}
+
+	var now = time.Date(2024, 1, 1, 11, 15, 0, 0, time.UTC)
+
+	nowNext, err := getNowNext("1", now)
+	require.NoError(t, err)
+	assert.Equal(t, "1", nowNext.Channel)
+	require.NotNil(t, nowNext.Now)
+	require.NotNil(t, nowNext.Next)
+	assert.Equal(t, NowNextProgramStruct{Title: "Sports", Start: "2024-01-01T11:00:00Z", Stop: "2024-01-01T12:00:00Z"}, *nowNext.Now)
+	assert.Equal(t, NowNextProgramStruct{Title: "Movie", Start: "2024-01-01T12:00:00Z", Stop: "2024-01-01T13:00:00Z"}, *nowNext.Next)
+
+	// XEPG ID and the start of a programme
+	nowNext, err = getNowNext("x-ID.1", time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Late Show", nowNext.Now.Title)
+	assert.Nil(t, nowNext.Next, "no programme after the last one")
+
+	// Timeshift of the channel, the times keep the hour and get the offset +0100
+	nowNext, err = getNowNext("4", now)
+	require.NoError(t, err)
+	assert.Equal(t, "Movie", nowNext.Now.Title)
+	assert.Equal(t, "2024-01-01T13:00:00+01:00", nowNext.Now.Stop)
+
+	// Before the first programme
+	nowNext, err = getNowNext("1", time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Nil(t, nowNext.Now)
+	assert.Equal(t, "News", nowNext.Next.Title)
+
+	// xTeVe Dummy
+	nowNext, err = getNowNext("2", now)
+	require.NoError(t, err)
+	assert.Equal(t, NowNextProgramStruct{Title: "Dummy (Mo. 11:00 - 11:30)", Start: "2024-01-01T11:00:00Z", Stop: "2024-01-01T11:30:00Z"}, *nowNext.Now)
+	assert.Equal(t, "2024-01-01T11:30:00Z", nowNext.Next.Start)
+
+	_, err = getNowNext("3", now)
+	assert.Error(t, err, "channel without EPG")
+
+	_, err = getNowNext("99", now)
+	assert.Error(t, err, "unknown channel")
+}
@@ -0,0 +1,115 @@
+package src
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupXEPG_RetainMissingChannels(t *testing.T) {
+	originalSettings, originalSystem, originalData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = originalSettings, originalSystem, originalData })
+
+	tempDir := t.TempDir() + string(os.PathSeparator)
+	System.Folder.Data = tempDir
+	System.Folder.Temp = tempDir
+	System.Folder.ImagesCache = tempDir + "images" + string(os.PathSeparator)
+	System.File.Settings = filepath.Join(tempDir, "settings.json")
+	System.File.XEPG = filepath.Join(tempDir, "xepg.json")
+	System.File.URLS = filepath.Join(tempDir, "urls.json")
+	System.File.PMS = filepath.Join(tempDir, "pms.json")
+	System.File.M3U = filepath.Join(tempDir, "xteve.m3u")
+	System.File.XML = filepath.Join(tempDir, "xteve.xml")
+	System.Compressed.GZxml = filepath.Join(tempDir, "xteve.xml.gz")
+	System.Domain = "localhost:34400"
+	System.ScanInProgress = 0
+	require.NoError(t, os.MkdirAll(System.Folder.ImagesCache, 0755))
+
+	Settings = SettingsStruct{EpgSource: "XEPG", TempPath: tempDir, M3USortOrder: "channel-number", MappingFirstChannel: 1000, DefaultMissingEPG: "-", XepgRetainMissingDays: 7}
+	Settings.Files.M3U = map[string]any{"Msource": map[string]any{"name": "Source Test"}}
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+	require.NoError(t, saveMapToJSONFile(System.File.PMS, map[string]any{}))
+	require.NoError(t, saveMapToJSONFile(System.File.XEPG, map[string]any{}))
+
+	update := func(channels ...string) {
+		var playlist = "#EXTM3U\n"
+		for _, name := range channels {
+			playlist += "#EXTINF:-1 group-title=\"News\"," + name + "\nhttp://example.com/" + name + "\n"
+		}
+		require.NoError(t, os.WriteFile(tempDir+"Msource.m3u", []byte(playlist), 0644))
+		require.NoError(t, buildDatabaseDVR())
+		require.NoError(t, buildXEPG(false))
+	}
+
+	channelByName := func(name string) (string, XEPGChannelStruct, bool) {
+		for id, channel := range Data.XEPG.Channels {
+			if channel.Name == name {
+				return id, channel, true
+			}
+		}
+		return "", XEPGChannelStruct{}, false
+	}
+
+	update("News1", "News2")
+
+	// Mapping of the channel that disappears
+	id, channel, ok := channelByName("News2")
+	require.True(t, ok)
+	assert.NotZero(t, channel.LastSeen)
+	channel.XName = "Mapped News"
+	channel.XmltvFile = "xTeVe Dummy"
+	channel.XMapping = "60_Minutes"
+	channel.XActive = true
+	Data.XEPG.Channels[id] = channel
+	require.NoError(t, saveMapToJSONFile(System.File.XEPG, Data.XEPG.Channels))
+
+	// The provider drops the channel temporarily
+	update("News1")
+
+	_, channel, ok = channelByName("News2")
+	require.True(t, ok, "the missing channel is kept")
+	assert.True(t, channel.Missing)
+	assert.False(t, isChannelEnabled(channel), "a missing channel is not part of the output")
+	assert.Equal(t, "Mapped News", channel.XName)
+
+	m3u, err := os.ReadFile(System.File.M3U)
+	require.NoError(t, err)
+	assert.NotContains(t, string(m3u), "Mapped News")
+
+	// The channel is back with its mapping and the same ID
+	update("News1", "News2")
+
+	newID, channel, ok := channelByName("News2")
+	require.True(t, ok)
+	assert.Equal(t, id, newID)
+	assert.False(t, channel.Missing)
+	assert.True(t, isChannelEnabled(channel))
+	assert.Equal(t, "Mapped News", channel.XName)
+	assert.Equal(t, "60_Minutes", channel.XMapping)
+
+	m3u, err = os.ReadFile(System.File.M3U)
+	require.NoError(t, err)
+	assert.Contains(t, string(m3u), "Mapped News")
+
+	// Channels that are missing for longer than the retention are deleted
+	channel.LastSeen = time.Now().Add(-8 * 24 * time.Hour).Unix()
+	Data.XEPG.Channels[id] = channel
+	require.NoError(t, saveMapToJSONFile(System.File.XEPG, Data.XEPG.Channels))
+
+	update("News1")
+	_, _, ok = channelByName("News2")
+	assert.False(t, ok)
+
+	// Without the setting, missing channels are deleted immediately
+	Settings.XepgRetainMissingDays = 0
+	update("News1", "News3")
+	update("News1")
+	_, _, ok = channelByName("News3")
+	assert.False(t, ok)
+	_, _, ok = channelByName("News1")
+	assert.True(t, ok)
+}
@@ -0,0 +1,71 @@
+package src
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapping_UserDisabledStaysInactive(t *testing.T) {
+	t.Cleanup(setupMappingTestGlobals())
+
+	Data.XEPG.Channels["x-ID.1"] = XEPGChannelStruct{
+		Name:          "Test Channel 1",
+		XmltvFile:     "test_provider.xml",
+		XMapping:      "channel1.tvg.id",
+		XActive:       false,
+		XUserDisabled: true,
+	}
+	Data.XEPG.Channels["x-ID.2"] = XEPGChannelStruct{
+		Name:      "Test Channel 2",
+		XmltvFile: "test_provider.xml",
+		XMapping:  "channel2.name.match",
+		XActive:   false,
+	}
+
+	assert.NoError(t, mapping())
+
+	disabled := Data.XEPG.Channels["x-ID.1"]
+	assert.False(t, disabled.XActive, "enableMappedChannels must not re-activate a channel disabled by the user")
+	assert.Equal(t, "test_provider.xml", disabled.XmltvFile, "the mapping is kept")
+	assert.Equal(t, "channel1.tvg.id", disabled.XMapping)
+	assert.False(t, isChannelEnabled(disabled))
+
+	enabled := Data.XEPG.Channels["x-ID.2"]
+	assert.True(t, enabled.XActive, "mapped channels are still activated by enableMappedChannels")
+	assert.True(t, isChannelEnabled(enabled))
+}
+
+func TestVerifyExistingChannelMappings_UserDisabled(t *testing.T) {
+	t.Cleanup(setupMappingTestGlobals())
+
+	channel := verifyExistingChannelMappings(XEPGChannelStruct{
+		XmltvFile:     "test_provider.xml",
+		XMapping:      "channel1.tvg.id",
+		XActive:       true,
+		XUserDisabled: true,
+	})
+
+	assert.False(t, channel.XActive)
+	assert.Equal(t, "channel1.tvg.id", channel.XMapping)
+}
+
+func TestApplyUserDisabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      XEPGChannelStruct
+		new      XEPGChannelStruct
+		expected bool
+	}{
+		{"deactivated by the user", XEPGChannelStruct{XActive: true}, XEPGChannelStruct{XActive: false}, true},
+		{"activated by the user", XEPGChannelStruct{XUserDisabled: true}, XEPGChannelStruct{XActive: true}, false},
+		{"flag is kept if not sent", XEPGChannelStruct{XUserDisabled: true}, XEPGChannelStruct{}, true},
+		{"never active", XEPGChannelStruct{}, XEPGChannelStruct{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, applyUserDisabled(tt.old, tt.new).XUserDisabled)
+		})
+	}
+}
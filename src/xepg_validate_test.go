@@ -0,0 +1,70 @@
+package src
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateXEPGMappings(t *testing.T) {
+	t.Cleanup(setupMappingTestGlobals())
+
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {XName: "Mapped", XChannelID: "1", XActive: true, XmltvFile: "test_provider.xml", XMapping: "channel1.tvg.id"},
+		"x-ID.2": {XName: "Missing File", XChannelID: "2", XActive: true, XmltvFile: "removed.xml", XMapping: "channel1.tvg.id"},
+		"x-ID.3": {XName: "Missing Channel", XChannelID: "3", XActive: true, XmltvFile: "test_provider.xml", XMapping: "gone"},
+		"x-ID.4": {XName: "No EPG B", XChannelID: "10", XActive: true, XmltvFile: "-", XMapping: "-"},
+		"x-ID.5": {XName: "No EPG A", XChannelID: "4", XActive: true, XmltvFile: "-", XMapping: "-"},
+		"x-ID.6": {XName: "Inactive", XChannelID: "6", XActive: false, XmltvFile: "removed.xml", XMapping: "gone"},
+		"x-ID.7": {XName: "Dummy", XChannelID: "7", XActive: true, XmltvFile: "xTeVe Dummy", XMapping: "60_Minutes"},
+	}
+
+	validation := validateXEPGMappings()
+
+	require.Len(t, validation.MissingFile, 1)
+	assert.Equal(t, "x-ID.2", validation.MissingFile[0].ID)
+	assert.Equal(t, "removed.xml", validation.MissingFile[0].XmltvFile)
+
+	require.Len(t, validation.MissingChannel, 1)
+	assert.Equal(t, "x-ID.3", validation.MissingChannel[0].ID)
+	assert.Equal(t, "gone", validation.MissingChannel[0].XMapping)
+
+	require.Len(t, validation.NoEPG, 2)
+	assert.Equal(t, "No EPG A", validation.NoEPG[0].Name, "sorted by channel number")
+	assert.Equal(t, "No EPG B", validation.NoEPG[1].Name)
+
+	// Read-only: the channels are not deactivated
+	assert.True(t, Data.XEPG.Channels["x-ID.2"].XActive)
+	assert.True(t, Data.XEPG.Channels["x-ID.3"].XActive)
+	assert.Equal(t, "gone", Data.XEPG.Channels["x-ID.3"].XMapping)
+}
+
+func TestAPI_XEPGValidate(t *testing.T) {
+	t.Cleanup(setupMappingTestGlobals())
+
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {XName: "No EPG", XChannelID: "1", XActive: true, XmltvFile: "-", XMapping: "-"},
+	}
+
+	body, err := json.Marshal(map[string]string{"cmd": "xepg.validate"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/", bytes.NewBuffer(body))
+	req.RemoteAddr = "127.0.0.1:12345"
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	API(w, req)
+
+	var response APIResponseStruct
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.True(t, response.Status, response.Error)
+	require.NotNil(t, response.XEPGValidation)
+	assert.Empty(t, response.XEPGValidation.MissingFile)
+	require.Len(t, response.XEPGValidation.NoEPG, 1)
+	assert.Equal(t, "x-ID.1", response.XEPGValidation.NoEPG[0].ID)
+}
@@ -1,14 +1,18 @@
 package src
 
 import (
+	"encoding/xml"
 	"fmt" // Re-add for panic message
 	"os"
 	"path"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
 	"xteve/src/internal/imgcache"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // --- Test Setup ---
@@ -234,6 +238,43 @@ func TestCreateChannelElements(t *testing.T) {
 	}
 }
 
+func TestCreateChannelElements_LogoFallback(t *testing.T) {
+	teardown := setupXMLTVTestGlobals()
+	defer teardown()
+
+	Settings.DefaultChannelLogo = "https://example.com/default.png"
+	Data.XMLTV.Mapping = map[string]map[string]XMLTVChannelMapping{
+		"guide.xml": {
+			"with.icon":    {ID: "with.icon", Icon: "https://example.com/xmltv.png"},
+			"without.icon": {ID: "without.icon"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		channel XEPGChannelStruct
+		want    string
+	}{
+		{"TvgLogo first", XEPGChannelStruct{TvgLogo: "https://example.com/m3u.png", XmltvFile: "guide.xml", XMapping: "with.icon"}, "https://example.com/m3u.png"},
+		{"XMLTV icon", XEPGChannelStruct{XmltvFile: "guide.xml", XMapping: "with.icon"}, "https://example.com/xmltv.png"},
+		{"mapping without icon", XEPGChannelStruct{XmltvFile: "guide.xml", XMapping: "without.icon"}, "https://example.com/default.png"},
+		{"no logo and no mapping", XEPGChannelStruct{XmltvFile: "-", XMapping: "-"}, "https://example.com/default.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := createChannelElements(tt.channel, Data.Cache.Images)
+			assert.Equal(t, tt.want, got.Icon.Src)
+		})
+	}
+
+	// Programs without an icon get the channel logo as poster
+	Settings.XepgReplaceMissingImages = true
+	var program Program
+	getPoster(&program, &Program{}, getChannelLogo(tests[3].channel))
+	assert.Equal(t, []Poster{{Src: "https://example.com/default.png"}}, program.Poster)
+}
+
 // --- Tests for createProgramElements ---
 func TestCreateProgramElements(t *testing.T) {
 	teardown := setupXMLTVTestGlobals()
@@ -372,3 +413,242 @@ Data = DataStruct{
 // `SystemFolder` and `SystemFile` were placeholders in the comment, the actual struct uses anonymous ones.
 // `testXMLTVSystem` definition was updated to use anonymous structs for Folder and File.
 // `XMLTVData` was a placeholder for the type of `Data.XMLTV`. The actual anonymous struct is used in `setupXMLTVTestGlobals`.
+
+func TestGetCategory_Filter(t *testing.T) {
+	originalWhitelist := Settings.XMLTVCategoryWhitelist
+	originalBlacklist := Settings.XMLTVCategoryBlacklist
+	t.Cleanup(func() {
+		Settings.XMLTVCategoryWhitelist = originalWhitelist
+		Settings.XMLTVCategoryBlacklist = originalBlacklist
+	})
+
+	xmltvProgram := &Program{
+		Category: []*Category{
+			{Value: "Movie", Lang: "en"},
+			{Value: "Drama", Lang: "en"},
+			{Value: "Teleshopping", Lang: "en"},
+			{Value: "Sports", Lang: "en"},
+		},
+	}
+
+	values := func(categories []*Category) (v []string) {
+		for _, c := range categories {
+			v = append(v, c.Value)
+		}
+		return
+	}
+
+	tests := []struct {
+		name      string
+		whitelist []string
+		blacklist []string
+		xCategory string
+		expected  []string
+	}{
+		{name: "no filter", expected: []string{"Movie", "Drama", "Teleshopping", "Sports"}},
+		{name: "whitelist keeps order", whitelist: []string{"sports", "Movie"}, expected: []string{"Movie", "Sports"}},
+		{name: "blacklist", blacklist: []string{"Teleshopping"}, expected: []string{"Movie", "Drama", "Sports"}},
+		{name: "whitelist and blacklist", whitelist: []string{"Movie", "Drama"}, blacklist: []string{"Drama"}, expected: []string{"Movie"}},
+		{name: "xCategory is always added", whitelist: []string{"Drama"}, xCategory: "Kids", expected: []string{"Drama", "Kids"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Settings.XMLTVCategoryWhitelist = tt.whitelist
+			Settings.XMLTVCategoryBlacklist = tt.blacklist
+
+			program := &Program{}
+			getCategory(program, xmltvProgram, tt.xCategory)
+
+			assert.Equal(t, tt.expected, values(program.Category))
+			assert.Len(t, xmltvProgram.Category, 4, "source categories must not be modified")
+		})
+	}
+}
+
+func TestGetEpisodeNum_GenerateProgID(t *testing.T) {
+	originalGenerate := Settings.XMLTVGenerateProgID
+	t.Cleanup(func() { Settings.XMLTVGenerateProgID = originalGenerate })
+	Settings.XMLTVGenerateProgID = true
+
+	newProgram := func(title, start string, episodeNum ...*EpisodeNum) *Program {
+		return &Program{Title: []*Title{{Value: title}}, Start: start, EpisodeNum: episodeNum}
+	}
+
+	progID := func(xmltvProgram *Program, xCategory string) string {
+		program := &Program{}
+		getEpisodeNum(program, xmltvProgram, xCategory)
+		for _, e := range program.EpisodeNum {
+			if e.System == "dd_progid" {
+				return e.Value
+			}
+		}
+		return ""
+	}
+
+	episode := progID(newProgram("The News", "20240101180000 +0000"), "Series")
+	assert.Regexp(t, `^EP[0-9a-f]{16}\.[0-9a-f]{16}$`, episode)
+	assert.Equal(t, episode, progID(newProgram(" the news ", "20240101180000 +0000"), "Series"), "the ID is stable")
+	assert.NotEqual(t, episode, progID(newProgram("The News", "20240102180000 +0000"), "Series"), "every airing is a new episode")
+	assert.Equal(t, episode[:18], progID(newProgram("The News", "20240102180000 +0000"), "Series")[:18], "same series")
+
+	movie := progID(newProgram("Big Movie", "20240101200000 +0000"), "Movie")
+	assert.Regexp(t, `^MV[0-9a-f]{16}\.0000$`, movie)
+	assert.Equal(t, movie, progID(newProgram("Big Movie", "20240301220000 +0000"), "Movie"), "movies are identified by the title")
+
+	// Existing IDs are kept
+	assert.Equal(t, "EP012345670001", progID(newProgram("The News", "20240101180000 +0000", &EpisodeNum{System: "dd_progid", Value: "EP012345670001"}), "Series"))
+	program := &Program{}
+	getEpisodeNum(program, newProgram("The News", "20240101180000 +0000", &EpisodeNum{System: "xmltv_ns", Value: "1.2."}), "Series")
+	assert.Len(t, program.EpisodeNum, 2)
+
+	assert.Empty(t, progID(newProgram("", "20240101180000 +0000"), "Series"), "no title")
+
+	Settings.XMLTVGenerateProgID = false
+	assert.Empty(t, progID(newProgram("The News", "20240101180000 +0000"), "Series"))
+}
+
+func TestCreateProgramElements_RadioVideo(t *testing.T) {
+	teardown := setupXMLTVTestGlobals()
+	defer teardown()
+
+	for _, radio := range []bool{false, true} {
+		var programs []*Program
+		err := createProgramElements(XEPGChannelStruct{
+			XChannelID: "x.1",
+			XName:      "Channel HD",
+			XmltvFile:  "provider.xml",
+			XMapping:   "dummy.ch1",
+			XTimeshift: "0",
+			Radio:      radio,
+		}, &programs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(programs) == 0 {
+			t.Fatal("no programs")
+		}
+
+		// The video quality is not inferred from the name of a radio channel
+		var expected = "HDTV"
+		if radio {
+			expected = ""
+		}
+		assert.Equal(t, expected, programs[0].Video.Quality, "radio: %v", radio)
+	}
+}
+
+func TestXMLTVUseSourceIDs_Consistency(t *testing.T) {
+	teardown := setupXMLTVTestGlobals()
+	defer teardown()
+
+	System.File.XML = t.TempDir() + string(os.PathSeparator) + "test_xepg.xml"
+	Settings.EpgSource = "XEPG"
+	Data.Cache.StreamingURLS = make(map[string]StreamInfo)
+	Data.Streams.Active = []any{"dummy"}
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {XActive: true, XChannelID: "5", XName: "Channel HD", XEPG: "x-ID.1", TvgID: "m3u.hd", XmltvFile: "provider.xml", XMapping: "dummy.ch1", XTimeshift: "0", FileM3UID: "M1", URL: "http://provider.example/1.ts"},
+		"x-ID.2": {XActive: true, XChannelID: "6", XName: "Channel SD", XEPG: "x-ID.2", TvgID: "m3u.sd", XmltvFile: "provider.xml", XMapping: "dummy.ch1", XTimeshift: "0", FileM3UID: "M1", URL: "http://provider.example/2.ts"},
+		"x-ID.3": {XActive: true, XChannelID: "7", XName: "Dummy", XEPG: "x-ID.3", XmltvFile: "xTeVe Dummy", XMapping: "30_Minutes", FileM3UID: "M1", URL: "http://provider.example/3.ts"},
+	}
+
+	ids := func() (m3uIDs, channelIDs, programChannels []string) {
+		require.NoError(t, createXMLTVFile())
+		content, err := os.ReadFile(System.File.XML)
+		require.NoError(t, err)
+
+		var xmltv XMLTV
+		require.NoError(t, xml.Unmarshal(content, &xmltv))
+		for _, channel := range xmltv.Channel {
+			channelIDs = append(channelIDs, channel.ID)
+		}
+		for _, program := range xmltv.Program {
+			if !slices.Contains(programChannels, program.Channel) {
+				programChannels = append(programChannels, program.Channel)
+			}
+		}
+
+		var m3u strings.Builder
+		require.NoError(t, buildM3UToWriter(&m3u, []string{}, OutputProfile{Name: defaultOutputProfile}))
+		for _, match := range regexp.MustCompile(`tvg-id="([^"]*)"`).FindAllStringSubmatch(m3u.String(), -1) {
+			m3uIDs = append(m3uIDs, match[1])
+		}
+		return
+	}
+
+	for _, tt := range []struct {
+		useSourceIDs bool
+		m3uIDs       []string
+		channelIDs   []string
+	}{
+		{false, []string{"5", "6", "7"}, []string{"5", "6", "7"}},
+		{true, []string{"dummy.ch1", "dummy.ch1", "7"}, []string{"dummy.ch1", "7"}},
+	} {
+		Settings.XMLTVUseSourceIDs = tt.useSourceIDs
+
+		m3uIDs, channelIDs, programChannels := ids()
+		assert.Equal(t, tt.m3uIDs, m3uIDs, "source IDs: %v", tt.useSourceIDs)
+		assert.ElementsMatch(t, tt.channelIDs, channelIDs, "source IDs: %v", tt.useSourceIDs)
+		assert.ElementsMatch(t, tt.channelIDs, programChannels, "source IDs: %v", tt.useSourceIDs)
+
+		// Every channel of the M3U has a channel in the XMLTV file
+		for _, id := range m3uIDs {
+			assert.Contains(t, channelIDs, id, "source IDs: %v", tt.useSourceIDs)
+		}
+	}
+}
+
+func TestAdjustProgramTime_Timeshift(t *testing.T) {
+	for _, tt := range []struct {
+		timeshift string
+		minutes   int
+		in, want  string
+	}{
+		{"0", 0, "20240101000000 +0000", "20240101000000 +0000"},
+		{"2", 120, "20240101000000 +0100", "20240101000000 +0300"},
+		{"-3", -180, "20240101000000 +0100", "20240101000000 -0200"},
+		{"+0.5", 30, "20240101000000 +0000", "20240101000000 +0030"},
+		{"0.5", 30, "20240101000000 +0545", "20240101000000 +0615"},
+		{"-1.5", -90, "20240101000000 +0000", "20240101000000 -0130"},
+		{"-1.5", -90, "20240101000000 +0100", "20240101000000 -0030"},
+		{"-1,5", -90, "20240101000000 -0430", "20240101000000 -0600"},
+		{"1.75", 105, "20240101000000 -0030", "20240101000000 +0115"},
+		{"+02:00", 120, "20240101000000 +0000", "20240101000000 +0200"},
+		{"-01:30", -90, "20240101000000 +0100", "20240101000000 -0030"},
+		{"-00:45", -45, "20240101000000 +0000", "20240101000000 -0045"},
+		{"45m", 45, "20240101000000 +0930", "20240101000000 +1015"},
+		{"-1h30m", -90, "20240101000000 +0000", "20240101000000 -0130"},
+		{"invalid", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"24", 1440, "20240101000000 +0000", "20240101000000 +2400"},
+		{"-24:00", -1440, "20240101000000 +0000", "20240101000000 -2400"},
+		{"NaN", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"Inf", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"-Inf", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"24.5", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"-25", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"1e300", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"+25:00", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"24:30", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"-9999999999:00", 0, "20240101000000 +0100", "20240101000000 +0100"},
+		{"25h", 0, "20240101000000 +0100", "20240101000000 +0100"},
+	} {
+		minutes := parseTimeshift(tt.timeshift)
+		assert.Equal(t, tt.minutes, minutes, tt.timeshift)
+		assert.Equal(t, tt.want, adjustProgramTime(tt.in, minutes), "%s %s", tt.timeshift, tt.in)
+	}
+
+	// Times without a valid offset are not changed
+	assert.Equal(t, "20240101000000", adjustProgramTime("20240101000000", 60))
+	assert.Equal(t, "20240101000000 UTC", adjustProgramTime("20240101000000 UTC", 60))
+}
+
+func TestCreateProgramElements_FractionalTimeshift(t *testing.T) {
+	teardown := setupXMLTVTestGlobals()
+	defer teardown()
+
+	var programs []*Program
+	require.NoError(t, createProgramElements(XEPGChannelStruct{XChannelID: "x.1", XName: "Channel", XmltvFile: "provider.xml", XMapping: "dummy.ch1", XTimeshift: "-1.5"}, &programs))
+	require.NotEmpty(t, programs)
+	assert.Equal(t, "20240101000000 -0130", programs[0].Start)
+	assert.Equal(t, "20240101010000 -0130", programs[0].Stop)
+}
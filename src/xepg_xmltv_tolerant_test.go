@@ -0,0 +1,88 @@
+package src
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenXMLTV : XMLTV file with one invalid programme (illegal character) among valid ones
+func brokenXMLTV(programs int) []byte {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<tv generator-info-name="Test Generator">` + "\n")
+	sb.WriteString(`<channel id="ch1"><display-name>Channel 1</display-name></channel>` + "\n")
+	for i := range programs {
+		title := fmt.Sprintf("Program %d", i)
+		switch i {
+		case 3:
+			title = "Broken \x01 Program"
+		case 5:
+			title = "Tom & Jerry"
+		}
+		fmt.Fprintf(&sb, `<programme channel="ch1" start="202401010%d0000 +0000" stop="202401010%d3000 +0000"><title>%s</title></programme>`+"\n", i, i, title)
+	}
+	sb.WriteString(`<channel id="ch2"><display-name>Channel 2</display-name></channel>` + "\n")
+	sb.WriteString("</tv>\n")
+	return []byte(sb.String())
+}
+
+func TestDecodeXMLTV_Tolerant(t *testing.T) {
+	oldSettings, oldSystem, oldData := Settings, System, Data
+	t.Cleanup(func() { Settings, System, Data = oldSettings, oldSystem, oldData })
+	System.Name = "xTeVe"
+	System.Flag.Info = false
+	logs := captureScreenLog(t)
+
+	content := brokenXMLTV(8)
+
+	// Without the tolerant mode the whole file is rejected
+	Settings.XMLTVTolerantParse = false
+	var xmltv XMLTV
+	assert.Error(t, decodeXMLTV(bytes.NewReader(content), &xmltv, "provider.xml"))
+
+	Settings.XMLTVTolerantParse = true
+	xmltv = XMLTV{}
+	require.NoError(t, decodeXMLTV(bytes.NewReader(content), &xmltv, "provider.xml"))
+
+	assert.Equal(t, "Test Generator", xmltv.Generator)
+	require.Len(t, xmltv.Channel, 2, "channels after the invalid programme are kept")
+	assert.Equal(t, "ch2", xmltv.Channel[1].ID)
+	require.Len(t, xmltv.Program, 7)
+
+	var titles []string
+	for _, program := range xmltv.Program {
+		titles = append(titles, program.Title[0].Value)
+	}
+	assert.NotContains(t, titles, "Program 3")
+	assert.Contains(t, titles, "Program 7")
+	assert.Contains(t, titles, "Tom & Jerry", "unescaped entities are accepted")
+
+	assert.Contains(t, strings.Join(logs(), "\n"), "1 malformed elements skipped (provider.xml)")
+
+	// A valid file is decoded like in the strict mode
+	var strict, tolerant XMLTV
+	valid := []byte(`<tv><channel id="a"><display-name>A</display-name></channel><programme channel="a" start="1" stop="2"><title>T</title></programme></tv>`)
+	Settings.XMLTVTolerantParse = false
+	require.NoError(t, decodeXMLTV(bytes.NewReader(valid), &strict, "valid.xml"))
+	Settings.XMLTVTolerantParse = true
+	require.NoError(t, decodeXMLTV(bytes.NewReader(valid), &tolerant, "valid.xml"))
+	assert.Equal(t, strict, tolerant)
+
+	// Other files are still rejected
+	assert.Error(t, decodeXMLTV(strings.NewReader("<html><body>Login</body></html>"), &XMLTV{}, "login.xml"))
+
+	// Local copy of the provider file
+	file := filepath.Join(t.TempDir(), "provider.xml")
+	require.NoError(t, os.WriteFile(file, content, 0644))
+	Data.Cache.XMLTV = nil
+	xmltv = XMLTV{}
+	require.NoError(t, getLocalXMLTV(file, &xmltv))
+	assert.Len(t, xmltv.Program, 7)
+}
@@ -0,0 +1,73 @@
+package src
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXMLTVChannelFragment(t *testing.T) {
+	oldSettings, oldSystem, oldChannels, oldCache := Settings, System, Data.XEPG.Channels, Data.Cache.XMLTV
+	t.Cleanup(func() {
+		Settings, System, Data.XEPG.Channels, Data.Cache.XMLTV = oldSettings, oldSystem, oldChannels, oldCache
+	})
+
+	System.Folder.Data = t.TempDir() + "/"
+	var file = System.Folder.Data + "guide.xml"
+	t.Cleanup(func() {
+		xmltvProgramMutex.Lock()
+		delete(xmltvProgramIndices, file)
+		xmltvProgramMutex.Unlock()
+	})
+
+	program := func(channel, start, title string) *Program {
+		return &Program{Channel: channel, Start: start + " +0000", Stop: start + " +0000", Title: []*Title{{Value: title, Lang: "en"}}}
+	}
+
+	Data.Cache.XMLTV = map[string]XMLTV{file: {Program: []*Program{
+		program("news", "20240101100000", "News at 10"),
+		program("sports", "20240101100000", "Football"),
+		program("news", "20240101110000", "News at 11"),
+	}}}
+
+	Data.XEPG.Channels = map[string]XEPGChannelStruct{
+		"x-ID.1": {XActive: true, XChannelID: "1", XName: "News", XmltvFile: "guide.xml", XMapping: "news"},
+		"x-ID.2": {XActive: true, XChannelID: "2", XName: "Sports", XmltvFile: "guide.xml", XMapping: "sports"},
+		"x-ID.3": {XActive: false, XChannelID: "3", XName: "Inactive", XmltvFile: "guide.xml", XMapping: "news"},
+	}
+	Settings.AuthenticationXML = false
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		xTeVe(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := request("/xmltv/channel/1.xml")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	var xmltv XMLTV
+	require.NoError(t, xml.Unmarshal(rr.Body.Bytes(), &xmltv))
+	require.Len(t, xmltv.Channel, 1)
+	assert.Equal(t, "1", xmltv.Channel[0].ID)
+	assert.Equal(t, "News", xmltv.Channel[0].DisplayNames[0].Value)
+
+	var titles []string
+	for _, p := range xmltv.Program {
+		assert.Equal(t, "1", p.Channel)
+		titles = append(titles, p.Title[0].Value)
+	}
+	assert.Equal(t, []string{"News at 10", "News at 11"}, titles)
+
+	assert.Equal(t, http.StatusNotFound, request("/xmltv/channel/99.xml").Code, "unknown channel")
+	assert.Equal(t, http.StatusNotFound, request("/xmltv/channel/3.xml").Code, "inactive channel")
+
+	// The same authentication as the XMLTV file
+	Settings.AuthenticationXML = true
+	assert.Equal(t, http.StatusForbidden, request("/xmltv/channel/1.xml").Code)
+}
@@ -5,6 +5,7 @@ var SEARCH_MAPPING: Record<string, any> = {};
 var UNDO: Record<string, any> = {};
 var SERVER_CONNECTION = false;
 var WS_AVAILABLE = false;
+var STATUS_SOCKET: WebSocket | null = null;
 
 // Menu
 var menuItems = new Array();
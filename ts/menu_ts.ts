@@ -1849,6 +1849,14 @@ function openPopUp(dataType: any, element: any) {
       select.setAttribute("onchange", "javascript: this.className = 'changed'");
       content.appendRow("{{.mapping.epgCategory.title}}", select);
 
+      // Stream format instead of the Content-Type
+      var dbKey: string = "x-stream-format";
+      var text: string[] = ["Content-Type", "MPEG-TS", "HLS"];
+      var values: string[] = ["", "ts", "hls"];
+      var select = content.createSelect(text, values, data[dbKey], dbKey);
+      select.setAttribute("onchange", "javascript: this.className = 'changed'");
+      content.appendRow("{{.mapping.streamFormat.title}}", select);
+
       // M3U group title
       var dbKey: string = "x-group-title";
       var input = content.createInput("text", dbKey, data[dbKey]);
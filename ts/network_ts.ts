@@ -57,11 +57,20 @@ class Server {
     };
 
     ws.onmessage = function (wsMessageEvt) {
+      const response: Record<string, any> = JSON.parse(wsMessageEvt.data);
+
+      // Pushed by the server, not an answer to this request
+      if (response["cmd"] == "streamStatusUpdate") {
+        showStreamStatus(response);
+        return;
+      }
+
+      // Only the socket of the last answered request stays open for the stream status updates
+      keepStatusSocket(ws);
+
       SERVER_CONNECTION = false;
       showElement("loading", false);
 
-      const response: Record<string, any> = JSON.parse(wsMessageEvt.data);
-
       if (response.hasOwnProperty("token")) {
         document.cookie = "Token=" + response["token"];
       }
@@ -125,6 +134,29 @@ class Server {
   }
 }
 
+function keepStatusSocket(ws: WebSocket) {
+  if (STATUS_SOCKET != null && STATUS_SOCKET != ws) {
+    STATUS_SOCKET.close();
+  }
+  STATUS_SOCKET = ws;
+}
+
+function closeStatusSocket() {
+  if (STATUS_SOCKET != null) {
+    STATUS_SOCKET.close();
+    STATUS_SOCKET = null;
+  }
+}
+
+window.addEventListener("pagehide", closeStatusSocket);
+
+function showStreamStatus(update: Record<string, any>) {
+  var element = document.getElementById("active-streams");
+  if (element) {
+    element.innerHTML = update["active"];
+  }
+}
+
 function getCookie(name: string) {
   var value = "; " + document.cookie;
   var parts = value.split("; " + name + "=");
@@ -46,6 +46,7 @@ var debug = flag.Int("debug", 0, ": Debug level          [0 - 3] (default: 0)")
 var info = flag.Bool("info", false, ": Show system info")
 var version = flag.Bool("version", false, ": Show system version")
 var h = flag.Bool("h", false, ": Show help")
+var selftest = flag.Bool("selftest", false, ": Check the embedded web interface and exit")
 
 // Activates Development Mode. The local Files are then used for the Webserver.
 var dev = flag.Bool("dev", false, ": Activates the developer mode, the source code must be available. The local files for the web interface are used.")
@@ -85,6 +86,10 @@ func run() (err error) {
 		return nil
 	}
 
+	if *selftest {
+		return src.SelfTest(os.Stdout)
+	}
+
 	system.Dev = *dev
 
 	// Set up OpenTelemetry.